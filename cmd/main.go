@@ -23,12 +23,81 @@ import (
 	svc_audit "github.com/synera-br/lockari-backend-app/internal/core/service/audit"
 	webhandler_audit "github.com/synera-br/lockari-backend-app/internal/handler/web/audit"
 
+	// VAULT
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	svc_vault "github.com/synera-br/lockari-backend-app/internal/core/service/vault"
+	webhandler_vault "github.com/synera-br/lockari-backend-app/internal/handler/web/vault"
+
+	// AUTHORIZATION OUTBOX
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+
+	// TENANT
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	repo_tenant "github.com/synera-br/lockari-backend-app/internal/core/repository/tenant"
+	svc_tenant "github.com/synera-br/lockari-backend-app/internal/core/service/tenant"
+	webhandler_tenant "github.com/synera-br/lockari-backend-app/internal/handler/web/tenant"
+
+	// GROUP
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	repo_group "github.com/synera-br/lockari-backend-app/internal/core/repository/group"
+	svc_group "github.com/synera-br/lockari-backend-app/internal/core/service/group"
+	webhandler_group "github.com/synera-br/lockari-backend-app/internal/handler/web/group"
+
+	// INVITATION
+	entity_invitation "github.com/synera-br/lockari-backend-app/internal/core/entity/invitation"
+	repo_invitation "github.com/synera-br/lockari-backend-app/internal/core/repository/invitation"
+	svc_invitation "github.com/synera-br/lockari-backend-app/internal/core/service/invitation"
+	webhandler_invitation "github.com/synera-br/lockari-backend-app/internal/handler/web/invitation"
+
+	// SHARE
+	entity_share "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	repo_share "github.com/synera-br/lockari-backend-app/internal/core/repository/share"
+	svc_share "github.com/synera-br/lockari-backend-app/internal/core/service/share"
+	webhandler_share "github.com/synera-br/lockari-backend-app/internal/handler/web/share"
+
+	// EXTERNAL SHARE
+	entity_externalshare "github.com/synera-br/lockari-backend-app/internal/core/entity/externalshare"
+	repo_externalshare "github.com/synera-br/lockari-backend-app/internal/core/repository/externalshare"
+	svc_externalshare "github.com/synera-br/lockari-backend-app/internal/core/service/externalshare"
+	webhandler_externalshare "github.com/synera-br/lockari-backend-app/internal/handler/web/externalshare"
+
+	// ACCESS REQUEST
+	entity_accessrequest "github.com/synera-br/lockari-backend-app/internal/core/entity/accessrequest"
+	repo_accessrequest "github.com/synera-br/lockari-backend-app/internal/core/repository/accessrequest"
+	svc_accessrequest "github.com/synera-br/lockari-backend-app/internal/core/service/accessrequest"
+	webhandler_accessrequest "github.com/synera-br/lockari-backend-app/internal/handler/web/accessrequest"
+
+	// SECRET
+	entity_secret "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	repo_secret "github.com/synera-br/lockari-backend-app/internal/core/repository/secret"
+	svc_secret "github.com/synera-br/lockari-backend-app/internal/core/service/secret"
+	webhandler_secret "github.com/synera-br/lockari-backend-app/internal/handler/web/secret"
+
+	// TRASH
+	entity_trash "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	repo_trash "github.com/synera-br/lockari-backend-app/internal/core/repository/trash"
+	svc_trash "github.com/synera-br/lockari-backend-app/internal/core/service/trash"
+	webhandler_trash "github.com/synera-br/lockari-backend-app/internal/handler/web/trash"
+
+	// BACKUP
+	entity_backup "github.com/synera-br/lockari-backend-app/internal/core/entity/backup"
+	repo_backup "github.com/synera-br/lockari-backend-app/internal/core/repository/backup"
+	svc_backup "github.com/synera-br/lockari-backend-app/internal/core/service/backup"
+	webhandler_backup "github.com/synera-br/lockari-backend-app/internal/handler/web/backup"
+
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
 	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
 	"github.com/synera-br/lockari-backend-app/pkg/cache"
 	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
 	"github.com/synera-br/lockari-backend-app/pkg/database"
 	httpserver "github.com/synera-br/lockari-backend-app/pkg/http_server"
+	"github.com/synera-br/lockari-backend-app/pkg/mailer"
 	"github.com/synera-br/lockari-backend-app/pkg/message_queue"
+	"github.com/synera-br/lockari-backend-app/pkg/storage"
 	"github.com/synera-br/lockari-backend-app/pkg/tokengen"
 )
 
@@ -49,7 +118,17 @@ func main() {
 		log.Fatal(err)
 	}
 
-	authClient, db, err := initializeFirebase(cfg.Fields["firebase"])
+	storageCrypt, err := initializeStorageCrypt(cfg.Fields["encrypt_storage"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	authClient, err := initializeFirebase(cfg.Fields["firebase"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := initializeDatabase(cfg.Fields["database"], cfg.Fields["firebase"])
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -75,12 +154,87 @@ func main() {
 		log.Fatal(err)
 	}
 
-	signup, err := initializeSignup(db, authClient, tokenJWT)
+	auditSvc, err := initializeAuditEvent(db, authClient, tokenJWT)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	auditSvc, err := initializeAuditEvent(db, authClient, tokenJWT)
+	authz, err := initializeAuthorizer(cfg.Fields["openfga"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outboxSvc, reconciler, err := initializeOutbox(db, authz)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go outboxSvc.Run(context.Background(), 10*time.Second)
+	go reconciler.Run(context.Background(), time.Hour, true)
+
+	// Everything below checks permissions through the guard, which denies the expired shares until the sweeper removes them.
+	authz, sweeper, err := initializeShareExpiry(db, authz, outboxSvc, auditSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go sweeper.Run(context.Background(), 5*time.Minute)
+
+	trashSvc, purger, err := initializeTrash(db, authz, outboxSvc, auditSvc, cfg.Fields["trash"])
+	if err != nil {
+		log.Fatal(err)
+	}
+	go purger.Run(context.Background(), time.Hour)
+
+	backupSvc, err := initializeBackup(db, authz, outboxSvc, auditSvc, cfg.Fields["backup"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tenantSvc, err := initializeTenant(db, authClient, authz, outboxSvc, auditSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	groupSvc, err := initializeGroup(db, authz, outboxSvc, auditSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	invitationSvc, err := initializeInvitation(db, authClient, outboxSvc, auditSvc, cfg.Fields["invitation"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	signup, err := initializeSignup(db, authClient, tokenJWT, tenantSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	vaultSvc, err := initializeVault(db, authz, outboxSvc, trashSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	shareSvc, err := initializeShare(db, authz, outboxSvc, auditSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	externalShareSvc, err := initializeExternalShare(db, authz, outboxSvc, auditSvc, cfg.Fields["externalShare"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accessRequestSvc, err := initializeAccessRequest(db, authClient, authz, outboxSvc, auditSvc, cfg.Fields["accessRequest"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	secretSvc, err := initializeSecret(db, vaultSvc, storageCrypt, authz, trashSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	permissions, err := mid.NewAuthorizationMiddleware(authz, auditSvc)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -88,6 +242,45 @@ func main() {
 	webhandler.InitializeLoginHandler(authSvc, crypt, authClient, tokenJWT, apiResponse.RouterGroup, apiResponse.MiddlewareHeader)
 	webhandler.InitializeSignupHandler(signup, crypt, authClient, tokenJWT, apiResponse.RouterGroup, apiResponse.MiddlewareHeader)
 	webhandler_audit.InitializeAuditSystemEventHandler(auditSvc, crypt, authClient, tokenJWT, apiResponse.RouterGroup, apiResponse.MiddlewareHeader)
+	if _, err := webhandler_tenant.InitializeTenantHandler(tenantSvc, crypt, authClient, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := webhandler_group.InitializeGroupHandler(groupSvc, crypt, authClient, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+		log.Fatal(err)
+	}
+	if invitationSvc != nil {
+		if _, err := webhandler_invitation.InitializeInvitationHandler(invitationSvc, crypt, authClient, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if _, err := webhandler_vault.InitializeVaultHandler(vaultSvc, crypt, authClient, permissions, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := webhandler_share.InitializeShareHandler(shareSvc, crypt, authClient, permissions, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+		log.Fatal(err)
+	}
+	if externalShareSvc != nil {
+		if _, err := webhandler_externalshare.InitializeExternalShareHandler(externalShareSvc, crypt, authClient, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if accessRequestSvc != nil {
+		if _, err := webhandler_accessrequest.InitializeAccessRequestHandler(accessRequestSvc, crypt, authClient, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if _, err := webhandler_secret.InitializeSecretHandler(secretSvc, crypt, authClient, permissions, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := webhandler_trash.InitializeTrashHandler(trashSvc, authClient, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+		log.Fatal(err)
+	}
+
+	if backupSvc != nil {
+		if _, err := webhandler_backup.InitializeBackupHandler(backupSvc, authClient, apiResponse.RouterGroup, apiResponse.MiddlewareHeader); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	log.Println(cacheClient, signup)
 	log.Println("Starting Lockari Backend App...")
@@ -115,24 +308,49 @@ func initializeCryptData(encryptField interface{}) (cryptserver.CryptDataInterfa
 	return cryptserver.InicializationCryptData(&token)
 }
 
-func initializeFirebase(firebaseField interface{}) (authenticator.Authenticator, database.FirebaseDBInterface, error) {
+func initializeStorageCrypt(encryptField interface{}) (cryptserver.StorageCryptInterface, error) {
+	token := fmt.Sprintf("%v", encryptField)
+	return cryptserver.InicializationStorageCrypt(&token)
+}
+
+func initializeFirebase(firebaseField interface{}) (authenticator.Authenticator, error) {
 	var fConfig authenticator.FirebaseConfig
 
 	b, err := json.Marshal(firebaseField)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal firebase config: %w", err)
+		return nil, fmt.Errorf("failed to marshal firebase config: %w", err)
 	}
 
 	if err := json.Unmarshal(b, &fConfig); err != nil {
-		return nil, nil, fmt.Errorf("failed to unmarshal firebase config: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal firebase config: %w", err)
 	}
 
 	authClient, err := authenticator.InitializeAuth(context.Background(), &fConfig)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize auth: %w", err)
+		return nil, fmt.Errorf("failed to initialize auth: %w", err)
+	}
+
+	return authClient, nil
+}
+
+// initializeDatabase selects the storage backend from the database.provider field:
+// firestore (the default when the section is missing), postgres or memory.
+func initializeDatabase(databaseField, firebaseField interface{}) (database.FirebaseDBInterface, error) {
+	var dbConfig database.Config
+
+	b, _ := json.Marshal(databaseField)
+	if err := json.Unmarshal(b, &dbConfig); err != nil {
+		return nil, fmt.Errorf("failed to read database config: %w", err)
+	}
+
+	var fConfig authenticator.FirebaseConfig
+
+	b, _ = json.Marshal(firebaseField)
+	if err := json.Unmarshal(b, &fConfig); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal firebase config: %w", err)
 	}
 
-	db, err := database.InitializeFirebaseDB(database.FirebaseConfig{
+	return database.Open(dbConfig, database.FirebaseConfig{
 		ProjectID:             fConfig.ProjectID,
 		APIKey:                fConfig.APIKey,
 		DatabaseURL:           fConfig.DatabaseURL,
@@ -142,11 +360,6 @@ func initializeFirebase(firebaseField interface{}) (authenticator.Authenticator,
 		MessagingSenderID:     fConfig.MessagingSenderID,
 		ServiceAccountKeyPath: fConfig.ServiceAccountKeyPath,
 	})
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize firebase DB: %w", err)
-	}
-
-	return authClient, db, nil
 }
 
 func initializeCache(fields interface{}) (cache.CacheService, error) {
@@ -221,14 +434,14 @@ func initializeAuth(db database.FirebaseDBInterface) (entity.LoginEventService,
 	return svc, nil
 }
 
-func initializeSignup(db database.FirebaseDBInterface, auth authenticator.Authenticator, tokenJWT tokengen.TokenGenerator) (entity_auth.SignupEventService, error) {
+func initializeSignup(db database.FirebaseDBInterface, auth authenticator.Authenticator, tokenJWT tokengen.TokenGenerator, tenants entity_tenant.TenantService) (entity_auth.SignupEventService, error) {
 
 	repo, err := repo_auth.InitializeSignupEventRepository(db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize signup event repository: %w", err)
 	}
 
-	svc, err := svc_auth.InitializeSignupEventService(repo, auth, tokenJWT)
+	svc, err := svc_auth.InitializeSignupEventService(repo, auth, tokenJWT, tenants)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize signup event service: %w", err)
 	}
@@ -250,3 +463,387 @@ func initializeAuditEvent(db database.FirebaseDBInterface, auth authenticator.Au
 	return svc, nil
 
 }
+
+func initializeAuthorizer(fields interface{}) (authorization.Authorizer, error) {
+
+	b, _ := json.Marshal(fields)
+
+	var config authorization.Config
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("failed to read openfga config: %w", err)
+	}
+
+	return authorization.NewOpenFGAClient(config)
+}
+
+func initializeOutbox(db database.FirebaseDBInterface, authz authorization.Authorizer) (entity_outbox.OutboxService, entity_outbox.ReconcilerService, error) {
+	repo, err := repo_outbox.InitializeOutboxRepository(db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize outbox repository: %w", err)
+	}
+
+	svc, err := svc_outbox.InitializeOutboxService(repo, authz)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize outbox service: %w", err)
+	}
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize vault repository: %w", err)
+	}
+
+	reconciler, err := svc_outbox.InitializeReconcilerService(vaults, authz, svc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize reconciler service: %w", err)
+	}
+
+	return svc, reconciler, nil
+}
+
+// initializeTrash reads trash.retentionDays, the days deleted items are kept before
+// the purger removes them (30 when missing).
+func initializeTrash(db database.FirebaseDBInterface, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, fields interface{}) (entity_trash.TrashService, entity_trash.PurgerService, error) {
+
+	var trashConfig struct {
+		RetentionDays int `json:"retentionDays"`
+	}
+
+	b, _ := json.Marshal(fields)
+	if err := json.Unmarshal(b, &trashConfig); err != nil {
+		return nil, nil, fmt.Errorf("failed to read trash config: %w", err)
+	}
+	retention := time.Duration(trashConfig.RetentionDays) * 24 * time.Hour
+
+	repo, err := repo_trash.InitializeTrashRepository(db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize trash repository: %w", err)
+	}
+
+	svc, err := svc_trash.InitializeTrashService(repo, authz, audit, retention)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize trash service: %w", err)
+	}
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize vault repository: %w", err)
+	}
+
+	secrets, err := repo_secret.InitializeSecretRepository(db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize secret repository: %w", err)
+	}
+
+	purger, err := svc_trash.InitializePurgerService(repo, vaults, secrets, authz, outbox, audit, retention)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize purger service: %w", err)
+	}
+
+	return svc, purger, nil
+}
+
+// initializeBackup reads the backup section: signingKey signs the archives, retentionDays
+// keeps each backup that many days (forever when missing), intervalHours schedules a backup
+// of every tenant (disabled when missing) and storage selects where the archives are kept.
+// Backups are disabled when there is no signing key.
+func initializeBackup(db database.FirebaseDBInterface, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, fields interface{}) (entity_backup.BackupService, error) {
+
+	var backupConfig struct {
+		SigningKey    string         `json:"signingKey"`
+		RetentionDays int            `json:"retentionDays"`
+		IntervalHours int            `json:"intervalHours"`
+		Storage       storage.Config `json:"storage"`
+	}
+
+	b, _ := json.Marshal(fields)
+	if err := json.Unmarshal(b, &backupConfig); err != nil {
+		return nil, fmt.Errorf("failed to read backup config: %w", err)
+	}
+
+	if backupConfig.SigningKey == "" {
+		log.Println("Backups are disabled: backup.signingKey is not set")
+		return nil, nil
+	}
+	retention := time.Duration(backupConfig.RetentionDays) * 24 * time.Hour
+
+	store, err := storage.New(backupConfig.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize backup storage: %w", err)
+	}
+
+	repo, err := repo_backup.InitializeBackupRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize backup repository: %w", err)
+	}
+
+	svc, err := svc_backup.InitializeBackupService(repo, store, authz, outbox, audit, backupConfig.SigningKey, retention)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize backup service: %w", err)
+	}
+
+	if backupConfig.IntervalHours > 0 {
+		vaults, err := repo_vault.InitializeVaultRepository(db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize vault repository: %w", err)
+		}
+
+		scheduler, err := svc_backup.InitializeSchedulerService(repo, store, vaults, audit, backupConfig.SigningKey, retention)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize backup scheduler: %w", err)
+		}
+		go scheduler.Run(context.Background(), time.Duration(backupConfig.IntervalHours)*time.Hour)
+	}
+
+	return svc, nil
+}
+
+func initializeTenant(db database.FirebaseDBInterface, auth authenticator.Authenticator, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (entity_tenant.TenantService, error) {
+	repo, err := repo_tenant.InitializeTenantRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tenant repository: %w", err)
+	}
+
+	svc, err := svc_tenant.InitializeTenantService(repo, auth, authz, outbox, audit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tenant service: %w", err)
+	}
+
+	return svc, nil
+}
+
+func initializeGroup(db database.FirebaseDBInterface, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (entity_group.GroupService, error) {
+	repo, err := repo_group.InitializeGroupRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize group repository: %w", err)
+	}
+
+	svc, err := svc_group.InitializeGroupService(repo, authz, outbox, audit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize group service: %w", err)
+	}
+
+	return svc, nil
+}
+
+// initializeInvitation reads invitation.signingKey, the secret of the invitation tokens,
+// invitation.link, the page of the web app that accepts them, invitation.mailer and
+// invitation.expiryHours (72 when missing). Invitations are disabled without a signing key.
+func initializeInvitation(db database.FirebaseDBInterface, auth authenticator.Authenticator, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, fields interface{}) (entity_invitation.InvitationService, error) {
+
+	var invitationConfig struct {
+		SigningKey  string        `json:"signingKey"`
+		Link        string        `json:"link"`
+		ExpiryHours int           `json:"expiryHours"`
+		Mailer      mailer.Config `json:"mailer"`
+	}
+
+	b, _ := json.Marshal(fields)
+	if err := json.Unmarshal(b, &invitationConfig); err != nil {
+		return nil, fmt.Errorf("failed to read invitation config: %w", err)
+	}
+
+	if invitationConfig.SigningKey == "" {
+		log.Println("Invitations are disabled: invitation.signingKey is not set")
+		return nil, nil
+	}
+
+	// The tokens have their own key, so they are never accepted where the API tokens are.
+	expiry := time.Duration(invitationConfig.ExpiryHours) * time.Hour
+	tokens := tokengen.NewTokenGenerator(invitationConfig.SigningKey, "lockari-invitations", entity_invitation.DefaultExpiry)
+
+	sender, err := mailer.New(invitationConfig.Mailer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+
+	repo, err := repo_invitation.InitializeInvitationRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize invitation repository: %w", err)
+	}
+
+	tenants, err := repo_tenant.InitializeTenantRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tenant repository: %w", err)
+	}
+
+	svc, err := svc_invitation.InitializeInvitationService(repo, tenants, auth, tokens, sender, outbox, audit, invitationConfig.Link, expiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize invitation service: %w", err)
+	}
+
+	return svc, nil
+}
+
+func initializeVault(db database.FirebaseDBInterface, authz authorization.Authorizer, outbox entity_outbox.OutboxService, trash entity_trash.TrashService) (entity_vault.VaultService, error) {
+	repo, err := repo_vault.InitializeVaultRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vault repository: %w", err)
+	}
+
+	svc, err := svc_vault.InitializeVaultService(repo, authz, outbox, trash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vault service: %w", err)
+	}
+
+	return svc, nil
+}
+
+func initializeShare(db database.FirebaseDBInterface, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (entity_share.ShareService, error) {
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vault repository: %w", err)
+	}
+
+	groups, err := repo_group.InitializeGroupRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize group repository: %w", err)
+	}
+
+	expirations, err := repo_share.InitializeExpirationRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize share expiration repository: %w", err)
+	}
+
+	svc, err := svc_share.InitializeShareService(vaults, groups, expirations, authz, outbox, audit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize share service: %w", err)
+	}
+
+	return svc, nil
+}
+
+// initializeShareExpiry returns the sweeper of the expired shares and the guard that wraps
+// authz, so a check never uses a share past its expiration.
+func initializeShareExpiry(db database.FirebaseDBInterface, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (authorization.Authorizer, entity_share.SweeperService, error) {
+	expirations, err := repo_share.InitializeExpirationRepository(db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize share expiration repository: %w", err)
+	}
+
+	sweeper, err := svc_share.InitializeSweeperService(expirations, authz, outbox, audit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize sweeper service: %w", err)
+	}
+
+	guard, err := svc_share.InitializeExpiryGuard(authz, expirations, time.Minute)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize expiry guard: %w", err)
+	}
+
+	return guard, sweeper, nil
+}
+
+// initializeExternalShare reads externalShare.link, the page of the web app where the admins
+// review a request, followed by its ID, externalShare.mailer and externalShare.expiryHours
+// (168 when missing). Sharing with other tenants is disabled without a link.
+func initializeExternalShare(db database.FirebaseDBInterface, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, fields interface{}) (entity_externalshare.ExternalShareService, error) {
+
+	var externalShareConfig struct {
+		Link        string        `json:"link"`
+		ExpiryHours int           `json:"expiryHours"`
+		Mailer      mailer.Config `json:"mailer"`
+	}
+
+	b, _ := json.Marshal(fields)
+	if err := json.Unmarshal(b, &externalShareConfig); err != nil {
+		return nil, fmt.Errorf("failed to read external share config: %w", err)
+	}
+
+	if externalShareConfig.Link == "" {
+		log.Println("External shares are disabled: externalShare.link is not set")
+		return nil, nil
+	}
+
+	sender, err := mailer.New(externalShareConfig.Mailer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+
+	repo, err := repo_externalshare.InitializeExternalShareRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize external share repository: %w", err)
+	}
+
+	tenants, err := repo_tenant.InitializeTenantRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tenant repository: %w", err)
+	}
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vault repository: %w", err)
+	}
+
+	expiry := time.Duration(externalShareConfig.ExpiryHours) * time.Hour
+	svc, err := svc_externalshare.InitializeExternalShareService(repo, tenants, vaults, authz, sender, outbox, audit, externalShareConfig.Link, expiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize external share service: %w", err)
+	}
+
+	return svc, nil
+}
+
+// initializeAccessRequest reads accessRequest.signingKey, the secret of the tokens of the
+// approval links, accessRequest.link, the page of the web app that decides with them, and
+// accessRequest.mailer. Access requests are disabled without a signing key.
+func initializeAccessRequest(db database.FirebaseDBInterface, auth authenticator.Authenticator, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, fields interface{}) (entity_accessrequest.AccessRequestService, error) {
+
+	var accessRequestConfig struct {
+		SigningKey string        `json:"signingKey"`
+		Link       string        `json:"link"`
+		Mailer     mailer.Config `json:"mailer"`
+	}
+
+	b, _ := json.Marshal(fields)
+	if err := json.Unmarshal(b, &accessRequestConfig); err != nil {
+		return nil, fmt.Errorf("failed to read access request config: %w", err)
+	}
+
+	if accessRequestConfig.SigningKey == "" {
+		log.Println("Access requests are disabled: accessRequest.signingKey is not set")
+		return nil, nil
+	}
+
+	tokens := tokengen.NewTokenGenerator(accessRequestConfig.SigningKey, "lockari-access-requests", entity_accessrequest.DecisionWindow)
+
+	sender, err := mailer.New(accessRequestConfig.Mailer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+
+	repo, err := repo_accessrequest.InitializeAccessRequestRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize access request repository: %w", err)
+	}
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize vault repository: %w", err)
+	}
+
+	secrets, err := repo_secret.InitializeSecretRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret repository: %w", err)
+	}
+
+	svc, err := svc_accessrequest.InitializeAccessRequestService(repo, vaults, secrets, auth, authz, tokens, sender, outbox, audit, accessRequestConfig.Link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize access request service: %w", err)
+	}
+
+	return svc, nil
+}
+
+func initializeSecret(db database.FirebaseDBInterface, vaultSvc entity_vault.VaultService, storage cryptserver.StorageCryptInterface, authz authorization.Authorizer, trash entity_trash.TrashService) (entity_secret.SecretService, error) {
+	repo, err := repo_secret.InitializeSecretRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret repository: %w", err)
+	}
+
+	svc, err := svc_secret.InitializeSecretService(repo, vaultSvc, storage, authz, trash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret service: %w", err)
+	}
+
+	return svc, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/synera-br/lockari-backend-app/config"
+	"github.com/synera-br/lockari-backend-app/internal/migrations"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/migration"
+)
+
+// migrate applies the document migrations to the database configured for the backend.
+//
+//	go run ./cmd/migrate -status
+//	go run ./cmd/migrate -dry-run
+//	go run ./cmd/migrate -target 0001_signup_user -batch 100
+func main() {
+
+	dryRun := flag.Bool("dry-run", false, "report the documents that would change without writing")
+	status := flag.Bool("status", false, "print the state of every migration and exit")
+	target := flag.String("target", "", "stop after the migration with this version")
+	batchSize := flag.Int("batch", migration.DefaultBatchSize, "documents read and written per batch")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := initializeDatabase(cfg.Fields["database"], cfg.Fields["firebase"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	runner, err := migration.NewRunner(db, *batchSize, migrations.All()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if *status {
+		records, err := runner.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, r := range records {
+			state := string(r.Status)
+			if state == "" {
+				state = "pending"
+			}
+			fmt.Printf("%-30s %-8s scanned=%d migrated=%d %s\n", r.Version, state, r.Scanned, r.Migrated, r.Error)
+		}
+		return
+	}
+
+	reports, err := runner.Run(ctx, migration.Options{DryRun: *dryRun, Target: *target})
+	for _, r := range reports {
+		switch {
+		case r.Skipped:
+			fmt.Printf("%-30s already applied\n", r.Version)
+		case r.DryRun:
+			fmt.Printf("%-30s dry run: %d scanned, %d would change\n", r.Version, r.Scanned, r.Migrated)
+		default:
+			fmt.Printf("%-30s %d scanned, %d migrated (resumed: %t)\n", r.Version, r.Scanned, r.Migrated, r.Resumed)
+		}
+	}
+	if err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
+
+func initializeDatabase(databaseField, firebaseField interface{}) (database.FirebaseDBInterface, error) {
+	var dbConfig database.Config
+
+	b, _ := json.Marshal(databaseField)
+	if err := json.Unmarshal(b, &dbConfig); err != nil {
+		return nil, fmt.Errorf("failed to read database config: %w", err)
+	}
+
+	var fConfig authenticator.FirebaseConfig
+
+	b, _ = json.Marshal(firebaseField)
+	if err := json.Unmarshal(b, &fConfig); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal firebase config: %w", err)
+	}
+
+	return database.Open(dbConfig, database.FirebaseConfig{
+		ProjectID:             fConfig.ProjectID,
+		APIKey:                fConfig.APIKey,
+		DatabaseURL:           fConfig.DatabaseURL,
+		StorageBucket:         fConfig.StorageBucket,
+		AppID:                 fConfig.AppID,
+		AuthDomain:            fConfig.AuthDomain,
+		MessagingSenderID:     fConfig.MessagingSenderID,
+		ServiceAccountKeyPath: fConfig.ServiceAccountKeyPath,
+	})
+}
@@ -0,0 +1,194 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	entity_share "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// AccessRequestCollection lives under tenant/<tenantId>.
+	AccessRequestCollection = "access_requests"
+
+	// TokenScope is the scope of the signed links sent to the approvers.
+	TokenScope = "access_request"
+
+	// Role is the role given on the vault when a request is approved.
+	Role = entity_share.ROLE_READER
+
+	// DefaultDuration is the access requested when the request sets none.
+	DefaultDuration = time.Hour
+
+	// MinDuration and MaxDuration bound the access a request can ask for.
+	MinDuration = 15 * time.Minute
+	MaxDuration = 12 * time.Hour
+
+	// DecisionWindow is the time the approvers have to decide.
+	DecisionWindow = 24 * time.Hour
+)
+
+// AccessRequestSortableFields are the fields accepted by the orderBy query parameter.
+var AccessRequestSortableFields = []string{"createdAt", "expiresAt", "status"}
+
+// AccessRequestRepository interface defines methods for store and retrieve the access requests of a tenant.
+type AccessRequestRepository interface {
+	Create(ctx context.Context, request map[string]interface{}) (*AccessRequest, error)
+	Get(ctx context.Context, id string) (*AccessRequest, error)
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]AccessRequest, string, error)
+	// Decide applies patch to the pending request in a transaction, with the expiration of the
+	// share and the outbox entry of its tuple when it is approved, so a request is decided only once.
+	Decide(ctx context.Context, id string, patch map[string]interface{}, expiration map[string]interface{}, entry map[string]interface{}) error
+}
+
+// AccessRequestService manages the requests of temporary read access to the vaults of the
+// tenant of the context.
+type AccessRequestService interface {
+	// Create stores a pending request and emails a signed link to the admins of the vault.
+	Create(ctx context.Context, request *AccessRequest) (*AccessRequest, error)
+	Get(ctx context.Context, id string) (*AccessRequest, error)
+	// List returns the requests of the vault to its admins or, without a vault, the requests
+	// of the user of the context.
+	List(ctx context.Context, vaultID string, opts database.QueryOptions) ([]AccessRequest, string, error)
+	// Approve gives the reader role on the whole vault to the requester until GrantedUntil, even
+	// when the request names a secret; the share sweeper removes it afterwards.
+	Approve(ctx context.Context, id string, comment string) (*AccessRequest, error)
+	Deny(ctx context.Context, id string, comment string) (*AccessRequest, error)
+	// Decide approves or denies the request of a signed link. The link only works for the
+	// approver it was sent to.
+	Decide(ctx context.Context, token string, decision Decision, comment string) (*AccessRequest, error)
+}
+
+type Status string
+
+const (
+	STATUS_PENDING  Status = "pending"
+	STATUS_APPROVED Status = "approved"
+	STATUS_DENIED   Status = "denied"
+	// STATUS_EXPIRED and STATUS_ENDED are never stored: pending requests are reported as
+	// expired after ExpiresAt and approved ones as ended after GrantedUntil.
+	STATUS_EXPIRED Status = "expired"
+	STATUS_ENDED   Status = "ended"
+)
+
+// Decision is the answer of an approver to a request.
+type Decision string
+
+const (
+	DECISION_APPROVE Decision = "approve"
+	DECISION_DENY    Decision = "deny"
+)
+
+// AccessRequest
+// A request of a user for temporary read access to a vault with a justification. An admin of the
+// vault approves it before ExpiresAt, which gives the requester the reader role until GrantedUntil.
+// SecretID only tells the admins which secret is needed: the role is given on the whole vault.
+type AccessRequest struct {
+	ID            string `json:"id,omitempty"`
+	TenantID      string `json:"tenantId"`
+	VaultID       string `json:"vaultId" binding:"required"`
+	VaultName     string `json:"vaultName,omitempty"`
+	SecretID      string `json:"secretId,omitempty"`
+	SecretName    string `json:"secretName,omitempty"`
+	RequesterID   string `json:"requesterId"`
+	Justification string `json:"justification" binding:"required"`
+	// Duration of the access, in minutes.
+	Duration     int        `json:"duration,omitempty"`
+	Status       Status     `json:"status"`
+	DecidedBy    string     `json:"decidedBy,omitempty"`
+	DecidedAt    *time.Time `json:"decidedAt,omitempty"`
+	Comment      string     `json:"comment,omitempty"`
+	GrantedUntil *time.Time `json:"grantedUntil,omitempty"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// IsValid
+// This method validates the AccessRequest struct to ensure that required fields are present.
+func (r *AccessRequest) IsValid() error {
+
+	if r == nil {
+		return errors.New("invalid access request: request cannot be nil")
+	}
+
+	if r.TenantID == "" || r.VaultID == "" || r.RequesterID == "" {
+		return errors.New("invalid access request: tenant, vault and requester are required")
+	}
+
+	if len(r.Justification) < 10 || len(r.Justification) > 1000 {
+		return errors.New("invalid access request: justification must have between 10 and 1000 characters")
+	}
+
+	if d := r.AccessDuration(); d < MinDuration || d > MaxDuration {
+		return errors.New("invalid access request: duration must be between " + MinDuration.String() + " and " + MaxDuration.String())
+	}
+
+	return nil
+}
+
+// AccessDuration returns the duration of the access.
+func (r *AccessRequest) AccessDuration() time.Duration {
+	return time.Duration(r.Duration) * time.Minute
+}
+
+// IsPending reports whether the request can still be decided at now.
+func (r *AccessRequest) IsPending(now time.Time) bool {
+	return r.Status == STATUS_PENDING && now.Before(r.ExpiresAt)
+}
+
+// Effective returns the request with the expired or ended status at now.
+func (r AccessRequest) Effective(now time.Time) AccessRequest {
+	switch {
+	case r.Status == STATUS_PENDING && !now.Before(r.ExpiresAt):
+		r.Status = STATUS_EXPIRED
+	case r.Status == STATUS_APPROVED && r.GrantedUntil != nil && !now.Before(*r.GrantedUntil):
+		r.Status = STATUS_ENDED
+	}
+	return r
+}
+
+// Share returns the share given to the requester when the request is approved at now.
+func (r *AccessRequest) Share(now time.Time) *entity_share.Share {
+	until := now.Add(r.AccessDuration()).UTC().Truncate(time.Second)
+	return &entity_share.Share{
+		VaultID:     r.VaultID,
+		SubjectType: entity_share.SUBJECT_USER,
+		SubjectID:   r.RequesterID,
+		Role:        Role,
+		ExpiresAt:   &until,
+	}
+}
+
+// Object returns the OpenFGA object of the vault of the request.
+func (r *AccessRequest) Object() string {
+	return authorization.Object(authorization.TypeVault, r.VaultID)
+}
+
+// NewAccessRequest creates a pending request of the user, to be decided within DecisionWindow.
+func NewAccessRequest(id string, data AccessRequest, tenantID, vaultName, secretName, userID string, now time.Time) *AccessRequest {
+	now = now.UTC()
+	duration := data.Duration
+	if duration == 0 {
+		duration = int(DefaultDuration.Minutes())
+	}
+	return &AccessRequest{
+		ID:            id,
+		TenantID:      tenantID,
+		VaultID:       data.VaultID,
+		VaultName:     vaultName,
+		SecretID:      data.SecretID,
+		SecretName:    secretName,
+		RequesterID:   userID,
+		Justification: strings.TrimSpace(data.Justification),
+		Duration:      duration,
+		Status:        STATUS_PENDING,
+		ExpiresAt:     now.Add(DecisionWindow),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+// AuditFilter
+// This struct narrows the events returned by List. Empty fields are ignored.
+// From and To are inclusive and compared with the event timestamp.
+type AuditFilter struct {
+	EventTypes []EventType
+	From       *time.Time
+	To         *time.Time
+	UserID     string
+	TenantID   string
+}
+
+// HasRange reports whether the filter limits the timestamp.
+func (f AuditFilter) HasRange() bool {
+	return f.From != nil || f.To != nil
+}
+
+// Conditionals converts the filter into database conditionals.
+// Timestamps are stored with the fixed width TimestampLayout, so the range is compared as strings.
+func (f AuditFilter) Conditionals() ([]database.Conditional, error) {
+
+	if f.From != nil && f.To != nil && f.From.After(*f.To) {
+		return nil, errors.New("invalid audit filter: from must be before to")
+	}
+
+	var conditionals []database.Conditional
+
+	switch len(f.EventTypes) {
+	case 0:
+	case 1:
+		conditionals = append(conditionals, database.Conditional{Field: "eventType", Value: f.EventTypes[0].String(), Filter: database.FilterEquals})
+	default:
+		types := make([]string, 0, len(f.EventTypes))
+		for _, t := range f.EventTypes {
+			types = append(types, t.String())
+		}
+		conditionals = append(conditionals, database.Conditional{Field: "eventType", Value: types, Filter: database.FilterIn})
+	}
+
+	if f.From != nil {
+		conditionals = append(conditionals, database.Conditional{Field: "timestamp", Value: FormatTimestamp(*f.From), Filter: database.FilterGreaterThanOrEqual})
+	}
+
+	if f.To != nil {
+		conditionals = append(conditionals, database.Conditional{Field: "timestamp", Value: FormatTimestamp(*f.To), Filter: database.FilterLessThanOrEqual})
+	}
+
+	if f.UserID != "" {
+		conditionals = append(conditionals, database.Conditional{Field: "user.uid", Value: f.UserID, Filter: database.FilterEquals})
+	}
+
+	if f.TenantID != "" {
+		conditionals = append(conditionals, database.Conditional{Field: "tenantId", Value: f.TenantID, Filter: database.FilterEquals})
+	}
+
+	for _, c := range conditionals {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return conditionals, nil
+}
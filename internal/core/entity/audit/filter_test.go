@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+func TestAuditFilterConditionals(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	filter := AuditFilter{
+		EventTypes: []EventType{LOGIN_FAILURE, ACCESS_DENIED},
+		From:       &from,
+		To:         &to,
+		TenantID:   "t1",
+	}
+
+	conditionals, err := filter.Conditionals()
+	require.NoError(t, err)
+	assert.Equal(t, []database.Conditional{
+		{Field: "eventType", Value: []string{"LOGIN_FAILURE", "ACCESS_DENIED"}, Filter: database.FilterIn},
+		{Field: "timestamp", Value: "2024-05-01T00:00:00.000000000Z", Filter: database.FilterGreaterThanOrEqual},
+		{Field: "timestamp", Value: "2024-05-02T00:00:00.000000000Z", Filter: database.FilterLessThanOrEqual},
+		{Field: "tenantId", Value: "t1", Filter: database.FilterEquals},
+	}, conditionals)
+
+	conditionals, err = AuditFilter{EventTypes: []EventType{LOGOUT}}.Conditionals()
+	require.NoError(t, err)
+	assert.Equal(t, database.FilterEquals, conditionals[0].Filter)
+
+	_, err = AuditFilter{From: &to, To: &from}.Conditionals()
+	assert.Error(t, err)
+}
+
+func TestAuditEventTimestampNormalized(t *testing.T) {
+	event := NewAccessDeniedEvent("alice", "", "t1", "vault:v1", "can_read", Client{})
+
+	for value, stored := range map[string]string{
+		"2024-05-01T00:00:00Z":         "2024-05-01T00:00:00.000000000Z",
+		"2024-05-01T00:00:00.5Z":       "2024-05-01T00:00:00.500000000Z",
+		"2024-05-01T02:00:00+02:00":    "2024-05-01T00:00:00.000000000Z",
+		"2024-04-30T21:00:00.25-03:00": "2024-05-01T00:00:00.250000000Z",
+	} {
+		event.Timestamp = value
+		require.NoError(t, event.IsValidInternal())
+		assert.Equal(t, stored, event.Timestamp, value)
+	}
+
+	event.Timestamp = "yesterday"
+	assert.Error(t, event.IsValidInternal())
+}
@@ -20,6 +20,42 @@ const (
 	PERMISSION_REVOKED EventType = "PERMISSION_REVOKED"
 	ACCESS_DENIED      EventType = "ACCESS_DENIED"
 
+	// Eventos de Backup
+	BACKUP_CREATED  EventType = "BACKUP_CREATED"
+	BACKUP_RESTORED EventType = "BACKUP_RESTORED"
+	BACKUP_FAILED   EventType = "BACKUP_FAILED"
+
+	// Eventos de Tenant
+	TENANT_CREATED      EventType = "TENANT_CREATED"
+	TENANT_MODIFIED     EventType = "TENANT_MODIFIED"
+	MEMBER_ADDED        EventType = "MEMBER_ADDED"
+	MEMBER_ROLE_CHANGED EventType = "MEMBER_ROLE_CHANGED"
+	MEMBER_REMOVED      EventType = "MEMBER_REMOVED"
+
+	// Eventos de Convite
+	INVITATION_SENT     EventType = "INVITATION_SENT"
+	INVITATION_ACCEPTED EventType = "INVITATION_ACCEPTED"
+	INVITATION_DECLINED EventType = "INVITATION_DECLINED"
+	INVITATION_REVOKED  EventType = "INVITATION_REVOKED"
+
+	// Eventos de Grupo
+	GROUP_CREATED        EventType = "GROUP_CREATED"
+	GROUP_MODIFIED       EventType = "GROUP_MODIFIED"
+	GROUP_DELETED        EventType = "GROUP_DELETED"
+	GROUP_MEMBER_ADDED   EventType = "GROUP_MEMBER_ADDED"
+	GROUP_MEMBER_REMOVED EventType = "GROUP_MEMBER_REMOVED"
+
+	// Eventos de Compartilhamento Externo
+	EXTERNAL_SHARE_REQUESTED EventType = "EXTERNAL_SHARE_REQUESTED"
+	EXTERNAL_SHARE_APPROVED  EventType = "EXTERNAL_SHARE_APPROVED"
+	EXTERNAL_SHARE_REJECTED  EventType = "EXTERNAL_SHARE_REJECTED"
+	EXTERNAL_SHARE_REVOKED   EventType = "EXTERNAL_SHARE_REVOKED"
+
+	// Eventos de Acesso Temporário
+	ACCESS_REQUESTED        EventType = "ACCESS_REQUESTED"
+	ACCESS_REQUEST_APPROVED EventType = "ACCESS_REQUEST_APPROVED"
+	ACCESS_REQUEST_DENIED   EventType = "ACCESS_REQUEST_DENIED"
+
 	// Eventos de Sistema
 	TOKEN_GENERATED     EventType = "TOKEN_GENERATED"
 	TOKEN_REVOKED       EventType = "TOKEN_REVOKED"
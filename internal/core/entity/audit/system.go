@@ -8,16 +8,64 @@ import (
 	"github.com/synera-br/lockari-backend-app/pkg/database"
 )
 
+// AuditSortableFields are the fields accepted by the orderBy query parameter.
+var AuditSortableFields = []string{"timestamp", "createdAt", "eventType"}
+
+// TimestampLayout is the layout of the stored timestamp and createdAt. It is always UTC with
+// nanoseconds, so the stored strings have a fixed width and sort in time order.
+const TimestampLayout = "2006-01-02T15:04:05.000000000Z"
+
+// FormatTimestamp formats t with TimestampLayout.
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+// normalizeTimestamp parses an RFC3339 value, with any offset and precision, and formats it with TimestampLayout.
+func normalizeTimestamp(value string) (string, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return "", errors.New("invalid audit event: timestamp must be RFC3339")
+	}
+	return FormatTimestamp(t), nil
+}
+
+// normalize sets the timestamps to TimestampLayout, and createdAt to now when it is empty.
+func (a *AuditSystemEvent) normalize() error {
+
+	timestamp, err := normalizeTimestamp(a.Timestamp)
+	if err != nil {
+		return err
+	}
+	a.Timestamp = timestamp
+
+	if a.CreatedAt == "" {
+		a.CreatedAt = FormatTimestamp(time.Now())
+		return nil
+	}
+
+	createdAt, err := normalizeTimestamp(a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	a.CreatedAt = createdAt
+
+	return nil
+}
+
 type AuditSystemEventRepository interface {
 	Create(ctx context.Context, audit map[string]interface{}) (*AuditSystemEvent, error)
-	Get(ctx context.Context, filters database.Conditional) (*AuditSystemEvent, error)
-	List(ctx context.Context, filters database.Conditional) ([]AuditSystemEvent, error)
+	Get(ctx context.Context, id string) (*AuditSystemEvent, error)
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]AuditSystemEvent, string, error)
 }
 
 type AuditSystemEventService interface {
 	Create(ctx context.Context, event *AuditSystemEvent) (*AuditSystemEvent, error)
 	Get(ctx context.Context, id string) (*AuditSystemEvent, error)
-	List(ctx context.Context) ([]AuditSystemEvent, error)
+	// List returns a page of the events that match the filter, newest first unless another order is requested.
+	List(ctx context.Context, filter AuditFilter, opts database.QueryOptions) ([]AuditSystemEvent, string, error)
+	// Record stores an event generated by the backend itself (e.g. ACCESS_DENIED).
+	// Unlike Create it does not require the X-TOKEN of the client application.
+	Record(ctx context.Context, event *AuditSystemEvent) (*AuditSystemEvent, error)
 }
 
 type AuditSystemEvent struct {
@@ -26,8 +74,12 @@ type AuditSystemEvent struct {
 	User          User          `json:"user" binding:"required"`
 	ClientInfo    Client        `json:"clientInfo" binding:"required"`
 	FailureReason FailureReason `json:"failureReason,omitempty"`
-	Timestamp     string        `json:"timestamp" binding:"required"` // ISO 8601 format
-	CreatedAt     string        `json:"createdAt,omitempty"`          // ISO 8601 format
+	TenantID      string        `json:"tenantId,omitempty"`
+	Resource      string        `json:"resource,omitempty"` // OpenFGA object, e.g. vault:<id>
+	Action        string        `json:"action,omitempty"`   // Relation or operation, e.g. can_write
+	Reason        string        `json:"reason,omitempty"`
+	Timestamp     string        `json:"timestamp" binding:"required"` // RFC3339, stored as TimestampLayout
+	CreatedAt     string        `json:"createdAt,omitempty"`          // RFC3339, stored as TimestampLayout
 }
 
 func (a *AuditSystemEvent) IsValid() error {
@@ -55,8 +107,8 @@ func (a *AuditSystemEvent) IsValid() error {
 		return errors.New("invalid audit event: timestamp is required")
 	}
 
-	if a.CreatedAt == "" {
-		a.CreatedAt = time.Now().Format(time.RFC3339)
+	if err := a.normalize(); err != nil {
+		return err
 	}
 
 	if err := a.FailureReason.IsValid(); err != nil {
@@ -65,3 +117,62 @@ func (a *AuditSystemEvent) IsValid() error {
 
 	return nil
 }
+
+// IsValidInternal
+// This method validates events generated by the backend. Only the user id, the event type
+// and the timestamp are required, since the client data may be unavailable.
+func (a *AuditSystemEvent) IsValidInternal() error {
+	if a == nil {
+		return errors.New("invalid audit event: event cannot be nil")
+	}
+
+	if a.User.Uid == "" {
+		return errors.New("invalid user: uid is required")
+	}
+
+	if a.EventType == "" {
+		return errors.New("invalid audit event: eventType is required")
+	}
+
+	if a.Timestamp == "" {
+		a.Timestamp = FormatTimestamp(time.Now())
+	}
+
+	if err := a.normalize(); err != nil {
+		return err
+	}
+
+	return a.FailureReason.IsValid()
+}
+
+// NewResourceEvent creates an event generated by the backend for an action on resource,
+// e.g. a VAULT_DELETED event with the "trash" action.
+func NewResourceEvent(eventType EventType, userID, tenantID, resource, action, reason string) *AuditSystemEvent {
+	now := FormatTimestamp(time.Now())
+	return &AuditSystemEvent{
+		EventType: eventType,
+		User:      User{Uid: userID},
+		TenantID:  tenantID,
+		Resource:  resource,
+		Action:    action,
+		Reason:    reason,
+		Timestamp: now,
+		CreatedAt: now,
+	}
+}
+
+// NewAccessDeniedEvent creates an ACCESS_DENIED event for a user that lacks relation on resource.
+func NewAccessDeniedEvent(userID, email, tenantID, resource, relation string, client Client) *AuditSystemEvent {
+	now := FormatTimestamp(time.Now())
+	return &AuditSystemEvent{
+		EventType:  ACCESS_DENIED,
+		User:       User{Uid: userID, Email: email},
+		ClientInfo: client,
+		TenantID:   tenantID,
+		Resource:   resource,
+		Action:     relation,
+		Reason:     "missing relation " + relation,
+		Timestamp:  now,
+		CreatedAt:  now,
+	}
+}
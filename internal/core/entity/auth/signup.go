@@ -11,7 +11,7 @@ import (
 // SignupEventRepository interface defines methods for store and retrieve signup events
 type SignupEventRepository interface {
 	Create(ctx context.Context, filters map[string]interface{}) (*Signup, error)
-	Get(ctx context.Context, filters database.Conditional) (*Signup, error)
+	Get(ctx context.Context, id string) (*Signup, error)
 	List(ctx context.Context, filters database.Conditional) ([]Signup, error)
 }
 
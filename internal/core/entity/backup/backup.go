@@ -0,0 +1,210 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// BackupCollection is a global collection, so the backups of a tenant are not part of its snapshot.
+	BackupCollection = "backups"
+
+	// ArchiveVersion is written in the header of the archives and checked on restore.
+	ArchiveVersion = 1
+
+	// SchedulerActor is the user recorded in the backups and audit events of the scheduler.
+	SchedulerActor = "system"
+
+	// TypeBackup is the OpenFGA type used as the resource of the backup audit events.
+	TypeBackup = "backup"
+)
+
+// BackupSortableFields are the fields accepted by the orderBy query parameter.
+var BackupSortableFields = []string{"createdAt", "status", "type"}
+
+// BackupRepository interface defines methods for store and retrieve the backup records.
+// The archives themselves are kept in a storage.Store.
+type BackupRepository interface {
+	Create(ctx context.Context, id string, backup map[string]interface{}) (*Backup, error)
+	Get(ctx context.Context, id string) (*Backup, error)
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]Backup, string, error)
+	Update(ctx context.Context, id string, backup map[string]interface{}) error
+	Delete(ctx context.Context, id string) error
+	// ListExpired returns the backups of every tenant that expire at or before the given time. Only for the scheduler.
+	ListExpired(ctx context.Context, before time.Time) ([]Backup, error)
+	// Export calls fn for every document stored under tenant/<tenantId>, a document before its subcollections.
+	Export(ctx context.Context, tenantID string, fn func(doc TenantDocument) error) error
+	// Import writes the documents under tenant/<tenantId>, replacing the existing ones. The
+	// documents are written in batches, so a failed import can leave some of them written.
+	Import(ctx context.Context, tenantID string, docs []TenantDocument) error
+	// HasDocuments reports whether anything is stored under tenant/<tenantId>.
+	HasDocuments(ctx context.Context, tenantID string) (bool, error)
+}
+
+// BackupService creates and restores the backups of the tenant of the context.
+// Only the owners and admins of the tenant can use it.
+type BackupService interface {
+	// Create snapshots every document of the tenant into a signed archive.
+	Create(ctx context.Context) (*Backup, error)
+	List(ctx context.Context, opts database.QueryOptions) ([]Backup, string, error)
+	Get(ctx context.Context, id string) (*Backup, error)
+	// Restore writes the documents of the backup back into its tenant, or into an empty tenant.
+	Restore(ctx context.Context, id string, request RestoreRequest) (*RestoreReport, error)
+}
+
+// SchedulerService backs up every tenant and removes the expired backups.
+type SchedulerService interface {
+	BackupAll(ctx context.Context) (*ScheduleReport, error)
+	// Run calls BackupAll every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type BackupType string
+
+const (
+	BACKUP_MANUAL    BackupType = "manual"
+	BACKUP_SCHEDULED BackupType = "scheduled"
+)
+
+type BackupStatus string
+
+const (
+	BACKUP_IN_PROGRESS BackupStatus = "in_progress"
+	BACKUP_COMPLETED   BackupStatus = "completed"
+	BACKUP_FAILED      BackupStatus = "failed"
+)
+
+// Backup
+// The record of a snapshot of a tenant. The archive holds the documents of tenant/<tenantId>
+// as they are stored, so secret values stay encrypted, and is signed with the backup key.
+type Backup struct {
+	ID          string         `json:"id,omitempty"`
+	TenantID    string         `json:"tenantId"`
+	CreatedBy   string         `json:"createdBy"`
+	Type        BackupType     `json:"type"`
+	Status      BackupStatus   `json:"status"`
+	Metadata    BackupMetadata `json:"metadata"`
+	Storage     BackupStorage  `json:"storage"`
+	Error       string         `json:"error"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	CompletedAt *time.Time     `json:"completedAt,omitempty"`
+}
+
+type BackupMetadata struct {
+	TotalDocuments int `json:"totalDocuments"`
+	TotalSecrets   int `json:"totalSecrets"`
+	// TotalSize is the size of the compressed archive in bytes.
+	TotalSize int64 `json:"totalSize"`
+	// Checksum is the hex SHA-256 of the compressed archive.
+	Checksum string `json:"checksum"`
+}
+
+type BackupStorage struct {
+	Provider  string     `json:"provider"`
+	Location  string     `json:"location"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// IsValid
+// This method validates the Backup struct to ensure that required fields are present.
+func (b *Backup) IsValid() error {
+
+	if b == nil {
+		return errors.New("invalid backup: backup cannot be nil")
+	}
+
+	if b.TenantID == "" || b.CreatedBy == "" {
+		return errors.New("invalid backup: tenant and creator are required")
+	}
+
+	if b.Type != BACKUP_MANUAL && b.Type != BACKUP_SCHEDULED {
+		return errors.New("invalid backup: type must be manual or scheduled")
+	}
+
+	return nil
+}
+
+// IsRestorable reports whether the archive of the backup was stored.
+func (b *Backup) IsRestorable() bool {
+	return b.Status == BACKUP_COMPLETED && b.Storage.Location != ""
+}
+
+// Object returns the OpenFGA object of the backup, used as the resource of its audit events.
+func (b *Backup) Object() string {
+	return authorization.Object(TypeBackup, b.ID)
+}
+
+// ArchiveKey returns the key of the archive in the store.
+func (b *Backup) ArchiveKey() string {
+	return b.TenantID + "/" + b.ID + ".jsonl.gz"
+}
+
+// NewBackup creates the record of a backup of the tenant started at now by the user.
+// A zero retention keeps the backup until it is deleted. Times are kept in seconds, so
+// expiresAt can be compared as an RFC 3339 string.
+func NewBackup(id, tenantID, userID string, backupType BackupType, now time.Time, retention time.Duration) *Backup {
+
+	now = now.UTC().Truncate(time.Second)
+	backup := &Backup{
+		ID:        id,
+		TenantID:  tenantID,
+		CreatedBy: userID,
+		Type:      backupType,
+		Status:    BACKUP_IN_PROGRESS,
+		CreatedAt: now,
+	}
+
+	if retention > 0 {
+		expiresAt := now.Add(retention)
+		backup.Storage.ExpiresAt = &expiresAt
+	}
+
+	return backup
+}
+
+// TenantDocument is a document of a tenant as stored in the archives. Collection is relative
+// to tenant/<tenantId>, e.g. vaults/<vaultId>/secrets, so it can be restored into another tenant.
+type TenantDocument struct {
+	Collection string                 `json:"collection"`
+	ID         string                 `json:"id"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// CollectionID returns the last segment of the collection, e.g. secrets.
+func (d *TenantDocument) CollectionID() string {
+	return d.Collection[strings.LastIndex(d.Collection, "/")+1:]
+}
+
+// RestoreRequest selects where a backup is restored. An empty target restores into the
+// tenant of the backup; another target must be an empty tenant the user administers.
+type RestoreRequest struct {
+	TargetTenantID string `json:"targetTenantId,omitempty"`
+}
+
+// RestoreReport summarises a restore. Remapped counts the vaults and secrets that got a
+// new ID because they were restored into another tenant.
+type RestoreReport struct {
+	BackupID       string    `json:"backupId"`
+	SourceTenantID string    `json:"sourceTenantId"`
+	TargetTenantID string    `json:"targetTenantId"`
+	Documents      int       `json:"documents"`
+	Vaults         int       `json:"vaults"`
+	Remapped       int       `json:"remapped"`
+	StartedAt      time.Time `json:"startedAt"`
+	EndedAt        time.Time `json:"endedAt"`
+}
+
+// ScheduleReport summarises a run of the scheduler.
+type ScheduleReport struct {
+	Tenants   int       `json:"tenants"`
+	Created   int       `json:"created"`
+	Failed    int       `json:"failed"`
+	Expired   int       `json:"expired"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+}
@@ -0,0 +1,239 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	entity_share "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// ExternalShareCollection is global: a request involves two tenants.
+	ExternalShareCollection = "external_share_requests"
+
+	// TypeExternalShareRequest is the OpenFGA type of the approved requests.
+	TypeExternalShareRequest = "external_share_request"
+
+	// RequiredApprovals is the number of distinct admins that must approve a request.
+	RequiredApprovals = 2
+
+	// DefaultExpiry is the time the admins have to decide when the request sets none.
+	DefaultExpiry = 7 * 24 * time.Hour
+
+	// MaxExpiry is the longest time a request can wait for its approvals.
+	MaxExpiry = 30 * 24 * time.Hour
+)
+
+// ExternalShareSortableFields are the fields accepted by the orderBy query parameter.
+var ExternalShareSortableFields = []string{"createdAt", "expiresAt", "status", "vaultId"}
+
+// ExternalShareRepository interface defines methods for store and retrieve external share requests.
+type ExternalShareRepository interface {
+	Create(ctx context.Context, request map[string]interface{}) (*ExternalShareRequest, error)
+	Get(ctx context.Context, id string) (*ExternalShareRequest, error)
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]ExternalShareRequest, string, error)
+	// Transition applies patch in a transaction, only if the request is still in status with the
+	// given number of decisions, and creates the outbox entry of its tuples, if any. Two admins
+	// deciding at the same time cannot both succeed. In the same transaction it creates the guest
+	// membership, unless the user already has one in that tenant, or deletes the membership
+	// removeGuest, if it is still a guest one.
+	Transition(ctx context.Context, id string, status Status, decisions int, patch map[string]interface{}, entry map[string]interface{}, guest map[string]interface{}, removeGuest string) error
+}
+
+// ExternalShareService manages the requests to share the vaults of the tenant of the context
+// with users of other tenants.
+type ExternalShareService interface {
+	// Create stores a pending request and notifies the admins that can approve it.
+	Create(ctx context.Context, request *ExternalShareRequest) (*ExternalShareRequest, error)
+	Get(ctx context.Context, id string) (*ExternalShareRequest, error)
+	List(ctx context.Context, opts database.QueryOptions) ([]ExternalShareRequest, string, error)
+	// Approve adds the approval of the user of the context. The second approval grants the access
+	// and makes the target user a guest of the tenant of the vault, so it can switch to it.
+	Approve(ctx context.Context, id string, comment string) (*ExternalShareRequest, error)
+	Reject(ctx context.Context, id string, comment string) (*ExternalShareRequest, error)
+	// Revoke cancels a pending request or removes the access granted by an approved one, and the
+	// guest membership with the last approved request of the user.
+	Revoke(ctx context.Context, id string) (*ExternalShareRequest, error)
+}
+
+type Status string
+
+const (
+	STATUS_PENDING  Status = "pending"
+	STATUS_APPROVED Status = "approved"
+	STATUS_REJECTED Status = "rejected"
+	STATUS_REVOKED  Status = "revoked"
+	// STATUS_EXPIRED is never stored: pending requests are reported as expired after ExpiresAt.
+	STATUS_EXPIRED Status = "expired"
+)
+
+// Decision is the answer of an admin to a request.
+type Decision string
+
+const (
+	DECISION_APPROVED Decision = "approved"
+	DECISION_REJECTED Decision = "rejected"
+)
+
+// Approval is the decision of an admin of the tenant that owns the vault.
+type Approval struct {
+	UserID    string    `json:"userId"`
+	Decision  Decision  `json:"decision"`
+	Comment   string    `json:"comment,omitempty"`
+	DecidedAt time.Time `json:"decidedAt"`
+}
+
+// ExternalShareRequest
+// A request to give a user of another tenant a read only role on a vault. The access is
+// granted only when two distinct admins of the tenant of the vault, other than the requester,
+// approve it before ExpiresAt.
+type ExternalShareRequest struct {
+	ID           string            `json:"id,omitempty"`
+	FromTenantID string            `json:"fromTenantId"`
+	ToTenantID   string            `json:"toTenantId" binding:"required"`
+	RequesterID  string            `json:"requesterId"`
+	VaultID      string            `json:"vaultId" binding:"required"`
+	VaultName    string            `json:"vaultName,omitempty"`
+	TargetUserID string            `json:"targetUserId" binding:"required"`
+	Role         entity_share.Role `json:"role" binding:"required"`
+	Message      string            `json:"message,omitempty"`
+	Approvals    []Approval        `json:"approvals"`
+	Status       Status            `json:"status"`
+	// ExpiresIn is the time to decide requested by the client, in hours. It is not stored.
+	ExpiresIn int        `json:"expiresIn,omitempty"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedBy string     `json:"revokedBy,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// IsValid
+// This method validates the ExternalShareRequest struct to ensure that required fields are present.
+func (r *ExternalShareRequest) IsValid() error {
+
+	if r == nil {
+		return errors.New("invalid external share request: request cannot be nil")
+	}
+
+	if r.FromTenantID == "" || r.ToTenantID == "" || r.RequesterID == "" || r.VaultID == "" || r.TargetUserID == "" {
+		return errors.New("invalid external share request: tenants, requester, vault and target user are required")
+	}
+
+	if r.FromTenantID == r.ToTenantID {
+		return errors.New("invalid external share request: the target tenant must be another tenant")
+	}
+
+	if !IsExternalRole(r.Role) {
+		return errors.New("invalid external share request: role must be viewer, reader, copier or downloader")
+	}
+
+	if len(r.Message) > 500 {
+		return errors.New("invalid external share request: message must have at most 500 characters")
+	}
+
+	if !r.ExpiresAt.After(r.CreatedAt) {
+		return errors.New("invalid external share request: expiration must be after creation")
+	}
+
+	return nil
+}
+
+// IsExternalRole reports whether the role can be given to users of other tenants. The model only
+// gives read access to external guests.
+func IsExternalRole(role entity_share.Role) bool {
+	switch role {
+	case entity_share.ROLE_VIEWER, entity_share.ROLE_READER, entity_share.ROLE_COPIER, entity_share.ROLE_DOWNLOADER:
+		return true
+	}
+	return false
+}
+
+// IsPending reports whether the request can still be decided at now.
+func (r *ExternalShareRequest) IsPending(now time.Time) bool {
+	return r.Status == STATUS_PENDING && now.Before(r.ExpiresAt)
+}
+
+// Effective returns the request with the expired status when it was not decided in time.
+func (r ExternalShareRequest) Effective(now time.Time) ExternalShareRequest {
+	if r.Status == STATUS_PENDING && !now.Before(r.ExpiresAt) {
+		r.Status = STATUS_EXPIRED
+	}
+	return r
+}
+
+// HasDecided reports whether the user already approved or rejected the request.
+func (r *ExternalShareRequest) HasDecided(userID string) bool {
+	for _, a := range r.Approvals {
+		if a.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Object returns the OpenFGA object of the request.
+func (r *ExternalShareRequest) Object() string {
+	return authorization.Object(TypeExternalShareRequest, r.ID)
+}
+
+// Tuples returns the tuples written once the request is approved: the request itself, with its
+// requester and approvers, and the external guest role of the target user on the vault.
+func (r *ExternalShareRequest) Tuples() []authorization.TupleKey {
+
+	vault := authorization.Object(authorization.TypeVault, r.VaultID)
+	target := authorization.User(r.TargetUserID)
+
+	tuples := []authorization.TupleKey{
+		{User: authorization.User(r.RequesterID), Relation: "requester", Object: r.Object()},
+		{User: authorization.Object(authorization.TypeTenant, r.ToTenantID), Relation: "target_tenant", Object: r.Object()},
+		{User: vault, Relation: authorization.RelationVault, Object: r.Object()},
+		{User: target, Relation: "external_guest", Object: vault},
+		{User: target, Relation: string(r.Role), Object: vault},
+	}
+
+	for i, a := range r.Approvals {
+		if i >= RequiredApprovals {
+			break
+		}
+		tuples = append(tuples, authorization.TupleKey{
+			User:     authorization.User(a.UserID),
+			Relation: fmt.Sprintf("approver%d", i+1),
+			Object:   r.Object(),
+		})
+	}
+
+	return tuples
+}
+
+// GuestTuple returns the guest role of the target user in the tenant of the vault.
+func (r *ExternalShareRequest) GuestTuple() authorization.TupleKey {
+	return entity_tenant.RoleTuple(r.FromTenantID, r.TargetUserID, entity_tenant.ROLE_GUEST)
+}
+
+// NewExternalShareRequest creates a pending request of the user to share the vault, valid for expiry.
+func NewExternalShareRequest(id string, data ExternalShareRequest, fromTenantID, vaultName, userID string, now time.Time, expiry time.Duration) *ExternalShareRequest {
+	now = now.UTC()
+	return &ExternalShareRequest{
+		ID:           id,
+		FromTenantID: fromTenantID,
+		ToTenantID:   strings.TrimSpace(data.ToTenantID),
+		RequesterID:  userID,
+		VaultID:      data.VaultID,
+		VaultName:    vaultName,
+		TargetUserID: strings.TrimSpace(data.TargetUserID),
+		Role:         data.Role,
+		Message:      strings.TrimSpace(data.Message),
+		Approvals:    []Approval{},
+		Status:       STATUS_PENDING,
+		ExpiresAt:    now.Add(expiry),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
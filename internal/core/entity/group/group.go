@@ -0,0 +1,227 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// GroupCollection and MemberCollection are relative to the tenant (tenant/<id>/groups).
+	GroupCollection  = "groups"
+	MemberCollection = "group_members"
+
+	// RelationActiveMember is the userset of a group that vaults and parent groups are granted to.
+	RelationActiveMember = "active_member"
+)
+
+// GroupSortableFields are the fields accepted by the orderBy query parameter.
+var GroupSortableFields = []string{"name", "createdAt", "updatedAt"}
+
+// MemberSortableFields are the fields accepted by the orderBy query parameter of the members.
+var MemberSortableFields = []string{"addedAt", "role", "type"}
+
+// GroupRepository interface defines methods for store and retrieve groups and their members.
+// Every change of a member is written in the same batch as the outbox entry of its tuples.
+type GroupRepository interface {
+	// Create writes the group, its owner and the outbox entry of their tuples in one batch.
+	Create(ctx context.Context, group map[string]interface{}, owner map[string]interface{}, entry map[string]interface{}) (*Group, error)
+	Get(ctx context.Context, id string) (*Group, error)
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]Group, string, error)
+	Update(ctx context.Context, id string, group map[string]interface{}) error
+	// Delete removes the group and its members, and creates the outbox entry of every tuple of the group.
+	Delete(ctx context.Context, id string, entry map[string]interface{}) error
+	GetMember(ctx context.Context, id string) (*Member, error)
+	ListMembers(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]Member, string, error)
+	AddMember(ctx context.Context, member map[string]interface{}, entry map[string]interface{}) (*Member, error)
+	RemoveMember(ctx context.Context, id string, entry map[string]interface{}) error
+}
+
+// GroupService manages the groups of the tenant of the context.
+type GroupService interface {
+	Create(ctx context.Context, group *Group) (*Group, error)
+	Get(ctx context.Context, id string) (*Group, error)
+	List(ctx context.Context, opts database.QueryOptions) ([]Group, string, error)
+	// ListForUser returns the groups the user belongs to, directly or through nested groups.
+	ListForUser(ctx context.Context, userID string) ([]Group, error)
+	Update(ctx context.Context, id string, group *Group) (*Group, error)
+	Delete(ctx context.Context, id string) error
+	ListMembers(ctx context.Context, id string, opts database.QueryOptions) ([]Member, string, error)
+	AddMember(ctx context.Context, id string, member *Member) (*Member, error)
+	RemoveMember(ctx context.Context, id string, memberType MemberType, memberID string) error
+}
+
+type Role string
+
+const (
+	ROLE_OWNER  Role = authorization.RelationOwner
+	ROLE_ADMIN  Role = authorization.RelationAdmin
+	ROLE_MEMBER Role = authorization.RelationMember
+)
+
+// IsValid reports whether the role is known.
+func (r Role) IsValid() bool {
+	return r == ROLE_OWNER || r == ROLE_ADMIN || r == ROLE_MEMBER
+}
+
+// MemberType tells whether a member is a user or a nested group.
+type MemberType string
+
+const (
+	MEMBER_USER  MemberType = authorization.TypeUser
+	MEMBER_GROUP MemberType = authorization.TypeGroup
+)
+
+// Group
+// A team of users of a tenant. A group can be a member of another group, so its active members
+// are members of the parent group too.
+type Group struct {
+	ID          string    `json:"id,omitempty"`
+	TenantID    string    `json:"tenantId"`
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// IsValid
+// This method validates the Group struct to ensure that required fields are present.
+func (g *Group) IsValid() error {
+
+	if g == nil {
+		return errors.New("invalid group: group cannot be nil")
+	}
+
+	if g.TenantID == "" || g.CreatedBy == "" {
+		return errors.New("invalid group: tenant and creator are required")
+	}
+
+	if strings.TrimSpace(g.Name) == "" {
+		return errors.New("invalid group: name is required")
+	}
+
+	if len(g.Name) > 100 {
+		return errors.New("invalid group: name must have at most 100 characters")
+	}
+
+	return nil
+}
+
+// Object returns the OpenFGA object of the group.
+func (g *Group) Object() string {
+	return authorization.Object(authorization.TypeGroup, g.ID)
+}
+
+// Userset returns the OpenFGA userset of the active members of the group, e.g. to grant a vault.
+func (g *Group) Userset() string {
+	return authorization.UsersetOf(authorization.TypeGroup, g.ID, RelationActiveMember)
+}
+
+// TenantTuple links the group to its tenant, so banned users of the tenant are not active members.
+func (g *Group) TenantTuple() authorization.TupleKey {
+	return authorization.TupleKey{
+		User:     authorization.Object(authorization.TypeTenant, g.TenantID),
+		Relation: authorization.RelationTenant,
+		Object:   g.Object(),
+	}
+}
+
+// NewGroup creates a new group of the tenant created by the user.
+func NewGroup(data Group, tenantID, userID string) *Group {
+	now := time.Now().UTC()
+	return &Group{
+		TenantID:    tenantID,
+		Name:        strings.TrimSpace(data.Name),
+		Description: data.Description,
+		CreatedBy:   userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// Member
+// A user or a nested group of a group, stored in group_members as <groupId>_<type>_<memberId>.
+type Member struct {
+	ID       string     `json:"id,omitempty"`
+	GroupID  string     `json:"groupId"`
+	Type     MemberType `json:"type" binding:"required"`
+	MemberID string     `json:"memberId" binding:"required"`
+	Role     Role       `json:"role"`
+	AddedBy  string     `json:"addedBy"`
+	AddedAt  time.Time  `json:"addedAt"`
+}
+
+// IsValid
+// This method validates the Member struct to ensure that required fields are present.
+func (m *Member) IsValid() error {
+
+	if m == nil {
+		return errors.New("invalid group member: member cannot be nil")
+	}
+
+	if m.GroupID == "" || m.MemberID == "" {
+		return errors.New("invalid group member: group and member ids are required")
+	}
+
+	switch m.Type {
+	case MEMBER_USER:
+		if !m.Role.IsValid() {
+			return errors.New("invalid group member: role must be owner, admin or member")
+		}
+	case MEMBER_GROUP:
+		if m.Role != ROLE_MEMBER {
+			return errors.New("invalid group member: nested groups can only be members")
+		}
+		if m.MemberID == m.GroupID {
+			return errors.New("invalid group member: a group cannot be a member of itself")
+		}
+	default:
+		return errors.New("invalid group member: type must be user or group")
+	}
+
+	return nil
+}
+
+// Tuple returns the tuple that gives the member its role in the group.
+func (m *Member) Tuple() authorization.TupleKey {
+
+	user := authorization.User(m.MemberID)
+	if m.Type == MEMBER_GROUP {
+		user = authorization.UsersetOf(authorization.TypeGroup, m.MemberID, RelationActiveMember)
+	}
+
+	return authorization.TupleKey{
+		User:     user,
+		Relation: string(m.Role),
+		Object:   authorization.Object(authorization.TypeGroup, m.GroupID),
+	}
+}
+
+// MemberID returns the ID of the membership of a user or a group in the group.
+func MemberID(groupID string, memberType MemberType, memberID string) string {
+	return groupID + "_" + string(memberType) + "_" + memberID
+}
+
+// NewMember creates the membership of a user or a group in the group, added now by addedBy.
+// An empty role defaults to member.
+func NewMember(groupID string, memberType MemberType, memberID string, role Role, addedBy string) *Member {
+
+	if role == "" {
+		role = ROLE_MEMBER
+	}
+
+	return &Member{
+		ID:       MemberID(groupID, memberType, memberID),
+		GroupID:  groupID,
+		Type:     memberType,
+		MemberID: memberID,
+		Role:     role,
+		AddedBy:  addedBy,
+		AddedAt:  time.Now().UTC(),
+	}
+}
@@ -0,0 +1,152 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// InvitationCollection is global, so the invitee can accept without belonging to the tenant.
+	InvitationCollection = "invitations"
+
+	// TokenScope is the scope of the signed invitation tokens.
+	TokenScope = "invitation"
+
+	// DefaultExpiry is the validity of an invitation when the request sets none.
+	DefaultExpiry = 72 * time.Hour
+
+	// MaxExpiry is the longest validity of an invitation.
+	MaxExpiry = 30 * 24 * time.Hour
+)
+
+// InvitationSortableFields are the fields accepted by the orderBy query parameter.
+var InvitationSortableFields = []string{"createdAt", "expiresAt", "email", "status"}
+
+// InvitationRepository interface defines methods for store and retrieve invitations.
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation map[string]interface{}) (*Invitation, error)
+	Get(ctx context.Context, id string) (*Invitation, error)
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]Invitation, string, error)
+	Update(ctx context.Context, id string, invitation map[string]interface{}) error
+	// Accept marks the pending invitation as accepted and creates the member and the outbox
+	// entry of its tuple in one transaction, so an invitation is used only once.
+	Accept(ctx context.Context, id string, patch map[string]interface{}, member map[string]interface{}, entry map[string]interface{}) error
+}
+
+// InvitationService manages the invitations of the tenant of the context, and their answer
+// by the invited users.
+type InvitationService interface {
+	// Create stores the invitation and emails its signed link to the invitee.
+	Create(ctx context.Context, invitation *Invitation) (*Invitation, error)
+	List(ctx context.Context, opts database.QueryOptions) ([]Invitation, string, error)
+	Revoke(ctx context.Context, id string) (*Invitation, error)
+	// Accept adds the user of the context to the tenant of the invitation and makes it the
+	// active tenant of the user.
+	Accept(ctx context.Context, token string) (*entity_tenant.Member, error)
+	Decline(ctx context.Context, token string) (*Invitation, error)
+}
+
+type Status string
+
+const (
+	STATUS_PENDING  Status = "pending"
+	STATUS_ACCEPTED Status = "accepted"
+	STATUS_DECLINED Status = "declined"
+	STATUS_REVOKED  Status = "revoked"
+	// STATUS_EXPIRED is never stored: pending invitations are reported as expired after ExpiresAt.
+	STATUS_EXPIRED Status = "expired"
+)
+
+// Invitation
+// An invitation of an email address to join a tenant with a role. The link sent to the
+// invitee holds a token signed for the invitation, valid until ExpiresAt.
+type Invitation struct {
+	ID         string             `json:"id,omitempty"`
+	TenantID   string             `json:"tenantId"`
+	TenantName string             `json:"tenantName,omitempty"`
+	Email      string             `json:"email" binding:"required"`
+	Role       entity_tenant.Role `json:"role" binding:"required"`
+	Status     Status             `json:"status"`
+	InvitedBy  string             `json:"invitedBy"`
+	// ExpiresIn is the validity requested by the client, in hours. It is not stored.
+	ExpiresIn  int        `json:"expiresIn,omitempty"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AnsweredBy string     `json:"answeredBy,omitempty"`
+	AnsweredAt *time.Time `json:"answeredAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// IsValid
+// This method validates the Invitation struct to ensure that required fields are present.
+func (i *Invitation) IsValid() error {
+
+	if i == nil {
+		return errors.New("invalid invitation: invitation cannot be nil")
+	}
+
+	if i.TenantID == "" || i.InvitedBy == "" {
+		return errors.New("invalid invitation: tenant and inviter are required")
+	}
+
+	if _, err := mail.ParseAddress(i.Email); err != nil {
+		return errors.New("invalid invitation: email is invalid")
+	}
+
+	if !i.Role.IsValid() || i.Role == entity_tenant.ROLE_BANNED {
+		return errors.New("invalid invitation: role must be owner, admin, member or guest")
+	}
+
+	if !i.ExpiresAt.After(i.CreatedAt) {
+		return errors.New("invalid invitation: expiration must be after creation")
+	}
+
+	return nil
+}
+
+// IsPending reports whether the invitation can still be answered at now.
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.Status == STATUS_PENDING && now.Before(i.ExpiresAt)
+}
+
+// Effective returns the invitation with the expired status when it was not answered in time.
+func (i Invitation) Effective(now time.Time) Invitation {
+	if i.Status == STATUS_PENDING && !now.Before(i.ExpiresAt) {
+		i.Status = STATUS_EXPIRED
+	}
+	return i
+}
+
+// Object returns the OpenFGA object of the tenant of the invitation.
+func (i *Invitation) Object() string {
+	return authorization.Object(authorization.TypeTenant, i.TenantID)
+}
+
+// NormalizeEmail lowercases the address, so an invitee matches its Firebase account.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NewInvitation creates a pending invitation of email to the tenant, valid for expiry.
+func NewInvitation(id, tenantID, tenantName, email string, role entity_tenant.Role, userID string, now time.Time, expiry time.Duration) *Invitation {
+	now = now.UTC()
+	return &Invitation{
+		ID:         id,
+		TenantID:   tenantID,
+		TenantName: tenantName,
+		Email:      NormalizeEmail(email),
+		Role:       role,
+		Status:     STATUS_PENDING,
+		InvitedBy:  userID,
+		ExpiresAt:  now.Add(expiry),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+)
+
+const (
+	// OutboxCollection is global: the relay reads it without a tenant in the context.
+	OutboxCollection = "authz_outbox"
+
+	OutboxMaxAttempts = 10
+)
+
+// OutboxRepository interface defines methods for store and retrieve pending authorization changes
+type OutboxRepository interface {
+	Create(ctx context.Context, entry map[string]interface{}) (*OutboxEntry, error)
+	ListPending(ctx context.Context, limit int) ([]OutboxEntry, error)
+	ListPendingBySource(ctx context.Context, source string) ([]OutboxEntry, error)
+	ListFailed(ctx context.Context) ([]OutboxEntry, error)
+	Update(ctx context.Context, id string, entry map[string]interface{}) error
+}
+
+// OutboxService applies the tuple changes recorded in the outbox.
+type OutboxService interface {
+	// Enqueue stores a new entry outside of a transaction, for repairs and for changes that
+	// have no document of their own, such as the shares of a vault.
+	Enqueue(ctx context.Context, operation OutboxOperation, tuples []authorization.TupleKey, source string) (*OutboxEntry, error)
+	// Dispatch applies a single entry right away and marks it as done or schedules a retry.
+	Dispatch(ctx context.Context, entry *OutboxEntry) error
+	// ProcessPending applies the due entries in the order they were created and returns how many
+	// were completed. An entry waits while an earlier entry of the same source is still pending,
+	// so a retried write never lands after the delete that followed it.
+	ProcessPending(ctx context.Context) (int, error)
+	// ListFailed returns the entries that gave up after OutboxMaxAttempts; their tuples may need a repair.
+	ListFailed(ctx context.Context) ([]OutboxEntry, error)
+	// Run calls ProcessPending every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// ReconcilerService compares the documents in the database with the stored tuples.
+type ReconcilerService interface {
+	Reconcile(ctx context.Context, repair bool) (*DriftReport, error)
+	// Run calls Reconcile every interval until ctx is cancelled and logs the drift found.
+	Run(ctx context.Context, interval time.Duration, repair bool)
+}
+
+type OutboxOperation string
+
+const (
+	OUTBOX_WRITE  OutboxOperation = "write"
+	OUTBOX_DELETE OutboxOperation = "delete"
+)
+
+type OutboxStatus string
+
+const (
+	OUTBOX_PENDING OutboxStatus = "pending"
+	OUTBOX_DONE    OutboxStatus = "done"
+	OUTBOX_FAILED  OutboxStatus = "failed"
+)
+
+// OutboxEntry
+// A set of tuple changes that must reach the authorization server. The entry is written in the
+// same transaction as the document it refers to, so a document never exists without its tuples
+// eventually being applied.
+type OutboxEntry struct {
+	ID            string                   `json:"id,omitempty"`
+	Operation     OutboxOperation          `json:"operation"`
+	Tuples        []authorization.TupleKey `json:"tuples"`
+	Source        string                   `json:"source"` // OpenFGA object that originated the change, e.g. vault:<id>
+	Status        OutboxStatus             `json:"status"`
+	Attempts      int                      `json:"attempts"`
+	LastError     string                   `json:"lastError,omitempty"`
+	NextAttemptAt time.Time                `json:"nextAttemptAt"`
+	CreatedAt     time.Time                `json:"createdAt"`
+	UpdatedAt     time.Time                `json:"updatedAt"`
+}
+
+// IsValid
+// This method validates the OutboxEntry struct to ensure that required fields are present.
+func (e *OutboxEntry) IsValid() error {
+
+	if e == nil {
+		return errors.New("invalid outbox entry: entry cannot be nil")
+	}
+
+	if e.Operation != OUTBOX_WRITE && e.Operation != OUTBOX_DELETE {
+		return errors.New("invalid outbox entry: operation must be write or delete")
+	}
+
+	if len(e.Tuples) == 0 {
+		return errors.New("invalid outbox entry: at least one tuple is required")
+	}
+
+	for _, t := range e.Tuples {
+		if err := t.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsDue reports whether the entry is pending and its next attempt time has passed.
+func (e *OutboxEntry) IsDue(now time.Time) bool {
+	return e.Status == OUTBOX_PENDING && !e.NextAttemptAt.After(now)
+}
+
+// Backoff returns the delay before the next attempt: 2^attempts seconds, capped at one hour.
+func (e *OutboxEntry) Backoff() time.Duration {
+	if e.Attempts >= 12 {
+		return time.Hour
+	}
+	delay := time.Duration(1<<e.Attempts) * time.Second
+	if delay > time.Hour {
+		return time.Hour
+	}
+	return delay
+}
+
+// NewOutboxEntry creates a pending entry with a pre-generated ID.
+func NewOutboxEntry(id string, operation OutboxOperation, tuples []authorization.TupleKey, source string) *OutboxEntry {
+	now := time.Now().UTC()
+	return &OutboxEntry{
+		ID:            id,
+		Operation:     operation,
+		Tuples:        tuples,
+		Source:        source,
+		Status:        OUTBOX_PENDING,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// DriftReport lists the differences found by the reconciler.
+type DriftReport struct {
+	Checked int `json:"checked"`
+	// Missing tuples: the document exists but the tuple does not.
+	Missing []authorization.TupleKey `json:"missing"`
+	// Orphans: tuples on objects whose document no longer exists.
+	Orphans []authorization.TupleKey `json:"orphans"`
+	// Failed: IDs of the outbox entries that gave up and were never applied.
+	Failed []string `json:"failed"`
+	// Repaired is the number of outbox entries created to fix the drift.
+	Repaired  int       `json:"repaired"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+}
+
+// HasDrift reports whether any difference was found.
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Missing) > 0 || len(r.Orphans) > 0 || len(r.Failed) > 0
+}
@@ -0,0 +1,179 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	SecretNameMaxLength = 200
+	SecretMaxTags       = 5
+)
+
+// SecretSortableFields are the fields accepted by the orderBy query parameter.
+var SecretSortableFields = []string{"name", "type", "createdAt", "updatedAt"}
+
+// SecretCollection returns the collection of the secrets of a vault, relative to the tenant.
+func SecretCollection(vaultID string) string {
+	return "vaults/" + vaultID + "/secrets"
+}
+
+// SecretRepository interface defines methods for store and retrieve secrets of a vault
+type SecretRepository interface {
+	Create(ctx context.Context, vaultID string, secret map[string]interface{}) (*Secret, error)
+	// CreateWithVersion creates the secret with the given ID and its first version in one transaction.
+	CreateWithVersion(ctx context.Context, vaultID, id string, secret map[string]interface{}, record map[string]interface{}) (*Secret, error)
+	Get(ctx context.Context, vaultID, id string) (*Secret, error)
+	// List returns a page of secrets and the token of the next page.
+	List(ctx context.Context, vaultID string, filters []database.Conditional, opts database.QueryOptions) ([]Secret, string, error)
+	Update(ctx context.Context, vaultID, id string, secret map[string]interface{}) error
+	// UpdateWithVersion updates the secret and records the version in one transaction, only if the
+	// secret is still at the given revision, otherwise it returns a conflict.
+	UpdateWithVersion(ctx context.Context, vaultID, id string, revision int64, secret map[string]interface{}, version int, record map[string]interface{}) error
+
+	// Purge hard deletes the secret and its versions.
+	Purge(ctx context.Context, vaultID, id string) error
+	// PurgeAll hard deletes every secret of the vault with its versions and returns how many secrets were removed.
+	PurgeAll(ctx context.Context, vaultID string) (int, error)
+
+	CreateVersion(ctx context.Context, vaultID, secretID string, version int, data map[string]interface{}) (*SecretVersion, error)
+	GetVersion(ctx context.Context, vaultID, secretID string, version int) (*SecretVersion, error)
+	ListVersions(ctx context.Context, vaultID, secretID string) ([]SecretVersion, error)
+}
+
+// SecretService interface defines methods for handling secrets.
+// Get and List only return metadata; the value is returned exclusively by Reveal.
+type SecretService interface {
+	Create(ctx context.Context, vaultID string, secret *Secret) (*Secret, error)
+	Get(ctx context.Context, vaultID, id string) (*Secret, error)
+	List(ctx context.Context, vaultID string, opts database.QueryOptions) ([]Secret, string, error)
+	// Update changes the secret and records a new version. The update only succeeds if the stored
+	// secret is still at secret.Revision or, when it is not set, at the revision that was read.
+	Update(ctx context.Context, vaultID, id string, secret *Secret) (*Secret, error)
+	Delete(ctx context.Context, vaultID, id string) error
+	Reveal(ctx context.Context, vaultID, id string) (*Secret, error)
+
+	ListVersions(ctx context.Context, vaultID, id string) ([]SecretVersion, error)
+	GetVersion(ctx context.Context, vaultID, id string, version int) (*SecretVersion, error)
+	RestoreVersion(ctx context.Context, vaultID, id string, version int, changeNote string) (*Secret, error)
+}
+
+// Secret
+// An item stored inside a vault. Value is only held in memory: it is encrypted
+// into EncryptedValue before reaching the database and never listed.
+// Revision is incremented by every update and is returned to clients as the ETag.
+type Secret struct {
+	ID             string      `json:"id,omitempty"`
+	VaultID        string      `json:"vaultId,omitempty"`
+	TenantID       string      `json:"tenantId,omitempty"`
+	Name           string      `json:"name" binding:"required"`
+	Description    string      `json:"description,omitempty"`
+	Type           SecretType  `json:"type" binding:"required"`
+	Tags           []string    `json:"tags,omitempty"`
+	IsSensitive    bool        `json:"isSensitive"`
+	IsProduction   bool        `json:"isProduction"`
+	ExpiresAt      *time.Time  `json:"expiresAt,omitempty"`
+	Value          SecretValue `json:"value,omitempty"`
+	EncryptedValue string      `json:"encryptedValue,omitempty"`
+	Version        int         `json:"version"`
+	ChangeNote     string      `json:"changeNote,omitempty"`
+	CreatedBy      string      `json:"createdBy,omitempty"`
+	UpdatedBy      string      `json:"updatedBy,omitempty"`
+	IsActive       bool        `json:"isActive"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+	DeletedAt      *time.Time  `json:"deletedAt,omitempty"`
+	DeletedBy      string      `json:"deletedBy,omitempty"`
+	Revision       int64       `json:"revision"`
+}
+
+// IsValid
+// This method validates the Secret struct to ensure that required fields are present.
+// The value is validated only when present, since updates may change metadata alone.
+func (s *Secret) IsValid() error {
+
+	if s == nil {
+		return errors.New("invalid secret: secret cannot be nil")
+	}
+
+	name := strings.TrimSpace(s.Name)
+	if name == "" {
+		return errors.New("invalid secret: name is required")
+	}
+
+	if len(name) > SecretNameMaxLength {
+		return errors.New("invalid secret: name must have at most 200 characters")
+	}
+
+	if err := s.Type.IsValid(); err != nil {
+		return err
+	}
+
+	if len(s.Tags) > SecretMaxTags {
+		return errors.New("invalid secret: maximum of 5 tags allowed")
+	}
+
+	if len(s.ChangeNote) > SecretChangeNoteMaxLength {
+		return errors.New("invalid secret: change note must have at most 500 characters")
+	}
+
+	if len(s.Value) > 0 {
+		if err := s.Value.Validate(s.Type); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsDeleted reports whether the secret was soft deleted.
+func (s *Secret) IsDeleted() bool {
+	return s.DeletedAt != nil || !s.IsActive
+}
+
+// Metadata returns a copy of the secret without the value or its ciphertext.
+func (s *Secret) Metadata() Secret {
+	m := *s
+	m.Value = nil
+	m.EncryptedValue = ""
+	return m
+}
+
+// GetOpenFGAID returns the object identifier used by the authorization model.
+func (s *Secret) GetOpenFGAID() string {
+	return s.Type.OpenFGAType() + ":" + s.ID
+}
+
+// GetVaultOpenFGAID returns the vault object identifier used by the authorization model.
+func (s *Secret) GetVaultOpenFGAID() string {
+	return "vault:" + s.VaultID
+}
+
+// NewSecret creates a new active Secret in the vault
+func NewSecret(secret Secret, vaultID, tenantID, userID string) *Secret {
+	now := time.Now().UTC()
+	return &Secret{
+		VaultID:      vaultID,
+		TenantID:     tenantID,
+		Name:         strings.TrimSpace(secret.Name),
+		Description:  secret.Description,
+		Type:         secret.Type,
+		Tags:         secret.Tags,
+		IsSensitive:  secret.IsSensitive,
+		IsProduction: secret.IsProduction,
+		ExpiresAt:    secret.ExpiresAt,
+		Value:        secret.Value,
+		Version:      1,
+		ChangeNote:   secret.ChangeNote,
+		CreatedBy:    userID,
+		UpdatedBy:    userID,
+		IsActive:     true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Revision:     1,
+	}
+}
@@ -0,0 +1,200 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SecretType
+// This type defines the kind of item stored in a vault and the shape of its value.
+type SecretType string
+
+const (
+	SECRET_PASSWORD            SecretType = "password"
+	SECRET_API_KEY             SecretType = "api_key"
+	SECRET_TOKEN               SecretType = "token"
+	SECRET_NOTE                SecretType = "note"
+	SECRET_KEY_VALUE           SecretType = "key_value"
+	SECRET_DATABASE_CONNECTION SecretType = "database_connection"
+)
+
+// IsValid
+// This method validates the SecretType to ensure that it is one of the predefined types.
+func (t SecretType) IsValid() error {
+	switch t {
+	case SECRET_PASSWORD, SECRET_API_KEY, SECRET_TOKEN, SECRET_NOTE, SECRET_KEY_VALUE, SECRET_DATABASE_CONNECTION:
+		return nil
+	case "":
+		return errors.New("invalid secret: type is required")
+	default:
+		return fmt.Errorf("invalid secret: unknown type %q", t)
+	}
+}
+
+// OpenFGAType returns the authorization model type that represents the secret.
+func (t SecretType) OpenFGAType() string {
+	switch t {
+	case SECRET_API_KEY:
+		return "api_key"
+	case SECRET_KEY_VALUE:
+		return "key_value"
+	case SECRET_DATABASE_CONNECTION:
+		return "database_connection"
+	default:
+		return "secret"
+	}
+}
+
+// String returns a string representation of the SecretType
+func (t SecretType) String() string {
+	return string(t)
+}
+
+// PasswordValue is the value of a password secret
+type PasswordValue struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password"`
+	URL      string `json:"url,omitempty"`
+}
+
+// APIKeyValue is the value of an api_key secret
+type APIKeyValue struct {
+	Key      string `json:"key"`
+	Secret   string `json:"secret,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// TokenValue is the value of a token secret
+type TokenValue struct {
+	Token     string `json:"token"`
+	TokenType string `json:"tokenType,omitempty"`
+}
+
+// NoteValue is the value of a generic note
+type NoteValue struct {
+	Content string `json:"content"`
+}
+
+// KeyValueValue is a set of environment variables
+type KeyValueValue struct {
+	Environment string            `json:"environment,omitempty"`
+	Entries     map[string]string `json:"entries"`
+}
+
+// DatabaseConnectionValue is the value of a database_connection secret
+type DatabaseConnectionValue struct {
+	Engine   string            `json:"engine"`
+	Host     string            `json:"host"`
+	Port     int               `json:"port,omitempty"`
+	Database string            `json:"database,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Options  map[string]string `json:"options,omitempty"`
+}
+
+// SecretValue holds the plaintext JSON value of a secret. Its shape depends on the SecretType.
+type SecretValue json.RawMessage
+
+// MarshalJSON keeps the raw JSON instead of encoding it as base64
+func (v SecretValue) MarshalJSON() ([]byte, error) {
+	if len(v) == 0 {
+		return []byte("null"), nil
+	}
+	return json.RawMessage(v).MarshalJSON()
+}
+
+// UnmarshalJSON stores a copy of the raw JSON value
+func (v *SecretValue) UnmarshalJSON(data []byte) error {
+	if v == nil {
+		return errors.New("secret value cannot be nil")
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*v = nil
+		return nil
+	}
+	*v = append((*v)[0:0], data...)
+	return nil
+}
+
+// Validate checks that the value matches the shape expected by the secret type.
+func (v SecretValue) Validate(t SecretType) error {
+
+	if len(v) == 0 {
+		return errors.New("invalid secret: value is required")
+	}
+
+	switch t {
+	case SECRET_PASSWORD:
+		var value PasswordValue
+		if err := v.decode(&value); err != nil {
+			return err
+		}
+		if value.Password == "" {
+			return errors.New("invalid secret: password is required")
+		}
+	case SECRET_API_KEY:
+		var value APIKeyValue
+		if err := v.decode(&value); err != nil {
+			return err
+		}
+		if value.Key == "" {
+			return errors.New("invalid secret: key is required")
+		}
+	case SECRET_TOKEN:
+		var value TokenValue
+		if err := v.decode(&value); err != nil {
+			return err
+		}
+		if value.Token == "" {
+			return errors.New("invalid secret: token is required")
+		}
+	case SECRET_NOTE:
+		var value NoteValue
+		if err := v.decode(&value); err != nil {
+			return err
+		}
+		if strings.TrimSpace(value.Content) == "" {
+			return errors.New("invalid secret: content is required")
+		}
+	case SECRET_KEY_VALUE:
+		var value KeyValueValue
+		if err := v.decode(&value); err != nil {
+			return err
+		}
+		if len(value.Entries) == 0 {
+			return errors.New("invalid secret: at least one entry is required")
+		}
+		for key := range value.Entries {
+			if strings.TrimSpace(key) == "" {
+				return errors.New("invalid secret: entry keys cannot be empty")
+			}
+		}
+	case SECRET_DATABASE_CONNECTION:
+		var value DatabaseConnectionValue
+		if err := v.decode(&value); err != nil {
+			return err
+		}
+		if value.Engine == "" || value.Host == "" {
+			return errors.New("invalid secret: engine and host are required")
+		}
+		if value.Port < 0 || value.Port > 65535 {
+			return errors.New("invalid secret: port is out of range")
+		}
+	default:
+		return t.IsValid()
+	}
+
+	return nil
+}
+
+func (v SecretValue) decode(out interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(v))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("invalid secret: value does not match type: %w", err)
+	}
+	return nil
+}
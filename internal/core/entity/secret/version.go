@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	SecretChangeNoteMaxLength = 500
+)
+
+// SecretVersion
+// Immutable snapshot of a secret value. A version is written on every change
+// to the secret and is never updated afterwards.
+type SecretVersion struct {
+	ID             string      `json:"id,omitempty"`
+	SecretID       string      `json:"secretId"`
+	VaultID        string      `json:"vaultId"`
+	Version        int         `json:"version"`
+	Type           SecretType  `json:"type"`
+	Value          SecretValue `json:"value,omitempty"`
+	EncryptedValue string      `json:"encryptedValue,omitempty"`
+	ChangedBy      string      `json:"changedBy"`
+	ChangedAt      time.Time   `json:"changedAt"`
+	ChangeNote     string      `json:"changeNote,omitempty"`
+	RestoredFrom   int         `json:"restoredFrom,omitempty"`
+}
+
+// Metadata returns a copy of the version without the value or its ciphertext.
+func (v *SecretVersion) Metadata() SecretVersion {
+	m := *v
+	m.Value = nil
+	m.EncryptedValue = ""
+	return m
+}
+
+// NewSecretVersion creates the version record of the current head of the secret.
+// The secret must already hold its ciphertext.
+func NewSecretVersion(secret Secret, changedBy, changeNote string) *SecretVersion {
+	return &SecretVersion{
+		SecretID:       secret.ID,
+		VaultID:        secret.VaultID,
+		Version:        secret.Version,
+		Type:           secret.Type,
+		EncryptedValue: secret.EncryptedValue,
+		ChangedBy:      changedBy,
+		ChangedAt:      time.Now().UTC(),
+		ChangeNote:     changeNote,
+	}
+}
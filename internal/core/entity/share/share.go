@@ -0,0 +1,317 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+)
+
+const (
+	// ExpirationCollection lives under tenant/<tenantId>; the sweeper reads it as a collection group.
+	ExpirationCollection = "share_expirations"
+
+	// ExpiryActor is the user recorded in the audit events of the expired shares.
+	ExpiryActor = "system"
+
+	// ExpiryReason is the reason recorded in the audit events of the expired shares.
+	ExpiryReason = "expired"
+
+	// MinExpiry is the shortest time a share can last. The guard loads the shares that expire
+	// within MinExpiry, so every instance knows a share before it expires.
+	MinExpiry = 5 * time.Minute
+)
+
+// ExpirationRepository interface defines methods for store and retrieve the expiration of the shares.
+type ExpirationRepository interface {
+	// Set creates or replaces the expiration of a share.
+	Set(ctx context.Context, expiration map[string]interface{}) error
+	Get(ctx context.Context, id string) (*Expiration, error)
+	ListByVault(ctx context.Context, vaultID string) ([]Expiration, error)
+	// ListExpiring returns the expirations of every tenant at or before the given time. Only for
+	// the sweeper and the guard.
+	ListExpiring(ctx context.Context, before time.Time) ([]Expiration, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// SweeperService removes the shares whose expiration passed.
+type SweeperService interface {
+	Sweep(ctx context.Context) (*SweepReport, error)
+	// Run calls Sweep every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// ShareService shares the vaults of the tenant of the context with its users and groups.
+// The shares are the tuples of the vault in the authorization model; no document is stored.
+type ShareService interface {
+	// Grant gives a role on the vault to a user or a group that has none, until ExpiresAt if set.
+	Grant(ctx context.Context, vaultID string, share *Share) (*Share, error)
+	// Change replaces the role of a user or a group on the vault and its expiration. A nil
+	// expiresAt keeps the role until it is revoked.
+	Change(ctx context.Context, vaultID string, subjectType SubjectType, subjectID string, role Role, expiresAt *time.Time) (*Share, error)
+	// Revoke removes every role given to a user or a group on the vault, except ownership.
+	Revoke(ctx context.Context, vaultID string, subjectType SubjectType, subjectID string) error
+	// ListAccess returns the shares of the vault and every user that can use it through them.
+	ListAccess(ctx context.Context, vaultID string) (*VaultAccess, error)
+	// SharedWithMe returns the vaults of the tenant shared with the user of the context,
+	// directly or through its groups.
+	SharedWithMe(ctx context.Context) ([]SharedVault, error)
+}
+
+type Role string
+
+// The roles of a vault, as defined by the authorization model.
+const (
+	ROLE_OWNER      Role = authorization.RelationOwner
+	ROLE_ADMIN      Role = authorization.RelationAdmin
+	ROLE_WRITER     Role = "writer"
+	ROLE_READER     Role = "reader"
+	ROLE_DOWNLOADER Role = "downloader"
+	ROLE_COPIER     Role = "copier"
+	ROLE_VIEWER     Role = "viewer"
+)
+
+// Roles lists the roles of a vault from the most to the least privileged.
+var Roles = []Role{ROLE_OWNER, ROLE_ADMIN, ROLE_WRITER, ROLE_READER, ROLE_DOWNLOADER, ROLE_COPIER, ROLE_VIEWER}
+
+// IsValid reports whether the role is a role of a vault.
+func (r Role) IsValid() bool {
+	return r.rank() > 0
+}
+
+// IsShareable reports whether the role can be given by sharing. Ownership comes from creating the vault.
+func (r Role) IsShareable() bool {
+	return r.IsValid() && r != ROLE_OWNER
+}
+
+// Outranks reports whether the role gives more permissions than other.
+func (r Role) Outranks(other Role) bool {
+	return r.rank() > other.rank()
+}
+
+// Grants reports whether the role gives relation on a vault, as the authorization model defines
+// it. The relations that need more than a role, like the external ones, are never given.
+func (r Role) Grants(relation string) bool {
+
+	if Role(relation) == r {
+		return true
+	}
+
+	for _, role := range permissions[relation] {
+		if role == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+// permissions lists the roles that give each permission of a vault in the authorization model.
+var permissions = map[string][]Role{
+	authorization.CanView:   {ROLE_VIEWER, ROLE_READER, ROLE_WRITER, ROLE_ADMIN, ROLE_OWNER},
+	authorization.CanRead:   {ROLE_READER, ROLE_WRITER, ROLE_ADMIN, ROLE_OWNER},
+	"can_copy":              {ROLE_COPIER, ROLE_READER, ROLE_WRITER, ROLE_ADMIN, ROLE_OWNER},
+	"can_download":          {ROLE_DOWNLOADER, ROLE_READER, ROLE_WRITER, ROLE_ADMIN, ROLE_OWNER},
+	authorization.CanWrite:  {ROLE_WRITER, ROLE_ADMIN, ROLE_OWNER},
+	authorization.CanDelete: {ROLE_ADMIN, ROLE_OWNER},
+	authorization.CanShare:  {ROLE_ADMIN, ROLE_OWNER},
+	authorization.CanManage: {ROLE_ADMIN, ROLE_OWNER},
+}
+
+func (r Role) rank() int {
+	for i, role := range Roles {
+		if r == role {
+			return len(Roles) - i
+		}
+	}
+	return 0
+}
+
+// SubjectType tells whether a vault is shared with a user or with the active members of a group.
+type SubjectType string
+
+const (
+	SUBJECT_USER  SubjectType = authorization.TypeUser
+	SUBJECT_GROUP SubjectType = authorization.TypeGroup
+)
+
+// Share
+// A role given on a vault to a user or to the active members of a group, optionally until ExpiresAt.
+type Share struct {
+	VaultID     string      `json:"vaultId"`
+	SubjectType SubjectType `json:"subjectType" binding:"required"`
+	SubjectID   string      `json:"subjectId" binding:"required"`
+	Role        Role        `json:"role" binding:"required"`
+	ExpiresAt   *time.Time  `json:"expiresAt,omitempty"`
+}
+
+// IsValid
+// This method validates the Share struct to ensure that required fields are present.
+func (s *Share) IsValid() error {
+
+	if s == nil {
+		return errors.New("invalid share: share cannot be nil")
+	}
+
+	if s.VaultID == "" || s.SubjectID == "" {
+		return errors.New("invalid share: vault and subject are required")
+	}
+
+	if s.SubjectType != SUBJECT_USER && s.SubjectType != SUBJECT_GROUP {
+		return errors.New("invalid share: subject type must be user or group")
+	}
+
+	if !s.Role.IsShareable() {
+		return errors.New("invalid share: role must be admin, writer, reader, downloader, copier or viewer")
+	}
+
+	return nil
+}
+
+// ValidateExpiry checks that the share lasts at least MinExpiry from now, when it expires.
+func (s *Share) ValidateExpiry(now time.Time) error {
+
+	if s.ExpiresAt == nil {
+		return nil
+	}
+
+	if s.ExpiresAt.Before(now.Add(MinExpiry)) {
+		return errors.New("invalid share: expiresAt must be at least " + MinExpiry.String() + " in the future")
+	}
+
+	return nil
+}
+
+// Subject returns the OpenFGA user of the share.
+func (s *Share) Subject() string {
+	return Subject(s.SubjectType, s.SubjectID)
+}
+
+// Tuple returns the tuple that gives the role on the vault.
+func (s *Share) Tuple() authorization.TupleKey {
+	return authorization.TupleKey{
+		User:     s.Subject(),
+		Relation: string(s.Role),
+		Object:   authorization.Object(authorization.TypeVault, s.VaultID),
+	}
+}
+
+// Subject returns the OpenFGA user of a user or of the active members of a group.
+func Subject(subjectType SubjectType, subjectID string) string {
+	if subjectType == SUBJECT_GROUP {
+		return authorization.UsersetOf(authorization.TypeGroup, subjectID, entity_group.RelationActiveMember)
+	}
+	return authorization.User(subjectID)
+}
+
+// ShareFromTuple returns the share of a tuple of a vault, or false when the tuple is not a role
+// given to a user or a group.
+func ShareFromTuple(t authorization.TupleKey) (Share, bool) {
+
+	role := Role(t.Relation)
+	if !role.IsValid() {
+		return Share{}, false
+	}
+
+	_, vaultID, err := authorization.SplitObject(t.Object)
+	if err != nil {
+		return Share{}, false
+	}
+
+	share := Share{VaultID: vaultID, Role: role}
+	if object, relation, ok := strings.Cut(t.User, "#"); ok {
+		objectType, groupID, err := authorization.SplitObject(object)
+		if err != nil || objectType != authorization.TypeGroup || relation != entity_group.RelationActiveMember {
+			return Share{}, false
+		}
+		share.SubjectType = SUBJECT_GROUP
+		share.SubjectID = groupID
+		return share, true
+	}
+
+	objectType, userID, err := authorization.SplitObject(t.User)
+	if err != nil || objectType != authorization.TypeUser {
+		return Share{}, false
+	}
+
+	share.SubjectType = SUBJECT_USER
+	share.SubjectID = userID
+	return share, true
+}
+
+// Access
+// A user that can use a vault, with its highest role and the group it comes from, if any.
+type Access struct {
+	UserID  string `json:"userId"`
+	Role    Role   `json:"role"`
+	GroupID string `json:"groupId,omitempty"`
+}
+
+// VaultAccess holds the shares of a vault and the users resolved from them.
+type VaultAccess struct {
+	VaultID string   `json:"vaultId"`
+	Shares  []Share  `json:"shares"`
+	Users   []Access `json:"users"`
+}
+
+// SharedVault is a vault shared with the user, with its highest role and the group it comes from, if any.
+type SharedVault struct {
+	Vault   entity_vault.Vault `json:"vault"`
+	Role    Role               `json:"role"`
+	GroupID string             `json:"groupId,omitempty"`
+}
+
+// Expiration
+// The time a share ends, stored in tenant/<tenantId>/share_expirations as
+// <vaultId>_<subjectType>_<subjectId>. The sweeper removes the roles of the subject on the
+// vault once it passes.
+type Expiration struct {
+	ID          string      `json:"id,omitempty"`
+	TenantID    string      `json:"tenantId"`
+	VaultID     string      `json:"vaultId"`
+	SubjectType SubjectType `json:"subjectType"`
+	SubjectID   string      `json:"subjectId"`
+	Role        Role        `json:"role"`
+	ExpiresAt   time.Time   `json:"expiresAt"`
+	GrantedBy   string      `json:"grantedBy"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// ExpirationID returns the ID of the expiration of the share of the subject on the vault.
+func ExpirationID(vaultID string, subjectType SubjectType, subjectID string) string {
+	return vaultID + "_" + string(subjectType) + "_" + subjectID
+}
+
+// NewExpiration creates the expiration of the share. The time is stored in seconds, as the
+// sweeper compares it as text.
+func NewExpiration(tenantID string, share *Share, userID string) *Expiration {
+	return &Expiration{
+		ID:          ExpirationID(share.VaultID, share.SubjectType, share.SubjectID),
+		TenantID:    tenantID,
+		VaultID:     share.VaultID,
+		SubjectType: share.SubjectType,
+		SubjectID:   share.SubjectID,
+		Role:        share.Role,
+		ExpiresAt:   share.ExpiresAt.UTC().Truncate(time.Second),
+		GrantedBy:   userID,
+		UpdatedAt:   time.Now().UTC(),
+	}
+}
+
+// Object returns the OpenFGA object of the vault of the expiration.
+func (e *Expiration) Object() string {
+	return authorization.Object(authorization.TypeVault, e.VaultID)
+}
+
+// SweepReport summarises a run of the sweeper.
+type SweepReport struct {
+	Checked   int       `json:"checked"`
+	Revoked   int       `json:"revoked"`
+	Failed    int       `json:"failed"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+}
@@ -0,0 +1,230 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// TenantCollection and MemberCollection are global, so a user can list the tenants it belongs to.
+	TenantCollection = "tenants"
+	MemberCollection = "tenant_members"
+)
+
+// MemberSortableFields are the fields accepted by the orderBy query parameter.
+var MemberSortableFields = []string{"joinedAt", "role", "email", "name"}
+
+// TenantRepository interface defines methods for store and retrieve tenants and their members.
+// Every change of a member is written in the same batch as the outbox entries of its tuples.
+type TenantRepository interface {
+	// Create writes the tenant, its owner and the outbox entry of the owner tuple in one batch.
+	Create(ctx context.Context, tenant map[string]interface{}, owner map[string]interface{}, entry map[string]interface{}) (*Tenant, error)
+	Get(ctx context.Context, id string) (*Tenant, error)
+	Update(ctx context.Context, id string, tenant map[string]interface{}) error
+	GetMember(ctx context.Context, tenantID, userID string) (*Member, error)
+	ListMembers(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]Member, string, error)
+	AddMember(ctx context.Context, member map[string]interface{}, entry map[string]interface{}) (*Member, error)
+	// UpdateMember applies patch to the member and creates the outbox entries in one batch.
+	UpdateMember(ctx context.Context, id string, patch map[string]interface{}, entries ...map[string]interface{}) error
+	// RemoveMember deletes the member and its group memberships (group member IDs of the tenant of
+	// the context) and creates the outbox entry of their tuples in one batch.
+	RemoveMember(ctx context.Context, id string, groupMembers []string, entry map[string]interface{}) error
+}
+
+// TenantService manages the tenant of the context and its members.
+type TenantService interface {
+	// Bootstrap creates the tenant of a new signup with the user as its owner.
+	Bootstrap(ctx context.Context, tenant *Tenant, owner *Member) (*Tenant, error)
+	Get(ctx context.Context) (*Tenant, error)
+	// Update replaces the name and description of the tenant. Only owners and admins.
+	Update(ctx context.Context, tenant *Tenant) (*Tenant, error)
+	// Memberships returns the tenants the user of the context belongs to.
+	Memberships(ctx context.Context) ([]Member, error)
+	// Switch makes tenantID the tenant of the next tokens of the user.
+	Switch(ctx context.Context, tenantID string) (*Member, error)
+	ListMembers(ctx context.Context, opts database.QueryOptions) ([]Member, string, error)
+	AddMember(ctx context.Context, member *Member) (*Member, error)
+	UpdateMemberRole(ctx context.Context, userID string, role Role) (*Member, error)
+	RemoveMember(ctx context.Context, userID string) error
+}
+
+// Role is the role of a member. Each role is the OpenFGA relation of the user with the tenant.
+type Role string
+
+const (
+	ROLE_OWNER  Role = authorization.RelationOwner
+	ROLE_ADMIN  Role = authorization.RelationAdmin
+	ROLE_MEMBER Role = authorization.RelationMember
+	ROLE_GUEST  Role = "guest"
+	ROLE_BANNED Role = "banned"
+)
+
+// IsValid reports whether the role is known.
+func (r Role) IsValid() bool {
+	switch r {
+	case ROLE_OWNER, ROLE_ADMIN, ROLE_MEMBER, ROLE_GUEST, ROLE_BANNED:
+		return true
+	}
+	return false
+}
+
+// CanManage reports whether the role can manage members and the tenant settings.
+func (r Role) CanManage() bool {
+	return r == ROLE_OWNER || r == ROLE_ADMIN
+}
+
+// CanAssign reports whether a member with this role can give or take away the other role.
+// Only owners can manage owners and admins.
+func (r Role) CanAssign(other Role) bool {
+	if r == ROLE_OWNER {
+		return true
+	}
+	return r == ROLE_ADMIN && other != ROLE_OWNER && other != ROLE_ADMIN
+}
+
+// Plans of a tenant. Sharing vaults with other tenants requires the enterprise plan.
+const (
+	PLAN_FREE       = "free"
+	PLAN_ENTERPRISE = "enterprise"
+)
+
+// Tenant
+// An organization. Its ID is the tenantId claim of the Firebase users and the root of its
+// documents (tenant/<id>/...).
+type Tenant struct {
+	ID          string    `json:"id,omitempty"`
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description,omitempty"`
+	Plan        string    `json:"plan,omitempty"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	IsActive    bool      `json:"isActive"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// IsValid
+// This method validates the Tenant struct to ensure that required fields are present.
+func (t *Tenant) IsValid() error {
+
+	if t == nil {
+		return errors.New("invalid tenant: tenant cannot be nil")
+	}
+
+	if t.ID == "" || t.CreatedBy == "" {
+		return errors.New("invalid tenant: id and creator are required")
+	}
+
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("invalid tenant: name is required")
+	}
+
+	if len(t.Name) > 100 {
+		return errors.New("invalid tenant: name must have at most 100 characters")
+	}
+
+	return nil
+}
+
+// HasCrossTenantSharing reports whether the plan of the tenant allows sharing vaults with other tenants.
+func (t *Tenant) HasCrossTenantSharing() bool {
+	return t.Plan == PLAN_ENTERPRISE
+}
+
+// Object returns the OpenFGA object of the tenant.
+func (t *Tenant) Object() string {
+	return authorization.Object(authorization.TypeTenant, t.ID)
+}
+
+// NewTenant creates a new active tenant created by the user.
+func NewTenant(id, name, plan, userID string) *Tenant {
+	now := time.Now().UTC()
+	return &Tenant{
+		ID:        id,
+		Name:      strings.TrimSpace(name),
+		Plan:      plan,
+		CreatedBy: userID,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Member
+// The role of a user in a tenant, stored in tenant_members as <tenantId>_<userId>.
+type Member struct {
+	ID       string    `json:"id,omitempty"`
+	TenantID string    `json:"tenantId"`
+	UserID   string    `json:"userId" binding:"required"`
+	Email    string    `json:"email,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Role     Role      `json:"role" binding:"required"`
+	AddedBy  string    `json:"addedBy,omitempty"`
+	JoinedAt time.Time `json:"joinedAt"`
+	// TenantName is only filled in the memberships of a user.
+	TenantName string    `json:"tenantName,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// IsValid
+// This method validates the Member struct to ensure that required fields are present.
+func (m *Member) IsValid() error {
+
+	if m == nil {
+		return errors.New("invalid member: member cannot be nil")
+	}
+
+	if m.TenantID == "" || m.UserID == "" {
+		return errors.New("invalid member: tenant and user ids are required")
+	}
+
+	if !m.Role.IsValid() {
+		return errors.New("invalid member: role must be owner, admin, member, guest or banned")
+	}
+
+	return nil
+}
+
+// IsActive reports whether the member can use the tenant.
+func (m *Member) IsActive() bool {
+	return m.Role != ROLE_BANNED
+}
+
+// RoleTuple returns the tuple that gives the user its role in the tenant.
+func (m *Member) RoleTuple() authorization.TupleKey {
+	return RoleTuple(m.TenantID, m.UserID, m.Role)
+}
+
+// RoleTuple returns the tuple of a role of the user in the tenant.
+func RoleTuple(tenantID, userID string, role Role) authorization.TupleKey {
+	return authorization.TupleKey{
+		User:     authorization.User(userID),
+		Relation: string(role),
+		Object:   authorization.Object(authorization.TypeTenant, tenantID),
+	}
+}
+
+// MemberID returns the ID of the membership of the user in the tenant.
+func MemberID(tenantID, userID string) string {
+	return tenantID + "_" + userID
+}
+
+// NewMember creates the membership of the user in the tenant, added now by addedBy.
+func NewMember(tenantID, userID, email, name string, role Role, addedBy string) *Member {
+	now := time.Now().UTC()
+	return &Member{
+		ID:        MemberID(tenantID, userID),
+		TenantID:  tenantID,
+		UserID:    userID,
+		Email:     email,
+		Name:      name,
+		Role:      role,
+		AddedBy:   addedBy,
+		JoinedAt:  now,
+		UpdatedAt: now,
+	}
+}
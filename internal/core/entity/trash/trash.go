@@ -0,0 +1,174 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// TrashCollection lives under tenant/<tenantId>; the purger reads it as a collection group.
+	TrashCollection = "trash"
+
+	// DefaultRetention is how long deleted items stay in the trash before they are purged.
+	DefaultRetention = 30 * 24 * time.Hour
+
+	// PurgeActor is the user recorded in the audit events of the purger.
+	PurgeActor = "system"
+)
+
+// TrashSortableFields are the fields accepted by the orderBy query parameter.
+var TrashSortableFields = []string{"deletedAt", "name", "itemType"}
+
+// TrashRepository interface defines methods for store and retrieve the trash of a tenant.
+// Moving to and restoring from the trash update the item and the trash entry atomically.
+type TrashRepository interface {
+	// MoveToTrash applies patch to the item and creates the trash entry in one batch.
+	MoveToTrash(ctx context.Context, item map[string]interface{}, patch map[string]interface{}) (*TrashItem, error)
+	// Restore applies patch to the item and removes the trash entry in one batch.
+	Restore(ctx context.Context, item *TrashItem, patch map[string]interface{}) error
+	Get(ctx context.Context, id string) (*TrashItem, error)
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]TrashItem, string, error)
+	// ListExpired returns the entries of every tenant deleted at or before the given time. Only for the purger.
+	ListExpired(ctx context.Context, before time.Time) ([]TrashItem, error)
+	// Delete removes the trash entry once the item was purged.
+	Delete(ctx context.Context, id string) error
+}
+
+// TrashService moves items to the trash and restores them.
+type TrashService interface {
+	// MoveToTrash soft deletes the item and records the VAULT_DELETED or SECRET_DELETED event.
+	MoveToTrash(ctx context.Context, item *TrashItem) (*TrashItem, error)
+	// List returns a page of the tenant trash with the items the user is allowed to restore.
+	List(ctx context.Context, opts database.QueryOptions) ([]TrashItem, string, error)
+	// Restore reactivates the item and removes it from the trash.
+	Restore(ctx context.Context, id string) (*TrashItem, error)
+}
+
+// PurgerService hard deletes the items whose retention expired.
+type PurgerService interface {
+	Purge(ctx context.Context) (*PurgeReport, error)
+	// Run calls Purge every interval until ctx is cancelled.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type ItemType string
+
+const (
+	TRASH_VAULT  ItemType = "vault"
+	TRASH_SECRET ItemType = "secret"
+)
+
+// TrashItem
+// An entry of the trash of a tenant. The deleted item keeps its document, flagged with
+// deletedAt/deletedBy, until the purger removes both after the retention period.
+type TrashItem struct {
+	ID       string   `json:"id,omitempty"`
+	TenantID string   `json:"tenantId"`
+	ItemType ItemType `json:"itemType"`
+	ItemID   string   `json:"itemId"`
+	VaultID  string   `json:"vaultId"`
+	Name     string   `json:"name"`
+	// Collection of the item relative to the tenant, e.g. vaults or vaults/<vaultId>/secrets.
+	Collection string     `json:"collection"`
+	DeletedBy  string     `json:"deletedBy"`
+	DeletedAt  time.Time  `json:"deletedAt"`
+	PurgeAt    *time.Time `json:"purgeAt,omitempty"`
+}
+
+// IsValid
+// This method validates the TrashItem struct to ensure that required fields are present.
+func (t *TrashItem) IsValid() error {
+
+	if t == nil {
+		return errors.New("invalid trash item: item cannot be nil")
+	}
+
+	if t.ItemType != TRASH_VAULT && t.ItemType != TRASH_SECRET {
+		return errors.New("invalid trash item: item type must be vault or secret")
+	}
+
+	if t.TenantID == "" || t.ItemID == "" || t.VaultID == "" {
+		return errors.New("invalid trash item: tenant, item and vault ids are required")
+	}
+
+	if t.Collection == "" {
+		return errors.New("invalid trash item: collection is required")
+	}
+
+	if t.DeletedBy == "" || t.DeletedAt.IsZero() {
+		return errors.New("invalid trash item: deletedBy and deletedAt are required")
+	}
+
+	return nil
+}
+
+// Object returns the OpenFGA object of the item, used as the resource of its audit events.
+func (t *TrashItem) Object() string {
+	if t.ItemType == TRASH_VAULT {
+		return authorization.Object(authorization.TypeVault, t.ItemID)
+	}
+	return authorization.Object(authorization.TypeSecret, t.ItemID)
+}
+
+// DeletedPatch returns the fields set on the item when it is moved to the trash.
+func (t *TrashItem) DeletedPatch() map[string]interface{} {
+	return map[string]interface{}{
+		"isActive":  false,
+		"deletedAt": t.DeletedAt.Format(time.RFC3339Nano),
+		"deletedBy": t.DeletedBy,
+		"updatedAt": t.DeletedAt.Format(time.RFC3339Nano),
+	}
+}
+
+// RestoredPatch returns the fields set on the item when it is restored.
+func RestoredPatch(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"isActive":  true,
+		"deletedAt": nil,
+		"deletedBy": "",
+		"updatedAt": now.Format(time.RFC3339Nano),
+	}
+}
+
+// WithPurgeAt sets the time the item will be purged with the given retention.
+func (t *TrashItem) WithPurgeAt(retention time.Duration) *TrashItem {
+	purgeAt := t.DeletedAt.Add(retention)
+	t.PurgeAt = &purgeAt
+	return t
+}
+
+// TrashID returns the ID of the trash entry of an item. An item is in the trash at most once.
+func TrashID(itemType ItemType, itemID string) string {
+	return string(itemType) + "_" + itemID
+}
+
+// NewTrashItem creates the trash entry of an item deleted now by the user.
+// The deletion time is kept in seconds, so it can be compared as an RFC 3339 string.
+func NewTrashItem(itemType ItemType, tenantID, vaultID, itemID, name, collection, userID string) *TrashItem {
+	return &TrashItem{
+		ID:         TrashID(itemType, itemID),
+		TenantID:   tenantID,
+		ItemType:   itemType,
+		ItemID:     itemID,
+		VaultID:    vaultID,
+		Name:       name,
+		Collection: collection,
+		DeletedBy:  userID,
+		DeletedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// PurgeReport summarises a run of the purger.
+type PurgeReport struct {
+	Checked int `json:"checked"`
+	Purged  int `json:"purged"`
+	Failed  int `json:"failed"`
+	// Secrets removed together with the purged vaults.
+	Secrets   int       `json:"secrets"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+}
@@ -1,6 +1,20 @@
 package types
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds wrapped by the constructors below, so callers can use errors.Is
+// (or the Is* helpers) to decide how an error should be surfaced.
+var (
+	ErrKindInvalidRequest = errors.New("invalid request")
+	ErrKindUnauthorized   = errors.New("unauthorized")
+	ErrKindForbidden      = errors.New("forbidden")
+	ErrKindNotFound       = errors.New("not found")
+	ErrKindConflict       = errors.New("conflict")
+	ErrKindBadRequest     = errors.New("bad request")
+)
 
 func ErrRepositoryNotFound(repository string) error {
 	return fmt.Errorf("repository '%s' not found", repository)
@@ -15,7 +29,7 @@ func ErrHandlerNotFound(handler string) error {
 }
 
 func ErrInvalidRequest(message string) error {
-	return fmt.Errorf("invalid request: %s", message)
+	return fmt.Errorf("%w: %s", ErrKindInvalidRequest, message)
 }
 
 func ErrInvalidResponse(message string) error {
@@ -27,19 +41,19 @@ func ErrInternalServer(message string) error {
 }
 
 func ErrUnauthorized(message string) error {
-	return fmt.Errorf("unauthorized: %s", message)
+	return fmt.Errorf("%w: %s", ErrKindUnauthorized, message)
 }
 
 func ErrForbidden(message string) error {
-	return fmt.Errorf("forbidden: %s", message)
+	return fmt.Errorf("%w: %s", ErrKindForbidden, message)
 }
 
 func ErrNotFound(message string) error {
-	return fmt.Errorf("not found: %s", message)
+	return fmt.Errorf("%w: %s", ErrKindNotFound, message)
 }
 
 func ErrConflict(message string) error {
-	return fmt.Errorf("conflict: %s", message)
+	return fmt.Errorf("%w: %s", ErrKindConflict, message)
 }
 
 func ErrMethodNotAllowed(message string) error {
@@ -59,7 +73,7 @@ func ErrGatewayTimeout(message string) error {
 }
 
 func ErrBadRequest(message string) error {
-	return fmt.Errorf("bad request: %s", message)
+	return fmt.Errorf("%w: %s", ErrKindBadRequest, message)
 }
 
 func ErrTooManyRequests(message string) error {
@@ -77,3 +91,28 @@ func ErrRequestEntityTooLarge(message string) error {
 func ErrGenericError(message string) error {
 	return fmt.Errorf("%s", message)
 }
+
+// IsNotFound reports whether err was built with ErrNotFound.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrKindNotFound)
+}
+
+// IsConflict reports whether err was built with ErrConflict.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrKindConflict)
+}
+
+// IsForbidden reports whether err was built with ErrForbidden.
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrKindForbidden)
+}
+
+// IsUnauthorized reports whether err was built with ErrUnauthorized.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrKindUnauthorized)
+}
+
+// IsInvalidRequest reports whether err was built with ErrInvalidRequest or ErrBadRequest.
+func IsInvalidRequest(err error) bool {
+	return errors.Is(err, ErrKindInvalidRequest) || errors.Is(err, ErrKindBadRequest)
+}
@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// VaultCollection lives under tenant/<tenantId>.
+	VaultCollection = "vaults"
+
+	VaultNameMinLength = 2
+	VaultNameMaxLength = 100
+	VaultMaxTags       = 5
+)
+
+// VaultSortableFields are the fields accepted by the orderBy query parameter.
+var VaultSortableFields = []string{"name", "createdAt", "updatedAt"}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}){1,2}$`)
+
+// VaultRepository interface defines methods for store and retrieve vaults
+type VaultRepository interface {
+	Create(ctx context.Context, vault map[string]interface{}) (*Vault, error)
+	// CreateWithOutbox writes the vault and the authorization outbox entry in one transaction.
+	CreateWithOutbox(ctx context.Context, vault map[string]interface{}, entry map[string]interface{}) (*Vault, error)
+	Get(ctx context.Context, id string) (*Vault, error)
+	// List returns a page of vaults and the token of the next page.
+	List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]Vault, string, error)
+	Update(ctx context.Context, id string, vault map[string]interface{}) error
+	// UpdateIfMatch updates the vault only if it is still at the given revision, otherwise it returns a conflict.
+	UpdateIfMatch(ctx context.Context, id string, revision int64, vault map[string]interface{}) error
+	// Purge hard deletes the vault document. Its secrets must be purged first.
+	Purge(ctx context.Context, id string) error
+	// ListAll returns the vaults of every tenant. Only for background jobs.
+	ListAll(ctx context.Context) ([]Vault, error)
+}
+
+// VaultService interface defines methods for handling vaults
+type VaultService interface {
+	Create(ctx context.Context, vault *Vault) (*Vault, error)
+	Get(ctx context.Context, id string) (*Vault, error)
+	// List returns a page of the vaults the user can view and the token of the next page.
+	List(ctx context.Context, opts database.QueryOptions) ([]Vault, string, error)
+	// Update replaces the editable fields. The update only succeeds if the stored vault is still
+	// at vault.Revision, or at the revision that was read when vault.Revision is not set.
+	Update(ctx context.Context, id string, vault *Vault) (*Vault, error)
+	Delete(ctx context.Context, id string) error
+	Search(ctx context.Context, name string) ([]Vault, error)
+}
+
+// Vault
+// A vault groups the secrets of a tenant. It lives under tenant/<tenantId>/vaults.
+// Revision is incremented by every update and is returned to clients as the ETag.
+type Vault struct {
+	ID          string     `json:"id,omitempty"`
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description,omitempty"`
+	TenantID    string     `json:"tenantId,omitempty"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+	Icon        string     `json:"icon,omitempty"`
+	Color       string     `json:"color,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	IsActive    bool       `json:"isActive"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy   string     `json:"deletedBy,omitempty"`
+	Revision    int64      `json:"revision"`
+}
+
+// IsValid
+// This method validates the Vault struct to ensure that required fields are present.
+func (v *Vault) IsValid() error {
+
+	if v == nil {
+		return errors.New("invalid vault: vault cannot be nil")
+	}
+
+	name := strings.TrimSpace(v.Name)
+	if name == "" {
+		return errors.New("invalid vault: name is required")
+	}
+
+	if len(name) < VaultNameMinLength || len(name) > VaultNameMaxLength {
+		return errors.New("invalid vault: name must have between 2 and 100 characters")
+	}
+
+	if len(v.Tags) > VaultMaxTags {
+		return errors.New("invalid vault: maximum of 5 tags allowed")
+	}
+
+	if v.Color != "" && !hexColor.MatchString(v.Color) {
+		return errors.New("invalid vault: color must be a hex color")
+	}
+
+	return nil
+}
+
+// AddTag adds a tag to the vault respecting the maximum of 5 tags.
+func (v *Vault) AddTag(tag string) error {
+	if len(v.Tags) >= VaultMaxTags {
+		return errors.New("maximum of 5 tags allowed")
+	}
+
+	for _, existingTag := range v.Tags {
+		if existingTag == tag {
+			return errors.New("tag already exists")
+		}
+	}
+
+	v.Tags = append(v.Tags, tag)
+	return nil
+}
+
+// IsDeleted reports whether the vault was soft deleted.
+func (v *Vault) IsDeleted() bool {
+	return v.DeletedAt != nil || !v.IsActive
+}
+
+// MatchName reports whether the vault name contains the term, ignoring case.
+func (v *Vault) MatchName(term string) bool {
+	return strings.Contains(strings.ToLower(v.Name), strings.ToLower(strings.TrimSpace(term)))
+}
+
+// OwnershipTuples returns the tuples that make the creator the owner of the vault
+// and link the vault to its tenant.
+func (v *Vault) OwnershipTuples() []authorization.TupleKey {
+	return []authorization.TupleKey{
+		{User: authorization.User(v.CreatedBy), Relation: authorization.RelationOwner, Object: v.GetOpenFGAID()},
+		{User: authorization.Object(authorization.TypeTenant, v.TenantID), Relation: authorization.RelationTenant, Object: v.GetOpenFGAID()},
+	}
+}
+
+// GetOpenFGAID returns the object identifier used by the authorization model.
+func (v *Vault) GetOpenFGAID() string {
+	return "vault:" + v.ID
+}
+
+// NewVault creates a new active Vault owned by the user in the tenant
+func NewVault(vault Vault, tenantID, userID string) *Vault {
+	now := time.Now().UTC()
+	return &Vault{
+		Name:        strings.TrimSpace(vault.Name),
+		Description: vault.Description,
+		TenantID:    tenantID,
+		CreatedBy:   userID,
+		Icon:        vault.Icon,
+		Color:       vault.Color,
+		Tags:        vault.Tags,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Revision:    1,
+	}
+}
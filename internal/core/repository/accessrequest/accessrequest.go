@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/accessrequest"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_share "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type accessRequest struct {
+	base *repo.Repository[entity.AccessRequest]
+}
+
+func InitializeAccessRequestRepository(db database.FirebaseDBInterface) (entity.AccessRequestRepository, error) {
+
+	base, err := repo.NewTenantRepository[entity.AccessRequest](db, "access request")
+	if err != nil {
+		return nil, err
+	}
+
+	return &accessRequest{
+		base: base,
+	}, nil
+}
+
+func (r *accessRequest) Create(ctx context.Context, data map[string]interface{}) (*entity.AccessRequest, error) {
+
+	if len(data) == 0 {
+		return nil, errors.New("invalid access request: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		return nil, errors.New("invalid access request: id is required")
+	}
+	delete(data, "id")
+
+	return r.base.CreateWithID(ctx, entity.AccessRequestCollection, id, data)
+}
+
+func (r *accessRequest) Get(ctx context.Context, id string) (*entity.AccessRequest, error) {
+	return r.base.Get(ctx, entity.AccessRequestCollection, id)
+}
+
+func (r *accessRequest) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.AccessRequest, string, error) {
+	return r.base.Page(ctx, entity.AccessRequestCollection, filters, opts)
+}
+
+func (r *accessRequest) Decide(ctx context.Context, id string, patch map[string]interface{}, expiration map[string]interface{}, entry map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" || len(patch) == 0 {
+		return errors.New("invalid access request: id and patch are required")
+	}
+
+	if (expiration == nil) != (entry == nil) {
+		return errors.New("invalid access request: expiration and outbox entry go together")
+	}
+
+	expirationID, entryID := "", ""
+	if entry != nil {
+		expirationID, _ = expiration["id"].(string)
+		entryID, _ = entry["id"].(string)
+		if expirationID == "" || entryID == "" {
+			return errors.New("invalid access request: expiration and outbox entry ids are required")
+		}
+		delete(expiration, "id")
+		delete(entry, "id")
+	}
+
+	requests, err := r.base.Path(ctx, entity.AccessRequestCollection)
+	if err != nil {
+		return err
+	}
+
+	expirations, err := r.base.Path(ctx, entity_share.ExpirationCollection)
+	if err != nil {
+		return err
+	}
+
+	err = r.base.DB().RunTransaction(ctx, func(tx database.Tx) error {
+
+		response, err := tx.Get(requests, id)
+		if err != nil {
+			return err
+		}
+
+		var current entity.AccessRequest
+		if err := json.Unmarshal(response, &current); err != nil {
+			return fmt.Errorf("failed to unmarshal access request: %w", err)
+		}
+
+		if current.Status != entity.STATUS_PENDING {
+			return core.ErrConflict("access request " + id + " was already " + string(current.Status))
+		}
+
+		if err := tx.Update(requests, id, patch); err != nil {
+			return err
+		}
+
+		if entry == nil {
+			return nil
+		}
+
+		if err := tx.Set(expirations, expirationID, expiration); err != nil {
+			return err
+		}
+
+		return tx.Create(entity_outbox.OutboxCollection, entryID, entry)
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return core.ErrNotFound("access request " + id)
+		}
+		if core.IsConflict(err) {
+			return err
+		}
+		return fmt.Errorf("failed to decide access request: %w", err)
+	}
+
+	return nil
+}
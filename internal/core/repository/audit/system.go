@@ -2,73 +2,38 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
-	"fmt"
 
 	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
 	"github.com/synera-br/lockari-backend-app/pkg/database"
-	"github.com/synera-br/lockari-backend-app/pkg/utils"
 )
 
 type auditSystemEvent struct {
-	db         database.FirebaseDBInterface
+	base       *repo.Repository[entity.AuditSystemEvent]
 	collection string
 }
 
 func InicializeAuditSystemEventRepository(db database.FirebaseDBInterface) (entity.AuditSystemEventRepository, error) {
-	if db == nil {
-		return nil, errors.New("database is required")
+
+	base, err := repo.NewGlobalRepository[entity.AuditSystemEvent](db, "audit event")
+	if err != nil {
+		return nil, err
 	}
 
 	return &auditSystemEvent{
-		db:         db,
+		base:       base,
 		collection: "system_audit",
 	}, nil
 }
 
 func (r *auditSystemEvent) Create(ctx context.Context, audit map[string]interface{}) (*entity.AuditSystemEvent, error) {
-
-	if ctx.Err() != nil {
-		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
-	}
-
-	if len(audit) == 0 {
-		return nil, errors.New("invalid audit: no data provided")
-	}
-
-	response, err := r.db.Create(ctx, audit, r.collection)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create audit: %w", err)
-	}
-
-	auditResponse, err := r.convertToEntity(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert audit response: %w", err)
-	}
-
-	return auditResponse, nil
+	return r.base.Create(ctx, r.collection, audit)
 }
 
-func (r *auditSystemEvent) Get(ctx context.Context, filters database.Conditional) (*entity.AuditSystemEvent, error) {
-	return nil, nil
+func (r *auditSystemEvent) Get(ctx context.Context, id string) (*entity.AuditSystemEvent, error) {
+	return r.base.Get(ctx, r.collection, id)
 }
 
-func (r *auditSystemEvent) List(ctx context.Context, filters database.Conditional) ([]entity.AuditSystemEvent, error) {
-	return nil, nil
-}
-
-func (r *auditSystemEvent) convertToEntity(data []byte) (*entity.AuditSystemEvent, error) {
-
-	if len(data) == 0 {
-		return nil, errors.New("error to convert audit data to map")
-	}
-
-	var audit entity.AuditSystemEvent
-	err := json.Unmarshal(data, &audit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal audit data: %w", err)
-	}
-
-	return &audit, nil
+func (r *auditSystemEvent) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.AuditSystemEvent, string, error) {
+	return r.base.Page(ctx, r.collection, filters, opts)
 }
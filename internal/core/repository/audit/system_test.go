@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+func TestAuditSystemEventRepository(t *testing.T) {
+	ctx := context.Background()
+	repo, err := InicializeAuditSystemEventRepository(database.NewMemoryDB())
+	require.NoError(t, err)
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	types := []entity.EventType{entity.LOGIN_SUCCESS, entity.LOGIN_FAILURE, entity.ACCESS_DENIED, entity.LOGIN_FAILURE, entity.ACCESS_DENIED}
+
+	var ids []string
+	for i, eventType := range types {
+		event := entity.NewAccessDeniedEvent("alice", "alice@lockari.io", "t1", "vault:v1", "can_read", entity.Client{})
+		event.EventType = eventType
+		event.Timestamp = start.Add(time.Duration(i) * time.Hour).Format(time.RFC3339)
+		ids = append(ids, create(t, repo, event))
+	}
+
+	got, err := repo.Get(ctx, ids[2])
+	require.NoError(t, err)
+	assert.Equal(t, entity.ACCESS_DENIED, got.EventType)
+
+	_, err = repo.Get(ctx, "missing")
+	assert.True(t, core.IsNotFound(err))
+
+	from, to := start.Add(time.Hour), start.Add(3*time.Hour)
+	filters, err := entity.AuditFilter{
+		EventTypes: []entity.EventType{entity.LOGIN_FAILURE, entity.ACCESS_DENIED},
+		From:       &from,
+		To:         &to,
+	}.Conditionals()
+	require.NoError(t, err)
+
+	opts := database.QueryOptions{PageSize: 2, OrderBy: []database.OrderBy{{Field: "timestamp", Direction: database.Desc}}}
+	page, next, err := repo.List(ctx, filters, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[3], ids[2]}, eventIDs(page))
+	require.NotEmpty(t, next)
+
+	opts.PageToken = next
+	page, next, err = repo.List(ctx, filters, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[1]}, eventIDs(page))
+	assert.Empty(t, next)
+}
+
+func TestAuditSystemEventRangeBoundaries(t *testing.T) {
+	ctx := context.Background()
+	repo, err := InicializeAuditSystemEventRepository(database.NewMemoryDB())
+	require.NoError(t, err)
+
+	var ids []string
+	for _, timestamp := range []string{"2024-05-01T00:00:00Z", "2024-05-01T00:00:00.5Z", "2024-05-01T02:00:01+02:00", "2024-05-01T00:00:01.000000001Z"} {
+		event := entity.NewAccessDeniedEvent("alice", "", "t1", "vault:v1", "can_read", entity.Client{})
+		event.Timestamp = timestamp
+		ids = append(ids, create(t, repo, event))
+	}
+
+	list := func(from, to time.Time) []string {
+		filters, err := entity.AuditFilter{From: &from, To: &to}.Conditionals()
+		require.NoError(t, err)
+		page, _, err := repo.List(ctx, filters, database.QueryOptions{OrderBy: []database.OrderBy{{Field: "timestamp", Direction: database.Asc}}})
+		require.NoError(t, err)
+		return eventIDs(page)
+	}
+
+	second := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{ids[0], ids[1]}, list(second, second.Add(time.Second-1)), "a fractional second is inside its second")
+	assert.Equal(t, []string{ids[1], ids[2]}, list(second.Add(500*time.Millisecond), second.Add(time.Second)), "both bounds are inclusive and offsets are compared in UTC")
+	assert.Equal(t, []string{ids[2], ids[3]}, list(second.Add(time.Second), second.Add(2*time.Second)))
+}
+
+// create normalizes and stores the event and returns its id.
+func create(t *testing.T, repo entity.AuditSystemEventRepository, event *entity.AuditSystemEvent) string {
+	require.NoError(t, event.IsValidInternal())
+
+	data, err := utils.StructToMap(event)
+	require.NoError(t, err)
+
+	created, err := repo.Create(context.Background(), data)
+	require.NoError(t, err)
+	return created.ID
+}
+
+func eventIDs(events []entity.AuditSystemEvent) []string {
+	ids := []string{}
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
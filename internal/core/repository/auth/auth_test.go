@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/auth"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type AuthRepositoryTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	db     *database.MemoryDB
+	signup entity.SignupEventRepository
+	login  entity.LoginEventRepository
+	client entity.Client
+}
+
+func TestAuthRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthRepositoryTestSuite))
+}
+
+func (s *AuthRepositoryTestSuite) SetupTest() {
+	var err error
+
+	s.ctx = context.WithValue(context.Background(), "UserID", "alice")
+	s.ctx = context.WithValue(s.ctx, "TenantID", "t1")
+	s.db = database.NewMemoryDB()
+	s.client = entity.Client{IpAddress: "10.0.0.1", UserAgent: "test"}
+
+	s.signup, err = InitializeSignupEventRepository(s.db)
+	s.Require().NoError(err)
+
+	s.login, err = InitializeLoginEventRepository(s.db)
+	s.Require().NoError(err)
+}
+
+func (s *AuthRepositoryTestSuite) createSignup(uid string) *entity.Signup {
+	signup := entity.NewSignup(entity.User{Uid: uid, Email: uid + "@lockari.io", Plan: "free"}, s.client, utils.GenerateTenant())
+	data, err := utils.StructToMap(signup)
+	s.Require().NoError(err)
+
+	created, err := s.signup.Create(s.ctx, data)
+	s.Require().NoError(err)
+	return created
+}
+
+func (s *AuthRepositoryTestSuite) TestSignup() {
+	created := s.createSignup("alice")
+	s.createSignup("bob")
+	s.NotEmpty(created.ID)
+
+	got, err := s.signup.Get(s.ctx, created.ID)
+	s.Require().NoError(err)
+	s.Equal("alice", got.Uid)
+	s.Equal(created.Tenant, got.Tenant)
+	s.Equal(entity.SIGNUP_SUCCESS, got.EventType)
+
+	_, err = s.signup.Get(s.ctx, "missing")
+	s.True(core.IsNotFound(err))
+
+	signups, err := s.signup.List(s.ctx, database.Conditional{Field: "uid", Value: "alice", Filter: database.FilterEquals})
+	s.Require().NoError(err)
+	s.Len(signups, 1)
+
+	_, err = s.signup.List(s.ctx, database.Conditional{})
+	s.Error(err)
+}
+
+func (s *AuthRepositoryTestSuite) TestLogin() {
+	login := entity.NewLogin(entity.User{Uid: "alice", Email: "alice@lockari.io", Plan: "free"}, s.client)
+
+	created, err := s.login.Create(s.ctx, login)
+	s.Require().NoError(err)
+	id, ok := created.GetID()
+	s.True(ok)
+
+	data, err := s.db.Get(s.ctx, "tenant/t1/logins")
+	s.Require().NoError(err)
+	s.Contains(string(data), id, "logins are stored under the tenant")
+
+	got, err := s.login.Get(s.ctx, []map[string]interface{}{{"user.uid": "alice", "eventType": "LOGIN_SUCCESS"}})
+	s.Require().NoError(err)
+	s.Equal(id, got.GetLogin().ID)
+
+	_, err = s.login.Get(s.ctx, []map[string]interface{}{{"user.uid": "bob"}})
+	s.True(core.IsNotFound(err))
+
+	logins, err := s.login.List(s.ctx)
+	s.Require().NoError(err)
+	s.Len(logins, 1)
+
+	other := entity.NewLogin(entity.User{Uid: "bob", Email: "bob@lockari.io", Plan: "free"}, s.client)
+	_, err = s.login.Create(s.ctx, other)
+	s.True(core.IsForbidden(err), "users cannot store logins of someone else")
+}
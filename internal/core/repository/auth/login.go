@@ -2,63 +2,115 @@ package repository
 
 import (
 	"context"
-	"errors"
 
 	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/auth"
+	types "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	core "github.com/synera-br/lockari-backend-app/internal/core/repository"
 	"github.com/synera-br/lockari-backend-app/pkg/database"
 	"github.com/synera-br/lockari-backend-app/pkg/utils"
 )
 
 type LoginEvent struct {
-	db database.FirebaseDBInterface
+	base       *core.Repository[entity.Login]
+	collection string
 }
 
 func InitializeLoginEventRepository(db database.FirebaseDBInterface) (entity.LoginEventRepository, error) {
 
-	if db == nil {
-		return nil, errors.New("database connection is nil")
-	}
-
-	if !db.IsConnected() {
-		return nil, errors.New("database connection is not initialized")
+	base, err := core.NewTenantRepository[entity.Login](db, "login")
+	if err != nil {
+		return nil, err
 	}
 
 	return &LoginEvent{
-		db: db,
+		base:       base,
+		collection: "logins",
 	}, nil
 }
 
-func (s *LoginEvent) Create(ctx context.Context, requestLogin entity.LoginEvent) (login entity.LoginEvent, err error) {
+// Create stores the login of the user in the context, under tenant/<tenantId>/logins.
+func (s *LoginEvent) Create(ctx context.Context, requestLogin entity.LoginEvent) (entity.LoginEvent, error) {
 
 	uid, err := utils.GetUserID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if requestLogin == nil {
+		return nil, types.ErrInvalidRequest("login is required")
+	}
+
 	if *uid != requestLogin.GetUser().Uid {
-		return nil, err
+		return nil, types.ErrForbidden("login does not belong to the user")
 	}
 
 	if err = requestLogin.IsValid(); err != nil {
 		return nil, err
 	}
 
-	return requestLogin, err
+	data, err := utils.StructToMap(requestLogin.GetLogin())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.base.Create(ctx, s.collection, data)
 }
 
-func (s *LoginEvent) Get(ctx context.Context, filters []map[string]interface{}) (login entity.LoginEvent, err error) {
-	_, err = utils.GetUserID(ctx)
-	if err != nil {
+// Get returns the first login that matches every field of the filters.
+func (s *LoginEvent) Get(ctx context.Context, filters []map[string]interface{}) (entity.LoginEvent, error) {
+
+	if _, err := utils.GetUserID(ctx); err != nil {
 		return nil, err
 	}
 
 	if len(filters) == 0 {
+		return nil, types.ErrInvalidRequest("at least one filter is required")
+	}
+
+	var conditionals []database.Conditional
+	for _, filter := range filters {
+		for field, value := range filter {
+			conditionals = append(conditionals, database.Conditional{Field: field, Value: value, Filter: database.FilterEquals})
+		}
+	}
+
+	logins, err := s.base.List(ctx, s.collection, conditionals)
+	if err != nil {
 		return nil, err
 	}
 
-	return login, err
+	if len(logins) == 0 {
+		return nil, types.ErrNotFound("login")
+	}
+
+	return &logins[0], nil
 }
 
-func (s *LoginEvent) List(ctx context.Context) (logins []entity.LoginEvent, err error) {
-	return logins, err
+// List returns the logins of the user in the context.
+func (s *LoginEvent) List(ctx context.Context) ([]entity.LoginEvent, error) {
+
+	uid, err := utils.GetUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "user.uid",
+			Value:  *uid,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	logins, err := s.base.List(ctx, s.collection, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.LoginEvent, 0, len(logins))
+	for i := range logins {
+		result = append(result, &logins[i])
+	}
+
+	return result, nil
 }
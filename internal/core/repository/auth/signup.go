@@ -2,7 +2,6 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 
 	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/auth"
@@ -11,53 +10,29 @@ import (
 )
 
 type SignupEvent struct {
-	db         database.FirebaseDBInterface
+	base       *core.Repository[entity.Signup]
 	collection string
 }
 
 func InitializeSignupEventRepository(db database.FirebaseDBInterface) (entity.SignupEventRepository, error) {
 
-	if db == nil {
-		return nil, errors.New("database connection is nil")
-	}
-
-	if !db.IsConnected() {
-		return nil, errors.New("database connection is not initialized")
+	base, err := core.NewGlobalRepository[entity.Signup](db, "signup")
+	if err != nil {
+		return nil, err
 	}
 
 	return &SignupEvent{
-		db:         db,
+		base:       base,
 		collection: "subscription",
 	}, nil
 }
 
 func (r *SignupEvent) Create(ctx context.Context, signup map[string]interface{}) (*entity.Signup, error) {
-	if len(signup) == 0 {
-		return nil, errors.New("invalid signup: no data provided")
-	}
-
-	if ctx.Err() != nil {
-		return nil, errors.New("context cancelled")
-	}
-
-	// Save the signup event to the database na coleção correta
-	response, err := r.db.Create(ctx, signup, r.collection)
-	if err != nil {
-		return nil, errors.New("failed to save signup event to database: " + err.Error())
-	}
-
-	newSignup, err := r.convertToEntity(response)
-	if err != nil {
-		return nil, errors.New("failed to convert response to signup entity: " + err.Error())
-	}
-
-	return newSignup, nil
+	return r.base.Create(ctx, r.collection, signup)
 }
 
-func (r *SignupEvent) Get(ctx context.Context, filters database.Conditional) (*entity.Signup, error) {
-	var signup entity.Signup
-
-	return &signup, nil
+func (r *SignupEvent) Get(ctx context.Context, id string) (*entity.Signup, error) {
+	return r.base.Get(ctx, r.collection, id)
 }
 
 func (r *SignupEvent) List(ctx context.Context, filter database.Conditional) ([]entity.Signup, error) {
@@ -69,54 +44,5 @@ func (r *SignupEvent) List(ctx context.Context, filter database.Conditional) ([]
 		return nil, errors.New("invalid filters: no value provided")
 	}
 
-	collection, err := core.SetCollection(ctx, r.collection)
-	if err != nil {
-		return nil, err
-	}
-
-	filters := []database.Conditional{
-		filter,
-	}
-
-	response, err := r.db.GetByConditional(ctx, filters, *collection)
-	if err != nil {
-		return nil, err
-	}
-
-	items, err := r.convertToEntities(response)
-	if err != nil {
-		return nil, err
-	}
-
-	return items, nil
-}
-
-func (r *SignupEvent) convertToEntity(data []byte) (*entity.Signup, error) {
-
-	if len(data) == 0 {
-		return nil, errors.New("error to convert signup data to map")
-	}
-
-	var signup entity.Signup
-	err := json.Unmarshal(data, &signup)
-	if err != nil {
-		return nil, errors.New("failed to unmarshal signup data")
-	}
-
-	return &signup, nil
-}
-
-func (r *SignupEvent) convertToEntities(data []byte) ([]entity.Signup, error) {
-
-	if len(data) == 0 {
-		return nil, errors.New("error to convert signup data to map")
-	}
-
-	var signups []entity.Signup
-	err := json.Unmarshal(data, &signups)
-	if err != nil {
-		return nil, errors.New("failed to unmarshal signup data")
-	}
-
-	return signups, nil
+	return r.base.List(ctx, r.collection, []database.Conditional{filter})
 }
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/backup"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+type backup struct {
+	base       *repo.Repository[entity.Backup]
+	collection string
+}
+
+func InitializeBackupRepository(db database.FirebaseDBInterface) (entity.BackupRepository, error) {
+
+	base, err := repo.NewGlobalRepository[entity.Backup](db, "backup")
+	if err != nil {
+		return nil, err
+	}
+
+	return &backup{
+		base:       base,
+		collection: entity.BackupCollection,
+	}, nil
+}
+
+func (r *backup) Create(ctx context.Context, id string, data map[string]interface{}) (*entity.Backup, error) {
+	return r.base.CreateWithID(ctx, r.collection, id, data)
+}
+
+func (r *backup) Get(ctx context.Context, id string) (*entity.Backup, error) {
+	return r.base.Get(ctx, r.collection, id)
+}
+
+// List returns a page of backups. The collection is global, so the service always filters by tenantId.
+func (r *backup) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.Backup, string, error) {
+	return r.base.Page(ctx, r.collection, filters, opts)
+}
+
+func (r *backup) Update(ctx context.Context, id string, data map[string]interface{}) error {
+	return r.base.Update(ctx, r.collection, id, data)
+}
+
+func (r *backup) Delete(ctx context.Context, id string) error {
+	return r.base.Delete(ctx, r.collection, id)
+}
+
+func (r *backup) ListExpired(ctx context.Context, before time.Time) ([]entity.Backup, error) {
+
+	filters := []database.Conditional{
+		{
+			Field:  "storage.expiresAt",
+			Value:  before.UTC().Truncate(time.Second).Format(time.RFC3339),
+			Filter: database.FilterLessThanOrEqual,
+		},
+	}
+
+	return r.base.List(ctx, r.collection, filters)
+}
+
+func (r *backup) Export(ctx context.Context, tenantID string, fn func(doc entity.TenantDocument) error) error {
+
+	root, err := tenantRoot(tenantID)
+	if err != nil {
+		return err
+	}
+
+	return r.base.DB().Walk(ctx, root, func(collection, id string, data map[string]interface{}) error {
+		return fn(entity.TenantDocument{
+			Collection: strings.TrimPrefix(collection, root+"/"),
+			ID:         id,
+			Data:       data,
+		})
+	})
+}
+
+func (r *backup) Import(ctx context.Context, tenantID string, docs []entity.TenantDocument) error {
+
+	root, err := tenantRoot(tenantID)
+	if err != nil {
+		return err
+	}
+
+	batch := database.NewBatch()
+	for _, doc := range docs {
+		if doc.Collection == "" || doc.ID == "" {
+			return fmt.Errorf("invalid backup document: collection and id are required")
+		}
+
+		batch.Set(root+"/"+doc.Collection, doc.ID, doc.Data)
+		if batch.Len() == database.MaxBatchWrites {
+			if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+				return fmt.Errorf("failed to import backup documents: %w", err)
+			}
+			batch = database.NewBatch()
+		}
+	}
+
+	if batch.Len() > 0 {
+		if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+			return fmt.Errorf("failed to import backup documents: %w", err)
+		}
+	}
+
+	return nil
+}
+
+var errFound = errors.New("found")
+
+func (r *backup) HasDocuments(ctx context.Context, tenantID string) (bool, error) {
+
+	root, err := tenantRoot(tenantID)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.base.DB().Walk(ctx, root, func(collection, id string, data map[string]interface{}) error {
+		return errFound
+	})
+	if errors.Is(err, errFound) {
+		return true, nil
+	}
+
+	return false, err
+}
+
+func tenantRoot(tenantID string) (string, error) {
+
+	if tenantID == "" || strings.Contains(tenantID, "/") {
+		return "", fmt.Errorf("invalid tenant id %q", tenantID)
+	}
+
+	return "tenant/" + tenantID, nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/externalshare"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type externalShare struct {
+	base *repo.Repository[entity.ExternalShareRequest]
+}
+
+func InitializeExternalShareRepository(db database.FirebaseDBInterface) (entity.ExternalShareRepository, error) {
+
+	base, err := repo.NewGlobalRepository[entity.ExternalShareRequest](db, "external share request")
+	if err != nil {
+		return nil, err
+	}
+
+	return &externalShare{
+		base: base,
+	}, nil
+}
+
+func (r *externalShare) Create(ctx context.Context, data map[string]interface{}) (*entity.ExternalShareRequest, error) {
+
+	if len(data) == 0 {
+		return nil, errors.New("invalid external share request: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		return nil, errors.New("invalid external share request: id is required")
+	}
+	delete(data, "id")
+
+	return r.base.CreateWithID(ctx, entity.ExternalShareCollection, id, data)
+}
+
+func (r *externalShare) Get(ctx context.Context, id string) (*entity.ExternalShareRequest, error) {
+	return r.base.Get(ctx, entity.ExternalShareCollection, id)
+}
+
+func (r *externalShare) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.ExternalShareRequest, string, error) {
+	return r.base.Page(ctx, entity.ExternalShareCollection, filters, opts)
+}
+
+func (r *externalShare) Transition(ctx context.Context, id string, status entity.Status, decisions int, patch map[string]interface{}, entry map[string]interface{}, guest map[string]interface{}, removeGuest string) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" || len(patch) == 0 {
+		return errors.New("invalid external share request: id and patch are required")
+	}
+
+	entryID := ""
+	if entry != nil {
+		entryID, _ = entry["id"].(string)
+		if entryID == "" {
+			return errors.New("invalid external share request: outbox entry id is required")
+		}
+		delete(entry, "id")
+	}
+
+	guestID := ""
+	if guest != nil {
+		guestID, _ = guest["id"].(string)
+		if guestID == "" {
+			return errors.New("invalid external share request: guest membership id is required")
+		}
+		delete(guest, "id")
+	}
+
+	err := r.base.DB().RunTransaction(ctx, func(tx database.Tx) error {
+
+		response, err := tx.Get(entity.ExternalShareCollection, id)
+		if err != nil {
+			return err
+		}
+
+		var current entity.ExternalShareRequest
+		if err := json.Unmarshal(response, &current); err != nil {
+			return fmt.Errorf("failed to unmarshal external share request: %w", err)
+		}
+
+		if current.Status != status || len(current.Approvals) != decisions {
+			return core.ErrConflict("external share request " + id + " was changed by another user")
+		}
+
+		// Every read comes before the writes, as Firestore requires.
+		createGuest := false
+		if guestID != "" {
+			if _, err := tx.Get(entity_tenant.MemberCollection, guestID); err != nil {
+				if !errors.Is(err, database.ErrNotFound) {
+					return err
+				}
+				createGuest = true
+			}
+		}
+
+		deleteGuest := false
+		if removeGuest != "" {
+			response, err := tx.Get(entity_tenant.MemberCollection, removeGuest)
+			if err != nil && !errors.Is(err, database.ErrNotFound) {
+				return err
+			}
+			if err == nil {
+				var member entity_tenant.Member
+				if err := json.Unmarshal(response, &member); err != nil {
+					return fmt.Errorf("failed to unmarshal member: %w", err)
+				}
+				deleteGuest = member.Role == entity_tenant.ROLE_GUEST
+			}
+		}
+
+		if err := tx.Update(entity.ExternalShareCollection, id, patch); err != nil {
+			return err
+		}
+
+		if createGuest {
+			if err := tx.Create(entity_tenant.MemberCollection, guestID, guest); err != nil {
+				return err
+			}
+		}
+
+		if deleteGuest {
+			if err := tx.Delete(entity_tenant.MemberCollection, removeGuest); err != nil {
+				return err
+			}
+		}
+
+		if entry == nil {
+			return nil
+		}
+
+		return tx.Create(entity_outbox.OutboxCollection, entryID, entry)
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return core.ErrNotFound("external share request " + id)
+		}
+		if core.IsConflict(err) {
+			return err
+		}
+		return fmt.Errorf("failed to update external share request: %w", err)
+	}
+
+	return nil
+}
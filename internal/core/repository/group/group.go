@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type group struct {
+	base    *repo.Repository[entity.Group]
+	members *repo.Repository[entity.Member]
+}
+
+func InitializeGroupRepository(db database.FirebaseDBInterface) (entity.GroupRepository, error) {
+
+	base, err := repo.NewTenantRepository[entity.Group](db, "group")
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := repo.NewTenantRepository[entity.Member](db, "group member")
+	if err != nil {
+		return nil, err
+	}
+
+	return &group{
+		base:    base,
+		members: members,
+	}, nil
+}
+
+func (r *group) Create(ctx context.Context, data map[string]interface{}, owner map[string]interface{}, entry map[string]interface{}) (*entity.Group, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if len(data) == 0 || len(owner) == 0 || len(entry) == 0 {
+		return nil, errors.New("invalid group: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	ownerID, _ := owner["id"].(string)
+	entryID, _ := entry["id"].(string)
+	if id == "" || ownerID == "" || entryID == "" {
+		return nil, errors.New("invalid group: group, owner and outbox entry ids are required")
+	}
+
+	groups, members, err := r.paths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(data, "id")
+	delete(owner, "id")
+	delete(entry, "id")
+
+	batch := database.NewBatch().
+		Create(groups, id, data).
+		Create(members, ownerID, owner).
+		Create(entity_outbox.OutboxCollection, entryID, entry)
+
+	if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return r.Get(ctx, id)
+}
+
+func (r *group) Get(ctx context.Context, id string) (*entity.Group, error) {
+	return r.base.Get(ctx, entity.GroupCollection, id)
+}
+
+func (r *group) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.Group, string, error) {
+	return r.base.Page(ctx, entity.GroupCollection, filters, opts)
+}
+
+func (r *group) Update(ctx context.Context, id string, data map[string]interface{}) error {
+	return r.base.Update(ctx, entity.GroupCollection, id, data)
+}
+
+// Delete removes the group, its members and its memberships in other groups.
+func (r *group) Delete(ctx context.Context, id string, entry map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	entryID, _ := entry["id"].(string)
+	if id == "" || entryID == "" {
+		return errors.New("invalid group: group and outbox entry ids are required")
+	}
+
+	groups, members, err := r.paths(ctx)
+	if err != nil {
+		return err
+	}
+
+	own, err := r.all(ctx, []database.Conditional{
+		{Field: "groupId", Value: id, Filter: database.FilterEquals},
+	})
+	if err != nil {
+		return err
+	}
+
+	parents, err := r.all(ctx, []database.Conditional{
+		{Field: "type", Value: string(entity.MEMBER_GROUP), Filter: database.FilterEquals},
+		{Field: "memberId", Value: id, Filter: database.FilterEquals},
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(entry, "id")
+
+	batch := database.NewBatch().Delete(groups, id)
+	for _, m := range append(own, parents...) {
+		batch.Delete(members, m.ID)
+	}
+	batch.Create(entity_outbox.OutboxCollection, entryID, entry)
+
+	if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return nil
+}
+
+func (r *group) GetMember(ctx context.Context, id string) (*entity.Member, error) {
+	return r.members.Get(ctx, entity.MemberCollection, id)
+}
+
+func (r *group) ListMembers(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.Member, string, error) {
+	return r.members.Page(ctx, entity.MemberCollection, filters, opts)
+}
+
+func (r *group) AddMember(ctx context.Context, data map[string]interface{}, entry map[string]interface{}) (*entity.Member, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if len(data) == 0 || len(entry) == 0 {
+		return nil, errors.New("invalid group member: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	entryID, _ := entry["id"].(string)
+	if id == "" || entryID == "" {
+		return nil, errors.New("invalid group member: member and outbox entry ids are required")
+	}
+
+	_, members, err := r.paths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(data, "id")
+	delete(entry, "id")
+
+	batch := database.NewBatch().
+		Create(members, id, data).
+		Create(entity_outbox.OutboxCollection, entryID, entry)
+
+	if err := r.members.DB().CommitBatch(ctx, batch); err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, core.ErrConflict("already a member of the group")
+		}
+		return nil, fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return r.GetMember(ctx, id)
+}
+
+func (r *group) RemoveMember(ctx context.Context, id string, entry map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	entryID, _ := entry["id"].(string)
+	if id == "" || entryID == "" {
+		return errors.New("invalid group member: member and outbox entry ids are required")
+	}
+
+	_, members, err := r.paths(ctx)
+	if err != nil {
+		return err
+	}
+
+	delete(entry, "id")
+
+	batch := database.NewBatch().
+		Delete(members, id).
+		Create(entity_outbox.OutboxCollection, entryID, entry)
+
+	if err := r.members.DB().CommitBatch(ctx, batch); err != nil {
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *group) paths(ctx context.Context) (string, string, error) {
+
+	groups, err := r.base.Path(ctx, entity.GroupCollection)
+	if err != nil {
+		return "", "", err
+	}
+
+	members, err := r.members.Path(ctx, entity.MemberCollection)
+	if err != nil {
+		return "", "", err
+	}
+
+	return groups, members, nil
+}
+
+// all reads every page of the members that match the filters.
+func (r *group) all(ctx context.Context, filters []database.Conditional) ([]entity.Member, error) {
+
+	result := []entity.Member{}
+	opts := database.QueryOptions{PageSize: database.MaxPageSize}
+	for {
+		page, next, err := r.ListMembers(ctx, filters, opts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+
+		if next == "" {
+			return result, nil
+		}
+		opts.PageToken = next
+	}
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/invitation"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type invitation struct {
+	base *repo.Repository[entity.Invitation]
+}
+
+func InitializeInvitationRepository(db database.FirebaseDBInterface) (entity.InvitationRepository, error) {
+
+	base, err := repo.NewGlobalRepository[entity.Invitation](db, "invitation")
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation{
+		base: base,
+	}, nil
+}
+
+func (r *invitation) Create(ctx context.Context, data map[string]interface{}) (*entity.Invitation, error) {
+
+	if len(data) == 0 {
+		return nil, errors.New("invalid invitation: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		return nil, errors.New("invalid invitation: id is required")
+	}
+	delete(data, "id")
+
+	return r.base.CreateWithID(ctx, entity.InvitationCollection, id, data)
+}
+
+func (r *invitation) Get(ctx context.Context, id string) (*entity.Invitation, error) {
+	return r.base.Get(ctx, entity.InvitationCollection, id)
+}
+
+func (r *invitation) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.Invitation, string, error) {
+	return r.base.Page(ctx, entity.InvitationCollection, filters, opts)
+}
+
+func (r *invitation) Update(ctx context.Context, id string, data map[string]interface{}) error {
+	return r.base.Update(ctx, entity.InvitationCollection, id, data)
+}
+
+func (r *invitation) Accept(ctx context.Context, id string, patch map[string]interface{}, member map[string]interface{}, entry map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	memberID, _ := member["id"].(string)
+	entryID, _ := entry["id"].(string)
+	if id == "" || memberID == "" || entryID == "" || len(patch) == 0 {
+		return errors.New("invalid invitation: invitation, member and outbox entry ids are required")
+	}
+
+	delete(member, "id")
+	delete(entry, "id")
+
+	err := r.base.DB().RunTransaction(ctx, func(tx database.Tx) error {
+
+		response, err := tx.Get(entity.InvitationCollection, id)
+		if err != nil {
+			return err
+		}
+
+		var current entity.Invitation
+		if err := json.Unmarshal(response, &current); err != nil {
+			return fmt.Errorf("failed to unmarshal invitation: %w", err)
+		}
+
+		if current.Status != entity.STATUS_PENDING {
+			return core.ErrConflict("invitation " + id + " was already " + string(current.Status))
+		}
+
+		if _, err := tx.Get(entity_tenant.MemberCollection, memberID); err == nil {
+			return core.ErrConflict("user is already a member of the tenant")
+		} else if !errors.Is(err, database.ErrNotFound) {
+			return err
+		}
+
+		if err := tx.Update(entity.InvitationCollection, id, patch); err != nil {
+			return err
+		}
+
+		if err := tx.Create(entity_tenant.MemberCollection, memberID, member); err != nil {
+			return err
+		}
+
+		return tx.Create(entity_outbox.OutboxCollection, entryID, entry)
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return core.ErrNotFound("invitation " + id)
+		}
+		if core.IsConflict(err) {
+			return err
+		}
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return nil
+}
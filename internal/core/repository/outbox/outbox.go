@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type outbox struct {
+	base       *repo.Repository[entity.OutboxEntry]
+	collection string
+}
+
+func InitializeOutboxRepository(db database.FirebaseDBInterface) (entity.OutboxRepository, error) {
+
+	base, err := repo.NewGlobalRepository[entity.OutboxEntry](db, "outbox entry")
+	if err != nil {
+		return nil, err
+	}
+
+	return &outbox{
+		base:       base,
+		collection: entity.OutboxCollection,
+	}, nil
+}
+
+func (r *outbox) Create(ctx context.Context, data map[string]interface{}) (*entity.OutboxEntry, error) {
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		id = utils.GenerateID()
+	}
+
+	return r.base.CreateWithID(ctx, r.collection, id, data)
+}
+
+// ListPending returns the pending entries, oldest first, including the ones waiting for a retry.
+// The relay needs them to keep the entries of a source in order.
+func (r *outbox) ListPending(ctx context.Context, limit int) ([]entity.OutboxEntry, error) {
+
+	entries, err := r.listByStatus(ctx, entity.OUTBOX_PENDING, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+// ListPendingBySource returns the pending entries of the source, oldest first.
+func (r *outbox) ListPendingBySource(ctx context.Context, source string) ([]entity.OutboxEntry, error) {
+
+	return r.listByStatus(ctx, entity.OUTBOX_PENDING, []database.Conditional{
+		{
+			Field:  "source",
+			Value:  source,
+			Filter: database.FilterEquals,
+		},
+	})
+}
+
+func (r *outbox) ListFailed(ctx context.Context) ([]entity.OutboxEntry, error) {
+	return r.listByStatus(ctx, entity.OUTBOX_FAILED, nil)
+}
+
+func (r *outbox) listByStatus(ctx context.Context, status entity.OutboxStatus, filters []database.Conditional) ([]entity.OutboxEntry, error) {
+
+	filters = append(filters, database.Conditional{
+		Field:  "status",
+		Value:  string(status),
+		Filter: database.FilterEquals,
+	})
+
+	entries, err := r.base.List(ctx, r.collection, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+
+	return entries, nil
+}
+
+func (r *outbox) Update(ctx context.Context, id string, data map[string]interface{}) error {
+	return r.base.Update(ctx, r.collection, id, data)
+}
@@ -0,0 +1,379 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+// Repository is a typed wrapper around FirebaseDBInterface. It converts the JSON
+// returned by the database into T, resolves the tenant scoped collection path and
+// translates database errors into the errors of internal/core/entity/types.
+//
+// Methods receive the collection relative to the tenant (e.g. "vaults" or
+// "vaults/<id>/secrets"), so one Repository serves nested collections too.
+type Repository[T any] struct {
+	db     database.FirebaseDBInterface
+	name   string
+	tenant bool
+}
+
+// NewTenantRepository creates a Repository whose collections live under tenant/<tenantId>.
+// name is the entity name used in error messages, e.g. "vault".
+func NewTenantRepository[T any](db database.FirebaseDBInterface, name string) (*Repository[T], error) {
+	return newRepository[T](db, name, true)
+}
+
+// NewGlobalRepository creates a Repository whose collections are not scoped by tenant.
+func NewGlobalRepository[T any](db database.FirebaseDBInterface, name string) (*Repository[T], error) {
+	return newRepository[T](db, name, false)
+}
+
+func newRepository[T any](db database.FirebaseDBInterface, name string, tenant bool) (*Repository[T], error) {
+
+	if db == nil {
+		return nil, errors.New("database connection is nil")
+	}
+
+	if !db.IsConnected() {
+		return nil, errors.New("database connection is not initialized")
+	}
+
+	return &Repository[T]{
+		db:     db,
+		name:   name,
+		tenant: tenant,
+	}, nil
+}
+
+// DB returns the underlying database, for operations the Repository does not cover.
+func (r *Repository[T]) DB() database.FirebaseDBInterface {
+	return r.db
+}
+
+// Path returns the full path of the collection.
+func (r *Repository[T]) Path(ctx context.Context, collection string) (string, error) {
+
+	if !r.tenant {
+		if collection == "" {
+			return "", errors.New("collection is empty")
+		}
+		return collection, nil
+	}
+
+	path, err := SetCollection(ctx, collection)
+	if err != nil {
+		return "", err
+	}
+
+	return *path, nil
+}
+
+// Create adds a document with an ID generated by the database.
+func (r *Repository[T]) Create(ctx context.Context, collection string, data map[string]interface{}) (*T, error) {
+
+	if err := r.check(ctx, data); err != nil {
+		return nil, err
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.db.Create(ctx, data, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", r.name, err)
+	}
+
+	return r.Decode(response)
+}
+
+// CreateWithID adds a document with the given ID. An existing document returns a conflict.
+func (r *Repository[T]) CreateWithID(ctx context.Context, collection, id string, data map[string]interface{}) (*T, error) {
+
+	if err := r.check(ctx, data); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf("invalid %s: id is required", r.name)
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(data, "id")
+
+	response, err := r.db.CreateWithID(ctx, id, data, path)
+	if err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, core.ErrConflict(r.name + " " + id + " already exists")
+		}
+		return nil, fmt.Errorf("failed to create %s: %w", r.name, err)
+	}
+
+	return r.Decode(response)
+}
+
+// Get returns the document with the given ID or a not found error.
+func (r *Repository[T]) Get(ctx context.Context, collection, id string) (*T, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf("invalid %s: id is required", r.name)
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.db.GetByID(ctx, id, path)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, core.ErrNotFound(r.name + " " + id)
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", r.name, err)
+	}
+
+	return r.Decode(response)
+}
+
+// List returns every document that matches the filters, or the whole collection without filters.
+func (r *Repository[T]) List(ctx context.Context, collection string, filters []database.Conditional) ([]T, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []byte
+	if len(filters) == 0 {
+		response, err = r.db.Get(ctx, path)
+	} else {
+		response, err = r.db.GetByConditional(ctx, filters, path)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidQuery) {
+			return nil, core.ErrInvalidRequest(err.Error())
+		}
+		return nil, fmt.Errorf("failed to list %s: %w", r.name, err)
+	}
+
+	return r.DecodeList(response)
+}
+
+// Page returns a page of the documents that match the filters and the token of the next page.
+func (r *Repository[T]) Page(ctx context.Context, collection string, filters []database.Conditional, opts database.QueryOptions) ([]T, string, error) {
+
+	if ctx.Err() != nil {
+		return nil, "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := r.db.GetPage(ctx, path, filters, opts)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidQuery) {
+			return nil, "", core.ErrInvalidRequest(err.Error())
+		}
+		return nil, "", fmt.Errorf("failed to list %s: %w", r.name, err)
+	}
+
+	items, err := r.DecodeList(page.Data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return items, page.NextPageToken, nil
+}
+
+// Update merges data into the document with the given ID.
+func (r *Repository[T]) Update(ctx context.Context, collection, id string, data map[string]interface{}) error {
+
+	if id == "" {
+		return fmt.Errorf("invalid %s: id is required", r.name)
+	}
+
+	if err := r.check(ctx, data); err != nil {
+		return err
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	delete(data, "id")
+
+	if err := r.db.Update(ctx, id, data, path); err != nil {
+		return fmt.Errorf("failed to update %s: %w", r.name, err)
+	}
+
+	return nil
+}
+
+// UpdateIfMatch merges data into the document only if it is still at the given revision.
+// A document changed in the meantime returns a conflict.
+func (r *Repository[T]) UpdateIfMatch(ctx context.Context, collection, id string, revision int64, data map[string]interface{}) error {
+
+	if id == "" {
+		return fmt.Errorf("invalid %s: id is required", r.name)
+	}
+
+	if err := r.check(ctx, data); err != nil {
+		return err
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	delete(data, "id")
+
+	if err := r.db.UpdateIfMatch(ctx, id, revision, data, path); err != nil {
+		switch {
+		case errors.Is(err, database.ErrRevisionMismatch):
+			return core.ErrConflict(fmt.Sprintf("%s %s was modified since revision %d", r.name, id, revision))
+		case errors.Is(err, database.ErrNotFound):
+			return core.ErrNotFound(r.name + " " + id)
+		}
+		return fmt.Errorf("failed to update %s: %w", r.name, err)
+	}
+
+	return nil
+}
+
+// Delete removes the document with the given ID. Deleting a missing document is not an error.
+func (r *Repository[T]) Delete(ctx context.Context, collection, id string) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return fmt.Errorf("invalid %s: id is required", r.name)
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	if err := r.db.Delete(ctx, id, path); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", r.name, err)
+	}
+
+	return nil
+}
+
+// DeleteAll removes every document of the collection, in batches of database.MaxBatchWrites,
+// and returns how many were removed. Subcollections of the documents are not removed.
+func (r *Repository[T]) DeleteAll(ctx context.Context, collection string) (int, error) {
+
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	path, err := r.Path(ctx, collection)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := r.db.Get(ctx, path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", r.name, err)
+	}
+
+	var docs []struct {
+		ID string `json:"id"`
+	}
+	if len(response) > 0 {
+		if err := json.Unmarshal(response, &docs); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal %s data: %w", r.name, err)
+		}
+	}
+
+	deleted := 0
+	for start := 0; start < len(docs); start += database.MaxBatchWrites {
+		end := min(start+database.MaxBatchWrites, len(docs))
+
+		batch := database.NewBatch()
+		for _, d := range docs[start:end] {
+			batch.Delete(path, d.ID)
+		}
+
+		if err := r.db.CommitBatch(ctx, batch); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", r.name, err)
+		}
+		deleted += batch.Len()
+	}
+
+	return deleted, nil
+}
+
+// Decode converts a JSON document into T.
+func (r *Repository[T]) Decode(data []byte) (*T, error) {
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("error to convert %s data: empty response", r.name)
+	}
+
+	var item T
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s data: %w", r.name, err)
+	}
+
+	return &item, nil
+}
+
+// DecodeList converts a JSON array of documents into []T. It never returns a nil slice.
+func (r *Repository[T]) DecodeList(data []byte) ([]T, error) {
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("error to convert %s data: empty response", r.name)
+	}
+
+	items := []T{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s data: %w", r.name, err)
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+
+	return items, nil
+}
+
+func (r *Repository[T]) check(ctx context.Context, data map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("invalid %s: no data provided", r.name)
+	}
+
+	return nil
+}
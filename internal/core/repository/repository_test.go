@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+type item struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestRepository(t *testing.T) {
+	db := database.NewMemoryDB()
+	ctx := context.WithValue(context.Background(), "TenantID", "t1")
+
+	repo, err := NewTenantRepository[item](db, "item")
+	require.NoError(t, err)
+
+	created, err := repo.CreateWithID(ctx, "items", "a", map[string]interface{}{"name": "first"})
+	require.NoError(t, err)
+	assert.Equal(t, item{ID: "a", Name: "first"}, *created)
+	_, err = db.GetByID(ctx, "a", "tenant/t1/items")
+	assert.NoError(t, err, "collections are scoped by tenant")
+
+	_, err = repo.CreateWithID(ctx, "items", "a", map[string]interface{}{"name": "again"})
+	assert.True(t, core.IsConflict(err))
+
+	got, err := repo.Get(ctx, "items", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "first", got.Name)
+
+	_, err = repo.Get(ctx, "items", "missing")
+	assert.True(t, core.IsNotFound(err))
+
+	require.NoError(t, repo.UpdateIfMatch(ctx, "items", "a", 0, map[string]interface{}{"name": "second"}))
+	err = repo.UpdateIfMatch(ctx, "items", "a", 0, map[string]interface{}{"name": "stale"})
+	assert.True(t, core.IsConflict(err))
+	err = repo.UpdateIfMatch(ctx, "items", "missing", 0, map[string]interface{}{"name": "x"})
+	assert.True(t, core.IsNotFound(err))
+
+	items, err := repo.List(ctx, "empty", nil)
+	require.NoError(t, err)
+	assert.NotNil(t, items)
+	assert.Empty(t, items)
+
+	global, err := NewGlobalRepository[item](db, "item")
+	require.NoError(t, err)
+	path, err := global.Path(ctx, "items")
+	require.NoError(t, err)
+	assert.Equal(t, "items", path)
+
+	_, err = NewGlobalRepository[item](nil, "item")
+	assert.Error(t, err)
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type secret struct {
+	base              *repo.Repository[entity.Secret]
+	versions          *repo.Repository[entity.SecretVersion]
+	collection        string
+	versionCollection string
+}
+
+func InitializeSecretRepository(db database.FirebaseDBInterface) (entity.SecretRepository, error) {
+
+	base, err := repo.NewTenantRepository[entity.Secret](db, "secret")
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := repo.NewTenantRepository[entity.SecretVersion](db, "secret version")
+	if err != nil {
+		return nil, err
+	}
+
+	return &secret{
+		base:              base,
+		versions:          versions,
+		collection:        "secrets",
+		versionCollection: "versions",
+	}, nil
+}
+
+func (r *secret) Create(ctx context.Context, vaultID string, data map[string]interface{}) (*entity.Secret, error) {
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.base.Create(ctx, collection, data)
+}
+
+// CreateWithVersion creates the secret with the given ID and its first version record in one
+// transaction, so a secret never exists without the version it was created with.
+func (r *secret) CreateWithVersion(ctx context.Context, vaultID, id string, data map[string]interface{}, record map[string]interface{}) (*entity.Secret, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return nil, errors.New("invalid secret: id is required")
+	}
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	versionCollection, err := r.setVersionCollection(vaultID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := r.base.Path(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := r.versions.Path(ctx, versionCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(data, "id")
+	delete(record, "id")
+
+	err = r.base.DB().RunTransaction(ctx, func(tx database.Tx) error {
+
+		if err := tx.Create(secrets, id, data); err != nil {
+			return err
+		}
+
+		return tx.Create(versions, "1", record)
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, core.ErrConflict("secret " + id + " already exists")
+		}
+		return nil, fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	return r.base.Get(ctx, collection, id)
+}
+
+func (r *secret) Get(ctx context.Context, vaultID, id string) (*entity.Secret, error) {
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.base.Get(ctx, collection, id)
+}
+
+func (r *secret) List(ctx context.Context, vaultID string, filters []database.Conditional, opts database.QueryOptions) ([]entity.Secret, string, error) {
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return r.base.Page(ctx, collection, filters, opts)
+}
+
+func (r *secret) Update(ctx context.Context, vaultID, id string, data map[string]interface{}) error {
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return err
+	}
+
+	return r.base.Update(ctx, collection, id, data)
+}
+
+// UpdateWithVersion updates the secret and creates its version record in one transaction, only
+// if the secret is still at the given revision. A concurrent update returns a conflict and
+// writes nothing, so the head and the history never diverge.
+func (r *secret) UpdateWithVersion(ctx context.Context, vaultID, id string, revision int64, data map[string]interface{}, version int, record map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" || version <= 0 {
+		return errors.New("invalid secret: id and a version greater than zero are required")
+	}
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return err
+	}
+
+	versionCollection, err := r.setVersionCollection(vaultID, id)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := r.base.Path(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	versions, err := r.versions.Path(ctx, versionCollection)
+	if err != nil {
+		return err
+	}
+
+	delete(data, "id")
+	delete(record, "id")
+
+	err = r.base.DB().RunTransaction(ctx, func(tx database.Tx) error {
+
+		response, err := tx.Get(secrets, id)
+		if err != nil {
+			return err
+		}
+
+		var current map[string]interface{}
+		if err := json.Unmarshal(response, &current); err != nil {
+			return fmt.Errorf("failed to unmarshal secret: %w", err)
+		}
+
+		if database.DocumentRevision(current) != revision {
+			return database.ErrRevisionMismatch
+		}
+
+		if err := tx.Update(secrets, id, data); err != nil {
+			return err
+		}
+
+		return tx.Create(versions, strconv.Itoa(version), record)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrRevisionMismatch), errors.Is(err, database.ErrAlreadyExists):
+			return core.ErrConflict(fmt.Sprintf("secret %s was modified since revision %d", id, revision))
+		case errors.Is(err, database.ErrNotFound):
+			return core.ErrNotFound("secret " + id)
+		}
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	return nil
+}
+
+func (r *secret) Purge(ctx context.Context, vaultID, id string) error {
+
+	versions, err := r.setVersionCollection(vaultID, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.versions.DeleteAll(ctx, versions); err != nil {
+		return err
+	}
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return err
+	}
+
+	return r.base.Delete(ctx, collection, id)
+}
+
+func (r *secret) PurgeAll(ctx context.Context, vaultID string) (int, error) {
+
+	collection, err := r.setCollection(vaultID)
+	if err != nil {
+		return 0, err
+	}
+
+	secrets, err := r.base.List(ctx, collection, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, item := range secrets {
+		if err := r.Purge(ctx, vaultID, item.ID); err != nil {
+			return i, err
+		}
+	}
+
+	return len(secrets), nil
+}
+
+// CreateVersion stores an immutable version record using the version number as document ID.
+// Writing the same version twice returns a conflict, which protects against concurrent updates.
+func (r *secret) CreateVersion(ctx context.Context, vaultID, secretID string, version int, data map[string]interface{}) (*entity.SecretVersion, error) {
+
+	if version <= 0 {
+		return nil, errors.New("invalid secret version: version must be greater than zero")
+	}
+
+	collection, err := r.setVersionCollection(vaultID, secretID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.versions.CreateWithID(ctx, collection, strconv.Itoa(version), data)
+}
+
+func (r *secret) GetVersion(ctx context.Context, vaultID, secretID string, version int) (*entity.SecretVersion, error) {
+
+	collection, err := r.setVersionCollection(vaultID, secretID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.versions.Get(ctx, collection, strconv.Itoa(version))
+}
+
+// ListVersions returns the versions of the secret, newest first.
+func (r *secret) ListVersions(ctx context.Context, vaultID, secretID string) ([]entity.SecretVersion, error) {
+
+	collection, err := r.setVersionCollection(vaultID, secretID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := r.versions.List(ctx, collection, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// setCollection returns vaults/<vaultId>/secrets, stored under tenant/<tenantId>
+func (r *secret) setCollection(vaultID string) (string, error) {
+
+	if vaultID == "" {
+		return "", errors.New("invalid secret: vault id is required")
+	}
+
+	return fmt.Sprintf("vaults/%s/%s", vaultID, r.collection), nil
+}
+
+// setVersionCollection returns vaults/<vaultId>/secrets/<secretId>/versions, stored under tenant/<tenantId>
+func (r *secret) setVersionCollection(vaultID, secretID string) (string, error) {
+
+	if vaultID == "" || secretID == "" {
+		return "", errors.New("invalid secret version: vault id and secret id are required")
+	}
+
+	return fmt.Sprintf("vaults/%s/%s/%s/%s", vaultID, r.collection, secretID, r.versionCollection), nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type expiration struct {
+	base       *repo.Repository[entity.Expiration]
+	collection string
+}
+
+func InitializeExpirationRepository(db database.FirebaseDBInterface) (entity.ExpirationRepository, error) {
+
+	base, err := repo.NewTenantRepository[entity.Expiration](db, "share expiration")
+	if err != nil {
+		return nil, err
+	}
+
+	return &expiration{
+		base:       base,
+		collection: entity.ExpirationCollection,
+	}, nil
+}
+
+func (r *expiration) Set(ctx context.Context, data map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		return errors.New("invalid share expiration: id is required")
+	}
+	delete(data, "id")
+
+	path, err := r.base.Path(ctx, r.collection)
+	if err != nil {
+		return err
+	}
+
+	if err := r.base.DB().CommitBatch(ctx, database.NewBatch().Set(path, id, data)); err != nil {
+		return fmt.Errorf("failed to set share expiration: %w", err)
+	}
+
+	return nil
+}
+
+func (r *expiration) Get(ctx context.Context, id string) (*entity.Expiration, error) {
+	return r.base.Get(ctx, r.collection, id)
+}
+
+func (r *expiration) ListByVault(ctx context.Context, vaultID string) ([]entity.Expiration, error) {
+
+	filters := []database.Conditional{
+		{
+			Field:  "vaultId",
+			Value:  vaultID,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	return r.base.List(ctx, r.collection, filters)
+}
+
+func (r *expiration) ListExpiring(ctx context.Context, before time.Time) ([]entity.Expiration, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "expiresAt",
+			Value:  before.UTC().Truncate(time.Second).Format(time.RFC3339),
+			Filter: database.FilterLessThanOrEqual,
+		},
+	}
+
+	response, err := r.base.DB().GetCollectionGroup(ctx, r.collection, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring shares: %w", err)
+	}
+
+	return r.base.DecodeList(response)
+}
+
+func (r *expiration) Delete(ctx context.Context, id string) error {
+	return r.base.Delete(ctx, r.collection, id)
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type tenant struct {
+	base         *repo.Repository[entity.Tenant]
+	members      *repo.Repository[entity.Member]
+	groupMembers *repo.Repository[entity_group.Member]
+}
+
+func InitializeTenantRepository(db database.FirebaseDBInterface) (entity.TenantRepository, error) {
+
+	base, err := repo.NewGlobalRepository[entity.Tenant](db, "tenant")
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := repo.NewGlobalRepository[entity.Member](db, "tenant member")
+	if err != nil {
+		return nil, err
+	}
+
+	groupMembers, err := repo.NewTenantRepository[entity_group.Member](db, "group member")
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant{
+		base:         base,
+		members:      members,
+		groupMembers: groupMembers,
+	}, nil
+}
+
+func (r *tenant) Create(ctx context.Context, data map[string]interface{}, owner map[string]interface{}, entry map[string]interface{}) (*entity.Tenant, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if len(data) == 0 || len(owner) == 0 || len(entry) == 0 {
+		return nil, errors.New("invalid tenant: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	ownerID, _ := owner["id"].(string)
+	entryID, _ := entry["id"].(string)
+	if id == "" || ownerID == "" || entryID == "" {
+		return nil, errors.New("invalid tenant: tenant, owner and outbox entry ids are required")
+	}
+
+	delete(data, "id")
+	delete(owner, "id")
+	delete(entry, "id")
+
+	batch := database.NewBatch().
+		Create(entity.TenantCollection, id, data).
+		Create(entity.MemberCollection, ownerID, owner).
+		Create(entity_outbox.OutboxCollection, entryID, entry)
+
+	if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, core.ErrConflict("tenant " + id + " already exists")
+		}
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return r.Get(ctx, id)
+}
+
+func (r *tenant) Get(ctx context.Context, id string) (*entity.Tenant, error) {
+	return r.base.Get(ctx, entity.TenantCollection, id)
+}
+
+func (r *tenant) Update(ctx context.Context, id string, data map[string]interface{}) error {
+	return r.base.Update(ctx, entity.TenantCollection, id, data)
+}
+
+func (r *tenant) GetMember(ctx context.Context, tenantID, userID string) (*entity.Member, error) {
+	return r.members.Get(ctx, entity.MemberCollection, entity.MemberID(tenantID, userID))
+}
+
+// ListMembers returns a page of memberships. The collection is global, so the filters
+// always select a tenant or a user.
+func (r *tenant) ListMembers(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.Member, string, error) {
+	return r.members.Page(ctx, entity.MemberCollection, filters, opts)
+}
+
+func (r *tenant) AddMember(ctx context.Context, data map[string]interface{}, entry map[string]interface{}) (*entity.Member, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if len(data) == 0 || len(entry) == 0 {
+		return nil, errors.New("invalid tenant member: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	entryID, _ := entry["id"].(string)
+	if id == "" || entryID == "" {
+		return nil, errors.New("invalid tenant member: member and outbox entry ids are required")
+	}
+
+	delete(data, "id")
+	delete(entry, "id")
+
+	batch := database.NewBatch().
+		Create(entity.MemberCollection, id, data).
+		Create(entity_outbox.OutboxCollection, entryID, entry)
+
+	if err := r.members.DB().CommitBatch(ctx, batch); err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, core.ErrConflict("user is already a member of the tenant")
+		}
+		return nil, fmt.Errorf("failed to add tenant member: %w", err)
+	}
+
+	return r.members.Get(ctx, entity.MemberCollection, id)
+}
+
+func (r *tenant) UpdateMember(ctx context.Context, id string, patch map[string]interface{}, entries ...map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" || len(patch) == 0 {
+		return errors.New("invalid tenant member: no data provided")
+	}
+
+	batch := database.NewBatch().Update(entity.MemberCollection, id, patch)
+	if err := addEntries(batch, entries); err != nil {
+		return err
+	}
+
+	if err := r.members.DB().CommitBatch(ctx, batch); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return core.ErrNotFound("tenant member " + id)
+		}
+		return fmt.Errorf("failed to update tenant member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember deletes the member and its memberships in the groups of the tenant of the
+// context, and creates the outbox entry of their tuples in one batch.
+func (r *tenant) RemoveMember(ctx context.Context, id string, groupMembers []string, entry map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return errors.New("invalid tenant member: id is required")
+	}
+
+	batch := database.NewBatch().Delete(entity.MemberCollection, id)
+	if len(groupMembers) > 0 {
+		path, err := r.groupMembers.Path(ctx, entity_group.MemberCollection)
+		if err != nil {
+			return err
+		}
+		for _, groupMember := range groupMembers {
+			batch.Delete(path, groupMember)
+		}
+	}
+
+	if err := addEntries(batch, []map[string]interface{}{entry}); err != nil {
+		return err
+	}
+
+	if err := r.members.DB().CommitBatch(ctx, batch); err != nil {
+		return fmt.Errorf("failed to remove tenant member: %w", err)
+	}
+
+	return nil
+}
+
+func addEntries(batch *database.Batch, entries []map[string]interface{}) error {
+
+	for _, entry := range entries {
+		entryID, _ := entry["id"].(string)
+		if entryID == "" {
+			return errors.New("invalid outbox entry: id is required")
+		}
+		delete(entry, "id")
+		batch.Create(entity_outbox.OutboxCollection, entryID, entry)
+	}
+
+	return nil
+}
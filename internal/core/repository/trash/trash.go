@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type trash struct {
+	base       *repo.Repository[entity.TrashItem]
+	collection string
+}
+
+func InitializeTrashRepository(db database.FirebaseDBInterface) (entity.TrashRepository, error) {
+
+	base, err := repo.NewTenantRepository[entity.TrashItem](db, "trash item")
+	if err != nil {
+		return nil, err
+	}
+
+	return &trash{
+		base:       base,
+		collection: entity.TrashCollection,
+	}, nil
+}
+
+func (r *trash) MoveToTrash(ctx context.Context, item map[string]interface{}, patch map[string]interface{}) (*entity.TrashItem, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if len(item) == 0 || len(patch) == 0 {
+		return nil, errors.New("invalid trash item: no data provided")
+	}
+
+	id, _ := item["id"].(string)
+	itemID, _ := item["itemId"].(string)
+	itemCollection, _ := item["collection"].(string)
+	if id == "" || itemID == "" || itemCollection == "" {
+		return nil, errors.New("invalid trash item: id, item id and collection are required")
+	}
+
+	collection, err := r.base.Path(ctx, r.collection)
+	if err != nil {
+		return nil, err
+	}
+
+	itemPath, err := r.base.Path(ctx, itemCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(item, "id")
+
+	batch := database.NewBatch().
+		Update(itemPath, itemID, patch).
+		Create(collection, id, item)
+
+	if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, core.ErrConflict(itemID + " is already in the trash")
+		}
+		return nil, fmt.Errorf("failed to move item to the trash: %w", err)
+	}
+
+	item["id"] = id
+	response, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trash item data: %w", err)
+	}
+
+	return r.base.Decode(response)
+}
+
+func (r *trash) Restore(ctx context.Context, item *entity.TrashItem, patch map[string]interface{}) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if item == nil || item.ID == "" || len(patch) == 0 {
+		return errors.New("invalid trash item: no data provided")
+	}
+
+	collection, err := r.base.Path(ctx, r.collection)
+	if err != nil {
+		return err
+	}
+
+	itemPath, err := r.base.Path(ctx, item.Collection)
+	if err != nil {
+		return err
+	}
+
+	batch := database.NewBatch().
+		Update(itemPath, item.ItemID, patch).
+		Delete(collection, item.ID)
+
+	if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+		return fmt.Errorf("failed to restore item from the trash: %w", err)
+	}
+
+	return nil
+}
+
+func (r *trash) Get(ctx context.Context, id string) (*entity.TrashItem, error) {
+	return r.base.Get(ctx, r.collection, id)
+}
+
+func (r *trash) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.TrashItem, string, error) {
+	return r.base.Page(ctx, r.collection, filters, opts)
+}
+
+// ListExpired queries the trash of every tenant. On Firestore it needs a collection group
+// index on deletedAt.
+func (r *trash) ListExpired(ctx context.Context, before time.Time) ([]entity.TrashItem, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "deletedAt",
+			Value:  before.UTC().Truncate(time.Second).Format(time.RFC3339),
+			Filter: database.FilterLessThanOrEqual,
+		},
+	}
+
+	response, err := r.base.DB().GetCollectionGroup(ctx, r.collection, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired trash items: %w", err)
+	}
+
+	return r.base.DecodeList(response)
+}
+
+func (r *trash) Delete(ctx context.Context, id string) error {
+	return r.base.Delete(ctx, r.collection, id)
+}
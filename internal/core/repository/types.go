@@ -6,6 +6,9 @@ import (
 	"fmt"
 )
 
+// SetCollection returns the tenant scoped path for the collection.
+// When the context carries a TenantID (set by handlers behind middleware.ValidateToken)
+// the path is tenant/<tenantId>/<collection>; otherwise the UserID is used.
 func SetCollection(ctx context.Context, collection string) (*string, error) {
 
 	if collection == "" {
@@ -14,6 +17,11 @@ func SetCollection(ctx context.Context, collection string) (*string, error) {
 
 	var col string
 
+	if tenantID, ok := ctx.Value("TenantID").(string); ok && tenantID != "" {
+		col = fmt.Sprintf("tenant/%s/%s", tenantID, collection)
+		return &col, nil
+	}
+
 	if ctx.Value("UserID") == nil {
 		return nil, errors.New("user id is nil")
 	} else {
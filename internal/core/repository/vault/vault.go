@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo "github.com/synera-br/lockari-backend-app/internal/core/repository"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type vault struct {
+	base       *repo.Repository[entity.Vault]
+	collection string
+}
+
+func InitializeVaultRepository(db database.FirebaseDBInterface) (entity.VaultRepository, error) {
+
+	base, err := repo.NewTenantRepository[entity.Vault](db, "vault")
+	if err != nil {
+		return nil, err
+	}
+
+	return &vault{
+		base:       base,
+		collection: entity.VaultCollection,
+	}, nil
+}
+
+func (r *vault) Create(ctx context.Context, data map[string]interface{}) (*entity.Vault, error) {
+	return r.base.Create(ctx, r.collection, data)
+}
+
+func (r *vault) CreateWithOutbox(ctx context.Context, data map[string]interface{}, entry map[string]interface{}) (*entity.Vault, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if len(data) == 0 || len(entry) == 0 {
+		return nil, errors.New("invalid vault: no data provided")
+	}
+
+	id, _ := data["id"].(string)
+	entryID, _ := entry["id"].(string)
+	if id == "" || entryID == "" {
+		return nil, errors.New("invalid vault: vault and outbox entry ids are required")
+	}
+
+	collection, err := r.base.Path(ctx, r.collection)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(data, "id")
+	delete(entry, "id")
+
+	batch := database.NewBatch().
+		Create(collection, id, data).
+		Create(entity_outbox.OutboxCollection, entryID, entry)
+
+	if err := r.base.DB().CommitBatch(ctx, batch); err != nil {
+		if errors.Is(err, database.ErrAlreadyExists) {
+			return nil, core.ErrConflict("vault " + id + " already exists")
+		}
+		return nil, fmt.Errorf("failed to create vault: %w", err)
+	}
+
+	data["id"] = id
+	response, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vault data: %w", err)
+	}
+
+	return r.base.Decode(response)
+}
+
+func (r *vault) Get(ctx context.Context, id string) (*entity.Vault, error) {
+	return r.base.Get(ctx, r.collection, id)
+}
+
+func (r *vault) List(ctx context.Context, filters []database.Conditional, opts database.QueryOptions) ([]entity.Vault, string, error) {
+	return r.base.Page(ctx, r.collection, filters, opts)
+}
+
+func (r *vault) Update(ctx context.Context, id string, data map[string]interface{}) error {
+	return r.base.Update(ctx, r.collection, id, data)
+}
+
+func (r *vault) UpdateIfMatch(ctx context.Context, id string, revision int64, data map[string]interface{}) error {
+	return r.base.UpdateIfMatch(ctx, r.collection, id, revision, data)
+}
+
+func (r *vault) Purge(ctx context.Context, id string) error {
+	return r.base.Delete(ctx, r.collection, id)
+}
+
+func (r *vault) ListAll(ctx context.Context) ([]entity.Vault, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	response, err := r.base.DB().GetCollectionGroup(ctx, r.collection, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vaults of all tenants: %w", err)
+	}
+
+	return r.base.DecodeList(response)
+}
@@ -0,0 +1,624 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/accessrequest"
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_secret "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	entity_share "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/mailer"
+	"github.com/synera-br/lockari-backend-app/pkg/tokengen"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type accessRequest struct {
+	repo    entity.AccessRequestRepository
+	vaults  entity_vault.VaultRepository
+	secrets entity_secret.SecretRepository
+	auth    authenticator.Authenticator
+	authz   authorization.Authorizer
+	tokens  tokengen.TokenGenerator
+	sender  mailer.Sender
+	outbox  entity_outbox.OutboxService
+	audit   entity_audit.AuditSystemEventService
+	link    string
+	now     func() time.Time
+}
+
+// InitializeAccessRequestService creates the access request service. The emails sent to the
+// admins of the vault link to link with a token signed for each of them in the token query
+// parameter, e.g. https://app.lockari.io/access-requests/decide.
+func InitializeAccessRequestService(repo entity.AccessRequestRepository, vaults entity_vault.VaultRepository, secrets entity_secret.SecretRepository, auth authenticator.Authenticator, authz authorization.Authorizer, tokens tokengen.TokenGenerator, sender mailer.Sender, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, link string) (entity.AccessRequestService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("AccessRequestRepository")
+	}
+
+	if vaults == nil {
+		return nil, core.ErrRepositoryNotFound("VaultRepository")
+	}
+
+	if secrets == nil {
+		return nil, core.ErrRepositoryNotFound("SecretRepository")
+	}
+
+	if auth == nil {
+		return nil, core.ErrServiceNotFound("Authenticator")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if tokens == nil {
+		return nil, core.ErrServiceNotFound("TokenGenerator")
+	}
+
+	if sender == nil {
+		return nil, core.ErrServiceNotFound("Sender")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	if _, err := url.ParseRequestURI(link); err != nil {
+		return nil, fmt.Errorf("invalid access request link %q: %w", link, err)
+	}
+
+	return &accessRequest{
+		repo:    repo,
+		vaults:  vaults,
+		secrets: secrets,
+		auth:    auth,
+		authz:   authz,
+		tokens:  tokens,
+		sender:  sender,
+		outbox:  outbox,
+		audit:   audit,
+		link:    link,
+		now:     func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+func (s *accessRequest) Create(ctx context.Context, data *entity.AccessRequest) (*entity.AccessRequest, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("access request is required")
+	}
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := s.vault(ctx, tenantID, data.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	secretName := ""
+	if data.SecretID != "" {
+		secret, err := s.secrets.Get(ctx, vault.ID, data.SecretID)
+		if err != nil {
+			if core.IsNotFound(err) {
+				return nil, core.ErrInvalidRequest("secret " + data.SecretID + " not found in vault " + vault.ID)
+			}
+			return nil, err
+		}
+		if secret.IsDeleted() {
+			return nil, core.ErrInvalidRequest("secret " + data.SecretID + " not found in vault " + vault.ID)
+		}
+		secretName = secret.Name
+	}
+
+	now := s.now()
+	request := entity.NewAccessRequest(utils.GenerateID(), *data, tenantID, vault.Name, secretName, userID, now)
+	if err := request.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if err := s.ensureMember(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureNoRole(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureNotRequested(ctx, request, now); err != nil {
+		return nil, err
+	}
+
+	payload, err := utils.StructToMap(request)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert access request data to map")
+	}
+
+	result, err := s.repo.Create(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, request)
+
+	s.record(ctx, entity_audit.ACCESS_REQUESTED, userID, request, "request",
+		fmt.Sprintf("%s of the whole vault requested for %d minutes%s: %s", entity.Role, request.Duration, s.scope(request), request.Justification))
+
+	return result, nil
+}
+
+func (s *accessRequest) Get(ctx context.Context, id string) (*entity.AccessRequest, error) {
+
+	userID, _, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.RequesterID != userID {
+		if err := s.ensureApprover(ctx, userID, request); err != nil {
+			return nil, err
+		}
+	}
+
+	result := request.Effective(s.now())
+	return &result, nil
+}
+
+func (s *accessRequest) List(ctx context.Context, vaultID string, opts database.QueryOptions) ([]entity.AccessRequest, string, error) {
+
+	if err := opts.Validate(entity.AccessRequestSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	userID, _, err := s.identity(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filter := database.Conditional{Field: "requesterId", Value: userID, Filter: database.FilterEquals}
+	if vaultID != "" {
+		if err := s.ensureApprover(ctx, userID, &entity.AccessRequest{VaultID: vaultID}); err != nil {
+			return nil, "", err
+		}
+		filter = database.Conditional{Field: "vaultId", Value: vaultID, Filter: database.FilterEquals}
+	}
+
+	result, next, err := s.repo.List(ctx, []database.Conditional{filter}, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now()
+	for i := range result {
+		result[i] = result[i].Effective(now)
+	}
+
+	return result, next, nil
+}
+
+func (s *accessRequest) Approve(ctx context.Context, id string, comment string) (*entity.AccessRequest, error) {
+
+	userID, request, err := s.decide(ctx, id, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.approve(ctx, userID, request, comment)
+}
+
+func (s *accessRequest) Deny(ctx context.Context, id string, comment string) (*entity.AccessRequest, error) {
+
+	userID, request, err := s.decide(ctx, id, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.deny(ctx, userID, request, comment)
+}
+
+func (s *accessRequest) Decide(ctx context.Context, token string, decision entity.Decision, comment string) (*entity.AccessRequest, error) {
+
+	if decision != entity.DECISION_APPROVE && decision != entity.DECISION_DENY {
+		return nil, core.ErrInvalidRequest("decision must be approve or deny")
+	}
+
+	if token == "" {
+		return nil, core.ErrInvalidRequest("access request token is required")
+	}
+
+	claims, err := s.tokens.Validate(token)
+	if err != nil {
+		return nil, core.ErrInvalidRequest("invalid or expired access request token")
+	}
+
+	id, _ := claims.Metadata["accessRequestId"].(string)
+	if id == "" || !slices.Contains(claims.Scope, entity.TokenScope) {
+		return nil, core.ErrInvalidRequest("invalid access request token")
+	}
+
+	userID, request, err := s.decide(ctx, id, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	// A forwarded link is of no use to anybody else.
+	if claims.UserID != userID || claims.TenantID != request.TenantID {
+		return nil, core.ErrForbidden("the access request link was sent to another user")
+	}
+
+	if decision == entity.DECISION_DENY {
+		return s.deny(ctx, userID, request, comment)
+	}
+
+	return s.approve(ctx, userID, request, comment)
+}
+
+// approve stores the decision with the expiration of the reader role and its outbox entry in one
+// transaction, then applies the tuple right away; on failure the relay retries.
+func (s *accessRequest) approve(ctx context.Context, userID string, request *entity.AccessRequest, comment string) (*entity.AccessRequest, error) {
+
+	// Roles given since the request would be removed with the temporary one when it expires.
+	if err := s.ensureNoRole(ctx, request); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	share := request.Share(now)
+	expiration := entity_share.NewExpiration(request.TenantID, share, userID)
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{share.Tuple()}, request.Object())
+
+	s.decided(request, entity.STATUS_APPROVED, userID, comment, now)
+	request.GrantedUntil = share.ExpiresAt
+
+	patch, err := s.patch(request)
+	if err != nil {
+		return nil, err
+	}
+
+	expirationPayload, err := utils.StructToMap(expiration)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert share expiration data to map")
+	}
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	if err := s.repo.Decide(ctx, request.ID, patch, expirationPayload, entryPayload); err != nil {
+		return nil, err
+	}
+
+	if err := s.outbox.Dispatch(ctx, entry); err != nil {
+		log.Printf("Access request %s approved but permissions are pending: %v", request.ID, err)
+	}
+
+	s.record(ctx, entity_audit.ACCESS_REQUEST_APPROVED, userID, request, "approve",
+		fmt.Sprintf("%s of the whole vault to %s granted until %s%s", entity.Role, request.RequesterID, request.GrantedUntil.Format(time.RFC3339), s.scope(request)))
+
+	return request, nil
+}
+
+func (s *accessRequest) deny(ctx context.Context, userID string, request *entity.AccessRequest, comment string) (*entity.AccessRequest, error) {
+
+	s.decided(request, entity.STATUS_DENIED, userID, comment, s.now())
+
+	patch, err := s.patch(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Decide(ctx, request.ID, patch, nil, nil); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entity_audit.ACCESS_REQUEST_DENIED, userID, request, "deny", "access of "+request.RequesterID+" denied")
+
+	return request, nil
+}
+
+// decide returns the pending request and the user of the context, which must be an admin of
+// the vault other than the requester.
+func (s *accessRequest) decide(ctx context.Context, id, comment string) (string, *entity.AccessRequest, error) {
+
+	userID, _, err := s.identity(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(comment) > 500 {
+		return "", nil, core.ErrInvalidRequest("comment must have at most 500 characters")
+	}
+
+	request, err := s.get(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.ensureApprover(ctx, userID, request); err != nil {
+		return "", nil, err
+	}
+
+	if request.RequesterID == userID {
+		return "", nil, core.ErrForbidden("the requester cannot decide on its own request")
+	}
+
+	if now := s.now(); !request.IsPending(now) {
+		return "", nil, core.ErrConflict("access request " + id + " is " + string(request.Effective(now).Status))
+	}
+
+	return userID, request, nil
+}
+
+func (s *accessRequest) decided(request *entity.AccessRequest, status entity.Status, userID, comment string, now time.Time) {
+	request.Status = status
+	request.DecidedBy = userID
+	request.DecidedAt = &now
+	request.Comment = comment
+	request.UpdatedAt = now
+}
+
+func (s *accessRequest) patch(request *entity.AccessRequest) (map[string]interface{}, error) {
+
+	fields := map[string]interface{}{
+		"status":    request.Status,
+		"decidedBy": request.DecidedBy,
+		"decidedAt": request.DecidedAt.Format(time.RFC3339Nano),
+		"comment":   request.Comment,
+		"updatedAt": request.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	if request.GrantedUntil != nil {
+		fields["grantedUntil"] = request.GrantedUntil.Format(time.RFC3339Nano)
+	}
+
+	patch, err := utils.StructToMap(fields)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert access request data to map")
+	}
+
+	return patch, nil
+}
+
+func (s *accessRequest) identity(ctx context.Context) (string, string, error) {
+
+	if ctx.Err() != nil {
+		return "", "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	return userID, tenantID, nil
+}
+
+func (s *accessRequest) get(ctx context.Context, id string) (*entity.AccessRequest, error) {
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("access request ID is required")
+	}
+
+	return s.repo.Get(ctx, id)
+}
+
+// vault returns the vault, if it is an active vault of the tenant.
+func (s *accessRequest) vault(ctx context.Context, tenantID, id string) (*entity_vault.Vault, error) {
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("vault ID is required")
+	}
+
+	v, err := s.vaults.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.TenantID != tenantID || v.IsDeleted() {
+		return nil, core.ErrNotFound("vault " + id)
+	}
+
+	return v, nil
+}
+
+// ensureMember allows the active members and the guests of the tenant to request access.
+func (s *accessRequest) ensureMember(ctx context.Context, tenantID, userID string) error {
+
+	tenant := authorization.Object(authorization.TypeTenant, tenantID)
+	allowed, err := s.authz.BatchCheck(ctx, []authorization.TupleKey{
+		{User: authorization.User(userID), Relation: "active_member", Object: tenant},
+		{User: authorization.User(userID), Relation: "guest", Object: tenant},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check tenant membership: %w", err)
+	}
+
+	if !slices.Contains(allowed, true) {
+		return core.ErrForbidden("user is not a member of tenant " + tenantID)
+	}
+
+	return nil
+}
+
+// ensureApprover allows the owners and admins of the vault to see and decide on its requests.
+func (s *accessRequest) ensureApprover(ctx context.Context, userID string, request *entity.AccessRequest) error {
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), authorization.CanManage, request.Object())
+	if err != nil {
+		return fmt.Errorf("failed to check vault permission: %w", err)
+	}
+
+	if !allowed {
+		return core.ErrForbidden("only owners and admins of the vault can decide on its access requests")
+	}
+
+	return nil
+}
+
+// ensureNoRole rejects requesters that already have a role on the vault, since the temporary
+// role would replace it and the sweeper would remove both, and the ones that can already read
+// it through a group, which need no request.
+func (s *accessRequest) ensureNoRole(ctx context.Context, request *entity.AccessRequest) error {
+
+	stored, err := s.authz.ReadTuples(ctx, authorization.TupleKey{User: authorization.User(request.RequesterID), Object: request.Object()})
+	if err != nil {
+		return fmt.Errorf("failed to read roles of vault %s: %w", request.VaultID, err)
+	}
+
+	for _, t := range stored {
+		if sh, ok := entity_share.ShareFromTuple(t); ok {
+			return core.ErrConflict(fmt.Sprintf("user %s already has role %s on vault %s", request.RequesterID, sh.Role, request.VaultID))
+		}
+	}
+
+	allowed, err := s.authz.Check(ctx, authorization.User(request.RequesterID), authorization.CanRead, request.Object())
+	if err != nil {
+		return fmt.Errorf("failed to check vault permission: %w", err)
+	}
+
+	if allowed {
+		return core.ErrConflict(fmt.Sprintf("user %s can already read vault %s", request.RequesterID, request.VaultID))
+	}
+
+	return nil
+}
+
+func (s *accessRequest) ensureNotRequested(ctx context.Context, request *entity.AccessRequest, now time.Time) error {
+
+	filters := []database.Conditional{
+		{
+			Field:  "vaultId",
+			Value:  request.VaultID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "requesterId",
+			Value:  request.RequesterID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "status",
+			Value:  string(entity.STATUS_PENDING),
+			Filter: database.FilterEquals,
+		},
+	}
+
+	pending, _, err := s.repo.List(ctx, filters, database.QueryOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		if p.IsPending(now) {
+			return core.ErrConflict("user " + request.RequesterID + " already has a pending request for vault " + request.VaultID)
+		}
+	}
+
+	return nil
+}
+
+// notify emails a signed link to every user that owns or administers the vault directly.
+// Failures are only logged: the admins also find the request in the list of the vault.
+func (s *accessRequest) notify(ctx context.Context, request *entity.AccessRequest) {
+
+	stored, err := s.authz.ReadTuples(ctx, authorization.TupleKey{Object: request.Object()})
+	if err != nil {
+		log.Printf("Failed to list approvers of access request %s: %v", request.ID, err)
+		return
+	}
+
+	notified := map[string]bool{request.RequesterID: true}
+	for _, t := range stored {
+		if t.Relation != authorization.RelationOwner && t.Relation != authorization.RelationAdmin {
+			continue
+		}
+
+		userType, approverID, err := authorization.SplitObject(t.User)
+		if err != nil || userType != authorization.TypeUser || notified[approverID] {
+			continue
+		}
+		notified[approverID] = true
+
+		email, err := s.auth.GetUserEmail(ctx, approverID)
+		if err != nil || email == "" {
+			log.Printf("Failed to find the email of approver %s of access request %s: %v", approverID, request.ID, err)
+			continue
+		}
+
+		token, err := s.tokens.Generate(tokengen.TokenClaims{
+			UserID:    approverID,
+			TenantID:  request.TenantID,
+			Scope:     []string{entity.TokenScope},
+			Metadata:  map[string]interface{}{"accessRequestId": request.ID},
+			ExpiresAt: request.ExpiresAt,
+		})
+		if err != nil {
+			log.Printf("Failed to sign access request %s for %s: %v", request.ID, approverID, err)
+			continue
+		}
+
+		if err := s.sender.Send(ctx, s.message(request, email, token)); err != nil {
+			log.Printf("Failed to notify %s of access request %s: %v", approverID, request.ID, err)
+		}
+	}
+}
+
+func (s *accessRequest) message(request *entity.AccessRequest, to, token string) mailer.Message {
+
+	target := "the vault " + request.VaultName
+	if request.SecretName != "" {
+		target = "the secret " + request.SecretName + " of the vault " + request.VaultName +
+			".\n\nApproving gives read access to every secret of the vault, not only to " + request.SecretName
+	}
+
+	link := s.link + "?token=" + url.QueryEscape(token)
+	return mailer.Message{
+		To:      to,
+		Subject: "Access request for " + request.VaultName,
+		Body: fmt.Sprintf("%s requested read access for %d minutes to %s.\n\nJustification: %s\n\nApprove or deny the request: %s\n\nThe link expires at %s.\n",
+			request.RequesterID, request.Duration, target, request.Justification, link, request.ExpiresAt.Format(time.RFC1123)),
+	}
+}
+
+// scope names the secret the request was made for, which the role on the vault goes beyond.
+func (s *accessRequest) scope(request *entity.AccessRequest) string {
+	if request.SecretID == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (requested for secret %s)", request.SecretID)
+}
+
+func (s *accessRequest) record(ctx context.Context, eventType entity_audit.EventType, userID string, request *entity.AccessRequest, action, reason string) {
+
+	event := entity_audit.NewResourceEvent(eventType, userID, request.TenantID, request.Object(), action, reason)
+	if _, err := s.audit.Record(context.WithValue(context.WithoutCancel(ctx), "TenantID", request.TenantID), event); err != nil {
+		log.Printf("Failed to record %s event for access request %s: %v", eventType, request.ID, err)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/accessrequest"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_accessrequest "github.com/synera-br/lockari-backend-app/internal/core/repository/accessrequest"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	repo_secret "github.com/synera-br/lockari-backend-app/internal/core/repository/secret"
+	repo_share "github.com/synera-br/lockari-backend-app/internal/core/repository/share"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/tokengen"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+func TestAccessRequestFlow(t *testing.T) {
+	as := func(userID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", "t1")
+	}
+
+	db := database.NewMemoryDB()
+	fga, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+	require.NoError(t, fga.WriteTuples(context.Background(), []authorization.TupleKey{
+		entity_tenant.RoleTuple("t1", "alice", entity_tenant.ROLE_OWNER),
+		entity_tenant.RoleTuple("t1", "carol", entity_tenant.ROLE_ADMIN),
+		entity_tenant.RoleTuple("t1", "bob", entity_tenant.ROLE_MEMBER),
+		entity_tenant.RoleTuple("t1", "dave", entity_tenant.ROLE_MEMBER),
+	}))
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, fga)
+	require.NoError(t, err)
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	require.NoError(t, err)
+	payload, err := utils.StructToMap(entity_vault.NewVault(entity_vault.Vault{Name: "Production"}, "t1", "alice"))
+	require.NoError(t, err)
+	vault, err := vaults.Create(as("alice"), payload)
+	require.NoError(t, err)
+	require.NoError(t, fga.WriteTuples(context.Background(), vault.OwnershipTuples()))
+	require.NoError(t, fga.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("carol"), Relation: authorization.RelationAdmin, Object: vault.GetOpenFGAID()},
+		{User: authorization.User("dave"), Relation: authorization.RelationMember, Object: authorization.Object(authorization.TypeGroup, "eng")},
+		{User: authorization.UsersetOf(authorization.TypeGroup, "eng", "active_member"), Relation: string(entity.Role), Object: vault.GetOpenFGAID()},
+	}))
+
+	secrets, err := repo_secret.InitializeSecretRepository(db)
+	require.NoError(t, err)
+	expirations, err := repo_share.InitializeExpirationRepository(db)
+	require.NoError(t, err)
+	repo, err := repo_accessrequest.InitializeAccessRequestRepository(db)
+	require.NoError(t, err)
+
+	auth := &servicetest.Auth{Emails: map[string]string{"alice": "alice@acme.io", "bob": "bob@acme.io", "carol": "carol@acme.io"}}
+	tokens := tokengen.NewTokenGenerator("access-request-test-key", "lockari-access-requests", entity.DecisionWindow)
+	sender := &servicetest.Sender{}
+	audit := &servicetest.Audit{}
+	svc, err := InitializeAccessRequestService(repo, vaults, secrets, auth, fga, tokens, sender, outbox, audit, "https://app.lockari.io/access-requests/decide")
+	require.NoError(t, err)
+
+	reader := func(userID string) bool {
+		allowed, err := fga.Check(context.Background(), authorization.User(userID), string(entity.Role), vault.GetOpenFGAID())
+		require.NoError(t, err)
+		return allowed
+	}
+
+	_, err = svc.Create(as("bob"), &entity.AccessRequest{VaultID: vault.ID, Justification: "too short"})
+	assert.True(t, core.IsInvalidRequest(err))
+
+	_, err = svc.Create(as("bob"), &entity.AccessRequest{VaultID: vault.ID, Justification: "incident INC-42 on the payments API", Duration: 24 * 60})
+	assert.True(t, core.IsInvalidRequest(err), "the access lasts at most MaxDuration")
+
+	_, err = svc.Create(as("carol"), &entity.AccessRequest{VaultID: vault.ID, Justification: "incident INC-42 on the payments API"})
+	assert.True(t, core.IsConflict(err), "admins of the vault already have access")
+
+	_, err = svc.Create(as("dave"), &entity.AccessRequest{VaultID: vault.ID, Justification: "incident INC-42 on the payments API"})
+	assert.True(t, core.IsConflict(err), "dave already reads the vault through a group")
+
+	request, err := svc.Create(as("bob"), &entity.AccessRequest{VaultID: vault.ID, Justification: "incident INC-42 on the payments API", Duration: 30})
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_PENDING, request.Status)
+	assert.Equal(t, "Production", request.VaultName)
+	require.Len(t, sender.Messages, 2, "the owners and admins of the vault are notified")
+
+	_, err = svc.Create(as("bob"), &entity.AccessRequest{VaultID: vault.ID, Justification: "incident INC-42 on the payments API"})
+	assert.True(t, core.IsConflict(err), "one pending request per vault")
+
+	_, _, err = svc.List(as("bob"), vault.ID, database.QueryOptions{})
+	assert.True(t, core.IsForbidden(err), "only admins list the requests of a vault")
+
+	pending, _, err := svc.List(as("alice"), vault.ID, database.QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	_, err = svc.Decide(as("alice"), sender.Token(t, "carol@acme.io"), entity.DECISION_APPROVE, "")
+	assert.True(t, core.IsForbidden(err), "the link only works for the user it was sent to")
+
+	approved, err := svc.Decide(as("carol"), sender.Token(t, "carol@acme.io"), entity.DECISION_APPROVE, "go ahead")
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_APPROVED, approved.Status)
+	require.NotNil(t, approved.GrantedUntil)
+	assert.True(t, reader("bob"))
+
+	expiring, err := expirations.ListByVault(as("alice"), vault.ID)
+	require.NoError(t, err)
+	require.Len(t, expiring, 1, "the sweeper revokes the role when the access ends")
+	assert.Equal(t, approved.GrantedUntil.Unix(), expiring[0].ExpiresAt.Unix())
+
+	_, err = svc.Approve(as("alice"), request.ID, "")
+	assert.True(t, core.IsConflict(err), "a request is decided once")
+
+	svc.(*accessRequest).now = func() time.Time { return time.Now().UTC().Add(time.Hour) }
+	ended, err := svc.Get(as("bob"), request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_ENDED, ended.Status)
+	svc.(*accessRequest).now = func() time.Time { return time.Now().UTC() }
+
+	// A denied request gives no access, and nobody decides on its own request.
+	require.NoError(t, fga.DeleteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("bob"), Relation: string(entity.Role), Object: vault.GetOpenFGAID()},
+	}))
+	secret, err := secrets.Create(as("alice"), vault.ID, map[string]interface{}{"name": "payments-key", "isActive": true})
+	require.NoError(t, err)
+	denied, err := svc.Create(as("bob"), &entity.AccessRequest{VaultID: vault.ID, SecretID: secret.ID, Justification: "rotate the payments API key"})
+	require.NoError(t, err)
+	assert.Equal(t, "payments-key", denied.SecretName)
+	assert.Contains(t, sender.Messages[len(sender.Messages)-1].Body, "every secret of the vault", "the admins know the role covers the whole vault")
+
+	_, err = svc.Approve(as("bob"), denied.ID, "")
+	assert.True(t, core.IsForbidden(err))
+
+	denied, err = svc.Deny(as("alice"), denied.ID, "use the staging vault")
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_DENIED, denied.Status)
+	assert.False(t, reader("bob"))
+
+	mine, _, err := svc.List(as("bob"), "", database.QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, mine, 2)
+
+	assert.Len(t, audit.Events, 4)
+}
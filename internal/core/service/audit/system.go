@@ -5,7 +5,9 @@ import (
 	"errors"
 
 	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
 	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
 	"github.com/synera-br/lockari-backend-app/pkg/tokengen"
 	"github.com/synera-br/lockari-backend-app/pkg/utils"
 )
@@ -67,10 +69,76 @@ func (s *auditSystemEvent) Create(ctx context.Context, event *entity.AuditSystem
 	return result, nil
 }
 
-func (a *auditSystemEvent) Get(ctx context.Context, id string) (*entity.AuditSystemEvent, error) {
-	return nil, nil
+func (s *auditSystemEvent) Record(ctx context.Context, event *entity.AuditSystemEvent) (*entity.AuditSystemEvent, error) {
+
+	if ctx.Err() != nil {
+		return nil, errors.New(utils.ContextCancelled)
+	}
+
+	if err := event.IsValidInternal(); err != nil {
+		return nil, err
+	}
+
+	data, err := utils.StructToMap(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, data)
+}
+
+func (s *auditSystemEvent) Get(ctx context.Context, id string) (*entity.AuditSystemEvent, error) {
+
+	if ctx.Err() != nil {
+		return nil, errors.New(utils.ContextCancelled)
+	}
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("audit event ID is required")
+	}
+
+	token := utils.GetTokenFromContext(ctx)
+	if _, err := s.tokenJWT.Validate(token); err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	return s.repo.Get(ctx, id)
 }
 
-func (a *auditSystemEvent) List(ctx context.Context) ([]entity.AuditSystemEvent, error) {
-	return nil, nil
+func (s *auditSystemEvent) List(ctx context.Context, filter entity.AuditFilter, opts database.QueryOptions) ([]entity.AuditSystemEvent, string, error) {
+
+	if ctx.Err() != nil {
+		return nil, "", errors.New(utils.ContextCancelled)
+	}
+
+	token := utils.GetTokenFromContext(ctx)
+	if _, err := s.tokenJWT.Validate(token); err != nil {
+		return nil, "", core.ErrUnauthorized(err.Error())
+	}
+
+	opts = opts.WithDefaultOrder(database.OrderBy{Field: "timestamp", Direction: database.Desc})
+	if err := opts.Validate(entity.AuditSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	// Firestore requires the first order of a range query to be the range field.
+	if filter.HasRange() && opts.OrderBy[0].Field != "timestamp" {
+		return nil, "", core.ErrInvalidRequest("events filtered by date must be ordered by timestamp")
+	}
+
+	filters, err := filter.Conditionals()
+	if err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	events, next, err := s.repo.List(ctx, filters, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if events == nil {
+		events = []entity.AuditSystemEvent{}
+	}
+
+	return events, next, nil
 }
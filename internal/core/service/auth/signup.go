@@ -6,6 +6,7 @@ import (
 	"log"
 
 	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/auth"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
 	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
 	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
 	"github.com/synera-br/lockari-backend-app/pkg/database"
@@ -17,9 +18,10 @@ type SignupEvent struct {
 	repo     entity.SignupEventRepository
 	auth     authenticator.Authenticator
 	tokenJWT tokengen.TokenGenerator
+	tenants  entity_tenant.TenantService
 }
 
-func InitializeSignupEventService(repo entity.SignupEventRepository, auth authenticator.Authenticator, tokenJWT tokengen.TokenGenerator) (entity.SignupEventService, error) {
+func InitializeSignupEventService(repo entity.SignupEventRepository, auth authenticator.Authenticator, tokenJWT tokengen.TokenGenerator, tenants entity_tenant.TenantService) (entity.SignupEventService, error) {
 
 	if repo == nil {
 		return nil, core.ErrRepositoryNotFound("SignupEventRepository")
@@ -33,10 +35,15 @@ func InitializeSignupEventService(repo entity.SignupEventRepository, auth authen
 		return nil, core.ErrRepositoryNotFound("TokenGenerator")
 	}
 
+	if tenants == nil {
+		return nil, core.ErrServiceNotFound("TenantService")
+	}
+
 	return &SignupEvent{
 		repo:     repo,
 		auth:     auth,
 		tokenJWT: tokenJWT,
+		tenants:  tenants,
 	}, nil
 }
 
@@ -117,6 +124,23 @@ func (s *SignupEvent) Create(ctx context.Context, signupData *entity.Signup) (en
 		return nil, originalErr
 	}
 
+	// CREATE TENANT WITH THE USER AS OWNER
+	user := signupData.User
+	name := user.Name
+	if name == "" {
+		name = user.Email
+	}
+
+	tenant := entity_tenant.NewTenant(tenantId, name, user.Plan, user.Uid)
+	owner := entity_tenant.NewMember(tenantId, user.Uid, user.Email, user.Name, entity_tenant.ROLE_OWNER, user.Uid)
+	if _, err := s.tenants.Bootstrap(ctx, tenant, owner); err != nil {
+		if rollbackErr := s.auth.SetTenantRollback(ctx, user.Uid, ""); rollbackErr != nil {
+			log.Printf("Failed to rollback tenant for user %s: %v", user.Uid, rollbackErr)
+		}
+
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -138,13 +162,7 @@ func (s *SignupEvent) Get(ctx context.Context, id string) (entity.SignupEvent, e
 	}
 
 	// Buscar o signup event primeiro
-	filter := database.Conditional{
-		Field:  "id",
-		Value:  id,
-		Filter: database.FilterEquals,
-	}
-
-	result, err := s.repo.Get(ctx, filter)
+	result, err := s.repo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +193,7 @@ func (s *SignupEvent) List(ctx context.Context) ([]entity.SignupEvent, error) {
 	}
 
 	filter := database.Conditional{
-		Field:  "userId",
+		Field:  "uid",
 		Value:  userFromToken,
 		Filter: database.FilterEquals,
 	}
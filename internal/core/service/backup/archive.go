@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/backup"
+)
+
+// An archive is a gzip compressed JSON lines file: a header, one line per document and a
+// trailer with the HMAC-SHA256 of every line before it, keyed with the backup signing key.
+// A restore reads the whole archive and checks the signature before writing anything.
+
+var errInvalidArchive = errors.New("invalid backup archive")
+
+type archiveHeader struct {
+	Version   int       `json:"version"`
+	BackupID  string    `json:"backupId"`
+	TenantID  string    `json:"tenantId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type archiveTrailer struct {
+	Documents int    `json:"documents"`
+	Signature string `json:"signature"`
+}
+
+type archiveWriter struct {
+	buf       bytes.Buffer
+	gz        *gzip.Writer
+	mac       hash.Hash
+	documents int
+}
+
+func newArchiveWriter(key []byte, header archiveHeader) (*archiveWriter, error) {
+
+	w := &archiveWriter{mac: hmac.New(sha256.New, key)}
+	w.gz = gzip.NewWriter(&w.buf)
+
+	if err := w.writeLine(header, true); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *archiveWriter) Write(doc entity.TenantDocument) error {
+
+	if err := w.writeLine(doc, true); err != nil {
+		return fmt.Errorf("failed to archive %s/%s: %w", doc.Collection, doc.ID, err)
+	}
+
+	w.documents++
+	return nil
+}
+
+// Close writes the trailer and returns the compressed archive.
+func (w *archiveWriter) Close() ([]byte, error) {
+
+	trailer := archiveTrailer{
+		Documents: w.documents,
+		Signature: hex.EncodeToString(w.mac.Sum(nil)),
+	}
+
+	if err := w.writeLine(trailer, false); err != nil {
+		return nil, err
+	}
+
+	if err := w.gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+func (w *archiveWriter) writeLine(v interface{}, signed bool) error {
+
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if signed {
+		w.mac.Write(line)
+	}
+
+	_, err = w.gz.Write(line)
+	return err
+}
+
+// readArchive checks the signature of the archive and returns its header and documents.
+func readArchive(key, data []byte) (*archiveHeader, []entity.TenantDocument, error) {
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", errInvalidArchive, err.Error())
+	}
+	defer gz.Close()
+
+	mac := hmac.New(sha256.New, key)
+	reader := bufio.NewReader(gz)
+
+	lines := [][]byte{}
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", errInvalidArchive, err.Error())
+		}
+	}
+
+	if len(lines) < 2 {
+		return nil, nil, fmt.Errorf("%w: header or trailer missing", errInvalidArchive)
+	}
+
+	for _, line := range lines[:len(lines)-1] {
+		mac.Write(line)
+	}
+
+	var trailer archiveTrailer
+	if err := json.Unmarshal(lines[len(lines)-1], &trailer); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", errInvalidArchive, err.Error())
+	}
+
+	signature, err := hex.DecodeString(trailer.Signature)
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, nil, fmt.Errorf("%w: signature mismatch", errInvalidArchive)
+	}
+
+	var header archiveHeader
+	if err := json.Unmarshal(lines[0], &header); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", errInvalidArchive, err.Error())
+	}
+
+	if header.Version != entity.ArchiveVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", errInvalidArchive, header.Version)
+	}
+
+	docs := make([]entity.TenantDocument, 0, len(lines)-2)
+	for _, line := range lines[1 : len(lines)-1] {
+		var doc entity.TenantDocument
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", errInvalidArchive, err.Error())
+		}
+		doc.Data, _ = numbers(doc.Data).(map[string]interface{})
+		docs = append(docs, doc)
+	}
+
+	if len(docs) != trailer.Documents {
+		return nil, nil, fmt.Errorf("%w: %d documents, %d expected", errInvalidArchive, len(docs), trailer.Documents)
+	}
+
+	return &header, docs, nil
+}
+
+// numbers converts the json.Number values back to int64 when they are integers, so counters
+// such as revision keep their type in the databases that distinguish them.
+func numbers(v interface{}) interface{} {
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = numbers(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = numbers(item)
+		}
+		return value
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// checksum returns the hex SHA-256 of the archive.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/backup"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/storage"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+// MinKeyLength is the minimum length of the key that signs the archives.
+const MinKeyLength = 32
+
+// remappedCollections hold the documents that get a new ID when restored into another
+// tenant, because their OpenFGA objects (vault:<id>, secret:<id>) are not scoped by tenant.
+var remappedCollections = map[string]bool{"vaults": true, "secrets": true}
+
+type backup struct {
+	repo      entity.BackupRepository
+	store     storage.Store
+	authz     authorization.Authorizer
+	outbox    entity_outbox.OutboxService
+	audit     entity_audit.AuditSystemEventService
+	key       []byte
+	retention time.Duration
+	now       func() time.Time
+}
+
+// InitializeBackupService creates the backup service. The archives are signed with key.
+// A zero retention keeps the backups until they are deleted.
+func InitializeBackupService(repo entity.BackupRepository, store storage.Store, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, key string, retention time.Duration) (entity.BackupService, error) {
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	s, err := newBackup(repo, store, audit, key, retention)
+	if err != nil {
+		return nil, err
+	}
+	s.authz = authz
+	s.outbox = outbox
+
+	return s, nil
+}
+
+// newBackup validates the dependencies shared by the service and the scheduler.
+func newBackup(repo entity.BackupRepository, store storage.Store, audit entity_audit.AuditSystemEventService, key string, retention time.Duration) (*backup, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("BackupRepository")
+	}
+
+	if store == nil {
+		return nil, core.ErrServiceNotFound("Store")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	if len(key) < MinKeyLength {
+		return nil, fmt.Errorf("backup signing key must have at least %d characters", MinKeyLength)
+	}
+
+	return &backup{
+		repo:      repo,
+		store:     store,
+		audit:     audit,
+		key:       []byte(key),
+		retention: retention,
+		now:       time.Now,
+	}, nil
+}
+
+func (s *backup) Create(ctx context.Context) (*entity.Backup, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, tenantID, err := s.administrator(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.create(ctx, tenantID, userID, entity.BACKUP_MANUAL)
+}
+
+func (s *backup) List(ctx context.Context, opts database.QueryOptions) ([]entity.Backup, string, error) {
+
+	if ctx.Err() != nil {
+		return nil, "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	opts = opts.WithDefaultOrder(database.OrderBy{Field: "createdAt", Direction: database.Desc})
+	if err := opts.Validate(entity.BackupSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	_, tenantID, err := s.administrator(ctx, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "tenantId",
+			Value:  tenantID,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	return s.repo.List(ctx, filters, opts)
+}
+
+func (s *backup) Get(ctx context.Context, id string) (*entity.Backup, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("backup ID is required")
+	}
+
+	_, tenantID, err := s.administrator(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.get(ctx, tenantID, id)
+}
+
+// Restore replaces the documents of the target tenant with the ones of the backup. Documents
+// created after the backup are kept. Another target must be empty: its vaults and secrets
+// get new IDs and every reference to them, and to the source tenant, is rewritten.
+func (s *backup) Restore(ctx context.Context, id string, request entity.RestoreRequest) (*entity.RestoreReport, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("backup ID is required")
+	}
+
+	userID, tenantID, err := s.administrator(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !b.IsRestorable() {
+		return nil, core.ErrConflict("backup " + id + " is " + string(b.Status) + " and cannot be restored")
+	}
+
+	target := strings.TrimSpace(request.TargetTenantID)
+	if target == "" {
+		target = tenantID
+	}
+
+	if target != tenantID {
+		if _, _, err := s.administrator(ctx, target); err != nil {
+			return nil, err
+		}
+
+		used, err := s.repo.HasDocuments(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			return nil, core.ErrConflict("tenant " + target + " is not empty, a backup can only be restored into its own tenant or an empty one")
+		}
+	}
+
+	report := &entity.RestoreReport{
+		BackupID:       b.ID,
+		SourceTenantID: b.TenantID,
+		TargetTenantID: target,
+		StartedAt:      s.now().UTC(),
+	}
+
+	data, err := s.store.Get(ctx, b.Storage.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup archive %s: %w", b.ID, err)
+	}
+
+	if checksum(data) != b.Metadata.Checksum {
+		return nil, fmt.Errorf("%w: checksum of backup %s does not match", errInvalidArchive, b.ID)
+	}
+
+	header, docs, err := readArchive(s.key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.TenantID != b.TenantID || header.BackupID != b.ID {
+		return nil, fmt.Errorf("%w: archive belongs to backup %s of tenant %s", errInvalidArchive, header.BackupID, header.TenantID)
+	}
+
+	if target != b.TenantID {
+		report.Remapped = remap(docs, b.TenantID, target)
+	}
+
+	if err := s.repo.Import(ctx, target, docs); err != nil {
+		s.record(ctx, entity_audit.BACKUP_FAILED, userID, target, b, "restore", "restore failed: "+err.Error())
+		return nil, err
+	}
+	report.Documents = len(docs)
+
+	tuples := []authorization.TupleKey{}
+	for _, doc := range docs {
+		if doc.Collection != entity_vault.VaultCollection {
+			continue
+		}
+
+		vault := entity_vault.Vault{ID: doc.ID, TenantID: target, CreatedBy: userID}
+		if createdBy, _ := doc.Data["createdBy"].(string); createdBy != "" && target == b.TenantID {
+			vault.CreatedBy = createdBy
+		}
+		tuples = append(tuples, vault.OwnershipTuples()...)
+		report.Vaults++
+	}
+
+	// The outbox writes the tuples that are missing, so a restore into the same tenant is safe.
+	if len(tuples) > 0 {
+		if _, err := s.outbox.Enqueue(ctx, entity_outbox.OUTBOX_WRITE, tuples, b.Object()); err != nil {
+			return nil, fmt.Errorf("failed to enqueue the tuples of the restored vaults: %w", err)
+		}
+	}
+
+	report.EndedAt = s.now().UTC()
+	s.record(ctx, entity_audit.BACKUP_RESTORED, userID, target, b, "restore",
+		fmt.Sprintf("%d documents restored from tenant %s", report.Documents, b.TenantID))
+
+	return report, nil
+}
+
+// create snapshots the tenant. The record is created first, so a failed backup is listed with its error.
+func (s *backup) create(ctx context.Context, tenantID, userID string, backupType entity.BackupType) (*entity.Backup, error) {
+
+	b := entity.NewBackup(utils.GenerateID(), tenantID, userID, backupType, s.now(), s.retention)
+	if err := b.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	payload, err := utils.StructToMap(b)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert backup data to map")
+	}
+	delete(payload, "completedAt")
+
+	if _, err := s.repo.Create(ctx, b.ID, payload); err != nil {
+		return nil, err
+	}
+
+	if err := s.snapshot(ctx, b); err != nil {
+		b.Status = entity.BACKUP_FAILED
+		b.Error = err.Error()
+		if err := s.repo.Update(context.WithoutCancel(ctx), b.ID, map[string]interface{}{"status": b.Status, "error": b.Error}); err != nil {
+			log.Printf("Failed to record the failure of backup %s: %v", b.ID, err)
+		}
+		s.record(ctx, entity_audit.BACKUP_FAILED, userID, tenantID, b, "create", b.Error)
+		return nil, fmt.Errorf("backup %s of tenant %s failed: %w", b.ID, tenantID, err)
+	}
+
+	completedAt := s.now().UTC()
+	b.Status = entity.BACKUP_COMPLETED
+	b.CompletedAt = &completedAt
+
+	patch := map[string]interface{}{
+		"status":      b.Status,
+		"metadata":    b.Metadata,
+		"storage":     b.Storage,
+		"completedAt": completedAt.Format(time.RFC3339Nano),
+	}
+	patch, err = utils.StructToMap(patch)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert backup data to map")
+	}
+
+	if err := s.repo.Update(ctx, b.ID, patch); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entity_audit.BACKUP_CREATED, userID, tenantID, b, "create",
+		fmt.Sprintf("%s backup with %d documents", b.Type, b.Metadata.TotalDocuments))
+
+	return b, nil
+}
+
+// snapshot writes the archive of the tenant to the store and fills the metadata of the backup.
+func (s *backup) snapshot(ctx context.Context, b *entity.Backup) error {
+
+	writer, err := newArchiveWriter(s.key, archiveHeader{
+		Version:   entity.ArchiveVersion,
+		BackupID:  b.ID,
+		TenantID:  b.TenantID,
+		CreatedAt: b.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Export(ctx, b.TenantID, func(doc entity.TenantDocument) error {
+		if doc.CollectionID() == "secrets" {
+			b.Metadata.TotalSecrets++
+		}
+		b.Metadata.TotalDocuments++
+		return writer.Write(doc)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export tenant: %w", err)
+	}
+
+	data, err := writer.Close()
+	if err != nil {
+		return err
+	}
+
+	location, err := s.store.Put(ctx, b.ArchiveKey(), data)
+	if err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	b.Metadata.TotalSize = int64(len(data))
+	b.Metadata.Checksum = checksum(data)
+	b.Storage.Provider = s.store.Provider()
+	b.Storage.Location = location
+
+	return nil
+}
+
+// get returns the backup if it belongs to the tenant. Backups of other tenants are not found.
+func (s *backup) get(ctx context.Context, tenantID, id string) (*entity.Backup, error) {
+
+	b, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.TenantID != tenantID {
+		return nil, core.ErrNotFound("backup " + id + " not found")
+	}
+
+	return b, nil
+}
+
+// administrator checks that the user of the context is an owner or admin of the tenant,
+// the tenant of the context when tenantID is empty, and returns the user and tenant IDs.
+func (s *backup) administrator(ctx context.Context, tenantID string) (string, string, error) {
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	if tenantID == "" {
+		tenantID, err = utils.GetTenantIDFromContext(ctx)
+		if err != nil {
+			return "", "", core.ErrUnauthorized(err.Error())
+		}
+	}
+
+	object := authorization.Object(authorization.TypeTenant, tenantID)
+	allowed, err := s.authz.BatchCheck(ctx, []authorization.TupleKey{
+		{User: authorization.User(userID), Relation: authorization.RelationOwner, Object: object},
+		{User: authorization.User(userID), Relation: authorization.RelationAdmin, Object: object},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check backup permission: %w", err)
+	}
+
+	for _, ok := range allowed {
+		if ok {
+			return userID, tenantID, nil
+		}
+	}
+
+	return "", "", core.ErrForbidden("only the owners and admins of tenant " + tenantID + " can manage its backups")
+}
+
+// record stores the audit event of the backup. Failures are only logged.
+func (s *backup) record(ctx context.Context, eventType entity_audit.EventType, userID, tenantID string, b *entity.Backup, action, reason string) {
+
+	event := entity_audit.NewResourceEvent(eventType, userID, tenantID, b.Object(), action, reason)
+	if _, err := s.audit.Record(context.WithValue(context.WithoutCancel(ctx), "TenantID", tenantID), event); err != nil {
+		log.Printf("Failed to record %s event for %s: %v", eventType, b.Object(), err)
+	}
+}
+
+// remap gives new IDs to the vaults and secrets of docs and rewrites the collection paths, the
+// IDs that embed them (e.g. trash entries) and the string values that equal an old ID or the
+// source tenant. It returns the number of remapped documents.
+func remap(docs []entity.TenantDocument, source, target string) int {
+
+	ids := map[string]string{}
+	for _, doc := range docs {
+		if remappedCollections[doc.CollectionID()] {
+			ids[doc.ID] = utils.GenerateID()
+		}
+	}
+
+	values := map[string]string{source: target}
+	for old, id := range ids {
+		values[old] = id
+	}
+
+	for i := range docs {
+		doc := &docs[i]
+
+		segments := strings.Split(doc.Collection, "/")
+		for j, segment := range segments {
+			if id, ok := ids[segment]; ok {
+				segments[j] = id
+			}
+		}
+		doc.Collection = strings.Join(segments, "/")
+
+		if id, ok := ids[doc.ID]; ok {
+			doc.ID = id
+		} else {
+			for old, id := range ids {
+				doc.ID = strings.ReplaceAll(doc.ID, old, id)
+			}
+		}
+
+		doc.Data, _ = replaceValues(doc.Data, values).(map[string]interface{})
+	}
+
+	return len(ids)
+}
+
+func replaceValues(v interface{}, values map[string]string) interface{} {
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = replaceValues(item, values)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = replaceValues(item, values)
+		}
+		return value
+	case string:
+		if replaced, ok := values[value]; ok {
+			return replaced
+		}
+		return value
+	default:
+		return v
+	}
+}
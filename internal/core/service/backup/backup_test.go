@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/backup"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo_backup "github.com/synera-br/lockari-backend-app/internal/core/repository/backup"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/storage"
+)
+
+const (
+	signingKey = "0123456789abcdef0123456789abcdef"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "UserID", "alice")
+	ctx = context.WithValue(ctx, "TenantID", "t1")
+
+	db := database.NewMemoryDB()
+	create := func(collection, id string, data map[string]interface{}) {
+		_, err := db.CreateWithID(ctx, id, data, collection)
+		require.NoError(t, err)
+	}
+	create("tenant/t1/vaults", "v1", map[string]interface{}{"name": "prod", "tenantId": "t1", "createdBy": "alice"})
+	create("tenant/t1/vaults/v1/secrets", "s1", map[string]interface{}{"name": "db", "vaultId": "v1", "encryptedValue": "c2VjcmV0"})
+	create("tenant/t1/vaults/v1/secrets/s1/versions", "1", map[string]interface{}{"version": 1, "encryptedValue": "c2VjcmV0"})
+	create("tenant/t1/trash", "secret_s1", map[string]interface{}{"itemId": "s1", "vaultId": "v1", "tenantId": "t1"})
+
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+	require.NoError(t, authz.WriteTuples(ctx, []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: "tenant:t1"},
+		{User: authorization.User("alice"), Relation: authorization.RelationAdmin, Object: "tenant:t2"},
+	}))
+
+	repo, err := repo_backup.InitializeBackupRepository(db)
+	require.NoError(t, err)
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	audit := &servicetest.Audit{}
+	outbox := &servicetest.Outbox{}
+
+	_, err = InitializeBackupService(repo, store, authz, outbox, audit, "short", 0)
+	require.Error(t, err)
+
+	svc, err := InitializeBackupService(repo, store, authz, outbox, audit, signingKey, time.Hour)
+	require.NoError(t, err)
+
+	_, err = svc.Create(context.WithValue(ctx, "UserID", "bob"))
+	assert.True(t, core.IsForbidden(err), "only owners and admins back up a tenant")
+
+	b, err := svc.Create(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entity.BACKUP_COMPLETED, b.Status)
+	assert.Equal(t, 4, b.Metadata.TotalDocuments)
+	assert.Equal(t, 1, b.Metadata.TotalSecrets)
+	assert.Equal(t, "local://t1/"+b.ID+".jsonl.gz", b.Storage.Location)
+	assert.Equal(t, entity_audit.BACKUP_CREATED, audit.Events[len(audit.Events)-1].EventType)
+
+	backups, _, err := svc.List(ctx, database.QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, b.Metadata.Checksum, backups[0].Metadata.Checksum)
+
+	_, err = svc.Get(context.WithValue(ctx, "TenantID", "t2"), b.ID)
+	assert.True(t, core.IsNotFound(err), "a backup is only visible to its tenant")
+
+	t.Run("restores into the same tenant", func(t *testing.T) {
+		require.NoError(t, db.Update(ctx, "v1", map[string]interface{}{"name": "changed"}, "tenant/t1/vaults"))
+
+		report, err := svc.Restore(ctx, b.ID, entity.RestoreRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Documents)
+		assert.Equal(t, 0, report.Remapped)
+		assert.Equal(t, "prod", document(t, db, "tenant/t1/vaults", "v1")["name"])
+		assert.Equal(t, float64(1), document(t, db, "tenant/t1/vaults/v1/secrets/s1/versions", "1")["version"])
+		assert.Contains(t, outbox.Written, authorization.TupleKey{User: "user:alice", Relation: authorization.RelationOwner, Object: "vault:v1"})
+	})
+
+	t.Run("restores into an empty tenant with new ids", func(t *testing.T) {
+		report, err := svc.Restore(ctx, b.ID, entity.RestoreRequest{TargetTenantID: "t2"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Remapped)
+		assert.Equal(t, 1, report.Vaults)
+
+		var vaults []map[string]interface{}
+		data, err := db.Get(ctx, "tenant/t2/vaults")
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &vaults))
+		require.Len(t, vaults, 1)
+		vaultID := vaults[0]["id"].(string)
+		assert.NotEqual(t, "v1", vaultID)
+		assert.Equal(t, "t2", vaults[0]["tenantId"])
+
+		var secrets []map[string]interface{}
+		data, err = db.Get(ctx, "tenant/t2/vaults/"+vaultID+"/secrets")
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &secrets))
+		require.Len(t, secrets, 1)
+		secretID := secrets[0]["id"].(string)
+		assert.Equal(t, vaultID, secrets[0]["vaultId"])
+		assert.Equal(t, "c2VjcmV0", secrets[0]["encryptedValue"], "secret values are restored encrypted")
+
+		trash := document(t, db, "tenant/t2/trash", "secret_"+secretID)
+		assert.Equal(t, secretID, trash["itemId"])
+		assert.Contains(t, outbox.Written, authorization.TupleKey{User: "tenant:t2", Relation: authorization.RelationTenant, Object: "vault:" + vaultID})
+
+		_, err = svc.Restore(ctx, b.ID, entity.RestoreRequest{TargetTenantID: "t2"})
+		assert.True(t, core.IsConflict(err), "another tenant must be empty")
+
+		_, err = svc.Restore(ctx, b.ID, entity.RestoreRequest{TargetTenantID: "t3"})
+		assert.True(t, core.IsForbidden(err), "the user must administer the target tenant")
+	})
+
+	t.Run("rejects a tampered archive", func(t *testing.T) {
+		data, err := store.Get(ctx, b.Storage.Location)
+		require.NoError(t, err)
+
+		_, docs, err := readArchive([]byte(signingKey), data)
+		require.NoError(t, err)
+		docs[0].Data["name"] = "tampered"
+
+		w, err := newArchiveWriter([]byte("another key of at least 32 chars"), archiveHeader{Version: entity.ArchiveVersion, BackupID: b.ID, TenantID: "t1"})
+		require.NoError(t, err)
+		for _, doc := range docs {
+			require.NoError(t, w.Write(doc))
+		}
+		forged, err := w.Close()
+		require.NoError(t, err)
+
+		_, _, err = readArchive([]byte(signingKey), forged)
+		assert.ErrorIs(t, err, errInvalidArchive)
+
+		_, err = store.Put(ctx, b.ArchiveKey(), forged)
+		require.NoError(t, err)
+		_, err = svc.Restore(ctx, b.ID, entity.RestoreRequest{})
+		assert.ErrorIs(t, err, errInvalidArchive, "the checksum of the record no longer matches")
+	})
+
+	t.Run("scheduler backs up every tenant and removes expired backups", func(t *testing.T) {
+		vaults, err := repo_vault.InitializeVaultRepository(db)
+		require.NoError(t, err)
+
+		sched, err := InitializeSchedulerService(repo, store, vaults, audit, signingKey, time.Hour)
+		require.NoError(t, err)
+
+		report, err := sched.BackupAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Tenants)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 0, report.Expired)
+
+		sched.(*scheduler).now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		report, err = sched.BackupAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Expired, "the manual and the first scheduled backups expired")
+
+		_, err = store.Get(ctx, b.Storage.Location)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func document(t *testing.T, db database.FirebaseDBInterface, collection, id string) map[string]interface{} {
+	data, err := db.GetByID(context.Background(), id, collection)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	return doc
+}
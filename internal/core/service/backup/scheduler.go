@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/backup"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/storage"
+)
+
+type scheduler struct {
+	*backup
+	vaults entity_vault.VaultRepository
+}
+
+// InitializeSchedulerService creates the scheduler. It shares the store, key and retention of the backup service.
+func InitializeSchedulerService(repo entity.BackupRepository, store storage.Store, vaults entity_vault.VaultRepository, audit entity_audit.AuditSystemEventService, key string, retention time.Duration) (entity.SchedulerService, error) {
+
+	if vaults == nil {
+		return nil, core.ErrRepositoryNotFound("VaultRepository")
+	}
+
+	b, err := newBackup(repo, store, audit, key, retention)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduler{
+		backup: b,
+		vaults: vaults,
+	}, nil
+}
+
+// BackupAll creates a scheduled backup of every tenant with at least one vault, then removes
+// the archives and records of the expired backups. A tenant that fails is retried on the next run.
+func (s *scheduler) BackupAll(ctx context.Context) (*entity.ScheduleReport, error) {
+
+	report := &entity.ScheduleReport{StartedAt: s.now().UTC()}
+
+	tenants, err := s.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tenantID := range tenants {
+		report.Tenants++
+
+		if _, err := s.create(ctx, tenantID, entity.SchedulerActor, entity.BACKUP_SCHEDULED); err != nil {
+			report.Failed++
+			log.Printf("Backup scheduler: %v", err)
+			continue
+		}
+		report.Created++
+	}
+
+	expired, err := s.repo.ListExpired(ctx, report.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range expired {
+		if err := s.expire(ctx, &b); err != nil {
+			log.Printf("Backup scheduler: failed to remove expired backup %s: %v", b.ID, err)
+			continue
+		}
+		report.Expired++
+	}
+
+	report.EndedAt = s.now().UTC()
+	return report, nil
+}
+
+func (s *scheduler) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.BackupAll(ctx)
+			if err != nil {
+				log.Printf("Backup scheduler failed: %v", err)
+				continue
+			}
+			log.Printf("Backup scheduler: %d tenants, %d backups created, %d failed, %d expired removed",
+				report.Tenants, report.Created, report.Failed, report.Expired)
+		}
+	}
+}
+
+// tenants returns the tenants that own vaults, in ID order.
+func (s *scheduler) tenants(ctx context.Context) ([]string, error) {
+
+	vaults, err := s.vaults.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tenants to back up: %w", err)
+	}
+
+	seen := map[string]bool{}
+	tenants := []string{}
+	for _, v := range vaults {
+		if v.TenantID != "" && !seen[v.TenantID] {
+			seen[v.TenantID] = true
+			tenants = append(tenants, v.TenantID)
+		}
+	}
+	sort.Strings(tenants)
+
+	return tenants, nil
+}
+
+// expire removes the archive before the record, so an archive is never left without a record.
+func (s *scheduler) expire(ctx context.Context, b *entity.Backup) error {
+
+	if b.Storage.Location != "" {
+		if err := s.store.Delete(ctx, b.Storage.Location); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Delete(ctx, b.ID)
+}
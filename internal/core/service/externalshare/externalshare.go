@@ -0,0 +1,675 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/externalshare"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/mailer"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type externalShare struct {
+	repo    entity.ExternalShareRepository
+	tenants entity_tenant.TenantRepository
+	vaults  entity_vault.VaultRepository
+	authz   authorization.Authorizer
+	sender  mailer.Sender
+	outbox  entity_outbox.OutboxService
+	audit   entity_audit.AuditSystemEventService
+	link    string
+	expiry  time.Duration
+	now     func() time.Time
+}
+
+// InitializeExternalShareService creates the external share service. The emails sent to the
+// approvers link to link followed by the ID of the request, e.g.
+// https://app.lockari.io/external-shares/. Expiry is the time to decide the requests that set
+// none, DefaultExpiry when zero.
+func InitializeExternalShareService(repo entity.ExternalShareRepository, tenants entity_tenant.TenantRepository, vaults entity_vault.VaultRepository, authz authorization.Authorizer, sender mailer.Sender, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, link string, expiry time.Duration) (entity.ExternalShareService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("ExternalShareRepository")
+	}
+
+	if tenants == nil {
+		return nil, core.ErrRepositoryNotFound("TenantRepository")
+	}
+
+	if vaults == nil {
+		return nil, core.ErrRepositoryNotFound("VaultRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if sender == nil {
+		return nil, core.ErrServiceNotFound("Sender")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	if _, err := url.ParseRequestURI(link); err != nil {
+		return nil, fmt.Errorf("invalid external share link %q: %w", link, err)
+	}
+
+	if expiry <= 0 {
+		expiry = entity.DefaultExpiry
+	}
+
+	if expiry > entity.MaxExpiry {
+		return nil, fmt.Errorf("external share expiry cannot exceed %s", entity.MaxExpiry)
+	}
+
+	return &externalShare{
+		repo:    repo,
+		tenants: tenants,
+		vaults:  vaults,
+		authz:   authz,
+		sender:  sender,
+		outbox:  outbox,
+		audit:   audit,
+		link:    link,
+		expiry:  expiry,
+		now:     func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+func (s *externalShare) Create(ctx context.Context, data *entity.ExternalShareRequest) (*entity.ExternalShareRequest, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("external share request is required")
+	}
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.VaultID == "" {
+		return nil, core.ErrInvalidRequest("vault ID is required")
+	}
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), authorization.CanShare, authorization.Object(authorization.TypeVault, data.VaultID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check vault permission: %w", err)
+	}
+
+	if !allowed {
+		return nil, core.ErrForbidden("user is not allowed to share vault " + data.VaultID)
+	}
+
+	vault, err := s.vaults.Get(ctx, data.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	if vault.TenantID != tenantID || vault.IsDeleted() {
+		return nil, core.ErrNotFound("vault " + data.VaultID)
+	}
+
+	tenant, err := s.tenants.Get(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tenant.HasCrossTenantSharing() {
+		return nil, core.ErrForbidden("sharing vaults with other tenants requires the enterprise plan")
+	}
+
+	expiry := time.Duration(data.ExpiresIn) * time.Hour
+	if expiry == 0 {
+		expiry = s.expiry
+	}
+	if expiry < 0 || expiry > entity.MaxExpiry {
+		return nil, core.ErrInvalidRequest(fmt.Sprintf("expiresIn must be between 1 and %d hours", int(entity.MaxExpiry.Hours())))
+	}
+
+	now := s.now()
+	request := entity.NewExternalShareRequest(utils.GenerateID(), *data, tenantID, vault.Name, userID, now, expiry)
+	if err := request.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if err := s.ensureTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureNotRequested(ctx, request, now); err != nil {
+		return nil, err
+	}
+
+	payload, err := utils.StructToMap(request)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert external share request data to map")
+	}
+
+	result, err := s.repo.Create(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, request)
+
+	s.record(ctx, entity_audit.EXTERNAL_SHARE_REQUESTED, userID, request, "request",
+		fmt.Sprintf("vault %s requested as %s for user %s of tenant %s", request.VaultID, request.Role, request.TargetUserID, request.ToTenantID))
+
+	return result, nil
+}
+
+func (s *externalShare) Get(ctx context.Context, id string) (*entity.ExternalShareRequest, error) {
+
+	_, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := request.Effective(s.now())
+	return &result, nil
+}
+
+// List returns the requests of the tenant, with the pending ones past their expiration as expired.
+func (s *externalShare) List(ctx context.Context, opts database.QueryOptions) ([]entity.ExternalShareRequest, string, error) {
+
+	if err := opts.Validate(entity.ExternalShareSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	_, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "fromTenantId",
+			Value:  tenantID,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	result, next, err := s.repo.List(ctx, filters, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now()
+	for i := range result {
+		result[i] = result[i].Effective(now)
+	}
+
+	return result, next, nil
+}
+
+func (s *externalShare) Approve(ctx context.Context, id string, comment string) (*entity.ExternalShareRequest, error) {
+
+	request, approver, err := s.decide(ctx, id, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := len(request.Approvals)
+	now := s.now()
+	request.Approvals = append(request.Approvals, entity.Approval{UserID: approver, Decision: entity.DECISION_APPROVED, Comment: comment, DecidedAt: now})
+	request.UpdatedAt = now
+
+	// The tuples are written only with the last approval, in the same transaction. The target
+	// also becomes a guest of the tenant, unless it already belongs to it, so it can switch to
+	// the tenant of the vault and use it.
+	var entry *entity_outbox.OutboxEntry
+	var guest *entity_tenant.Member
+	if len(request.Approvals) >= entity.RequiredApprovals {
+		request.Status = entity.STATUS_APPROVED
+
+		member, err := s.membership(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		tuples := request.Tuples()
+		if member == nil {
+			guest = entity_tenant.NewMember(request.FromTenantID, request.TargetUserID, "", "", entity_tenant.ROLE_GUEST, approver)
+			tuples = append(tuples, request.GuestTuple())
+		}
+		entry = entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, tuples, request.Object())
+	}
+
+	if err := s.transition(ctx, request, decisions, entry, guest, ""); err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		s.record(ctx, entity_audit.EXTERNAL_SHARE_APPROVED, approver, request, "approve",
+			fmt.Sprintf("approval %d of %d", len(request.Approvals), entity.RequiredApprovals))
+		return request, nil
+	}
+
+	// Apply the tuples right away so the guest can use the vault; on failure the relay retries.
+	if err := s.outbox.Dispatch(ctx, entry); err != nil {
+		log.Printf("External share request %s approved but permissions are pending: %v", request.ID, err)
+	}
+
+	s.record(ctx, entity_audit.EXTERNAL_SHARE_APPROVED, approver, request, "approve",
+		fmt.Sprintf("vault %s shared as %s with user %s of tenant %s", request.VaultID, request.Role, request.TargetUserID, request.ToTenantID))
+
+	return request, nil
+}
+
+func (s *externalShare) Reject(ctx context.Context, id string, comment string) (*entity.ExternalShareRequest, error) {
+
+	request, approver, err := s.decide(ctx, id, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := len(request.Approvals)
+	now := s.now()
+	request.Approvals = append(request.Approvals, entity.Approval{UserID: approver, Decision: entity.DECISION_REJECTED, Comment: comment, DecidedAt: now})
+	request.Status = entity.STATUS_REJECTED
+	request.UpdatedAt = now
+
+	if err := s.transition(ctx, request, decisions, nil, nil, ""); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entity_audit.EXTERNAL_SHARE_REJECTED, approver, request, "reject", "external share request rejected")
+
+	return request, nil
+}
+
+func (s *externalShare) Revoke(ctx context.Context, id string) (*entity.ExternalShareRequest, error) {
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.RequesterID != userID {
+		if _, err := s.manager(ctx, tenantID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := s.now()
+	status := request.Status
+	if status != entity.STATUS_APPROVED && !request.IsPending(now) {
+		return nil, core.ErrConflict("external share request " + id + " is " + string(request.Effective(now).Status))
+	}
+
+	var entry *entity_outbox.OutboxEntry
+	removeGuest := ""
+	if status == entity.STATUS_APPROVED {
+		last, err := s.lastGuestShare(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		tuples := request.Tuples()
+		if last {
+			removeGuest = entity_tenant.MemberID(request.FromTenantID, request.TargetUserID)
+			tuples = append(tuples, request.GuestTuple())
+		}
+		entry = entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_DELETE, tuples, request.Object())
+	}
+
+	request.Status = entity.STATUS_REVOKED
+	request.RevokedBy = userID
+	request.RevokedAt = &now
+	request.UpdatedAt = now
+
+	if err := s.transition(ctx, request, len(request.Approvals), entry, nil, removeGuest, status); err != nil {
+		return nil, err
+	}
+
+	if entry != nil {
+		if err := s.outbox.Dispatch(ctx, entry); err != nil {
+			log.Printf("External share request %s revoked but permissions are pending: %v", request.ID, err)
+		}
+	}
+
+	s.record(ctx, entity_audit.EXTERNAL_SHARE_REVOKED, userID, request, "revoke", "external share request revoked")
+
+	return request, nil
+}
+
+// decide returns the pending request and the user of the context, which must be an admin of the
+// tenant of the vault other than the requester that did not decide yet.
+func (s *externalShare) decide(ctx context.Context, id, comment string) (*entity.ExternalShareRequest, string, error) {
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(comment) > 500 {
+		return nil, "", core.ErrInvalidRequest("comment must have at most 500 characters")
+	}
+
+	request, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err := s.manager(ctx, tenantID, userID); err != nil {
+		return nil, "", err
+	}
+
+	if request.RequesterID == userID {
+		return nil, "", core.ErrForbidden("the requester cannot decide on its own request")
+	}
+
+	if now := s.now(); !request.IsPending(now) {
+		return nil, "", core.ErrConflict("external share request " + id + " is " + string(request.Effective(now).Status))
+	}
+
+	if request.HasDecided(userID) {
+		return nil, "", core.ErrConflict("user already decided on external share request " + id)
+	}
+
+	return request, userID, nil
+}
+
+// transition stores the new status and decisions of the request if it is still in the given
+// status, pending by default, with the decisions it had when read, and creates the guest
+// membership or removes the one with ID removeGuest.
+func (s *externalShare) transition(ctx context.Context, request *entity.ExternalShareRequest, decisions int, entry *entity_outbox.OutboxEntry, guest *entity_tenant.Member, removeGuest string, from ...entity.Status) error {
+
+	status := entity.STATUS_PENDING
+	if len(from) > 0 {
+		status = from[0]
+	}
+
+	fields := map[string]interface{}{
+		"status":    request.Status,
+		"approvals": request.Approvals,
+		"updatedAt": request.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	if request.RevokedAt != nil {
+		fields["revokedBy"] = request.RevokedBy
+		fields["revokedAt"] = request.RevokedAt.Format(time.RFC3339Nano)
+	}
+
+	patch, err := utils.StructToMap(fields)
+	if err != nil {
+		return core.ErrGenericError("Failed to convert external share request data to map")
+	}
+
+	var entryPayload map[string]interface{}
+	if entry != nil {
+		entryPayload, err = utils.StructToMap(entry)
+		if err != nil {
+			return core.ErrGenericError("Failed to convert outbox entry to map")
+		}
+	}
+
+	var guestPayload map[string]interface{}
+	if guest != nil {
+		guestPayload, err = utils.StructToMap(guest)
+		if err != nil {
+			return core.ErrGenericError("Failed to convert member data to map")
+		}
+	}
+
+	return s.repo.Transition(ctx, request.ID, status, decisions, patch, entryPayload, guestPayload, removeGuest)
+}
+
+// membership returns the membership of the target user in the tenant of the vault, or nil
+// when it has none.
+func (s *externalShare) membership(ctx context.Context, request *entity.ExternalShareRequest) (*entity_tenant.Member, error) {
+
+	member, err := s.tenants.GetMember(ctx, request.FromTenantID, request.TargetUserID)
+	if err != nil {
+		if core.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// lastGuestShare reports whether the target user is a guest of the tenant of the vault only
+// through this request, so revoking it also removes the guest membership.
+func (s *externalShare) lastGuestShare(ctx context.Context, request *entity.ExternalShareRequest) (bool, error) {
+
+	member, err := s.membership(ctx, request)
+	if err != nil || member == nil || member.Role != entity_tenant.ROLE_GUEST {
+		return false, err
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "fromTenantId",
+			Value:  request.FromTenantID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "targetUserId",
+			Value:  request.TargetUserID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "status",
+			Value:  string(entity.STATUS_APPROVED),
+			Filter: database.FilterEquals,
+		},
+	}
+
+	opts := database.QueryOptions{PageSize: database.MaxPageSize}
+	for {
+		approved, next, err := s.repo.List(ctx, filters, opts)
+		if err != nil {
+			return false, err
+		}
+
+		for _, other := range approved {
+			if other.ID != request.ID {
+				return false, nil
+			}
+		}
+
+		if next == "" {
+			return true, nil
+		}
+		opts.PageToken = next
+	}
+}
+
+func (s *externalShare) identity(ctx context.Context) (string, string, error) {
+
+	if ctx.Err() != nil {
+		return "", "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	return userID, tenantID, nil
+}
+
+// get returns the request, only if it shares a vault of the tenant.
+func (s *externalShare) get(ctx context.Context, tenantID, id string) (*entity.ExternalShareRequest, error) {
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("external share request ID is required")
+	}
+
+	request, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.FromTenantID != tenantID {
+		return nil, core.ErrNotFound("external share request " + id)
+	}
+
+	return request, nil
+}
+
+// manager returns the membership of the user, which must be an active owner or admin of the tenant.
+func (s *externalShare) manager(ctx context.Context, tenantID, userID string) (*entity_tenant.Member, error) {
+
+	member, err := s.tenants.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if core.IsNotFound(err) {
+			return nil, core.ErrForbidden("user is not a member of tenant " + tenantID)
+		}
+		return nil, err
+	}
+
+	if !member.IsActive() || !member.Role.CanManage() {
+		return nil, core.ErrForbidden("only owners and admins can decide on external share requests")
+	}
+
+	return member, nil
+}
+
+// ensureTarget checks that the target tenant exists and that the user is one of its active members.
+func (s *externalShare) ensureTarget(ctx context.Context, request *entity.ExternalShareRequest) error {
+
+	if _, err := s.tenants.Get(ctx, request.ToTenantID); err != nil {
+		if core.IsNotFound(err) {
+			return core.ErrInvalidRequest("tenant " + request.ToTenantID + " not found")
+		}
+		return err
+	}
+
+	member, err := s.tenants.GetMember(ctx, request.ToTenantID, request.TargetUserID)
+	if err != nil {
+		if core.IsNotFound(err) {
+			return core.ErrInvalidRequest("user " + request.TargetUserID + " is not a member of tenant " + request.ToTenantID)
+		}
+		return err
+	}
+
+	if !member.IsActive() {
+		return core.ErrInvalidRequest("user " + request.TargetUserID + " is not an active member of tenant " + request.ToTenantID)
+	}
+
+	return nil
+}
+
+// ensureNotRequested rejects a request for a user that already has a pending or approved
+// request on the vault.
+func (s *externalShare) ensureNotRequested(ctx context.Context, request *entity.ExternalShareRequest, now time.Time) error {
+
+	filters := []database.Conditional{
+		{
+			Field:  "vaultId",
+			Value:  request.VaultID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "targetUserId",
+			Value:  request.TargetUserID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "status",
+			Value:  []string{string(entity.STATUS_PENDING), string(entity.STATUS_APPROVED)},
+			Filter: database.FilterIn,
+		},
+	}
+
+	existing, _, err := s.repo.List(ctx, filters, database.QueryOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range existing {
+		if e.Status == entity.STATUS_APPROVED || e.IsPending(now) {
+			return core.ErrConflict("user " + request.TargetUserID + " already has a " + string(e.Status) + " request for vault " + request.VaultID)
+		}
+	}
+
+	return nil
+}
+
+// notify emails the owners and admins of the tenant that can approve the request. Failures are
+// only logged: the requests are listed for the admins anyway.
+func (s *externalShare) notify(ctx context.Context, request *entity.ExternalShareRequest) {
+
+	filters := []database.Conditional{
+		{
+			Field:  "tenantId",
+			Value:  request.FromTenantID,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	opts := database.QueryOptions{PageSize: database.MaxPageSize}
+	for {
+		members, next, err := s.tenants.ListMembers(ctx, filters, opts)
+		if err != nil {
+			log.Printf("Failed to list approvers of external share request %s: %v", request.ID, err)
+			return
+		}
+
+		for _, m := range members {
+			if m.UserID == request.RequesterID || m.Email == "" || !m.IsActive() || !m.Role.CanManage() {
+				continue
+			}
+			if err := s.sender.Send(ctx, s.message(request, m.Email)); err != nil {
+				log.Printf("Failed to notify %s of external share request %s: %v", m.UserID, request.ID, err)
+			}
+		}
+
+		if next == "" {
+			return
+		}
+		opts.PageToken = next
+	}
+}
+
+func (s *externalShare) message(request *entity.ExternalShareRequest, to string) mailer.Message {
+
+	return mailer.Message{
+		To:      to,
+		Subject: "Approval required: share " + request.VaultName + " with another tenant",
+		Body: fmt.Sprintf("%s requested to share the vault %s as %s with user %s of tenant %s.\n\n%s\n\nReview the request: %s%s\n\nIt needs %d approvals before %s.\n",
+			request.RequesterID, request.VaultName, request.Role, request.TargetUserID, request.ToTenantID,
+			request.Message, s.link, url.PathEscape(request.ID), entity.RequiredApprovals, request.ExpiresAt.Format(time.RFC1123)),
+	}
+}
+
+func (s *externalShare) record(ctx context.Context, eventType entity_audit.EventType, userID string, request *entity.ExternalShareRequest, action, reason string) {
+
+	event := entity_audit.NewResourceEvent(eventType, userID, request.FromTenantID, request.Object(), action, reason)
+	if _, err := s.audit.Record(context.WithValue(context.WithoutCancel(ctx), "TenantID", request.FromTenantID), event); err != nil {
+		log.Printf("Failed to record %s event for external share request %s: %v", eventType, request.ID, err)
+	}
+}
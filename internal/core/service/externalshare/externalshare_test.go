@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/externalshare"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_secret "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	entity_share "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_externalshare "github.com/synera-br/lockari-backend-app/internal/core/repository/externalshare"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	repo_secret "github.com/synera-br/lockari-backend-app/internal/core/repository/secret"
+	repo_tenant "github.com/synera-br/lockari-backend-app/internal/core/repository/tenant"
+	repo_trash "github.com/synera-br/lockari-backend-app/internal/core/repository/trash"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	svc_secret "github.com/synera-br/lockari-backend-app/internal/core/service/secret"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	svc_tenant "github.com/synera-br/lockari-backend-app/internal/core/service/tenant"
+	svc_trash "github.com/synera-br/lockari-backend-app/internal/core/service/trash"
+	svc_vault "github.com/synera-br/lockari-backend-app/internal/core/service/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+// storageKey is the base64 of a 32 bytes key.
+const storageKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestExternalShareApproval(t *testing.T) {
+	as := func(userID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", "t1")
+	}
+	from := func(userID, tenantID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", tenantID)
+	}
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+
+	tenants, err := repo_tenant.InitializeTenantRepository(db)
+	require.NoError(t, err)
+	done := func(id string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "status": entity_outbox.OUTBOX_DONE}
+	}
+	for _, tn := range []struct{ id, plan, owner string }{{"t1", entity_tenant.PLAN_ENTERPRISE, "alice"}, {"t2", entity_tenant.PLAN_FREE, "dave"}} {
+		tenant, err := utils.StructToMap(entity_tenant.NewTenant(tn.id, tn.id, tn.plan, tn.owner))
+		require.NoError(t, err)
+		member := entity_tenant.NewMember(tn.id, tn.owner, tn.owner+"@"+tn.id+".io", "", entity_tenant.ROLE_OWNER, tn.owner)
+		owner, err := utils.StructToMap(member)
+		require.NoError(t, err)
+		_, err = tenants.Create(context.Background(), tenant, owner, done("e-"+tn.id))
+		require.NoError(t, err)
+		require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{member.RoleTuple()}))
+	}
+	for _, m := range []*entity_tenant.Member{
+		entity_tenant.NewMember("t1", "bob", "bob@t1.io", "", entity_tenant.ROLE_ADMIN, "alice"),
+		entity_tenant.NewMember("t1", "carol", "carol@t1.io", "", entity_tenant.ROLE_ADMIN, "alice"),
+		entity_tenant.NewMember("t1", "erin", "erin@t1.io", "", entity_tenant.ROLE_MEMBER, "alice"),
+	} {
+		member, err := utils.StructToMap(m)
+		require.NoError(t, err)
+		_, err = tenants.AddMember(context.Background(), member, done("e-"+m.UserID))
+		require.NoError(t, err)
+		require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{m.RoleTuple()}))
+	}
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	require.NoError(t, err)
+	payload, err := utils.StructToMap(entity_vault.NewVault(entity_vault.Vault{Name: "Production"}, "t1", "alice"))
+	require.NoError(t, err)
+	vault, err := vaults.Create(as("alice"), payload)
+	require.NoError(t, err)
+	require.NoError(t, authz.WriteTuples(context.Background(), vault.OwnershipTuples()))
+
+	// The services the guest uses once the share is approved.
+	trashRepo, err := repo_trash.InitializeTrashRepository(db)
+	require.NoError(t, err)
+	trash, err := svc_trash.InitializeTrashService(trashRepo, authz, &servicetest.Audit{}, time.Hour)
+	require.NoError(t, err)
+	vaultSvc, err := svc_vault.InitializeVaultService(vaults, authz, outbox, trash)
+	require.NoError(t, err)
+	key := storageKey
+	storage, err := cryptserver.InicializationStorageCrypt(&key)
+	require.NoError(t, err)
+	secretRepo, err := repo_secret.InitializeSecretRepository(db)
+	require.NoError(t, err)
+	secrets, err := svc_secret.InitializeSecretService(secretRepo, vaultSvc, storage, authz, trash)
+	require.NoError(t, err)
+	auth := &servicetest.Auth{Claims: map[string]string{}}
+	tenantSvc, err := svc_tenant.InitializeTenantService(tenants, auth, authz, outbox, &servicetest.Audit{})
+	require.NoError(t, err)
+
+	secret, err := secrets.Create(as("alice"), vault.ID, &entity_secret.Secret{Name: "db", Type: entity_secret.SECRET_PASSWORD, Value: entity_secret.SecretValue(`{"password":"s3cret"}`)})
+	require.NoError(t, err)
+
+	repo, err := repo_externalshare.InitializeExternalShareRepository(db)
+	require.NoError(t, err)
+	sender := &servicetest.Sender{}
+	audit := &servicetest.Audit{}
+	svc, err := InitializeExternalShareService(repo, tenants, vaults, authz, sender, outbox, audit, "https://app.lockari.io/external-shares/", 0)
+	require.NoError(t, err)
+
+	_, err = svc.Create(as("alice"), &entity.ExternalShareRequest{VaultID: vault.ID, ToTenantID: "t2", TargetUserID: "dave", Role: entity_share.ROLE_WRITER})
+	assert.True(t, core.IsInvalidRequest(err), "external guests only get read roles")
+
+	_, err = svc.Create(as("alice"), &entity.ExternalShareRequest{VaultID: vault.ID, ToTenantID: "t2", TargetUserID: "erin", Role: entity_share.ROLE_READER})
+	assert.True(t, core.IsInvalidRequest(err), "the target user must be a member of the target tenant")
+
+	_, err = svc.Create(as("erin"), &entity.ExternalShareRequest{VaultID: vault.ID, ToTenantID: "t2", TargetUserID: "dave", Role: entity_share.ROLE_READER})
+	assert.True(t, core.IsForbidden(err), "only users that can share the vault can request")
+
+	request, err := svc.Create(as("alice"), &entity.ExternalShareRequest{VaultID: vault.ID, ToTenantID: "t2", TargetUserID: "dave", Role: entity_share.ROLE_READER})
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_PENDING, request.Status)
+	assert.Equal(t, "Production", request.VaultName)
+	require.Len(t, sender.Messages, 2, "owners and admins other than the requester are notified")
+
+	_, err = svc.Create(as("alice"), &entity.ExternalShareRequest{VaultID: vault.ID, ToTenantID: "t2", TargetUserID: "dave", Role: entity_share.ROLE_VIEWER})
+	assert.True(t, core.IsConflict(err))
+
+	_, err = svc.Approve(as("alice"), request.ID, "")
+	assert.True(t, core.IsForbidden(err), "the requester cannot approve")
+
+	_, err = svc.Approve(as("erin"), request.ID, "")
+	assert.True(t, core.IsForbidden(err), "only owners and admins approve")
+
+	approved, err := svc.Approve(as("bob"), request.ID, "ok")
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_PENDING, approved.Status)
+
+	_, err = svc.Approve(as("bob"), request.ID, "")
+	assert.True(t, core.IsConflict(err), "each admin approves once")
+
+	allowed, err := authz.Check(context.Background(), authorization.User("dave"), "can_read_external", vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.False(t, allowed, "the access waits for the second approval")
+
+	approved, err = svc.Approve(as("carol"), request.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_APPROVED, approved.Status)
+
+	allowed, err = authz.Check(context.Background(), authorization.User("dave"), "can_read_external", vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Dave becomes a guest of t1, switches to it and reads the secret of the shared vault.
+	guest, err := tenantSvc.Switch(from("dave", "t2"), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, entity_tenant.ROLE_GUEST, guest.Role)
+	assert.Equal(t, "t1", auth.Claims["dave"])
+	allowed, err = authz.Check(context.Background(), authorization.User("dave"), string(entity_tenant.ROLE_GUEST), authorization.Object(authorization.TypeTenant, "t1"))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	revealed, err := secrets.Reveal(from("dave", "t1"), vault.ID, secret.ID)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"password":"s3cret"}`, string(revealed.Value))
+
+	revoked, err := svc.Revoke(as("bob"), request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_REVOKED, revoked.Status)
+
+	allowed, err = authz.Check(context.Background(), authorization.User("dave"), "can_read_external", vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = tenantSvc.Switch(from("dave", "t2"), "t1")
+	assert.True(t, core.IsNotFound(err), "revoking the last share removes the guest membership")
+	allowed, err = authz.Check(context.Background(), authorization.User("dave"), string(entity_tenant.ROLE_GUEST), authorization.Object(authorization.TypeTenant, "t1"))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	_, err = secrets.Reveal(from("dave", "t1"), vault.ID, secret.ID)
+	assert.True(t, core.IsForbidden(err))
+
+	// A rejection closes the request, and pending requests expire.
+	rejected, err := svc.Create(as("alice"), &entity.ExternalShareRequest{VaultID: vault.ID, ToTenantID: "t2", TargetUserID: "dave", Role: entity_share.ROLE_VIEWER})
+	require.NoError(t, err)
+	rejected, err = svc.Reject(as("carol"), rejected.ID, "not needed")
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_REJECTED, rejected.Status)
+	_, err = svc.Approve(as("bob"), rejected.ID, "")
+	assert.True(t, core.IsConflict(err))
+
+	expiring, err := svc.Create(as("alice"), &entity.ExternalShareRequest{VaultID: vault.ID, ToTenantID: "t2", TargetUserID: "dave", Role: entity_share.ROLE_VIEWER, ExpiresIn: 1})
+	require.NoError(t, err)
+	svc.(*externalShare).now = func() time.Time { return time.Now().UTC().Add(2 * time.Hour) }
+	_, err = svc.Approve(as("bob"), expiring.ID, "")
+	assert.True(t, core.IsConflict(err))
+	expired, err := svc.Get(as("bob"), expiring.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_EXPIRED, expired.Status)
+
+	assert.Len(t, audit.Events, 7)
+}
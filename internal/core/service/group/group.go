@@ -0,0 +1,544 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type group struct {
+	repo   entity.GroupRepository
+	authz  authorization.Authorizer
+	outbox entity_outbox.OutboxService
+	audit  entity_audit.AuditSystemEventService
+}
+
+func InitializeGroupService(repo entity.GroupRepository, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (entity.GroupService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("GroupRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	return &group{
+		repo:   repo,
+		authz:  authz,
+		outbox: outbox,
+		audit:  audit,
+	}, nil
+}
+
+// Create creates a group in the tenant of the context. The creator becomes its owner.
+func (s *group) Create(ctx context.Context, data *entity.Group) (*entity.Group, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("group is required")
+	}
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newGroup := entity.NewGroup(*data, tenantID, userID)
+	if err := newGroup.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	// The ID is generated here so the owner and the outbox entry can reference the group
+	// and every document is written in the same batch.
+	newGroup.ID = utils.GenerateID()
+	owner := entity.NewMember(newGroup.ID, entity.MEMBER_USER, userID, entity.ROLE_OWNER, userID)
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE,
+		[]authorization.TupleKey{newGroup.TenantTuple(), owner.Tuple()}, newGroup.Object())
+
+	payload, err := utils.StructToMap(newGroup)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert group data to map")
+	}
+
+	ownerPayload, err := utils.StructToMap(owner)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert member data to map")
+	}
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	result, err := s.repo.Create(ctx, payload, ownerPayload, entryPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dispatch(ctx, entry)
+	s.record(ctx, entity_audit.GROUP_CREATED, userID, tenantID, result.Object(), "create", "group "+result.Name+" created")
+
+	return result, nil
+}
+
+func (s *group) Get(ctx context.Context, id string) (*entity.Group, error) {
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("group ID is required")
+	}
+
+	if _, _, err := s.identity(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Get(ctx, id)
+}
+
+func (s *group) List(ctx context.Context, opts database.QueryOptions) ([]entity.Group, string, error) {
+
+	if err := opts.Validate(entity.GroupSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	if _, _, err := s.identity(ctx); err != nil {
+		return nil, "", err
+	}
+
+	return s.repo.List(ctx, []database.Conditional{}, opts)
+}
+
+// ListForUser returns the groups of the tenant of the context where the user is an active member.
+func (s *group) ListForUser(ctx context.Context, userID string) ([]entity.Group, error) {
+
+	actorID, _, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID == "" {
+		userID = actorID
+	}
+
+	objects, err := s.authz.ListObjects(ctx, authorization.User(userID), entity.RelationActiveMember, authorization.TypeGroup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups of user %s: %w", userID, err)
+	}
+
+	// The authorization model holds the groups of every tenant; only those found in the tenant are returned.
+	groups := []entity.Group{}
+	for _, id := range authorization.ObjectIDs(objects) {
+		g, err := s.repo.Get(ctx, id)
+		if err != nil {
+			if core.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		groups = append(groups, *g)
+	}
+
+	return groups, nil
+}
+
+func (s *group) Update(ctx context.Context, id string, data *entity.Group) (*entity.Group, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("group is required")
+	}
+
+	current, userID, err := s.manage(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := current.Name
+	current.Name = strings.TrimSpace(data.Name)
+	current.Description = data.Description
+	current.UpdatedAt = time.Now().UTC()
+
+	if err := current.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	payload, err := utils.StructToMap(current)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert group data to map")
+	}
+
+	if err := s.repo.Update(ctx, current.ID, payload); err != nil {
+		return nil, err
+	}
+
+	reason := "group " + current.Name + " updated"
+	if previous != current.Name {
+		reason = "group " + previous + " renamed to " + current.Name
+	}
+	s.record(ctx, entity_audit.GROUP_MODIFIED, userID, current.TenantID, current.Object(), "update", reason)
+
+	return current, nil
+}
+
+// Delete removes the group, its members and every grant given to its active members.
+func (s *group) Delete(ctx context.Context, id string) error {
+
+	current, userID, err := s.manage(ctx, id, true)
+	if err != nil {
+		return err
+	}
+
+	// Tuples of the group itself (its tenant and members) and tuples granted to the group,
+	// such as vault roles and memberships in parent groups.
+	own, err := s.authz.ReadTuples(ctx, authorization.TupleKey{Object: current.Object()})
+	if err != nil {
+		return fmt.Errorf("failed to read tuples of group %s: %w", id, err)
+	}
+
+	// OpenFGA only reads the tuples of a user together with an object type.
+	tuples := own
+	for _, objectType := range []string{authorization.TypeVault, authorization.TypeGroup} {
+		granted, err := s.authz.ReadTuples(ctx, authorization.TupleKey{User: current.Userset(), Object: objectType + ":"})
+		if err != nil {
+			return fmt.Errorf("failed to read grants of group %s: %w", id, err)
+		}
+		tuples = append(tuples, granted...)
+	}
+
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_DELETE, tuples, current.Object())
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	if err := s.repo.Delete(ctx, current.ID, entryPayload); err != nil {
+		return err
+	}
+
+	s.dispatch(ctx, entry)
+	s.record(ctx, entity_audit.GROUP_DELETED, userID, current.TenantID, current.Object(), "delete", "group "+current.Name+" deleted")
+
+	return nil
+}
+
+func (s *group) ListMembers(ctx context.Context, id string, opts database.QueryOptions) ([]entity.Member, string, error) {
+
+	if err := opts.Validate(entity.MemberSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, "", err
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "groupId",
+			Value:  id,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	return s.repo.ListMembers(ctx, filters, opts)
+}
+
+// AddMember adds a user of the tenant or another group of the tenant to the group. Only owners
+// of the group and managers of the tenant can add owners and admins.
+func (s *group) AddMember(ctx context.Context, id string, data *entity.Member) (*entity.Member, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("member is required")
+	}
+
+	current, userID, err := s.manage(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	member := entity.NewMember(current.ID, data.Type, strings.TrimSpace(data.MemberID), data.Role, userID)
+	if err := member.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if member.Role != entity.ROLE_MEMBER {
+		allowed, err := s.can(ctx, userID, current, true)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to add a member with role %s", member.Role))
+		}
+	}
+
+	switch member.Type {
+	case entity.MEMBER_USER:
+		if err := s.ensureTenantMember(ctx, current.TenantID, member.MemberID); err != nil {
+			return nil, err
+		}
+	case entity.MEMBER_GROUP:
+		if _, err := s.repo.Get(ctx, member.MemberID); err != nil {
+			if core.IsNotFound(err) {
+				return nil, core.ErrInvalidRequest("group " + member.MemberID + " not found in the tenant")
+			}
+			return nil, err
+		}
+		if err := s.ensureNoCycle(ctx, current.ID, member.MemberID); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{member.Tuple()}, current.Object())
+
+	payload, err := utils.StructToMap(member)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert member data to map")
+	}
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	result, err := s.repo.AddMember(ctx, payload, entryPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dispatch(ctx, entry)
+	s.record(ctx, entity_audit.GROUP_MEMBER_ADDED, userID, current.TenantID, current.Object(), "add_member",
+		fmt.Sprintf("%s %s added as %s", member.Type, member.MemberID, member.Role))
+
+	return result, nil
+}
+
+// RemoveMember removes a user or a nested group from the group. Users can always leave a group.
+func (s *group) RemoveMember(ctx context.Context, id string, memberType entity.MemberType, memberID string) error {
+
+	if memberType != entity.MEMBER_USER && memberType != entity.MEMBER_GROUP {
+		return core.ErrInvalidRequest("member type must be user or group")
+	}
+
+	if memberID == "" {
+		return core.ErrInvalidRequest("member ID is required")
+	}
+
+	userID, _, err := s.identity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current *entity.Group
+	if memberType == entity.MEMBER_USER && memberID == userID {
+		current, err = s.Get(ctx, id)
+	} else {
+		current, _, err = s.manage(ctx, id, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	member, err := s.repo.GetMember(ctx, entity.MemberID(current.ID, memberType, memberID))
+	if err != nil {
+		if core.IsNotFound(err) {
+			return core.ErrNotFound(fmt.Sprintf("member %s %s", memberType, memberID))
+		}
+		return err
+	}
+
+	if member.Role == entity.ROLE_OWNER && memberID != userID {
+		allowed, err := s.can(ctx, userID, current, true)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return core.ErrForbidden("only owners of the group can remove an owner")
+		}
+	}
+
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_DELETE, []authorization.TupleKey{member.Tuple()}, current.Object())
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	if err := s.repo.RemoveMember(ctx, member.ID, entryPayload); err != nil {
+		return err
+	}
+
+	s.dispatch(ctx, entry)
+	s.record(ctx, entity_audit.GROUP_MEMBER_REMOVED, userID, current.TenantID, current.Object(), "remove_member",
+		fmt.Sprintf("%s %s removed", member.Type, member.MemberID))
+
+	return nil
+}
+
+// identity returns the user and tenant of the context, once the user is an active member of the tenant.
+func (s *group) identity(ctx context.Context) (string, string, error) {
+
+	if ctx.Err() != nil {
+		return "", "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	if err := s.ensureTenantMember(ctx, tenantID, userID); err != nil {
+		return "", "", core.ErrForbidden("user is not an active member of tenant " + tenantID)
+	}
+
+	return userID, tenantID, nil
+}
+
+func (s *group) ensureTenantMember(ctx context.Context, tenantID, userID string) error {
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), "active_member", authorization.Object(authorization.TypeTenant, tenantID))
+	if err != nil {
+		return fmt.Errorf("failed to check tenant membership: %w", err)
+	}
+
+	if !allowed {
+		return core.ErrInvalidRequest("user " + userID + " is not an active member of the tenant")
+	}
+
+	return nil
+}
+
+// manage returns the group if the user of the context can manage it. Owners of the tenant and
+// of the group can do anything; admins of either can manage members and, unless ownerOnly, the group.
+func (s *group) manage(ctx context.Context, id string, ownerOnly bool) (*entity.Group, string, error) {
+
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	userID, _ := utils.GetUserIDFromContext(ctx)
+	allowed, err := s.can(ctx, userID, current, ownerOnly)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !allowed {
+		return nil, "", core.ErrForbidden("user is not allowed to manage group " + id)
+	}
+
+	return current, userID, nil
+}
+
+// can reports whether the user is an owner (or, unless ownerOnly, an admin) of the group, or a
+// manager of its tenant.
+func (s *group) can(ctx context.Context, userID string, g *entity.Group, ownerOnly bool) (bool, error) {
+
+	user := authorization.User(userID)
+	tenant := authorization.Object(authorization.TypeTenant, g.TenantID)
+	checks := []authorization.TupleKey{
+		{User: user, Relation: authorization.RelationOwner, Object: g.Object()},
+		{User: user, Relation: authorization.RelationOwner, Object: tenant},
+		{User: user, Relation: authorization.RelationAdmin, Object: tenant},
+	}
+	if !ownerOnly {
+		checks = append(checks, authorization.TupleKey{User: user, Relation: authorization.RelationAdmin, Object: g.Object()})
+	}
+
+	results, err := s.authz.BatchCheck(ctx, checks)
+	if err != nil {
+		return false, fmt.Errorf("failed to check group permission: %w", err)
+	}
+
+	for _, allowed := range results {
+		if allowed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ensureNoCycle rejects adding child to parent when parent is already a member of child,
+// directly or through nested groups.
+func (s *group) ensureNoCycle(ctx context.Context, parentID, childID string) error {
+
+	visited := map[string]bool{childID: true}
+	queue := []string{childID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		filters := []database.Conditional{
+			{
+				Field:  "groupId",
+				Value:  id,
+				Filter: database.FilterEquals,
+			},
+			{
+				Field:  "type",
+				Value:  string(entity.MEMBER_GROUP),
+				Filter: database.FilterEquals,
+			},
+		}
+
+		opts := database.QueryOptions{PageSize: database.MaxPageSize}
+		for {
+			members, next, err := s.repo.ListMembers(ctx, filters, opts)
+			if err != nil {
+				return err
+			}
+
+			for _, m := range members {
+				if m.MemberID == parentID {
+					return core.ErrConflict(fmt.Sprintf("group %s is already a member of group %s", parentID, childID))
+				}
+				if !visited[m.MemberID] {
+					visited[m.MemberID] = true
+					queue = append(queue, m.MemberID)
+				}
+			}
+
+			if next == "" {
+				break
+			}
+			opts.PageToken = next
+		}
+	}
+
+	return nil
+}
+
+func (s *group) dispatch(ctx context.Context, entry *entity_outbox.OutboxEntry) {
+	if err := s.outbox.Dispatch(ctx, entry); err != nil {
+		log.Printf("Membership of %s changed but permissions are pending: %v", entry.Source, err)
+	}
+}
+
+func (s *group) record(ctx context.Context, eventType entity_audit.EventType, userID, tenantID, resource, action, reason string) {
+
+	event := entity_audit.NewResourceEvent(eventType, userID, tenantID, resource, action, reason)
+	if _, err := s.audit.Record(context.WithValue(context.WithoutCancel(ctx), "TenantID", tenantID), event); err != nil {
+		log.Printf("Failed to record %s event for %s: %v", eventType, resource, err)
+	}
+}
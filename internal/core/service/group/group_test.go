@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo_group "github.com/synera-br/lockari-backend-app/internal/core/repository/group"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+func TestGroupNestedMembership(t *testing.T) {
+	as := func(userID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", "t1")
+	}
+	check := func(authz authorization.Authorizer, userID, relation, object string) bool {
+		allowed, err := authz.Check(context.Background(), authorization.User(userID), relation, object)
+		require.NoError(t, err)
+		return allowed
+	}
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	tenant := authorization.Object(authorization.TypeTenant, "t1")
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: tenant},
+		{User: authorization.User("bob"), Relation: authorization.RelationMember, Object: tenant},
+		{User: authorization.User("carol"), Relation: authorization.RelationMember, Object: tenant},
+	}))
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+
+	repo, err := repo_group.InitializeGroupRepository(db)
+	require.NoError(t, err)
+	audit := &servicetest.Audit{}
+
+	svc, err := InitializeGroupService(repo, authz, outbox, audit)
+	require.NoError(t, err)
+
+	eng, err := svc.Create(as("alice"), &entity.Group{Name: " Engineering "})
+	require.NoError(t, err)
+	assert.Equal(t, "Engineering", eng.Name)
+	assert.True(t, check(authz, "alice", entity.RelationActiveMember, eng.Object()))
+
+	platform, err := svc.Create(as("carol"), &entity.Group{Name: "Platform"})
+	require.NoError(t, err)
+
+	_, err = svc.AddMember(as("alice"), eng.ID, &entity.Member{Type: entity.MEMBER_USER, MemberID: "bob"})
+	require.NoError(t, err)
+
+	_, err = svc.AddMember(as("alice"), eng.ID, &entity.Member{Type: entity.MEMBER_USER, MemberID: "dave"})
+	assert.True(t, core.IsInvalidRequest(err), "only members of the tenant can join a group")
+
+	_, err = svc.AddMember(as("bob"), eng.ID, &entity.Member{Type: entity.MEMBER_USER, MemberID: "carol"})
+	assert.True(t, core.IsForbidden(err), "plain members cannot manage the group")
+
+	// Carol owns platform and nests engineering in it, so bob reaches platform and its vault.
+	_, err = svc.AddMember(as("carol"), platform.ID, &entity.Member{Type: entity.MEMBER_GROUP, MemberID: eng.ID})
+	require.NoError(t, err)
+	assert.True(t, check(authz, "bob", entity.RelationActiveMember, platform.Object()))
+
+	vault := authorization.Object(authorization.TypeVault, "v1")
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: tenant, Relation: authorization.RelationTenant, Object: vault},
+		{User: platform.Userset(), Relation: "reader", Object: vault},
+	}))
+	assert.True(t, check(authz, "bob", authorization.CanRead, vault))
+
+	_, err = svc.AddMember(as("alice"), eng.ID, &entity.Member{Type: entity.MEMBER_GROUP, MemberID: platform.ID})
+	assert.True(t, core.IsConflict(err), "nested groups cannot form a cycle")
+
+	groups, err := svc.ListForUser(as("alice"), "bob")
+	require.NoError(t, err)
+	assert.Len(t, groups, 2)
+
+	members, _, err := svc.ListMembers(as("bob"), platform.ID, database.QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	renamed, err := svc.Update(as("alice"), eng.ID, &entity.Group{Name: "Core Engineering"})
+	require.NoError(t, err)
+	assert.Equal(t, "Core Engineering", renamed.Name)
+
+	direct := authorization.Object(authorization.TypeVault, "v2")
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: tenant, Relation: authorization.RelationTenant, Object: direct},
+		{User: eng.Userset(), Relation: "writer", Object: direct},
+	}))
+	assert.True(t, check(authz, "bob", authorization.CanWrite, direct))
+
+	// Deleting engineering removes its members, its membership in platform and its vault roles.
+	require.NoError(t, svc.Delete(as("alice"), eng.ID))
+	assert.False(t, check(authz, "bob", authorization.CanRead, vault))
+	assert.False(t, check(authz, "bob", authorization.CanWrite, direct))
+	remaining, err := authz.ReadTuples(context.Background(), authorization.TupleKey{Object: direct})
+	require.NoError(t, err)
+	assert.Equal(t, []authorization.TupleKey{{User: tenant, Relation: authorization.RelationTenant, Object: direct}}, remaining)
+	assert.False(t, check(authz, "alice", entity.RelationActiveMember, eng.Object()))
+
+	_, err = svc.Get(as("alice"), eng.ID)
+	assert.True(t, core.IsNotFound(err))
+
+	members, _, err = svc.ListMembers(as("carol"), platform.ID, database.QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	assert.Equal(t, "carol", members[0].MemberID)
+
+	require.NoError(t, svc.RemoveMember(as("carol"), platform.ID, entity.MEMBER_USER, "carol"))
+	assert.False(t, check(authz, "carol", entity.RelationActiveMember, platform.Object()))
+
+	assert.Len(t, audit.Events, 7)
+}
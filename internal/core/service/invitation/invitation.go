@@ -0,0 +1,462 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/invitation"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/mailer"
+	"github.com/synera-br/lockari-backend-app/pkg/tokengen"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type invitation struct {
+	repo    entity.InvitationRepository
+	tenants entity_tenant.TenantRepository
+	auth    authenticator.Authenticator
+	tokens  tokengen.TokenGenerator
+	sender  mailer.Sender
+	outbox  entity_outbox.OutboxService
+	audit   entity_audit.AuditSystemEventService
+	link    string
+	expiry  time.Duration
+	now     func() time.Time
+}
+
+// InitializeInvitationService creates the invitation service. The emails link to link with the
+// signed token in the token query parameter, e.g. https://app.lockari.io/invitations/accept.
+// Expiry is the validity of the invitations that request none, DefaultExpiry when zero.
+func InitializeInvitationService(repo entity.InvitationRepository, tenants entity_tenant.TenantRepository, auth authenticator.Authenticator, tokens tokengen.TokenGenerator, sender mailer.Sender, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, link string, expiry time.Duration) (entity.InvitationService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("InvitationRepository")
+	}
+
+	if tenants == nil {
+		return nil, core.ErrRepositoryNotFound("TenantRepository")
+	}
+
+	if auth == nil {
+		return nil, core.ErrServiceNotFound("Authenticator")
+	}
+
+	if tokens == nil {
+		return nil, core.ErrServiceNotFound("TokenGenerator")
+	}
+
+	if sender == nil {
+		return nil, core.ErrServiceNotFound("Sender")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	if _, err := url.ParseRequestURI(link); err != nil {
+		return nil, fmt.Errorf("invalid invitation link %q: %w", link, err)
+	}
+
+	if expiry <= 0 {
+		expiry = entity.DefaultExpiry
+	}
+
+	if expiry > entity.MaxExpiry {
+		return nil, fmt.Errorf("invitation expiry cannot exceed %s", entity.MaxExpiry)
+	}
+
+	return &invitation{
+		repo:    repo,
+		tenants: tenants,
+		auth:    auth,
+		tokens:  tokens,
+		sender:  sender,
+		outbox:  outbox,
+		audit:   audit,
+		link:    link,
+		expiry:  expiry,
+		now:     func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+func (s *invitation) Create(ctx context.Context, data *entity.Invitation) (*entity.Invitation, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("invitation is required")
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Role.CanAssign(data.Role) {
+		return nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to invite a member with role %s", data.Role))
+	}
+
+	expiry := time.Duration(data.ExpiresIn) * time.Hour
+	if expiry == 0 {
+		expiry = s.expiry
+	}
+	if expiry < 0 || expiry > entity.MaxExpiry {
+		return nil, core.ErrInvalidRequest(fmt.Sprintf("expiresIn must be between 1 and %d hours", int(entity.MaxExpiry.Hours())))
+	}
+
+	tenant, err := s.tenants.Get(ctx, actor.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	inv := entity.NewInvitation(utils.GenerateID(), tenant.ID, tenant.Name, data.Email, data.Role, actor.UserID, now, expiry)
+	if err := inv.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if err := s.ensureNotInvited(ctx, inv, now); err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokens.Generate(tokengen.TokenClaims{
+		UserID:    inv.Email,
+		TenantID:  inv.TenantID,
+		Scope:     []string{entity.TokenScope},
+		Metadata:  map[string]interface{}{"invitationId": inv.ID},
+		ExpiresAt: inv.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign invitation: %w", err)
+	}
+
+	payload, err := utils.StructToMap(inv)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert invitation data to map")
+	}
+
+	result, err := s.repo.Create(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sender.Send(ctx, s.message(inv, token)); err != nil {
+		// A pending invitation that nobody received would block a new one for the same email.
+		if err := s.setStatus(context.WithoutCancel(ctx), inv, entity.STATUS_REVOKED, actor.UserID); err != nil {
+			log.Printf("Failed to revoke undelivered invitation %s: %v", inv.ID, err)
+		}
+		return nil, fmt.Errorf("failed to send invitation to %s: %w", inv.Email, err)
+	}
+
+	s.record(ctx, entity_audit.INVITATION_SENT, actor.UserID, inv, "invite",
+		fmt.Sprintf("%s invited as %s until %s", inv.Email, inv.Role, inv.ExpiresAt.Format(time.RFC3339)))
+
+	return result, nil
+}
+
+// List returns the invitations of the tenant, with the pending ones past their expiration as expired.
+func (s *invitation) List(ctx context.Context, opts database.QueryOptions) ([]entity.Invitation, string, error) {
+
+	if err := opts.Validate(entity.InvitationSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "tenantId",
+			Value:  actor.TenantID,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	result, next, err := s.repo.List(ctx, filters, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now()
+	for i := range result {
+		result[i] = result[i].Effective(now)
+	}
+
+	return result, next, nil
+}
+
+func (s *invitation) Revoke(ctx context.Context, id string) (*entity.Invitation, error) {
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("invitation ID is required")
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if inv.TenantID != actor.TenantID {
+		return nil, core.ErrNotFound("invitation " + id)
+	}
+
+	if !actor.Role.CanAssign(inv.Role) {
+		return nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to revoke an invitation with role %s", inv.Role))
+	}
+
+	if !inv.IsPending(s.now()) {
+		return nil, core.ErrConflict("invitation " + id + " is " + string(inv.Effective(s.now()).Status))
+	}
+
+	if err := s.setStatus(ctx, inv, entity.STATUS_REVOKED, actor.UserID); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entity_audit.INVITATION_REVOKED, actor.UserID, inv, "revoke", "invitation of "+inv.Email+" revoked")
+
+	return inv, nil
+}
+
+func (s *invitation) Accept(ctx context.Context, token string) (*entity_tenant.Member, error) {
+
+	userID, email, inv, err := s.answer(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// The name is optional in the identity provider.
+	name, _ := s.auth.GetUserName(ctx, userID)
+
+	now := s.now()
+	member := entity_tenant.NewMember(inv.TenantID, userID, email, name, inv.Role, inv.InvitedBy)
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{member.RoleTuple()}, inv.Object())
+
+	patch, err := utils.StructToMap(map[string]interface{}{
+		"status":     entity.STATUS_ACCEPTED,
+		"answeredBy": userID,
+		"answeredAt": now.Format(time.RFC3339Nano),
+		"updatedAt":  now.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert invitation data to map")
+	}
+
+	memberPayload, err := utils.StructToMap(member)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert member data to map")
+	}
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	if err := s.repo.Accept(ctx, inv.ID, patch, memberPayload, entryPayload); err != nil {
+		return nil, err
+	}
+
+	// Apply the tuple right away so the member can use the tenant; on failure the relay retries.
+	if err := s.outbox.Dispatch(ctx, entry); err != nil {
+		log.Printf("Invitation %s accepted but permissions are pending: %v", inv.ID, err)
+	}
+
+	// The membership exists even if the claim fails: the user can switch to the tenant later.
+	if err := s.auth.SetTenantId(ctx, userID, inv.TenantID); err != nil {
+		log.Printf("Invitation %s accepted but the tenant claim of user %s was not set: %v", inv.ID, userID, err)
+	}
+
+	s.record(ctx, entity_audit.INVITATION_ACCEPTED, userID, inv, "accept",
+		fmt.Sprintf("%s joined as %s", inv.Email, inv.Role))
+
+	member.TenantName = inv.TenantName
+	return member, nil
+}
+
+func (s *invitation) Decline(ctx context.Context, token string) (*entity.Invitation, error) {
+
+	userID, _, inv, err := s.answer(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setStatus(ctx, inv, entity.STATUS_DECLINED, userID); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entity_audit.INVITATION_DECLINED, userID, inv, "decline", "invitation of "+inv.Email+" declined")
+
+	return inv, nil
+}
+
+// answer validates the token and returns the pending invitation it was signed for, with the
+// user of the context and its email, which must be the invited one.
+func (s *invitation) answer(ctx context.Context, token string) (string, string, *entity.Invitation, error) {
+
+	if ctx.Err() != nil {
+		return "", "", nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return "", "", nil, core.ErrUnauthorized(err.Error())
+	}
+
+	if token == "" {
+		return "", "", nil, core.ErrInvalidRequest("invitation token is required")
+	}
+
+	claims, err := s.tokens.Validate(token)
+	if err != nil {
+		return "", "", nil, core.ErrInvalidRequest("invalid or expired invitation token")
+	}
+
+	id, _ := claims.Metadata["invitationId"].(string)
+	if id == "" || !slices.Contains(claims.Scope, entity.TokenScope) {
+		return "", "", nil, core.ErrInvalidRequest("invalid invitation token")
+	}
+
+	inv, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if inv.TenantID != claims.TenantID || inv.Email != claims.UserID {
+		return "", "", nil, core.ErrInvalidRequest("invalid invitation token")
+	}
+
+	if now := s.now(); !inv.IsPending(now) {
+		return "", "", nil, core.ErrConflict("invitation " + id + " is " + string(inv.Effective(now).Status))
+	}
+
+	email, err := s.auth.GetUserEmail(ctx, userID)
+	if err != nil || entity.NormalizeEmail(email) != inv.Email {
+		return "", "", nil, core.ErrForbidden("the invitation was sent to another email address")
+	}
+
+	return userID, email, inv, nil
+}
+
+// actor returns the membership of the user of the context, which must manage its tenant.
+func (s *invitation) actor(ctx context.Context) (*entity_tenant.Member, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	member, err := s.tenants.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if core.IsNotFound(err) {
+			return nil, core.ErrForbidden("user is not a member of tenant " + tenantID)
+		}
+		return nil, err
+	}
+
+	if !member.IsActive() || !member.Role.CanManage() {
+		return nil, core.ErrForbidden("only owners and admins can manage invitations")
+	}
+
+	return member, nil
+}
+
+func (s *invitation) ensureNotInvited(ctx context.Context, inv *entity.Invitation, now time.Time) error {
+
+	filters := []database.Conditional{
+		{
+			Field:  "tenantId",
+			Value:  inv.TenantID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "email",
+			Value:  inv.Email,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "status",
+			Value:  string(entity.STATUS_PENDING),
+			Filter: database.FilterEquals,
+		},
+	}
+
+	pending, _, err := s.repo.List(ctx, filters, database.QueryOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		if p.IsPending(now) {
+			return core.ErrConflict(inv.Email + " already has a pending invitation")
+		}
+	}
+
+	return nil
+}
+
+func (s *invitation) setStatus(ctx context.Context, inv *entity.Invitation, status entity.Status, userID string) error {
+
+	now := s.now()
+	inv.Status = status
+	inv.AnsweredBy = userID
+	inv.AnsweredAt = &now
+	inv.UpdatedAt = now
+
+	patch, err := utils.StructToMap(map[string]interface{}{
+		"status":     inv.Status,
+		"answeredBy": inv.AnsweredBy,
+		"answeredAt": now.Format(time.RFC3339Nano),
+		"updatedAt":  now.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return core.ErrGenericError("Failed to convert invitation data to map")
+	}
+
+	return s.repo.Update(ctx, inv.ID, patch)
+}
+
+func (s *invitation) message(inv *entity.Invitation, token string) mailer.Message {
+
+	link := s.link + "?token=" + url.QueryEscape(token)
+	return mailer.Message{
+		To:      inv.Email,
+		Subject: "You were invited to " + inv.TenantName + " on Lockari",
+		Body: fmt.Sprintf("You were invited to join %s as %s.\n\nAccept the invitation: %s\n\nThe link expires at %s.\n",
+			inv.TenantName, inv.Role, link, inv.ExpiresAt.Format(time.RFC1123)),
+	}
+}
+
+func (s *invitation) record(ctx context.Context, eventType entity_audit.EventType, userID string, inv *entity.Invitation, action, reason string) {
+
+	event := entity_audit.NewResourceEvent(eventType, userID, inv.TenantID, inv.Object(), action, reason)
+	if _, err := s.audit.Record(context.WithValue(context.WithoutCancel(ctx), "TenantID", inv.TenantID), event); err != nil {
+		log.Printf("Failed to record %s event for invitation %s: %v", eventType, inv.ID, err)
+	}
+}
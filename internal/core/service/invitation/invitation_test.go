@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/invitation"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_tenant "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo_invitation "github.com/synera-br/lockari-backend-app/internal/core/repository/invitation"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	repo_tenant "github.com/synera-br/lockari-backend-app/internal/core/repository/tenant"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/tokengen"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+func TestInvitationLifecycle(t *testing.T) {
+	as := func(userID, tenantID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		if tenantID != "" {
+			ctx = context.WithValue(ctx, "TenantID", tenantID)
+		}
+		return ctx
+	}
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+
+	tenants, err := repo_tenant.InitializeTenantRepository(db)
+	require.NoError(t, err)
+	tenant, err := utils.StructToMap(entity_tenant.NewTenant("t1", "Acme", "free", "alice"))
+	require.NoError(t, err)
+	owner, err := utils.StructToMap(entity_tenant.NewMember("t1", "alice", "alice@acme.io", "", entity_tenant.ROLE_OWNER, "alice"))
+	require.NoError(t, err)
+	_, err = tenants.Create(context.Background(), tenant, owner, map[string]interface{}{"id": "e1", "status": entity_outbox.OUTBOX_DONE})
+	require.NoError(t, err)
+
+	repo, err := repo_invitation.InitializeInvitationRepository(db)
+	require.NoError(t, err)
+
+	auth := &servicetest.Auth{
+		Emails: map[string]string{"alice": "alice@acme.io", "bob": "Bob@Acme.io", "eve": "eve@evil.io"},
+		Claims: map[string]string{},
+	}
+	sender := &servicetest.Sender{}
+	audit := &servicetest.Audit{}
+	tokens := tokengen.NewTokenGenerator("invitation-test-key", "lockari-invitations", time.Hour)
+
+	svc, err := InitializeInvitationService(repo, tenants, auth, tokens, sender, outbox, audit, "https://app.lockari.io/invitations/accept", 0)
+	require.NoError(t, err)
+
+	inv, err := svc.Create(as("alice", "t1"), &entity.Invitation{Email: " bob@acme.io ", Role: entity_tenant.ROLE_ADMIN})
+	require.NoError(t, err)
+	assert.Equal(t, "bob@acme.io", inv.Email)
+	assert.Equal(t, entity.STATUS_PENDING, inv.Status)
+	assert.Equal(t, "Acme", inv.TenantName)
+	assert.Equal(t, entity.DefaultExpiry, inv.ExpiresAt.Sub(inv.CreatedAt))
+	require.Len(t, sender.Messages, 1)
+	assert.Equal(t, "bob@acme.io", sender.Messages[0].To)
+	token := sender.Token(t, "")
+
+	_, err = svc.Create(as("alice", "t1"), &entity.Invitation{Email: "bob@acme.io", Role: entity_tenant.ROLE_MEMBER})
+	assert.True(t, core.IsConflict(err), "an email has one pending invitation per tenant")
+
+	_, err = svc.Create(as("alice", "t1"), &entity.Invitation{Email: "carol@acme.io", Role: entity_tenant.ROLE_BANNED})
+	assert.True(t, core.IsInvalidRequest(err))
+
+	_, err = svc.Accept(as("eve", ""), token)
+	assert.True(t, core.IsForbidden(err), "only the invited email can accept")
+
+	_, err = svc.Accept(as("bob", ""), token+"x")
+	assert.True(t, core.IsInvalidRequest(err))
+
+	member, err := svc.Accept(as("bob", ""), token)
+	require.NoError(t, err)
+	assert.Equal(t, entity_tenant.ROLE_ADMIN, member.Role)
+	assert.Equal(t, "t1", auth.Claims["bob"])
+
+	allowed, err := authz.Check(context.Background(), authorization.User("bob"), "active_member", authorization.Object(authorization.TypeTenant, "t1"))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, err = svc.Accept(as("bob", "t1"), token)
+	assert.True(t, core.IsConflict(err), "an invitation is used only once")
+
+	// Bob is an admin now and invites carol, then revokes the invitation.
+	_, err = svc.Create(as("bob", "t1"), &entity.Invitation{Email: "carol@acme.io", Role: entity_tenant.ROLE_OWNER})
+	assert.True(t, core.IsForbidden(err), "admins cannot invite owners")
+
+	carol, err := svc.Create(as("bob", "t1"), &entity.Invitation{Email: "carol@acme.io", Role: entity_tenant.ROLE_MEMBER, ExpiresIn: 1})
+	require.NoError(t, err)
+	carolToken := sender.Token(t, "")
+
+	revoked, err := svc.Revoke(as("bob", "t1"), carol.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.STATUS_REVOKED, revoked.Status)
+
+	auth.Emails["carol"] = "carol@acme.io"
+	_, err = svc.Decline(as("carol", ""), carolToken)
+	assert.True(t, core.IsConflict(err), "a revoked invitation cannot be answered")
+
+	dave, err := svc.Create(as("alice", "t1"), &entity.Invitation{Email: "dave@acme.io", Role: entity_tenant.ROLE_GUEST})
+	require.NoError(t, err)
+	svc.(*invitation).now = func() time.Time { return time.Now().UTC().Add(entity.DefaultExpiry + time.Hour) }
+
+	invitations, _, err := svc.List(as("alice", "t1"), database.QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, invitations, 3)
+	for _, i := range invitations {
+		if i.ID == dave.ID {
+			assert.Equal(t, entity.STATUS_EXPIRED, i.Status)
+		}
+	}
+
+	_, _, err = svc.List(as("carol", "t1"), database.QueryOptions{})
+	assert.True(t, core.IsForbidden(err))
+
+	assert.Len(t, audit.Events, 5)
+}
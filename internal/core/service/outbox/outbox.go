@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+const outboxBatchSize = 100
+
+type outbox struct {
+	repo  entity.OutboxRepository
+	authz authorization.Authorizer
+}
+
+func InitializeOutboxService(repo entity.OutboxRepository, authz authorization.Authorizer) (entity.OutboxService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("OutboxRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	return &outbox{
+		repo:  repo,
+		authz: authz,
+	}, nil
+}
+
+func (s *outbox) Enqueue(ctx context.Context, operation entity.OutboxOperation, tuples []authorization.TupleKey, source string) (*entity.OutboxEntry, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	entry := entity.NewOutboxEntry(utils.GenerateID(), operation, tuples, source)
+	if err := entry.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	payload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	return s.repo.Create(ctx, payload)
+}
+
+// Dispatch applies the entry. It is idempotent: tuples that already exist are not written
+// again and tuples that are already gone are not deleted, so an entry can be retried safely
+// after a partial failure or by several relays at the same time. While an earlier entry of
+// the same source is pending the entry is left to the relay, which applies them in order.
+func (s *outbox) Dispatch(ctx context.Context, entry *entity.OutboxEntry) error {
+
+	if err := entry.IsValid(); err != nil {
+		return core.ErrInvalidRequest(err.Error())
+	}
+
+	pending, err := s.repo.ListPendingBySource(ctx, entry.Source)
+	if err != nil {
+		return fmt.Errorf("failed to list pending outbox entries of %s: %w", entry.Source, err)
+	}
+
+	for _, earlier := range pending {
+		if earlier.ID != entry.ID && earlier.CreatedAt.Before(entry.CreatedAt) {
+			return fmt.Errorf("outbox entry %s waits for entry %s of %s", entry.ID, earlier.ID, entry.Source)
+		}
+	}
+
+	return s.dispatch(ctx, entry)
+}
+
+func (s *outbox) dispatch(ctx context.Context, entry *entity.OutboxEntry) error {
+
+	applyErr := s.apply(ctx, entry)
+
+	now := time.Now().UTC()
+	entry.Attempts++
+	entry.UpdatedAt = now
+
+	switch {
+	case applyErr == nil:
+		entry.Status = entity.OUTBOX_DONE
+		entry.LastError = ""
+	case entry.Attempts >= entity.OutboxMaxAttempts:
+		entry.Status = entity.OUTBOX_FAILED
+		entry.LastError = applyErr.Error()
+		log.Printf("Outbox entry %s of %s failed after %d attempts and will not be retried: %v", entry.ID, entry.Source, entry.Attempts, applyErr)
+	default:
+		entry.LastError = applyErr.Error()
+		entry.NextAttemptAt = now.Add(entry.Backoff())
+	}
+
+	payload := map[string]interface{}{
+		"status":        string(entry.Status),
+		"attempts":      entry.Attempts,
+		"lastError":     entry.LastError,
+		"nextAttemptAt": entry.NextAttemptAt.Format(time.RFC3339Nano),
+		"updatedAt":     now.Format(time.RFC3339Nano),
+	}
+
+	if err := s.repo.Update(context.WithoutCancel(ctx), entry.ID, payload); err != nil {
+		log.Printf("Failed to update outbox entry %s: %v", entry.ID, err)
+		if applyErr == nil {
+			// The tuples were applied; the next run finds them and only marks the entry.
+			return nil
+		}
+	}
+
+	if applyErr != nil {
+		return fmt.Errorf("outbox entry %s (attempt %d): %w", entry.ID, entry.Attempts, applyErr)
+	}
+
+	return nil
+}
+
+func (s *outbox) apply(ctx context.Context, entry *entity.OutboxEntry) error {
+
+	pending := []authorization.TupleKey{}
+	for _, t := range entry.Tuples {
+		existing, err := s.authz.ReadTuples(ctx, t)
+		if err != nil {
+			return err
+		}
+
+		exists := len(existing) > 0
+		if (entry.Operation == entity.OUTBOX_WRITE && !exists) || (entry.Operation == entity.OUTBOX_DELETE && exists) {
+			pending = append(pending, t)
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if entry.Operation == entity.OUTBOX_DELETE {
+		return s.authz.DeleteTuples(ctx, pending)
+	}
+
+	return s.authz.WriteTuples(ctx, pending)
+}
+
+func (s *outbox) ProcessPending(ctx context.Context) (int, error) {
+
+	entries, err := s.repo.ListPending(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	waiting := map[string]bool{}
+	done, attempted := 0, 0
+	for i := range entries {
+		if ctx.Err() != nil {
+			return done, ctx.Err()
+		}
+
+		entry := &entries[i]
+		if waiting[entry.Source] {
+			continue
+		}
+
+		// An entry that is not due, or fails again, holds back the later entries of its source.
+		if !entry.IsDue(now) {
+			waiting[entry.Source] = true
+			continue
+		}
+
+		if attempted == outboxBatchSize {
+			break
+		}
+		attempted++
+
+		if err := s.dispatch(ctx, entry); err != nil {
+			log.Printf("Outbox relay: %v", err)
+			if entry.Status == entity.OUTBOX_PENDING {
+				waiting[entry.Source] = true
+			}
+			continue
+		}
+		done++
+	}
+
+	return done, nil
+}
+
+func (s *outbox) ListFailed(ctx context.Context) ([]entity.OutboxEntry, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	return s.repo.ListFailed(ctx)
+}
+
+func (s *outbox) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ProcessPending(ctx); err != nil {
+				log.Printf("Outbox relay failed: %v", err)
+			}
+		}
+	}
+}
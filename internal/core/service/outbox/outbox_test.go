@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+type outboxRepoStub struct {
+	updates map[string]map[string]interface{}
+	created []map[string]interface{}
+	failed  []entity.OutboxEntry
+}
+
+func (r *outboxRepoStub) Create(ctx context.Context, entry map[string]interface{}) (*entity.OutboxEntry, error) {
+	r.created = append(r.created, entry)
+	return &entity.OutboxEntry{}, nil
+}
+
+func (r *outboxRepoStub) ListPending(ctx context.Context, limit int) ([]entity.OutboxEntry, error) {
+	return nil, nil
+}
+
+func (r *outboxRepoStub) ListPendingBySource(ctx context.Context, source string) ([]entity.OutboxEntry, error) {
+	return nil, nil
+}
+
+func (r *outboxRepoStub) ListFailed(ctx context.Context) ([]entity.OutboxEntry, error) {
+	return r.failed, nil
+}
+
+func (r *outboxRepoStub) Update(ctx context.Context, id string, entry map[string]interface{}) error {
+	r.updates[id] = entry
+	return nil
+}
+
+type vaultRepoStub struct {
+	entity_vault.VaultRepository
+	vaults []entity_vault.Vault
+	stored []entity_vault.Vault
+}
+
+func (r *vaultRepoStub) ListAll(ctx context.Context) ([]entity_vault.Vault, error) {
+	return r.vaults, nil
+}
+
+// Get also finds the stored vaults, which ListAll missed because they were created during the run.
+func (r *vaultRepoStub) Get(ctx context.Context, id string) (*entity_vault.Vault, error) {
+	tenantID, _ := ctx.Value("TenantID").(string)
+	for _, v := range append(r.vaults, r.stored...) {
+		if v.ID == id && v.TenantID == tenantID {
+			return &v, nil
+		}
+	}
+	return nil, core.ErrNotFound("vault " + id)
+}
+
+// failingAuthorizer fails every write, to exercise retries.
+type failingAuthorizer struct {
+	authorization.Authorizer
+}
+
+func (f failingAuthorizer) WriteTuples(ctx context.Context, tuples []authorization.TupleKey) error {
+	return errors.New("openfga unavailable")
+}
+
+func newAuthorizer(t *testing.T) authorization.Authorizer {
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+	return authz
+}
+
+func TestOutboxDispatch(t *testing.T) {
+	ctx := context.Background()
+	vault := entity_vault.Vault{ID: "v1", TenantID: "t1", CreatedBy: "alice"}
+	tuples := vault.OwnershipTuples()
+
+	t.Run("is idempotent", func(t *testing.T) {
+		authz := newAuthorizer(t)
+		repo := &outboxRepoStub{updates: map[string]map[string]interface{}{}}
+		svc, err := InitializeOutboxService(repo, authz)
+		require.NoError(t, err)
+
+		// One tuple already written by a previous, partially failed attempt.
+		require.NoError(t, authz.WriteTuples(ctx, tuples[:1]))
+
+		entry := entity.NewOutboxEntry("e1", entity.OUTBOX_WRITE, tuples, vault.GetOpenFGAID())
+		require.NoError(t, svc.Dispatch(ctx, entry))
+		require.NoError(t, svc.Dispatch(ctx, entry))
+
+		assert.Equal(t, entity.OUTBOX_DONE, entry.Status)
+		assert.Equal(t, "done", repo.updates["e1"]["status"])
+
+		allowed, err := authz.Check(ctx, authorization.User("alice"), authorization.RelationOwner, "vault:v1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("schedules a retry and gives up after max attempts", func(t *testing.T) {
+		repo := &outboxRepoStub{updates: map[string]map[string]interface{}{}}
+		svc, err := InitializeOutboxService(repo, failingAuthorizer{newAuthorizer(t)})
+		require.NoError(t, err)
+
+		entry := entity.NewOutboxEntry("e2", entity.OUTBOX_WRITE, tuples, vault.GetOpenFGAID())
+		before := time.Now().UTC()
+		assert.Error(t, svc.Dispatch(ctx, entry))
+		assert.Equal(t, entity.OUTBOX_PENDING, entry.Status)
+		assert.Equal(t, 1, entry.Attempts)
+		assert.True(t, entry.NextAttemptAt.After(before))
+
+		for entry.Attempts < entity.OutboxMaxAttempts {
+			_ = svc.Dispatch(ctx, entry)
+		}
+		assert.Equal(t, entity.OUTBOX_FAILED, entry.Status)
+		assert.Equal(t, "openfga unavailable", entry.LastError)
+	})
+}
+
+func TestOutboxKeepsSourceOrder(t *testing.T) {
+	ctx := context.Background()
+	vault := entity_vault.Vault{ID: "v1", TenantID: "t1", CreatedBy: "alice"}
+	tuples := vault.OwnershipTuples()
+
+	authz := newAuthorizer(t)
+	repo, err := repo_outbox.InitializeOutboxRepository(database.NewMemoryDB())
+	require.NoError(t, err)
+	failing, err := InitializeOutboxService(repo, failingAuthorizer{authz})
+	require.NoError(t, err)
+	svc, err := InitializeOutboxService(repo, authz)
+	require.NoError(t, err)
+
+	write, err := failing.Enqueue(ctx, entity.OUTBOX_WRITE, tuples, vault.GetOpenFGAID())
+	require.NoError(t, err)
+	require.Error(t, failing.Dispatch(ctx, write))
+
+	// The write is backed off, so the delete that follows it must wait.
+	remove, err := svc.Enqueue(ctx, entity.OUTBOX_DELETE, tuples, vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.ErrorContains(t, svc.Dispatch(ctx, remove), "waits for entry "+write.ID)
+
+	done, err := svc.ProcessPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, done)
+
+	require.NoError(t, repo.Update(ctx, write.ID, map[string]interface{}{"nextAttemptAt": time.Now().UTC().Format(time.RFC3339Nano)}))
+	done, err = svc.ProcessPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, done)
+
+	stored, err := authz.ReadTuples(ctx, authorization.TupleKey{Object: vault.GetOpenFGAID()})
+	require.NoError(t, err)
+	assert.Empty(t, stored, "the retried write does not bring the deleted tuples back")
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	authz := newAuthorizer(t)
+
+	healthy := entity_vault.Vault{ID: "v1", TenantID: "t1", CreatedBy: "alice"}
+	drifted := entity_vault.Vault{ID: "v2", TenantID: "t1", CreatedBy: "bob"}
+	require.NoError(t, authz.WriteTuples(ctx, healthy.OwnershipTuples()))
+	require.NoError(t, authz.WriteTuples(ctx, drifted.OwnershipTuples()[1:]))
+	gone := entity_vault.Vault{ID: "gone", TenantID: "t1", CreatedBy: "carol"}
+	require.NoError(t, authz.WriteTuples(ctx, gone.OwnershipTuples()))
+	late := entity_vault.Vault{ID: "late", TenantID: "t1", CreatedBy: "dave"}
+	require.NoError(t, authz.WriteTuples(ctx, late.OwnershipTuples()))
+	untenanted := authorization.TupleKey{User: authorization.User("erin"), Relation: authorization.RelationOwner, Object: "vault:untenanted"}
+	require.NoError(t, authz.WriteTuples(ctx, []authorization.TupleKey{untenanted}))
+
+	outboxRepo := &outboxRepoStub{updates: map[string]map[string]interface{}{}, failed: []entity.OutboxEntry{{ID: "e9"}}}
+	outboxSvc, err := InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+
+	svc, err := InitializeReconcilerService(&vaultRepoStub{vaults: []entity_vault.Vault{healthy, drifted}, stored: []entity_vault.Vault{late}}, authz, outboxSvc)
+	require.NoError(t, err)
+
+	report, err := svc.Reconcile(ctx, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Checked)
+	assert.Equal(t, []authorization.TupleKey{drifted.OwnershipTuples()[0]}, report.Missing)
+	assert.ElementsMatch(t, gone.OwnershipTuples(), report.Orphans, "late is found again and untenanted cannot be looked up")
+	assert.Equal(t, []string{"e9"}, report.Failed)
+	assert.Equal(t, 2, report.Repaired)
+	require.Len(t, outboxRepo.created, 2)
+}
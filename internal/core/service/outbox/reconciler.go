@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+)
+
+type reconciler struct {
+	vaults entity_vault.VaultRepository
+	authz  authorization.Authorizer
+	outbox entity.OutboxService
+}
+
+func InitializeReconcilerService(vaults entity_vault.VaultRepository, authz authorization.Authorizer, outbox entity.OutboxService) (entity.ReconcilerService, error) {
+
+	if vaults == nil {
+		return nil, core.ErrRepositoryNotFound("VaultRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	return &reconciler{
+		vaults: vaults,
+		authz:  authz,
+		outbox: outbox,
+	}, nil
+}
+
+// Reconcile compares the vaults stored in the database with the vault tuples.
+// A vault must have its tenant tuple and the owner tuple of its creator; tuples on
+// vaults that do not exist anymore are orphans. Soft deleted vaults keep their tuples.
+// When repair is true the differences are enqueued in the outbox.
+//
+// The tuples are read before the vaults: a vault is stored before its tuples are written,
+// so a vault created during the run has no tuples yet instead of looking orphaned. Each
+// orphan is still looked up again before it is reported. Outbox entries that gave up are
+// reported but not retried, since a later entry of the same source may have replaced them.
+func (s *reconciler) Reconcile(ctx context.Context, repair bool) (*entity.DriftReport, error) {
+
+	report := &entity.DriftReport{
+		Missing:   []authorization.TupleKey{},
+		Orphans:   []authorization.TupleKey{},
+		StartedAt: time.Now().UTC(),
+	}
+
+	// OpenFGA only reads a whole type together with a user, so every tuple is read.
+	stored, err := s.authz.ReadTuples(ctx, authorization.TupleKey{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault tuples: %w", err)
+	}
+
+	tuples := []authorization.TupleKey{}
+	existing := map[authorization.TupleKey]bool{}
+	for _, t := range stored {
+		if objectType, _, err := authorization.SplitObject(t.Object); err == nil && objectType == authorization.TypeVault {
+			tuples = append(tuples, t)
+			existing[t] = true
+		}
+	}
+
+	vaults, err := s.vaults.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	missing := map[string][]authorization.TupleKey{}
+	for _, v := range vaults {
+		report.Checked++
+		known[v.GetOpenFGAID()] = true
+
+		for _, t := range v.OwnershipTuples() {
+			if !existing[t] {
+				report.Missing = append(report.Missing, t)
+				missing[v.GetOpenFGAID()] = append(missing[v.GetOpenFGAID()], t)
+			}
+		}
+	}
+
+	unknown := []string{}
+	candidates := map[string][]authorization.TupleKey{}
+	for _, t := range tuples {
+		if known[t.Object] {
+			continue
+		}
+		if _, ok := candidates[t.Object]; !ok {
+			unknown = append(unknown, t.Object)
+		}
+		candidates[t.Object] = append(candidates[t.Object], t)
+	}
+
+	orphans := map[string][]authorization.TupleKey{}
+	for _, object := range unknown {
+		objectTuples := candidates[object]
+		orphan, err := s.isOrphan(ctx, object, objectTuples)
+		if err != nil {
+			log.Printf("Reconciler: failed to look up %s: %v", object, err)
+			continue
+		}
+		if orphan {
+			report.Orphans = append(report.Orphans, objectTuples...)
+			orphans[object] = objectTuples
+		}
+	}
+
+	failed, err := s.outbox.ListFailed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed outbox entries: %w", err)
+	}
+
+	report.Failed = []string{}
+	for _, entry := range failed {
+		report.Failed = append(report.Failed, entry.ID)
+	}
+
+	if repair {
+		report.Repaired += s.enqueue(ctx, entity.OUTBOX_WRITE, missing)
+		report.Repaired += s.enqueue(ctx, entity.OUTBOX_DELETE, orphans)
+	}
+
+	report.EndedAt = time.Now().UTC()
+	return report, nil
+}
+
+// isOrphan looks the vault up again in the tenant of its tenant tuple. Without that tuple the
+// vault cannot be found, so its tuples are left alone.
+func (s *reconciler) isOrphan(ctx context.Context, object string, tuples []authorization.TupleKey) (bool, error) {
+
+	tenantID := ""
+	for _, t := range tuples {
+		if objectType, id, err := authorization.SplitObject(t.User); err == nil && t.Relation == authorization.RelationTenant && objectType == authorization.TypeTenant {
+			tenantID = id
+		}
+	}
+
+	if tenantID == "" {
+		log.Printf("Reconciler: %s has no vault and no tenant tuple; its tuples were kept", object)
+		return false, nil
+	}
+
+	_, id, err := authorization.SplitObject(object)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.vaults.Get(context.WithValue(ctx, "TenantID", tenantID), id)
+	if err == nil {
+		return false, nil
+	}
+
+	if core.IsNotFound(err) {
+		return true, nil
+	}
+
+	return false, err
+}
+
+func (s *reconciler) Run(ctx context.Context, interval time.Duration, repair bool) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Reconcile(ctx, repair)
+			if err != nil {
+				log.Printf("Reconciler failed: %v", err)
+				continue
+			}
+			if report.HasDrift() {
+				log.Printf("Reconciler: %d vaults checked, %d missing tuples, %d orphan tuples, %d failed outbox entries %v, %d repairs enqueued",
+					report.Checked, len(report.Missing), len(report.Orphans), len(report.Failed), report.Failed, report.Repaired)
+			}
+		}
+	}
+}
+
+func (s *reconciler) enqueue(ctx context.Context, operation entity.OutboxOperation, bySource map[string][]authorization.TupleKey) int {
+
+	created := 0
+	for source, tuples := range bySource {
+		if _, err := s.outbox.Enqueue(ctx, operation, tuples, source); err != nil {
+			log.Printf("Reconciler: failed to enqueue %s of %d tuples for %s: %v", operation, len(tuples), source, err)
+			continue
+		}
+		created++
+	}
+
+	return created
+}
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	entity_trash "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type secret struct {
+	repo    entity.SecretRepository
+	vaults  entity_vault.VaultService
+	storage cryptserver.StorageCryptInterface
+	authz   authorization.Authorizer
+	trash   entity_trash.TrashService
+}
+
+func InitializeSecretService(repo entity.SecretRepository, vaults entity_vault.VaultService, storage cryptserver.StorageCryptInterface, authz authorization.Authorizer, trash entity_trash.TrashService) (entity.SecretService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("SecretRepository")
+	}
+
+	if vaults == nil {
+		return nil, core.ErrServiceNotFound("VaultService")
+	}
+
+	if storage == nil {
+		return nil, core.ErrServiceNotFound("StorageCrypt")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if trash == nil {
+		return nil, core.ErrServiceNotFound("TrashService")
+	}
+
+	return &secret{
+		repo:    repo,
+		vaults:  vaults,
+		storage: storage,
+		authz:   authz,
+		trash:   trash,
+	}, nil
+}
+
+func (s *secret) Create(ctx context.Context, vaultID string, data *entity.Secret) (*entity.Secret, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("secret is required")
+	}
+
+	vault, err := s.vault(ctx, authorization.CanWrite, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	newSecret := entity.NewSecret(*data, vault.ID, vault.TenantID, userID)
+	if len(newSecret.Value) == 0 {
+		return nil, core.ErrInvalidRequest("secret value is required")
+	}
+
+	if err := newSecret.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if err := s.seal(newSecret); err != nil {
+		return nil, err
+	}
+
+	newSecret.ID = utils.GenerateID()
+
+	payload, err := s.toMap(newSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.versionMap(entity.NewSecretVersion(*newSecret, userID, newSecret.ChangeNote))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.CreateWithVersion(ctx, vault.ID, newSecret.ID, payload, version)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := result.Metadata()
+	return &metadata, nil
+}
+
+func (s *secret) Get(ctx context.Context, vaultID, id string) (*entity.Secret, error) {
+
+	result, err := s.get(ctx, authorization.CanView, vaultID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := result.Metadata()
+	return &metadata, nil
+}
+
+func (s *secret) List(ctx context.Context, vaultID string, opts database.QueryOptions) ([]entity.Secret, string, error) {
+
+	if ctx.Err() != nil {
+		return nil, "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if err := opts.Validate(entity.SecretSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	vault, err := s.vault(ctx, authorization.CanView, vaultID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "isActive",
+			Value:  true,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	result, next, err := s.repo.List(ctx, vault.ID, filters, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secrets := make([]entity.Secret, 0, len(result))
+	for _, item := range result {
+		secrets = append(secrets, item.Metadata())
+	}
+
+	return secrets, next, nil
+}
+
+func (s *secret) Update(ctx context.Context, vaultID, id string, data *entity.Secret) (*entity.Secret, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("secret is required")
+	}
+
+	current, err := s.get(ctx, authorization.CanWrite, vaultID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	if data.Type != "" && data.Type != current.Type {
+		if len(data.Value) == 0 {
+			return nil, core.ErrInvalidRequest("changing the secret type requires a new value")
+		}
+		current.Type = data.Type
+	}
+
+	current.Name = strings.TrimSpace(data.Name)
+	current.Description = data.Description
+	current.Tags = data.Tags
+	current.IsSensitive = data.IsSensitive
+	current.IsProduction = data.IsProduction
+	current.ExpiresAt = data.ExpiresAt
+	current.Value = data.Value
+	current.ChangeNote = data.ChangeNote
+	current.Version++
+	current.UpdatedBy = userID
+	current.UpdatedAt = time.Now().UTC()
+
+	if err := current.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if err := s.seal(current); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveHead(ctx, current, entity.NewSecretVersion(*current, userID, current.ChangeNote), data.Revision); err != nil {
+		return nil, err
+	}
+
+	metadata := current.Metadata()
+	return &metadata, nil
+}
+
+// Delete moves the secret to the trash, where it keeps its versions until it is purged.
+func (s *secret) Delete(ctx context.Context, vaultID, id string) error {
+
+	current, err := s.get(ctx, authorization.CanDelete, vaultID, id)
+	if err != nil {
+		return err
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return core.ErrUnauthorized(err.Error())
+	}
+
+	item := entity_trash.NewTrashItem(entity_trash.TRASH_SECRET, tenantID, current.VaultID, current.ID, current.Name, entity.SecretCollection(current.VaultID), userID)
+	_, err = s.trash.MoveToTrash(ctx, item)
+	return err
+}
+
+// Reveal returns the secret with its decrypted value.
+func (s *secret) Reveal(ctx context.Context, vaultID, id string) (*entity.Secret, error) {
+
+	result, err := s.get(ctx, authorization.CanRead, vaultID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.EncryptedValue == "" {
+		return nil, core.ErrNotFound("secret value " + id)
+	}
+
+	plaintext, err := s.storage.Decrypt(result.EncryptedValue)
+	if err != nil {
+		return nil, fmt.Errorf(utils.DecryptionError, err.Error())
+	}
+
+	revealed := result.Metadata()
+	revealed.Value = entity.SecretValue(plaintext)
+
+	return &revealed, nil
+}
+
+func (s *secret) get(ctx context.Context, relation, vaultID, id string) (*entity.Secret, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("secret ID is required")
+	}
+
+	vault, err := s.vault(ctx, relation, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.Get(ctx, vault.ID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.VaultID != vault.ID || result.IsDeleted() {
+		return nil, core.ErrNotFound("secret " + id)
+	}
+
+	return result, nil
+}
+
+// vault checks the relation on the vault of the secret before loading it.
+// Secrets inherit their permissions from the vault, as in the authorization model.
+func (s *secret) vault(ctx context.Context, relation, vaultID string) (*entity_vault.Vault, error) {
+
+	if vaultID == "" {
+		return nil, core.ErrInvalidRequest("vault ID is required")
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), relation, authorization.Object(authorization.TypeVault, vaultID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check secret permission: %w", err)
+	}
+
+	if !allowed {
+		return nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to %s secrets of vault %s", strings.TrimPrefix(relation, "can_"), vaultID))
+	}
+
+	return s.vaults.Get(ctx, vaultID)
+}
+
+// ListVersions returns the version history of the secret without values, newest first.
+func (s *secret) ListVersions(ctx context.Context, vaultID, id string) ([]entity.SecretVersion, error) {
+
+	current, err := s.get(ctx, authorization.CanView, vaultID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.ListVersions(ctx, current.VaultID, current.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]entity.SecretVersion, 0, len(result))
+	for _, item := range result {
+		versions = append(versions, item.Metadata())
+	}
+
+	return versions, nil
+}
+
+// GetVersion returns a specific version of the secret with its decrypted value.
+func (s *secret) GetVersion(ctx context.Context, vaultID, id string, version int) (*entity.SecretVersion, error) {
+
+	if version <= 0 {
+		return nil, core.ErrInvalidRequest("version must be greater than zero")
+	}
+
+	current, err := s.get(ctx, authorization.CanRead, vaultID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.GetVersion(ctx, current.VaultID, current.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.storage.Decrypt(result.EncryptedValue)
+	if err != nil {
+		return nil, fmt.Errorf(utils.DecryptionError, err.Error())
+	}
+
+	revealed := result.Metadata()
+	revealed.Value = entity.SecretValue(plaintext)
+
+	return &revealed, nil
+}
+
+// RestoreVersion copies an older version into a new head. History is never rewritten:
+// the restored value becomes the next version of the secret.
+func (s *secret) RestoreVersion(ctx context.Context, vaultID, id string, version int, changeNote string) (*entity.Secret, error) {
+
+	if version <= 0 {
+		return nil, core.ErrInvalidRequest("version must be greater than zero")
+	}
+
+	current, err := s.get(ctx, authorization.CanWrite, vaultID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	if version == current.Version {
+		return nil, core.ErrInvalidRequest(fmt.Sprintf("version %d is already the current version", version))
+	}
+
+	target, err := s.repo.GetVersion(ctx, current.VaultID, current.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if changeNote == "" {
+		changeNote = fmt.Sprintf("restored from version %d", target.Version)
+	}
+
+	current.Type = target.Type
+	current.EncryptedValue = target.EncryptedValue
+	current.ChangeNote = changeNote
+	current.Version++
+	current.UpdatedBy = userID
+	current.UpdatedAt = time.Now().UTC()
+
+	if err := current.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	record := entity.NewSecretVersion(*current, userID, changeNote)
+	record.RestoredFrom = target.Version
+
+	if err := s.saveHead(ctx, current, record, 0); err != nil {
+		return nil, err
+	}
+
+	metadata := current.Metadata()
+	return &metadata, nil
+}
+
+// saveHead updates the current state of the secret and records it as a version in one write.
+// The update is conditional on revision or, when it is zero, on the revision of head, and head
+// gets the revision that was stored.
+func (s *secret) saveHead(ctx context.Context, head *entity.Secret, record *entity.SecretVersion, revision int64) error {
+
+	if revision == 0 {
+		revision = head.Revision
+	}
+
+	payload, err := s.toMap(head)
+	if err != nil {
+		return err
+	}
+
+	version, err := s.versionMap(record)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateWithVersion(ctx, head.VaultID, head.ID, revision, payload, record.Version, version); err != nil {
+		return err
+	}
+	head.Revision = revision + 1
+
+	return nil
+}
+
+// versionMap converts the version to the map stored in the database, without the plaintext value.
+func (s *secret) versionMap(record *entity.SecretVersion) (map[string]interface{}, error) {
+
+	if record.EncryptedValue == "" {
+		return nil, core.ErrInvalidRequest("secret version requires an encrypted value")
+	}
+
+	persisted := *record
+	persisted.Value = nil
+
+	payload, err := utils.StructToMap(persisted)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert secret version data to map")
+	}
+
+	return payload, nil
+}
+
+// seal encrypts the plaintext value (when present) into EncryptedValue and drops the plaintext.
+func (s *secret) seal(item *entity.Secret) error {
+
+	if len(item.Value) == 0 {
+		return nil
+	}
+
+	encrypted, err := s.storage.Encrypt(item.Value)
+	if err != nil {
+		return fmt.Errorf(utils.EncryptionError, err.Error())
+	}
+
+	item.EncryptedValue = encrypted
+	item.Value = nil
+
+	return nil
+}
+
+// toMap converts the secret to the map stored in the database. The plaintext value is never part of the map.
+// The optional fields are always present, empty or null, so an update that clears them is stored.
+func (s *secret) toMap(item *entity.Secret) (map[string]interface{}, error) {
+
+	persisted := *item
+	persisted.Value = nil
+
+	payload, err := utils.StructToMap(persisted)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert secret data to map")
+	}
+
+	payload["description"] = persisted.Description
+	payload["tags"] = []string{}
+	if persisted.Tags != nil {
+		payload["tags"] = persisted.Tags
+	}
+	payload["expiresAt"] = nil
+	if persisted.ExpiresAt != nil {
+		payload["expiresAt"] = persisted.ExpiresAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	return payload, nil
+}
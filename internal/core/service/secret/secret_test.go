@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	repo_secret "github.com/synera-br/lockari-backend-app/internal/core/repository/secret"
+	repo_trash "github.com/synera-br/lockari-backend-app/internal/core/repository/trash"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	svc_trash "github.com/synera-br/lockari-backend-app/internal/core/service/trash"
+	svc_vault "github.com/synera-br/lockari-backend-app/internal/core/service/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+// storageKey is the base64 of a 32 bytes key.
+const storageKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func as(userID string) context.Context {
+	ctx := context.WithValue(context.Background(), "UserID", userID)
+	return context.WithValue(ctx, "TenantID", "t1")
+}
+
+// setup returns the secret service and a vault of t1 owned by alice.
+func setup(t *testing.T) (entity.SecretService, authorization.Authorizer, *entity_vault.Vault) {
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: authorization.Object(authorization.TypeTenant, "t1")},
+	}))
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+	trashRepo, err := repo_trash.InitializeTrashRepository(db)
+	require.NoError(t, err)
+	trash, err := svc_trash.InitializeTrashService(trashRepo, authz, &servicetest.Audit{}, time.Hour)
+	require.NoError(t, err)
+	vaultRepo, err := repo_vault.InitializeVaultRepository(db)
+	require.NoError(t, err)
+	vaults, err := svc_vault.InitializeVaultService(vaultRepo, authz, outbox, trash)
+	require.NoError(t, err)
+
+	key := storageKey
+	storage, err := cryptserver.InicializationStorageCrypt(&key)
+	require.NoError(t, err)
+	repo, err := repo_secret.InitializeSecretRepository(db)
+	require.NoError(t, err)
+	svc, err := InitializeSecretService(repo, vaults, storage, authz, trash)
+	require.NoError(t, err)
+
+	vault, err := vaults.Create(as("alice"), &entity_vault.Vault{Name: "Production"})
+	require.NoError(t, err)
+
+	return svc, authz, vault
+}
+
+func password(p string) entity.SecretValue {
+	return entity.SecretValue(`{"password":"` + p + `"}`)
+}
+
+func TestSecretVersions(t *testing.T) {
+	ctx := as("alice")
+	svc, _, vault := setup(t)
+
+	created, err := svc.Create(ctx, vault.ID, &entity.Secret{Name: "db", Type: entity.SECRET_PASSWORD, Value: password("one")})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Version)
+
+	updated, err := svc.Update(ctx, vault.ID, created.ID, &entity.Secret{Name: "db", Value: password("two"), ChangeNote: "rotated"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, created.Revision+1, updated.Revision, "the revision that was stored is returned")
+
+	_, err = svc.Update(ctx, vault.ID, created.ID, &entity.Secret{Name: "db", Value: password("lost"), Revision: created.Revision})
+	assert.True(t, core.IsConflict(err), "a stale revision is rejected")
+
+	updated, err = svc.Update(ctx, vault.ID, created.ID, &entity.Secret{Name: "db", Value: password("three"), Revision: updated.Revision})
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated.Version)
+
+	versions, err := svc.ListVersions(ctx, vault.ID, created.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3, "the rejected update wrote no version")
+	assert.Equal(t, []int{3, 2, 1}, []int{versions[0].Version, versions[1].Version, versions[2].Version})
+	assert.Equal(t, "rotated", versions[1].ChangeNote)
+	assert.Empty(t, versions[0].EncryptedValue)
+
+	first, err := svc.GetVersion(ctx, vault.ID, created.ID, 1)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"password":"one"}`, string(first.Value))
+
+	_, err = svc.RestoreVersion(ctx, vault.ID, created.ID, 3, "")
+	assert.True(t, core.IsInvalidRequest(err), "the current version cannot be restored")
+
+	restored, err := svc.RestoreVersion(ctx, vault.ID, created.ID, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, 4, restored.Version)
+	assert.Equal(t, updated.Revision+1, restored.Revision)
+
+	revealed, err := svc.Reveal(ctx, vault.ID, created.ID)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"password":"one"}`, string(revealed.Value))
+
+	record, err := svc.GetVersion(ctx, vault.ID, created.ID, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 1, record.RestoredFrom)
+	assert.Equal(t, "restored from version 1", record.ChangeNote)
+}
+
+func TestSecretUpdateClearsFields(t *testing.T) {
+	ctx := as("alice")
+	svc, _, vault := setup(t)
+
+	expires := time.Now().Add(time.Hour).UTC()
+	created, err := svc.Create(ctx, vault.ID, &entity.Secret{Name: "db", Type: entity.SECRET_PASSWORD, Value: password("one"), Description: "primary", Tags: []string{"prod"}, ExpiresAt: &expires})
+	require.NoError(t, err)
+
+	_, err = svc.Update(ctx, vault.ID, created.ID, &entity.Secret{Name: "db", Value: password("two")})
+	require.NoError(t, err)
+
+	stored, err := svc.Get(ctx, vault.ID, created.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Description)
+	assert.Empty(t, stored.Tags)
+	assert.Nil(t, stored.ExpiresAt)
+}
+
+func TestSecretCreateWritesHeadWithVersion(t *testing.T) {
+	ctx := as("alice")
+	svc, _, vault := setup(t)
+
+	created, err := svc.Create(ctx, vault.ID, &entity.Secret{Name: "db", Type: entity.SECRET_PASSWORD, Value: password("one")})
+	require.NoError(t, err)
+
+	versions, err := svc.ListVersions(ctx, vault.ID, created.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 1, versions[0].Version)
+
+	db := database.NewMemoryDB()
+	repo, err := repo_secret.InitializeSecretRepository(db)
+	require.NoError(t, err)
+
+	_, err = db.CreateWithID(ctx, "1", map[string]interface{}{"version": 1}, "tenant/t1/vaults/v1/secrets/s1/versions")
+	require.NoError(t, err)
+
+	_, err = repo.CreateWithVersion(ctx, "v1", "s1", map[string]interface{}{"name": "db"}, map[string]interface{}{"version": 1})
+	assert.True(t, core.IsConflict(err))
+
+	_, err = repo.Get(ctx, "v1", "s1")
+	assert.True(t, core.IsNotFound(err), "the head is not written when its version cannot be")
+}
+
+func TestSecretRevealRequiresRole(t *testing.T) {
+	svc, authz, vault := setup(t)
+
+	created, err := svc.Create(as("alice"), vault.ID, &entity.Secret{Name: "db", Type: entity.SECRET_PASSWORD, Value: password("one")})
+	require.NoError(t, err)
+
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("bob"), Relation: authorization.RelationMember, Object: authorization.Object(authorization.TypeTenant, "t1")},
+	}))
+
+	_, err = svc.Reveal(as("bob"), vault.ID, created.ID)
+	assert.True(t, core.IsForbidden(err), "a member of the tenant cannot read a vault that was not shared with it")
+
+	_, err = svc.Get(as("bob"), vault.ID, created.ID)
+	assert.True(t, core.IsForbidden(err))
+
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("bob"), Relation: "reader", Object: vault.GetOpenFGAID()},
+	}))
+
+	revealed, err := svc.Reveal(as("bob"), vault.ID, created.ID)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"password":"one"}`, string(revealed.Value))
+}
@@ -0,0 +1,120 @@
+// Package servicetest provides the stubs and fixtures shared by the tests of the services.
+package servicetest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/mailer"
+)
+
+// ModelFile returns the path of the OpenFGA model of the repository.
+func ModelFile() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "docker", "openfga", "lockari-refined-model.fga")
+}
+
+// Audit keeps the recorded events in memory.
+type Audit struct {
+	entity_audit.AuditSystemEventService
+	Events []*entity_audit.AuditSystemEvent
+}
+
+func (a *Audit) Record(ctx context.Context, event *entity_audit.AuditSystemEvent) (*entity_audit.AuditSystemEvent, error) {
+	a.Events = append(a.Events, event)
+	return event, nil
+}
+
+// Auth keeps the emails and the tenantId claims of the users in memory.
+type Auth struct {
+	authenticator.Authenticator
+	Emails map[string]string
+	Claims map[string]string
+}
+
+func (a *Auth) GetUserEmail(ctx context.Context, uid string) (string, error) {
+	if email, ok := a.Emails[uid]; ok {
+		return email, nil
+	}
+	return "", errors.New("user or email not found")
+}
+
+func (a *Auth) GetUserName(ctx context.Context, uid string) (string, error) {
+	return "", errors.New("user or name not found")
+}
+
+func (a *Auth) GetTenant(ctx context.Context, uid string) (string, error) {
+	if tenantID := a.Claims[uid]; tenantID != "" {
+		return tenantID, nil
+	}
+	return "", errors.New("tenant ID not found in user claims")
+}
+
+func (a *Auth) SetTenantId(ctx context.Context, uid string, tenantID string) error {
+	a.Claims[uid] = tenantID
+	return nil
+}
+
+func (a *Auth) SetTenantRollback(ctx context.Context, uid string, tenantID string) error {
+	a.Claims[uid] = tenantID
+	return nil
+}
+
+// Sender keeps the sent emails in memory.
+type Sender struct {
+	Messages []mailer.Message
+}
+
+func (s *Sender) Provider() string {
+	return "stub"
+}
+
+func (s *Sender) Send(ctx context.Context, msg mailer.Message) error {
+	s.Messages = append(s.Messages, msg)
+	return nil
+}
+
+// Token reads the token of the link of the last email sent to the address, or of the last
+// email when to is empty.
+func (s *Sender) Token(t *testing.T, to string) string {
+	t.Helper()
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		if to != "" && s.Messages[i].To != to {
+			continue
+		}
+		body := s.Messages[i].Body
+		start := strings.Index(body, "token=") + len("token=")
+		token, err := url.QueryUnescape(strings.Fields(body[start:])[0])
+		require.NoError(t, err)
+		return token
+	}
+	t.Fatalf("no message sent to %q", to)
+	return ""
+}
+
+// Outbox keeps the enqueued tuples in memory without applying them.
+type Outbox struct {
+	entity_outbox.OutboxService
+	Written []authorization.TupleKey
+	Deleted []authorization.TupleKey
+}
+
+func (o *Outbox) Enqueue(ctx context.Context, operation entity_outbox.OutboxOperation, tuples []authorization.TupleKey, source string) (*entity_outbox.OutboxEntry, error) {
+	switch operation {
+	case entity_outbox.OUTBOX_WRITE:
+		o.Written = append(o.Written, tuples...)
+	case entity_outbox.OUTBOX_DELETE:
+		o.Deleted = append(o.Deleted, tuples...)
+	}
+	return &entity_outbox.OutboxEntry{}, nil
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+)
+
+// guard is an Authorizer that denies the shares whose expiration passed, so a role is never
+// used after its expiration even if the sweeper has not removed it yet. A user reached by a
+// due expiration keeps the relations it has through its other roles on the vault; removing the
+// role is left to the sweeper. The writes and reads of tuples go straight to the wrapped
+// authorizer.
+type guard struct {
+	authorization.Authorizer
+	expirations entity.ExpirationRepository
+	refresh     time.Duration
+	now         func() time.Time
+
+	// mu only guards the fields below; it is never held across a call to the database.
+	mu       sync.Mutex
+	loadedAt time.Time
+	loading  bool
+	expiring []entity.Expiration
+}
+
+// InitializeExpiryGuard wraps authz. The guard reloads the shares that expire within
+// entity.MinExpiry every refresh, which must be shorter than entity.MinExpiry, so it knows a
+// share before it expires even while a reload fails.
+func InitializeExpiryGuard(authz authorization.Authorizer, expirations entity.ExpirationRepository, refresh time.Duration) (authorization.Authorizer, error) {
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if expirations == nil {
+		return nil, core.ErrRepositoryNotFound("ExpirationRepository")
+	}
+
+	if refresh <= 0 || refresh >= entity.MinExpiry {
+		return nil, fmt.Errorf("expiry guard refresh must be between 0 and %s", entity.MinExpiry)
+	}
+
+	return &guard{
+		Authorizer:  authz,
+		expirations: expirations,
+		refresh:     refresh,
+		now:         func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+func (g *guard) Check(ctx context.Context, user, relation, object string) (bool, error) {
+
+	due, err := g.due(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	allowed, err := g.Authorizer.Check(ctx, user, relation, object)
+	if err != nil || !allowed {
+		return false, err
+	}
+
+	return g.allowed(ctx, due, user, relation, object)
+}
+
+func (g *guard) BatchCheck(ctx context.Context, checks []authorization.TupleKey) ([]bool, error) {
+
+	due, err := g.due(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := g.Authorizer.BatchCheck(ctx, checks)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, c := range checks {
+		if !results[i] {
+			continue
+		}
+
+		results[i], err = g.allowed(ctx, due, c.User, c.Relation, c.Object)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+func (g *guard) ListObjects(ctx context.Context, user, relation, objectType string) ([]string, error) {
+
+	due, err := g.due(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := g.Authorizer.ListObjects(ctx, user, relation, objectType)
+	if err != nil || len(due) == 0 {
+		return objects, err
+	}
+
+	allowed := []string{}
+	for _, object := range objects {
+		ok, err := g.allowed(ctx, due, user, relation, object)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			allowed = append(allowed, object)
+		}
+	}
+
+	return allowed, nil
+}
+
+// allowed reports whether a relation the wrapped authorizer allowed still holds without the due
+// expirations. A user that no due expiration reaches keeps it; otherwise one of the roles that
+// give the relation must come from a tuple that did not expire.
+func (g *guard) allowed(ctx context.Context, due []entity.Expiration, user, relation, object string) (bool, error) {
+
+	expired, err := g.expired(ctx, due, user, object)
+	if err != nil || len(expired) == 0 {
+		return err == nil, err
+	}
+
+	for _, role := range entity.Roles {
+		if !role.Grants(relation) {
+			continue
+		}
+
+		if !expiredRole(expired, role) {
+			// No due expiration gives the role to the user, so any path to it is still valid.
+			ok, err := g.Authorizer.Check(ctx, user, string(role), object)
+			if err != nil || ok {
+				return ok, err
+			}
+			continue
+		}
+
+		ok, err := g.hasUnexpiredRole(ctx, due, user, role, object)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+// hasUnexpiredRole reports whether a tuple of role on object that is not due gives the role
+// to the user, directly or through a group it is an active member of.
+func (g *guard) hasUnexpiredRole(ctx context.Context, due []entity.Expiration, user string, role entity.Role, object string) (bool, error) {
+
+	tuples, err := g.Authorizer.ReadTuples(ctx, authorization.TupleKey{Relation: string(role), Object: object})
+	if err != nil {
+		return false, fmt.Errorf("failed to read the %s tuples of %s: %w", role, object, err)
+	}
+
+	for _, t := range tuples {
+		if isDue(due, t) {
+			continue
+		}
+
+		if t.User == user {
+			return true, nil
+		}
+
+		share, ok := entity.ShareFromTuple(t)
+		if !ok || share.SubjectType != entity.SUBJECT_GROUP {
+			continue
+		}
+
+		member, err := g.isMember(ctx, user, share.SubjectID)
+		if err != nil || member {
+			return member, err
+		}
+	}
+
+	return false, nil
+}
+
+// expired returns the due expirations of object that reach the user: the user itself or a
+// group it is an active member of.
+func (g *guard) expired(ctx context.Context, due []entity.Expiration, user, object string) ([]entity.Expiration, error) {
+
+	var expired []entity.Expiration
+	for _, e := range due {
+		if e.Object() != object {
+			continue
+		}
+
+		if e.SubjectType != entity.SUBJECT_GROUP {
+			if entity.Subject(e.SubjectType, e.SubjectID) == user {
+				expired = append(expired, e)
+			}
+			continue
+		}
+
+		member, err := g.isMember(ctx, user, e.SubjectID)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			expired = append(expired, e)
+		}
+	}
+
+	return expired, nil
+}
+
+func (g *guard) isMember(ctx context.Context, user, groupID string) (bool, error) {
+
+	member, err := g.Authorizer.Check(ctx, user, entity_group.RelationActiveMember, authorization.Object(authorization.TypeGroup, groupID))
+	if err != nil {
+		return false, fmt.Errorf("failed to check membership of group %s: %w", groupID, err)
+	}
+
+	return member, nil
+}
+
+func expiredRole(expirations []entity.Expiration, role entity.Role) bool {
+	for _, e := range expirations {
+		if e.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// isDue reports whether the tuple is the role of one of the due expirations.
+func isDue(due []entity.Expiration, t authorization.TupleKey) bool {
+	for _, e := range due {
+		if e.Object() == t.Object && string(e.Role) == t.Relation && entity.Subject(e.SubjectType, e.SubjectID) == t.User {
+			return true
+		}
+	}
+	return false
+}
+
+// due returns the loaded expirations that passed. The list is reloaded every refresh by one
+// caller while the others keep using the previous one; only the first load is waited for.
+func (g *guard) due(ctx context.Context) ([]entity.Expiration, error) {
+
+	now := g.now()
+
+	g.mu.Lock()
+	loaded := !g.loadedAt.IsZero()
+	reload := !g.loading && now.Sub(g.loadedAt) >= g.refresh
+	if reload {
+		g.loading = true
+	}
+	expiring := g.expiring
+	g.mu.Unlock()
+
+	if reload {
+		fresh, err := g.load(ctx, now)
+		if err != nil {
+			if !loaded {
+				return nil, err
+			}
+			log.Printf("Expiry guard: %v", err)
+		} else {
+			expiring = fresh
+		}
+	}
+
+	due := []entity.Expiration{}
+	for _, e := range expiring {
+		if !e.ExpiresAt.After(now) {
+			due = append(due, e)
+		}
+	}
+
+	return due, nil
+}
+
+// load reads the expirations and replaces the loaded list. The list is never modified in
+// place, so the callers can read the previous one without the lock.
+func (g *guard) load(ctx context.Context, now time.Time) ([]entity.Expiration, error) {
+
+	expiring, err := g.expirations.ListExpiring(context.WithoutCancel(ctx), now.Add(entity.MinExpiry))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.loading = false
+	if err != nil {
+		return nil, fmt.Errorf("failed to load expiring shares: %w", err)
+	}
+
+	g.expiring = expiring
+	g.loadedAt = now
+
+	return expiring, nil
+}
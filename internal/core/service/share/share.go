@@ -0,0 +1,604 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type share struct {
+	vaults      entity_vault.VaultRepository
+	groups      entity_group.GroupRepository
+	expirations entity.ExpirationRepository
+	authz       authorization.Authorizer
+	outbox      entity_outbox.OutboxService
+	audit       entity_audit.AuditSystemEventService
+	now         func() time.Time
+}
+
+func InitializeShareService(vaults entity_vault.VaultRepository, groups entity_group.GroupRepository, expirations entity.ExpirationRepository, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (entity.ShareService, error) {
+
+	if vaults == nil {
+		return nil, core.ErrRepositoryNotFound("VaultRepository")
+	}
+
+	if groups == nil {
+		return nil, core.ErrRepositoryNotFound("GroupRepository")
+	}
+
+	if expirations == nil {
+		return nil, core.ErrRepositoryNotFound("ExpirationRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	return &share{
+		vaults:      vaults,
+		groups:      groups,
+		expirations: expirations,
+		authz:       authz,
+		outbox:      outbox,
+		audit:       audit,
+		now:         func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+func (s *share) Grant(ctx context.Context, vaultID string, data *entity.Share) (*entity.Share, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("share is required")
+	}
+
+	grant := &entity.Share{VaultID: vaultID, SubjectType: data.SubjectType, SubjectID: strings.TrimSpace(data.SubjectID), Role: data.Role, ExpiresAt: data.ExpiresAt}
+	if err := grant.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if err := grant.ValidateExpiry(s.now()); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	userID, vault, err := s.vault(ctx, vaultID, authorization.CanShare)
+	if err != nil {
+		return nil, err
+	}
+
+	if grant.Role == entity.ROLE_ADMIN {
+		if err := s.ensureOwner(ctx, userID, vault); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.ensureSubject(ctx, vault.TenantID, grant.SubjectType, grant.SubjectID); err != nil {
+		return nil, err
+	}
+
+	current, err := s.roles(ctx, vault, grant.SubjectType, grant.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(current) > 0 {
+		return nil, core.ErrConflict(fmt.Sprintf("%s %s already has role %s on the vault", grant.SubjectType, grant.SubjectID, current[0].Role))
+	}
+
+	// The expiration is stored first, so a role is never written without it.
+	if err := s.setExpiry(ctx, userID, vault, grant); err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(ctx, vault, entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{grant.Tuple()}); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entity_audit.VAULT_SHARED, userID, vault, "share",
+		fmt.Sprintf("vault shared with %s %s as %s%s", grant.SubjectType, grant.SubjectID, grant.Role, until(grant.ExpiresAt)))
+
+	return grant, nil
+}
+
+func (s *share) Change(ctx context.Context, vaultID string, subjectType entity.SubjectType, subjectID string, role entity.Role, expiresAt *time.Time) (*entity.Share, error) {
+
+	change := &entity.Share{VaultID: vaultID, SubjectType: subjectType, SubjectID: subjectID, Role: role, ExpiresAt: expiresAt}
+	if err := change.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if err := change.ValidateExpiry(s.now()); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	userID, vault, err := s.vault(ctx, vaultID, authorization.CanShare)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.shares(ctx, vault, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == entity.ROLE_ADMIN || hasRole(current, entity.ROLE_ADMIN) {
+		if err := s.ensureOwner(ctx, userID, vault); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.setExpiry(ctx, userID, vault, change); err != nil {
+		return nil, err
+	}
+
+	if len(current) != 1 || current[0].Role != role {
+		// The old roles are removed first, so a failed grant never leaves more access than requested.
+		if err := s.apply(ctx, vault, entity_outbox.OUTBOX_DELETE, tuples(current)); err != nil {
+			return nil, err
+		}
+
+		if err := s.apply(ctx, vault, entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{change.Tuple()}); err != nil {
+			return nil, err
+		}
+	}
+
+	s.record(ctx, entity_audit.PERMISSION_GRANTED, userID, vault, "change_role",
+		fmt.Sprintf("%s %s changed from %s to %s%s", subjectType, subjectID, current[0].Role, role, until(expiresAt)))
+
+	return change, nil
+}
+
+func (s *share) Revoke(ctx context.Context, vaultID string, subjectType entity.SubjectType, subjectID string) error {
+
+	if subjectType != entity.SUBJECT_USER && subjectType != entity.SUBJECT_GROUP {
+		return core.ErrInvalidRequest("subject type must be user or group")
+	}
+
+	if subjectID == "" {
+		return core.ErrInvalidRequest("subject ID is required")
+	}
+
+	userID, vault, err := s.vault(ctx, vaultID, authorization.CanShare)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.shares(ctx, vault, subjectType, subjectID)
+	if err != nil {
+		return err
+	}
+
+	if hasRole(current, entity.ROLE_ADMIN) {
+		if err := s.ensureOwner(ctx, userID, vault); err != nil {
+			return err
+		}
+	}
+
+	if err := s.apply(ctx, vault, entity_outbox.OUTBOX_DELETE, tuples(current)); err != nil {
+		return err
+	}
+
+	// A leftover expiration only makes the sweeper find no role to remove.
+	if err := s.expirations.Delete(ctx, entity.ExpirationID(vault.ID, subjectType, subjectID)); err != nil {
+		log.Printf("Failed to delete expiration of the share of vault %s with %s %s: %v", vault.ID, subjectType, subjectID, err)
+	}
+
+	s.record(ctx, entity_audit.PERMISSION_REVOKED, userID, vault, "revoke",
+		fmt.Sprintf("access of %s %s revoked", subjectType, subjectID))
+
+	return nil
+}
+
+// ListAccess resolves the users of the groups the vault is shared with, including nested groups.
+// Each user is listed once with its highest role.
+func (s *share) ListAccess(ctx context.Context, vaultID string) (*entity.VaultAccess, error) {
+
+	_, vault, err := s.vault(ctx, vaultID, authorization.CanView)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.authz.ReadTuples(ctx, authorization.TupleKey{Object: vault.GetOpenFGAID()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shares of vault %s: %w", vaultID, err)
+	}
+
+	expirations, err := s.expirations.ListByVault(ctx, vault.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := map[string]*time.Time{}
+	for i := range expirations {
+		expiresAt[expirations[i].ID] = &expirations[i].ExpiresAt
+	}
+
+	result := &entity.VaultAccess{VaultID: vault.ID, Shares: []entity.Share{}, Users: []entity.Access{}}
+	users := map[string]entity.Access{}
+	keep := func(access entity.Access) {
+		if current, ok := users[access.UserID]; !ok || access.Role.Outranks(current.Role) {
+			users[access.UserID] = access
+		}
+	}
+
+	for _, t := range stored {
+		sh, ok := entity.ShareFromTuple(t)
+		if !ok {
+			continue
+		}
+		sh.ExpiresAt = expiresAt[entity.ExpirationID(sh.VaultID, sh.SubjectType, sh.SubjectID)]
+		result.Shares = append(result.Shares, sh)
+
+		if sh.SubjectType == entity.SUBJECT_USER {
+			keep(entity.Access{UserID: sh.SubjectID, Role: sh.Role})
+			continue
+		}
+
+		members, err := s.activeMembers(ctx, sh.SubjectID)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			keep(entity.Access{UserID: member, Role: sh.Role, GroupID: sh.SubjectID})
+		}
+	}
+
+	for _, access := range users {
+		result.Users = append(result.Users, access)
+	}
+	sort.Slice(result.Users, func(i, j int) bool { return result.Users[i].UserID < result.Users[j].UserID })
+
+	return result, nil
+}
+
+func (s *share) SharedWithMe(ctx context.Context) ([]entity.SharedVault, error) {
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user := authorization.User(userID)
+	vaultType := authorization.Object(authorization.TypeVault, "")
+
+	direct, err := s.authz.ReadTuples(ctx, authorization.TupleKey{User: user, Object: vaultType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vaults shared with user %s: %w", userID, err)
+	}
+
+	// Vaults owned by the user are not shared with it, even when its groups have a role on them.
+	owned := map[string]bool{}
+	for _, t := range direct {
+		if sh, ok := entity.ShareFromTuple(t); ok && sh.Role == entity.ROLE_OWNER {
+			owned[sh.VaultID] = true
+		}
+	}
+
+	best := map[string]entity.SharedVault{}
+	keep := func(t authorization.TupleKey, groupID string) {
+		sh, ok := entity.ShareFromTuple(t)
+		if !ok || owned[sh.VaultID] {
+			return
+		}
+		if current, ok := best[sh.VaultID]; !ok || sh.Role.Outranks(current.Role) {
+			best[sh.VaultID] = entity.SharedVault{Role: sh.Role, GroupID: groupID}
+		}
+	}
+
+	for _, t := range direct {
+		keep(t, "")
+	}
+
+	groups, err := s.authz.ListObjects(ctx, user, entity_group.RelationActiveMember, authorization.TypeGroup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups of user %s: %w", userID, err)
+	}
+
+	for _, groupID := range authorization.ObjectIDs(groups) {
+		granted, err := s.authz.ReadTuples(ctx, authorization.TupleKey{User: entity.Subject(entity.SUBJECT_GROUP, groupID), Object: vaultType})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vaults shared with group %s: %w", groupID, err)
+		}
+		for _, t := range granted {
+			keep(t, groupID)
+		}
+	}
+
+	// The authorization model holds the vaults of every tenant; only those of the tenant are returned.
+	result := []entity.SharedVault{}
+	for id, shared := range best {
+		v, err := s.vaults.Get(ctx, id)
+		if err != nil {
+			if core.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if v.TenantID != tenantID || v.IsDeleted() {
+			continue
+		}
+		shared.Vault = *v
+		result = append(result, shared)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Vault.Name < result[j].Vault.Name })
+
+	return result, nil
+}
+
+func (s *share) identity(ctx context.Context) (string, string, error) {
+
+	if ctx.Err() != nil {
+		return "", "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	return userID, tenantID, nil
+}
+
+// vault checks the relation of the user of the context on the vault and returns the vault,
+// if it is an active vault of the tenant of the context.
+func (s *share) vault(ctx context.Context, id, relation string) (string, *entity_vault.Vault, error) {
+
+	if id == "" {
+		return "", nil, core.ErrInvalidRequest("vault ID is required")
+	}
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), relation, authorization.Object(authorization.TypeVault, id))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to check vault permission: %w", err)
+	}
+
+	if !allowed {
+		return "", nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to %s vault %s", strings.TrimPrefix(relation, "can_"), id))
+	}
+
+	v, err := s.vaults.Get(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if v.TenantID != tenantID || v.IsDeleted() {
+		return "", nil, core.ErrNotFound("vault " + id)
+	}
+
+	return userID, v, nil
+}
+
+// ensureOwner allows only owners of the vault to give or take the admin role.
+func (s *share) ensureOwner(ctx context.Context, userID string, vault *entity_vault.Vault) error {
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), authorization.RelationOwner, vault.GetOpenFGAID())
+	if err != nil {
+		return fmt.Errorf("failed to check vault ownership: %w", err)
+	}
+
+	if !allowed {
+		return core.ErrForbidden("only owners of the vault can manage admins")
+	}
+
+	return nil
+}
+
+// ensureSubject checks that the user is an active member of the tenant, or that the group belongs to it.
+func (s *share) ensureSubject(ctx context.Context, tenantID string, subjectType entity.SubjectType, subjectID string) error {
+
+	if subjectType == entity.SUBJECT_GROUP {
+		if _, err := s.groups.Get(ctx, subjectID); err != nil {
+			if core.IsNotFound(err) {
+				return core.ErrInvalidRequest("group " + subjectID + " not found in the tenant")
+			}
+			return err
+		}
+		return nil
+	}
+
+	allowed, err := s.authz.Check(ctx, authorization.User(subjectID), "active_member", authorization.Object(authorization.TypeTenant, tenantID))
+	if err != nil {
+		return fmt.Errorf("failed to check tenant membership: %w", err)
+	}
+
+	if !allowed {
+		return core.ErrInvalidRequest("user " + subjectID + " is not an active member of the tenant")
+	}
+
+	return nil
+}
+
+// roles returns every role the subject holds directly on the vault.
+func (s *share) roles(ctx context.Context, vault *entity_vault.Vault, subjectType entity.SubjectType, subjectID string) ([]entity.Share, error) {
+
+	stored, err := s.authz.ReadTuples(ctx, authorization.TupleKey{User: entity.Subject(subjectType, subjectID), Object: vault.GetOpenFGAID()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shares of vault %s: %w", vault.ID, err)
+	}
+
+	result := []entity.Share{}
+	for _, t := range stored {
+		if sh, ok := entity.ShareFromTuple(t); ok {
+			result = append(result, sh)
+		}
+	}
+
+	return result, nil
+}
+
+// shares returns the shareable roles of the subject on the vault, or not found when it has none.
+func (s *share) shares(ctx context.Context, vault *entity_vault.Vault, subjectType entity.SubjectType, subjectID string) ([]entity.Share, error) {
+
+	current, err := s.roles(ctx, vault, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []entity.Share{}
+	for _, sh := range current {
+		if sh.Role.IsShareable() {
+			result = append(result, sh)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, core.ErrNotFound(fmt.Sprintf("share of vault %s with %s %s", vault.ID, subjectType, subjectID))
+	}
+
+	return result, nil
+}
+
+// activeMembers returns the users that are active members of the group, directly or through
+// nested groups.
+func (s *share) activeMembers(ctx context.Context, groupID string) ([]string, error) {
+
+	candidates := []string{}
+	seen := map[string]bool{}
+	visited := map[string]bool{groupID: true}
+	queue := []string{groupID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		stored, err := s.authz.ReadTuples(ctx, authorization.TupleKey{Object: authorization.Object(authorization.TypeGroup, id)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read members of group %s: %w", id, err)
+		}
+
+		for _, t := range stored {
+			if t.Relation == authorization.RelationTenant {
+				continue
+			}
+			if object, _, nested := strings.Cut(t.User, "#"); nested {
+				if _, child, err := authorization.SplitObject(object); err == nil && !visited[child] {
+					visited[child] = true
+					queue = append(queue, child)
+				}
+				continue
+			}
+			if _, userID, err := authorization.SplitObject(t.User); err == nil && !seen[userID] {
+				seen[userID] = true
+				candidates = append(candidates, userID)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	// Users banned from the tenant are members of the group but not active members.
+	checks := make([]authorization.TupleKey, len(candidates))
+	for i, userID := range candidates {
+		checks[i] = authorization.TupleKey{User: authorization.User(userID), Relation: entity_group.RelationActiveMember, Object: authorization.Object(authorization.TypeGroup, groupID)}
+	}
+
+	allowed, err := s.authz.BatchCheck(ctx, checks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check members of group %s: %w", groupID, err)
+	}
+
+	result := []string{}
+	for i, userID := range candidates {
+		if allowed[i] {
+			result = append(result, userID)
+		}
+	}
+
+	return result, nil
+}
+
+// setExpiry stores the expiration of the share, or removes it when the share does not expire.
+func (s *share) setExpiry(ctx context.Context, userID string, vault *entity_vault.Vault, sh *entity.Share) error {
+
+	id := entity.ExpirationID(vault.ID, sh.SubjectType, sh.SubjectID)
+	if sh.ExpiresAt == nil {
+		return s.expirations.Delete(ctx, id)
+	}
+
+	expiration := entity.NewExpiration(vault.TenantID, sh, userID)
+	sh.ExpiresAt = &expiration.ExpiresAt
+
+	payload, err := utils.StructToMap(expiration)
+	if err != nil {
+		return core.ErrGenericError("Failed to convert share expiration data to map")
+	}
+
+	return s.expirations.Set(ctx, payload)
+}
+
+// apply stores the outbox entry of the tuples and applies it right away; on failure the relay retries.
+func (s *share) apply(ctx context.Context, vault *entity_vault.Vault, operation entity_outbox.OutboxOperation, changes []authorization.TupleKey) error {
+
+	entry, err := s.outbox.Enqueue(ctx, operation, changes, vault.GetOpenFGAID())
+	if err != nil {
+		return fmt.Errorf("failed to store the permissions of vault %s: %w", vault.ID, err)
+	}
+
+	if err := s.outbox.Dispatch(ctx, entry); err != nil {
+		log.Printf("Shares of vault %s changed but permissions are pending: %v", vault.ID, err)
+	}
+
+	return nil
+}
+
+func (s *share) record(ctx context.Context, eventType entity_audit.EventType, userID string, vault *entity_vault.Vault, action, reason string) {
+
+	event := entity_audit.NewResourceEvent(eventType, userID, vault.TenantID, vault.GetOpenFGAID(), action, reason)
+	if _, err := s.audit.Record(context.WithValue(context.WithoutCancel(ctx), "TenantID", vault.TenantID), event); err != nil {
+		log.Printf("Failed to record %s event for vault %s: %v", eventType, vault.ID, err)
+	}
+}
+
+func hasRole(shares []entity.Share, role entity.Role) bool {
+	for _, sh := range shares {
+		if sh.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func tuples(shares []entity.Share) []authorization.TupleKey {
+	result := make([]authorization.TupleKey, len(shares))
+	for i := range shares {
+		result[i] = shares[i].Tuple()
+	}
+	return result
+}
+
+func until(expiresAt *time.Time) string {
+	if expiresAt == nil {
+		return ""
+	}
+	return " until " + expiresAt.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_group "github.com/synera-br/lockari-backend-app/internal/core/repository/group"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	repo_share "github.com/synera-br/lockari-backend-app/internal/core/repository/share"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	svc_group "github.com/synera-br/lockari-backend-app/internal/core/service/group"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+func TestVaultSharing(t *testing.T) {
+	as := func(userID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", "t1")
+	}
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	tenant := authorization.Object(authorization.TypeTenant, "t1")
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: tenant},
+		{User: authorization.User("bob"), Relation: authorization.RelationMember, Object: tenant},
+		{User: authorization.User("carol"), Relation: authorization.RelationMember, Object: tenant},
+	}))
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	require.NoError(t, err)
+	payload, err := utils.StructToMap(entity_vault.NewVault(entity_vault.Vault{Name: "Production"}, "t1", "alice"))
+	require.NoError(t, err)
+	vault, err := vaults.Create(as("alice"), payload)
+	require.NoError(t, err)
+	require.NoError(t, authz.WriteTuples(context.Background(), vault.OwnershipTuples()))
+
+	groups, err := repo_group.InitializeGroupRepository(db)
+	require.NoError(t, err)
+	groupSvc, err := svc_group.InitializeGroupService(groups, authz, outbox, &servicetest.Audit{})
+	require.NoError(t, err)
+	ops, err := groupSvc.Create(as("alice"), &entity_group.Group{Name: "Ops"})
+	require.NoError(t, err)
+	_, err = groupSvc.AddMember(as("alice"), ops.ID, &entity_group.Member{Type: entity_group.MEMBER_USER, MemberID: "carol"})
+	require.NoError(t, err)
+
+	expirations, err := repo_share.InitializeExpirationRepository(db)
+	require.NoError(t, err)
+
+	audit := &servicetest.Audit{}
+	svc, err := InitializeShareService(vaults, groups, expirations, authz, outbox, audit)
+	require.NoError(t, err)
+
+	_, err = svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_USER, SubjectID: "bob", Role: entity.ROLE_WRITER})
+	require.NoError(t, err)
+
+	_, err = svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_USER, SubjectID: "bob", Role: entity.ROLE_VIEWER})
+	assert.True(t, core.IsConflict(err), "a subject with a role must change it instead")
+
+	_, err = svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_USER, SubjectID: "dave", Role: entity.ROLE_READER})
+	assert.True(t, core.IsInvalidRequest(err), "only members of the tenant can receive a share")
+
+	_, err = svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_USER, SubjectID: "carol", Role: entity.ROLE_OWNER})
+	assert.True(t, core.IsInvalidRequest(err), "ownership is not shareable")
+
+	_, err = svc.Grant(as("bob"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_GROUP, SubjectID: ops.ID, Role: entity.ROLE_READER})
+	assert.True(t, core.IsForbidden(err), "writers cannot share the vault")
+
+	_, err = svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_GROUP, SubjectID: ops.ID, Role: entity.ROLE_COPIER})
+	require.NoError(t, err)
+
+	allowed, err := authz.Check(context.Background(), authorization.User("carol"), "can_copy", vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	access, err := svc.ListAccess(as("bob"), vault.ID)
+	require.NoError(t, err)
+	assert.Len(t, access.Shares, 3)
+	assert.Equal(t, []entity.Access{
+		{UserID: "alice", Role: entity.ROLE_OWNER},
+		{UserID: "bob", Role: entity.ROLE_WRITER},
+		{UserID: "carol", Role: entity.ROLE_COPIER, GroupID: ops.ID},
+	}, access.Users)
+
+	shared, err := svc.SharedWithMe(as("carol"))
+	require.NoError(t, err)
+	require.Len(t, shared, 1)
+	assert.Equal(t, vault.ID, shared[0].Vault.ID)
+	assert.Equal(t, ops.ID, shared[0].GroupID)
+
+	shared, err = svc.SharedWithMe(as("alice"))
+	require.NoError(t, err)
+	assert.Empty(t, shared, "owned vaults are not shared with their owner")
+
+	changed, err := svc.Change(as("alice"), vault.ID, entity.SUBJECT_USER, "bob", entity.ROLE_ADMIN, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ROLE_ADMIN, changed.Role)
+
+	// Bob is an admin now, but only owners can take the admin role back.
+	_, err = svc.Change(as("bob"), vault.ID, entity.SUBJECT_USER, "bob", entity.ROLE_READER, nil)
+	assert.True(t, core.IsForbidden(err))
+
+	require.NoError(t, svc.Revoke(as("bob"), vault.ID, entity.SUBJECT_GROUP, ops.ID))
+	shared, err = svc.SharedWithMe(as("carol"))
+	require.NoError(t, err)
+	assert.Empty(t, shared)
+
+	err = svc.Revoke(as("alice"), vault.ID, entity.SUBJECT_GROUP, ops.ID)
+	assert.True(t, core.IsNotFound(err))
+
+	assert.Len(t, audit.Events, 4)
+}
+
+func TestShareExpiry(t *testing.T) {
+	as := func(userID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", "t1")
+	}
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	tenant := authorization.Object(authorization.TypeTenant, "t1")
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: tenant},
+		{User: authorization.User("bob"), Relation: authorization.RelationMember, Object: tenant},
+		{User: authorization.User("carol"), Relation: authorization.RelationMember, Object: tenant},
+	}))
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	require.NoError(t, err)
+	payload, err := utils.StructToMap(entity_vault.NewVault(entity_vault.Vault{Name: "Production"}, "t1", "alice"))
+	require.NoError(t, err)
+	vault, err := vaults.Create(as("alice"), payload)
+	require.NoError(t, err)
+	require.NoError(t, authz.WriteTuples(context.Background(), vault.OwnershipTuples()))
+
+	groups, err := repo_group.InitializeGroupRepository(db)
+	require.NoError(t, err)
+	expirations, err := repo_share.InitializeExpirationRepository(db)
+	require.NoError(t, err)
+
+	audit := &servicetest.Audit{}
+	sweeperSvc, err := InitializeSweeperService(expirations, authz, outbox, audit)
+	require.NoError(t, err)
+	guarded, err := InitializeExpiryGuard(authz, expirations, time.Minute)
+	require.NoError(t, err)
+	svc, err := InitializeShareService(vaults, groups, expirations, guarded, outbox, audit)
+	require.NoError(t, err)
+
+	soon := time.Now().Add(time.Minute)
+	_, err = svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_USER, SubjectID: "bob", Role: entity.ROLE_READER, ExpiresAt: &soon})
+	assert.True(t, core.IsInvalidRequest(err), "a share lasts at least MinExpiry")
+
+	inHour := time.Now().Add(time.Hour)
+	grant, err := svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_USER, SubjectID: "bob", Role: entity.ROLE_READER, ExpiresAt: &inHour})
+	require.NoError(t, err)
+	require.NotNil(t, grant.ExpiresAt)
+
+	inDay := time.Now().Add(24 * time.Hour)
+	_, err = svc.Grant(as("alice"), vault.ID, &entity.Share{SubjectType: entity.SUBJECT_USER, SubjectID: "carol", Role: entity.ROLE_READER, ExpiresAt: &inDay})
+	require.NoError(t, err)
+
+	access, err := svc.ListAccess(as("alice"), vault.ID)
+	require.NoError(t, err)
+	for _, sh := range access.Shares {
+		if sh.SubjectID == "bob" {
+			require.NotNil(t, sh.ExpiresAt)
+			assert.True(t, sh.ExpiresAt.Equal(*grant.ExpiresAt))
+		}
+	}
+
+	allowed, err := guarded.Check(context.Background(), authorization.User("bob"), string(entity.ROLE_READER), vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Two hours later the guard denies bob's role before the sweeper removes it, and only his.
+	later := func() time.Time { return time.Now().UTC().Add(2 * time.Hour) }
+	guarded.(*guard).now = later
+	sweeperSvc.(*sweeper).now = later
+
+	allowed, err = guarded.Check(context.Background(), authorization.User("bob"), string(entity.ROLE_READER), vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	objects, err := guarded.ListObjects(context.Background(), authorization.User("bob"), authorization.CanView, authorization.TypeVault)
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	allowed, err = guarded.Check(context.Background(), authorization.User("carol"), string(entity.ROLE_READER), vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.True(t, allowed, "carol's share lasts a day")
+
+	allowed, err = authz.Check(context.Background(), authorization.User("bob"), string(entity.ROLE_READER), vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.True(t, allowed, "the role is stored until the sweeper runs")
+
+	report, err := sweeperSvc.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Revoked)
+
+	require.Len(t, audit.Events, 3)
+	assert.Equal(t, entity_audit.PERMISSION_REVOKED, audit.Events[2].EventType)
+	assert.Equal(t, entity.ExpiryReason, audit.Events[2].Reason)
+
+	// Removing the expiration keeps carol's role after the day.
+	_, err = svc.Change(as("alice"), vault.ID, entity.SUBJECT_USER, "carol", entity.ROLE_READER, nil)
+	require.NoError(t, err)
+
+	week := func() time.Time { return time.Now().UTC().Add(7 * 24 * time.Hour) }
+	sweeperSvc.(*sweeper).now = week
+	report, err = sweeperSvc.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, report.Checked)
+
+	allowed, err = authz.Check(context.Background(), authorization.User("carol"), string(entity.ROLE_READER), vault.GetOpenFGAID())
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestExpiryGuardKeepsOtherRoles(t *testing.T) {
+	ctx := context.WithValue(context.WithValue(context.Background(), "UserID", "alice"), "TenantID", "t1")
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	// alice owns the vault and erin is a writer; both are in ops with dave, whose reader share expires.
+	tenant := authorization.Object(authorization.TypeTenant, "t1")
+	ops := authorization.Object(authorization.TypeGroup, "ops")
+	vault := authorization.Object(authorization.TypeVault, "v1")
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: tenant},
+		{User: authorization.User("dave"), Relation: authorization.RelationMember, Object: tenant},
+		{User: authorization.User("erin"), Relation: authorization.RelationMember, Object: tenant},
+		{User: tenant, Relation: authorization.RelationTenant, Object: ops},
+		{User: authorization.User("alice"), Relation: authorization.RelationMember, Object: ops},
+		{User: authorization.User("dave"), Relation: authorization.RelationMember, Object: ops},
+		{User: authorization.User("erin"), Relation: authorization.RelationMember, Object: ops},
+		{User: tenant, Relation: authorization.RelationTenant, Object: vault},
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: vault},
+		{User: authorization.User("erin"), Relation: string(entity.ROLE_WRITER), Object: vault},
+		{User: entity.Subject(entity.SUBJECT_GROUP, "ops"), Relation: string(entity.ROLE_READER), Object: vault},
+	}))
+
+	expirations, err := repo_share.InitializeExpirationRepository(db)
+	require.NoError(t, err)
+	inHour := time.Now().Add(time.Hour)
+	share := &entity.Share{VaultID: "v1", SubjectType: entity.SUBJECT_GROUP, SubjectID: "ops", Role: entity.ROLE_READER, ExpiresAt: &inHour}
+	payload, err := utils.StructToMap(entity.NewExpiration("t1", share, "alice"))
+	require.NoError(t, err)
+	require.NoError(t, expirations.Set(ctx, payload))
+
+	guarded, err := InitializeExpiryGuard(authz, expirations, time.Minute)
+	require.NoError(t, err)
+	guarded.(*guard).now = func() time.Time { return time.Now().UTC().Add(2 * time.Hour) }
+
+	for _, c := range []struct {
+		user, relation string
+		allowed        bool
+	}{
+		{"alice", authorization.CanManage, true},
+		{"alice", authorization.CanRead, true},
+		{"erin", authorization.CanRead, true},
+		{"erin", authorization.CanManage, false},
+		{"dave", authorization.CanView, false},
+	} {
+		raw, err := authz.Check(context.Background(), authorization.User(c.user), c.relation, vault)
+		require.NoError(t, err)
+		assert.Equal(t, c.allowed || c.user == "dave", raw, "%s %s before the guard", c.user, c.relation)
+
+		allowed, err := guarded.Check(context.Background(), authorization.User(c.user), c.relation, vault)
+		require.NoError(t, err)
+		assert.Equal(t, c.allowed, allowed, "%s %s", c.user, c.relation)
+	}
+
+	objects, err := guarded.ListObjects(context.Background(), authorization.User("alice"), authorization.CanRead, authorization.TypeVault)
+	require.NoError(t, err)
+	assert.Equal(t, []string{vault}, objects)
+
+	results, err := guarded.BatchCheck(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("dave"), Relation: authorization.CanRead, Object: vault},
+		{User: authorization.User("erin"), Relation: authorization.CanWrite, Object: vault},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, results)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+)
+
+type sweeper struct {
+	expirations entity.ExpirationRepository
+	authz       authorization.Authorizer
+	outbox      entity_outbox.OutboxService
+	audit       entity_audit.AuditSystemEventService
+	now         func() time.Time
+}
+
+// InitializeSweeperService creates the sweeper. Authz must be the authorizer itself, not the
+// guard, which would hide the expired roles the sweeper removes.
+func InitializeSweeperService(expirations entity.ExpirationRepository, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (entity.SweeperService, error) {
+
+	if expirations == nil {
+		return nil, core.ErrRepositoryNotFound("ExpirationRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	return &sweeper{
+		expirations: expirations,
+		authz:       authz,
+		outbox:      outbox,
+		audit:       audit,
+		now:         func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+// Sweep removes the roles of every tenant whose expiration passed and records a
+// PERMISSION_REVOKED event for each. A share that fails keeps its expiration for the next run.
+func (s *sweeper) Sweep(ctx context.Context) (*entity.SweepReport, error) {
+
+	report := &entity.SweepReport{StartedAt: s.now()}
+
+	expired, err := s.expirations.ListExpiring(ctx, report.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, expiration := range expired {
+		report.Checked++
+
+		// The repositories resolve the tenant collections from the context.
+		tenantCtx := context.WithValue(ctx, "TenantID", expiration.TenantID)
+
+		revoked, err := s.expire(tenantCtx, &expiration)
+		if err != nil {
+			report.Failed++
+			log.Printf("Sweeper: failed to expire the share of %s with %s %s: %v", expiration.Object(), expiration.SubjectType, expiration.SubjectID, err)
+			continue
+		}
+
+		if len(revoked) == 0 {
+			continue
+		}
+		report.Revoked++
+		log.Printf("Sweeper: share of %s with %s %s as %s expired at %s", expiration.Object(), expiration.SubjectType, expiration.SubjectID, revoked[0].Role, expiration.ExpiresAt.Format(time.RFC3339))
+
+		event := entity_audit.NewResourceEvent(entity_audit.PERMISSION_REVOKED, entity.ExpiryActor, expiration.TenantID, expiration.Object(), "expire", entity.ExpiryReason)
+		if _, err := s.audit.Record(tenantCtx, event); err != nil {
+			log.Printf("Sweeper: failed to record %s event for %s: %v", event.EventType, expiration.Object(), err)
+		}
+	}
+
+	report.EndedAt = s.now()
+	return report, nil
+}
+
+func (s *sweeper) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Sweep(ctx)
+			if err != nil {
+				log.Printf("Sweeper failed: %v", err)
+				continue
+			}
+			if report.Checked > 0 {
+				log.Printf("Sweeper: %d shares expired, %d revoked, %d failed", report.Checked, report.Revoked, report.Failed)
+			}
+		}
+	}
+}
+
+// expire removes the shareable roles of the subject on the vault and then the expiration, and
+// returns the removed shares. Unlike the share service, it fails when the tuples are not
+// applied right away, so the expiration the guard denies is kept while a role is stored.
+func (s *sweeper) expire(ctx context.Context, expiration *entity.Expiration) ([]entity.Share, error) {
+
+	stored, err := s.authz.ReadTuples(ctx, authorization.TupleKey{User: entity.Subject(expiration.SubjectType, expiration.SubjectID), Object: expiration.Object()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shares of %s: %w", expiration.Object(), err)
+	}
+
+	revoked := []entity.Share{}
+	for _, t := range stored {
+		if sh, ok := entity.ShareFromTuple(t); ok && sh.Role.IsShareable() {
+			revoked = append(revoked, sh)
+		}
+	}
+
+	if len(revoked) > 0 {
+		entry, err := s.outbox.Enqueue(ctx, entity_outbox.OUTBOX_DELETE, tuples(revoked), expiration.Object())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.outbox.Dispatch(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.expirations.Delete(ctx, expiration.ID); err != nil {
+		return nil, err
+	}
+
+	return revoked, nil
+}
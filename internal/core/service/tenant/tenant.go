@@ -0,0 +1,619 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type tenant struct {
+	repo   entity.TenantRepository
+	auth   authenticator.Authenticator
+	authz  authorization.Authorizer
+	outbox entity_outbox.OutboxService
+	audit  entity_audit.AuditSystemEventService
+}
+
+func InitializeTenantService(repo entity.TenantRepository, auth authenticator.Authenticator, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService) (entity.TenantService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("TenantRepository")
+	}
+
+	if auth == nil {
+		return nil, core.ErrServiceNotFound("Authenticator")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	return &tenant{
+		repo:   repo,
+		auth:   auth,
+		authz:  authz,
+		outbox: outbox,
+		audit:  audit,
+	}, nil
+}
+
+func (s *tenant) Bootstrap(ctx context.Context, data *entity.Tenant, owner *entity.Member) (*entity.Tenant, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if err := data.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if owner == nil || owner.TenantID != data.ID || owner.UserID != data.CreatedBy || owner.Role != entity.ROLE_OWNER {
+		return nil, core.ErrInvalidRequest("the creator of the tenant must be its owner")
+	}
+
+	if err := owner.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{owner.RoleTuple()}, data.Object())
+
+	payload, err := utils.StructToMap(data)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert tenant data to map")
+	}
+
+	ownerPayload, err := utils.StructToMap(owner)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert member data to map")
+	}
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	result, err := s.repo.Create(ctx, payload, ownerPayload, entryPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dispatch(ctx, result.Object(), entry)
+	s.record(ctx, entity_audit.TENANT_CREATED, owner.UserID, result.ID, result.Object(), "create", "tenant "+result.Name+" created")
+
+	return result, nil
+}
+
+func (s *tenant) Get(ctx context.Context) (*entity.Tenant, error) {
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Get(ctx, actor.TenantID)
+}
+
+func (s *tenant) Update(ctx context.Context, data *entity.Tenant) (*entity.Tenant, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("tenant is required")
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Role.CanManage() {
+		return nil, core.ErrForbidden("only owners and admins can update the tenant")
+	}
+
+	current, err := s.repo.Get(ctx, actor.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	current.Name = strings.TrimSpace(data.Name)
+	current.Description = data.Description
+	current.UpdatedAt = time.Now().UTC()
+
+	if err := current.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	payload, err := utils.StructToMap(current)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert tenant data to map")
+	}
+
+	if err := s.repo.Update(ctx, current.ID, payload); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, entity_audit.TENANT_MODIFIED, actor.UserID, current.ID, current.Object(), "update", "tenant renamed to "+current.Name)
+
+	return current, nil
+}
+
+// Memberships returns the active memberships of the user with the name of each tenant.
+func (s *tenant) Memberships(ctx context.Context) ([]entity.Member, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "userId",
+			Value:  userID,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	memberships := []entity.Member{}
+	opts := database.QueryOptions{}
+	for {
+		result, next, err := s.repo.ListMembers(ctx, filters, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range result {
+			if !m.IsActive() {
+				continue
+			}
+			if t, err := s.repo.Get(ctx, m.TenantID); err == nil {
+				m.TenantName = t.Name
+			}
+			memberships = append(memberships, m)
+		}
+
+		if next == "" {
+			return memberships, nil
+		}
+		opts.PageToken = next
+	}
+}
+
+// Switch sets the tenantId claim of the user, so the tokens issued after it act on tenantID.
+func (s *tenant) Switch(ctx context.Context, tenantID string) (*entity.Member, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if tenantID == "" {
+		return nil, core.ErrInvalidRequest("tenant ID is required")
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	member, err := s.repo.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if core.IsNotFound(err) {
+			return nil, core.ErrNotFound("tenant " + tenantID)
+		}
+		return nil, err
+	}
+
+	if !member.IsActive() {
+		return nil, core.ErrForbidden("user is banned from tenant " + tenantID)
+	}
+
+	if err := s.auth.SetTenantId(ctx, userID, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to switch tenant: %w", err)
+	}
+
+	return member, nil
+}
+
+func (s *tenant) ListMembers(ctx context.Context, opts database.QueryOptions) ([]entity.Member, string, error) {
+
+	if err := opts.Validate(entity.MemberSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if actor.Role == entity.ROLE_GUEST {
+		return nil, "", core.ErrForbidden("guests cannot list the members of the tenant")
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "tenantId",
+			Value:  actor.TenantID,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	return s.repo.ListMembers(ctx, filters, opts)
+}
+
+// AddMember adds an existing user to the tenant of the context. The email and name are read from
+// the identity provider, and the tenant becomes the active one of users that have none.
+func (s *tenant) AddMember(ctx context.Context, data *entity.Member) (*entity.Member, error) {
+
+	if data == nil || data.UserID == "" {
+		return nil, core.ErrInvalidRequest("user ID is required")
+	}
+
+	if !data.Role.IsValid() || data.Role == entity.ROLE_BANNED {
+		return nil, core.ErrInvalidRequest("role must be owner, admin, member or guest")
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Role.CanManage() || !actor.Role.CanAssign(data.Role) {
+		return nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to add a member with role %s", data.Role))
+	}
+
+	email, err := s.auth.GetUserEmail(ctx, data.UserID)
+	if err != nil {
+		return nil, core.ErrInvalidRequest("user " + data.UserID + " not found")
+	}
+
+	// The name is optional in the identity provider.
+	name, _ := s.auth.GetUserName(ctx, data.UserID)
+
+	member := entity.NewMember(actor.TenantID, data.UserID, email, name, data.Role, actor.UserID)
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{member.RoleTuple()}, member.RoleTuple().Object)
+
+	payload, err := utils.StructToMap(member)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert member data to map")
+	}
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	result, err := s.repo.AddMember(ctx, payload, entryPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dispatch(ctx, entry.Source, entry)
+
+	if current, err := s.auth.GetTenant(ctx, member.UserID); err != nil || current == "" {
+		if err := s.auth.SetTenantId(ctx, member.UserID, member.TenantID); err != nil {
+			log.Printf("Member %s added to tenant %s but its tenant claim was not set: %v", member.UserID, member.TenantID, err)
+		}
+	}
+
+	s.record(ctx, entity_audit.MEMBER_ADDED, actor.UserID, actor.TenantID, entry.Source, "add_member",
+		fmt.Sprintf("user %s added as %s", member.UserID, member.Role))
+
+	return result, nil
+}
+
+// UpdateMemberRole replaces the role of a member and its tuple. Banning a member keeps the
+// membership and its other tuples: the model only honours the vault roles of members and guests
+// that are not banned, and banned users are not active members of the groups of the tenant, so
+// lifting the ban gives back the access the member had.
+func (s *tenant) UpdateMemberRole(ctx context.Context, userID string, role entity.Role) (*entity.Member, error) {
+
+	if userID == "" {
+		return nil, core.ErrInvalidRequest("user ID is required")
+	}
+
+	if !role.IsValid() {
+		return nil, core.ErrInvalidRequest("role must be owner, admin, member, guest or banned")
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.UserID == userID {
+		return nil, core.ErrForbidden("users cannot change their own role")
+	}
+
+	target, err := s.member(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Role == role {
+		return target, nil
+	}
+
+	if !actor.Role.CanAssign(role) {
+		return nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to assign role %s", role))
+	}
+
+	source := authorization.Object(authorization.TypeTenant, actor.TenantID)
+	revoke := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_DELETE, []authorization.TupleKey{target.RoleTuple()}, source)
+	grant := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, []authorization.TupleKey{entity.RoleTuple(target.TenantID, target.UserID, role)}, source)
+
+	previous := target.Role
+	target.Role = role
+	target.UpdatedAt = time.Now().UTC()
+
+	patch, err := utils.StructToMap(map[string]interface{}{
+		"role":      target.Role,
+		"updatedAt": target.UpdatedAt.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert member data to map")
+	}
+
+	revokePayload, err := utils.StructToMap(revoke)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	grantPayload, err := utils.StructToMap(grant)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	if err := s.repo.UpdateMember(ctx, target.ID, patch, revokePayload, grantPayload); err != nil {
+		return nil, err
+	}
+
+	// The old tuple is removed first, so a failed grant never leaves two roles.
+	s.dispatch(ctx, source, revoke)
+	s.dispatch(ctx, source, grant)
+
+	if !target.IsActive() {
+		s.clearClaim(ctx, target)
+	}
+
+	s.record(ctx, entity_audit.MEMBER_ROLE_CHANGED, actor.UserID, actor.TenantID, source, "change_role",
+		fmt.Sprintf("user %s changed from %s to %s", target.UserID, previous, role))
+
+	return target, nil
+}
+
+// RemoveMember removes a member from the tenant with its roles on the vaults and its memberships
+// in the groups of the tenant. Members can always leave, except the last owner.
+func (s *tenant) RemoveMember(ctx context.Context, userID string) error {
+
+	if userID == "" {
+		return core.ErrInvalidRequest("user ID is required")
+	}
+
+	actor, err := s.actor(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := actor
+	if actor.UserID != userID {
+		if target, err = s.member(ctx, actor, userID); err != nil {
+			return err
+		}
+	} else if actor.Role == entity.ROLE_OWNER {
+		if err := s.ensureAnotherOwner(ctx, actor); err != nil {
+			return err
+		}
+	}
+
+	tuples, groupMembers, err := s.grants(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	source := authorization.Object(authorization.TypeTenant, actor.TenantID)
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_DELETE, append([]authorization.TupleKey{target.RoleTuple()}, tuples...), source)
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	if err := s.repo.RemoveMember(ctx, target.ID, groupMembers, entryPayload); err != nil {
+		return err
+	}
+
+	s.dispatch(ctx, source, entry)
+	s.clearClaim(ctx, target)
+
+	s.record(ctx, entity_audit.MEMBER_REMOVED, actor.UserID, actor.TenantID, source, "remove_member",
+		fmt.Sprintf("user %s removed", target.UserID))
+
+	return nil
+}
+
+// grants returns the tuples of the member on the vaults and groups of its tenant, including the
+// roles and guest marks of external shares, and the IDs of its group memberships.
+func (s *tenant) grants(ctx context.Context, member *entity.Member) ([]authorization.TupleKey, []string, error) {
+
+	user := authorization.User(member.UserID)
+	tenant := authorization.Object(authorization.TypeTenant, member.TenantID)
+
+	var tuples []authorization.TupleKey
+	var groupMembers []string
+	for _, objectType := range []string{authorization.TypeVault, authorization.TypeGroup} {
+
+		found, err := s.authz.ReadTuples(ctx, authorization.TupleKey{User: user, Object: objectType + ":"})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read the %s tuples of user %s: %w", objectType, member.UserID, err)
+		}
+
+		owned := map[string]bool{}
+		for _, t := range found {
+			inTenant, ok := owned[t.Object]
+			if !ok {
+				if inTenant, err = s.belongs(ctx, t.Object, tenant); err != nil {
+					return nil, nil, err
+				}
+				owned[t.Object] = inTenant
+			}
+			if !inTenant {
+				continue
+			}
+
+			tuples = append(tuples, t)
+			if objectType == authorization.TypeGroup {
+				_, groupID, _ := authorization.SplitObject(t.Object)
+				groupMembers = append(groupMembers, entity_group.MemberID(groupID, entity_group.MEMBER_USER, member.UserID))
+			}
+		}
+	}
+
+	return tuples, groupMembers, nil
+}
+
+// belongs reports whether object is linked to tenant by its tenant tuple.
+func (s *tenant) belongs(ctx context.Context, object, tenant string) (bool, error) {
+
+	found, err := s.authz.ReadTuples(ctx, authorization.TupleKey{Relation: authorization.RelationTenant, Object: object})
+	if err != nil {
+		return false, fmt.Errorf("failed to read the tenant of %s: %w", object, err)
+	}
+
+	for _, t := range found {
+		if t.User == tenant {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// actor returns the active membership of the user of the context in its tenant.
+func (s *tenant) actor(ctx context.Context) (*entity.Member, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	member, err := s.repo.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if core.IsNotFound(err) {
+			return nil, core.ErrForbidden("user is not a member of tenant " + tenantID)
+		}
+		return nil, err
+	}
+
+	if !member.IsActive() {
+		return nil, core.ErrForbidden("user is banned from tenant " + tenantID)
+	}
+
+	return member, nil
+}
+
+// member returns another member of the tenant of the actor, if the actor can manage its role.
+func (s *tenant) member(ctx context.Context, actor *entity.Member, userID string) (*entity.Member, error) {
+
+	if !actor.Role.CanManage() {
+		return nil, core.ErrForbidden("only owners and admins can manage members")
+	}
+
+	target, err := s.repo.GetMember(ctx, actor.TenantID, userID)
+	if err != nil {
+		if core.IsNotFound(err) {
+			return nil, core.ErrNotFound("member " + userID)
+		}
+		return nil, err
+	}
+
+	if !actor.Role.CanAssign(target.Role) {
+		return nil, core.ErrForbidden(fmt.Sprintf("user is not allowed to manage a member with role %s", target.Role))
+	}
+
+	return target, nil
+}
+
+func (s *tenant) ensureAnotherOwner(ctx context.Context, owner *entity.Member) error {
+
+	filters := []database.Conditional{
+		{
+			Field:  "tenantId",
+			Value:  owner.TenantID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "role",
+			Value:  string(entity.ROLE_OWNER),
+			Filter: database.FilterEquals,
+		},
+	}
+
+	owners, _, err := s.repo.ListMembers(ctx, filters, database.QueryOptions{PageSize: 2})
+	if err != nil {
+		return err
+	}
+
+	if len(owners) < 2 {
+		return core.ErrConflict("the last owner cannot leave the tenant")
+	}
+
+	return nil
+}
+
+// clearClaim removes the tenantId claim of a user that can no longer use the tenant.
+func (s *tenant) clearClaim(ctx context.Context, member *entity.Member) {
+
+	current, err := s.auth.GetTenant(ctx, member.UserID)
+	if err != nil || current != member.TenantID {
+		return
+	}
+
+	if err := s.auth.SetTenantRollback(ctx, member.UserID, ""); err != nil {
+		log.Printf("Failed to clear the tenant claim of user %s: %v", member.UserID, err)
+	}
+}
+
+// dispatch applies the tuples right away; on failure the relay retries.
+func (s *tenant) dispatch(ctx context.Context, source string, entry *entity_outbox.OutboxEntry) {
+	if err := s.outbox.Dispatch(ctx, entry); err != nil {
+		log.Printf("Membership of %s changed but permissions are pending: %v", source, err)
+	}
+}
+
+func (s *tenant) record(ctx context.Context, eventType entity_audit.EventType, userID, tenantID, resource, action, reason string) {
+
+	event := entity_audit.NewResourceEvent(eventType, userID, tenantID, resource, action, reason)
+	if _, err := s.audit.Record(context.WithValue(context.WithoutCancel(ctx), "TenantID", tenantID), event); err != nil {
+		log.Printf("Failed to record %s event for %s: %v", eventType, resource, err)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity_group "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	repo_tenant "github.com/synera-br/lockari-backend-app/internal/core/repository/tenant"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+func TestTenantMembers(t *testing.T) {
+	as := func(userID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", "t1")
+	}
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+
+	repo, err := repo_tenant.InitializeTenantRepository(db)
+	require.NoError(t, err)
+
+	auth := &servicetest.Auth{
+		Emails: map[string]string{"alice": "alice@acme.io", "bob": "bob@acme.io", "carol": "carol@acme.io"},
+		Claims: map[string]string{"alice": "t1", "carol": "t2"},
+	}
+	audit := &servicetest.Audit{}
+	svc, err := InitializeTenantService(repo, auth, authz, outbox, audit)
+	require.NoError(t, err)
+
+	activeMember := func(userID string) bool {
+		allowed, err := authz.Check(context.Background(), authorization.User(userID), "active_member", authorization.Object(authorization.TypeTenant, "t1"))
+		require.NoError(t, err)
+		return allowed
+	}
+
+	_, err = svc.Bootstrap(context.Background(), entity.NewTenant("t1", "Acme", "free", "alice"), entity.NewMember("t1", "alice", "alice@acme.io", "", entity.ROLE_OWNER, "alice"))
+	require.NoError(t, err)
+	assert.True(t, activeMember("alice"))
+
+	_, err = svc.Bootstrap(context.Background(), entity.NewTenant("t1", "Acme", "free", "alice"), entity.NewMember("t1", "alice", "alice@acme.io", "", entity.ROLE_OWNER, "alice"))
+	assert.True(t, core.IsConflict(err))
+
+	bob, err := svc.AddMember(as("alice"), &entity.Member{UserID: "bob", Role: entity.ROLE_ADMIN})
+	require.NoError(t, err)
+	assert.Equal(t, "bob@acme.io", bob.Email)
+	assert.Equal(t, "t1", auth.Claims["bob"], "a user without tenant switches to the new one")
+	assert.True(t, activeMember("bob"))
+
+	_, err = svc.AddMember(as("alice"), &entity.Member{UserID: "bob", Role: entity.ROLE_MEMBER})
+	assert.True(t, core.IsConflict(err))
+
+	_, err = svc.AddMember(as("bob"), &entity.Member{UserID: "carol", Role: entity.ROLE_ADMIN})
+	assert.True(t, core.IsForbidden(err), "only owners add admins")
+
+	_, err = svc.AddMember(as("bob"), &entity.Member{UserID: "carol", Role: entity.ROLE_MEMBER})
+	require.NoError(t, err)
+	assert.Equal(t, "t2", auth.Claims["carol"], "the active tenant of an existing user is kept")
+
+	_, err = svc.UpdateMemberRole(as("bob"), "alice", entity.ROLE_MEMBER)
+	assert.True(t, core.IsForbidden(err), "admins cannot demote owners")
+
+	_, err = svc.UpdateMemberRole(as("bob"), "bob", entity.ROLE_OWNER)
+	assert.True(t, core.IsForbidden(err), "users cannot change their own role")
+
+	auth.Claims["carol"] = "t1"
+	carol, err := svc.UpdateMemberRole(as("bob"), "carol", entity.ROLE_BANNED)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ROLE_BANNED, carol.Role)
+	assert.False(t, activeMember("carol"))
+	assert.Empty(t, auth.Claims["carol"], "a banned member loses the tenant claim")
+
+	tuples, err := authz.ReadTuples(context.Background(), authorization.TupleKey{User: authorization.User("carol"), Object: authorization.Object(authorization.TypeTenant, "t1")})
+	require.NoError(t, err)
+	assert.Equal(t, []authorization.TupleKey{entity.RoleTuple("t1", "carol", entity.ROLE_BANNED)}, tuples)
+
+	_, err = svc.Get(as("carol"))
+	assert.True(t, core.IsForbidden(err))
+
+	members, _, err := svc.ListMembers(as("bob"), database.QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, members, 3)
+
+	err = svc.RemoveMember(as("alice"), "alice")
+	assert.True(t, core.IsConflict(err), "the last owner cannot leave")
+
+	require.NoError(t, svc.RemoveMember(as("bob"), "bob"))
+	assert.False(t, activeMember("bob"))
+	assert.Empty(t, auth.Claims["bob"])
+
+	updated, err := svc.Update(as("alice"), &entity.Tenant{Name: " Acme Inc "})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Inc", updated.Name)
+
+	memberships, err := svc.Memberships(as("alice"))
+	require.NoError(t, err)
+	require.Len(t, memberships, 1)
+	assert.Equal(t, "Acme Inc", memberships[0].TenantName)
+
+	_, err = svc.Switch(as("carol"), "t1")
+	assert.True(t, core.IsForbidden(err), "banned members cannot switch to the tenant")
+
+	assert.Len(t, audit.Events, 6)
+}
+
+func TestRemovedMemberLosesVaultRead(t *testing.T) {
+	as := func(userID string) context.Context {
+		ctx := context.WithValue(context.Background(), "UserID", userID)
+		return context.WithValue(ctx, "TenantID", "t1")
+	}
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+	repo, err := repo_tenant.InitializeTenantRepository(db)
+	require.NoError(t, err)
+	auth := &servicetest.Auth{Emails: map[string]string{"bob": "bob@acme.io", "carol": "carol@acme.io"}, Claims: map[string]string{}}
+	svc, err := InitializeTenantService(repo, auth, authz, outbox, &servicetest.Audit{})
+	require.NoError(t, err)
+
+	_, err = svc.Bootstrap(context.Background(), entity.NewTenant("t1", "Acme", "free", "alice"), entity.NewMember("t1", "alice", "alice@acme.io", "", entity.ROLE_OWNER, "alice"))
+	require.NoError(t, err)
+	for _, userID := range []string{"bob", "carol"} {
+		_, err = svc.AddMember(as("alice"), &entity.Member{UserID: userID, Role: entity.ROLE_MEMBER})
+		require.NoError(t, err)
+	}
+
+	// bob reads v1 directly and v2 through ops; v3 belongs to another tenant and is left alone.
+	tenant := authorization.Object(authorization.TypeTenant, "t1")
+	v1 := authorization.Object(authorization.TypeVault, "v1")
+	v2 := authorization.Object(authorization.TypeVault, "v2")
+	v3 := authorization.Object(authorization.TypeVault, "v3")
+	ops := authorization.Object(authorization.TypeGroup, "ops")
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: tenant, Relation: authorization.RelationTenant, Object: v1},
+		{User: tenant, Relation: authorization.RelationTenant, Object: v2},
+		{User: authorization.Object(authorization.TypeTenant, "t2"), Relation: authorization.RelationTenant, Object: v3},
+		{User: tenant, Relation: authorization.RelationTenant, Object: ops},
+		{User: authorization.User("bob"), Relation: "reader", Object: v1},
+		{User: authorization.User("bob"), Relation: "reader", Object: v3},
+		{User: authorization.User("bob"), Relation: authorization.RelationMember, Object: ops},
+		{User: authorization.UsersetOf(authorization.TypeGroup, "ops", entity_group.RelationActiveMember), Relation: "reader", Object: v2},
+		{User: authorization.User("carol"), Relation: "reader", Object: v1},
+	}))
+	groupMember := entity_group.MemberID("ops", entity_group.MEMBER_USER, "bob")
+	_, err = db.CreateWithID(context.Background(), groupMember, map[string]interface{}{"groupId": "ops", "memberId": "bob"}, "tenant/t1/"+entity_group.MemberCollection)
+	require.NoError(t, err)
+
+	canRead := func(userID, object string) bool {
+		allowed, err := authz.Check(context.Background(), authorization.User(userID), authorization.CanRead, object)
+		require.NoError(t, err)
+		return allowed
+	}
+	require.True(t, canRead("bob", v1))
+	require.True(t, canRead("bob", v2))
+
+	require.NoError(t, svc.RemoveMember(as("alice"), "bob"))
+	assert.False(t, canRead("bob", v1), "a removed member loses its vault roles")
+	assert.False(t, canRead("bob", v2), "and its groups")
+
+	remaining, err := authz.ReadTuples(context.Background(), authorization.TupleKey{User: authorization.User("bob"), Object: "vault:"})
+	require.NoError(t, err)
+	assert.Equal(t, []authorization.TupleKey{{User: authorization.User("bob"), Relation: "reader", Object: v3}}, remaining)
+	remaining, err = authz.ReadTuples(context.Background(), authorization.TupleKey{User: authorization.User("bob"), Object: "group:"})
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+	_, err = db.GetByID(context.Background(), groupMember, "tenant/t1/"+entity_group.MemberCollection)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+
+	// A ban keeps the role of carol, but it grants nothing until the ban is lifted.
+	_, err = svc.UpdateMemberRole(as("alice"), "carol", entity.ROLE_BANNED)
+	require.NoError(t, err)
+	assert.False(t, canRead("carol", v1))
+
+	_, err = svc.UpdateMemberRole(as("alice"), "carol", entity.ROLE_MEMBER)
+	require.NoError(t, err)
+	assert.True(t, canRead("carol", v1))
+}
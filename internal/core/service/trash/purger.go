@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_secret "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+type purger struct {
+	trash     entity.TrashRepository
+	vaults    entity_vault.VaultRepository
+	secrets   entity_secret.SecretRepository
+	authz     authorization.Authorizer
+	outbox    entity_outbox.OutboxService
+	audit     entity_audit.AuditSystemEventService
+	retention time.Duration
+	now       func() time.Time
+}
+
+// InitializePurgerService creates the purger. A zero retention uses entity.DefaultRetention.
+func InitializePurgerService(trash entity.TrashRepository, vaults entity_vault.VaultRepository, secrets entity_secret.SecretRepository, authz authorization.Authorizer, outbox entity_outbox.OutboxService, audit entity_audit.AuditSystemEventService, retention time.Duration) (entity.PurgerService, error) {
+
+	if trash == nil {
+		return nil, core.ErrRepositoryNotFound("TrashRepository")
+	}
+
+	if vaults == nil {
+		return nil, core.ErrRepositoryNotFound("VaultRepository")
+	}
+
+	if secrets == nil {
+		return nil, core.ErrRepositoryNotFound("SecretRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	if retention <= 0 {
+		retention = entity.DefaultRetention
+	}
+
+	return &purger{
+		trash:     trash,
+		vaults:    vaults,
+		secrets:   secrets,
+		authz:     authz,
+		outbox:    outbox,
+		audit:     audit,
+		retention: retention,
+		now:       time.Now,
+	}, nil
+}
+
+// Purge hard deletes the items of every tenant that stayed in the trash longer than the
+// retention. A vault is removed with all its secrets and versions, and the removal of its
+// tuples is enqueued in the outbox. An item that fails stays in the trash for the next run.
+func (s *purger) Purge(ctx context.Context) (*entity.PurgeReport, error) {
+
+	report := &entity.PurgeReport{StartedAt: s.now().UTC()}
+
+	items, err := s.trash.ListExpired(ctx, report.StartedAt.Add(-s.retention))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		report.Checked++
+
+		// The repositories resolve the tenant collections from the context.
+		tenantCtx := context.WithValue(ctx, "TenantID", item.TenantID)
+
+		secrets, err := s.purge(tenantCtx, &item)
+		if err != nil {
+			report.Failed++
+			log.Printf("Purger: failed to purge %s: %v", item.Object(), err)
+			continue
+		}
+
+		report.Purged++
+		report.Secrets += secrets
+
+		event := entity_audit.NewResourceEvent(deletedEvent(item.ItemType), entity.PurgeActor, item.TenantID, item.Object(), "purge",
+			fmt.Sprintf("retention of %s expired, deleted by %s", s.retention, item.DeletedBy))
+		if _, err := s.audit.Record(tenantCtx, event); err != nil {
+			log.Printf("Purger: failed to record %s event for %s: %v", event.EventType, item.Object(), err)
+		}
+	}
+
+	report.EndedAt = s.now().UTC()
+	return report, nil
+}
+
+func (s *purger) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Purge(ctx)
+			if err != nil {
+				log.Printf("Purger failed: %v", err)
+				continue
+			}
+			if report.Checked > 0 {
+				log.Printf("Purger: %d items expired, %d purged with %d secrets, %d failed",
+					report.Checked, report.Purged, report.Secrets, report.Failed)
+			}
+		}
+	}
+}
+
+// purge removes the item and then its trash entry, and returns how many secrets were
+// removed with it. Every step can be repeated, so a failed item is retried as a whole.
+func (s *purger) purge(ctx context.Context, item *entity.TrashItem) (int, error) {
+
+	secrets := 0
+
+	switch item.ItemType {
+	case entity.TRASH_VAULT:
+		purged, err := s.secrets.PurgeAll(ctx, item.ItemID)
+		if err != nil {
+			return 0, err
+		}
+		secrets = purged
+
+		// Secrets deleted before the vault are gone with it.
+		if err := s.deleteSecretEntries(ctx, item.ItemID); err != nil {
+			return 0, err
+		}
+
+		if err := s.vaults.Purge(ctx, item.ItemID); err != nil {
+			return 0, err
+		}
+
+		if err := s.revokeTuples(ctx, item); err != nil {
+			return 0, err
+		}
+	case entity.TRASH_SECRET:
+		if err := s.secrets.Purge(ctx, item.VaultID, item.ItemID); err != nil {
+			return 0, err
+		}
+		secrets = 1
+	default:
+		return 0, fmt.Errorf("unknown trash item type %q", item.ItemType)
+	}
+
+	if err := s.trash.Delete(ctx, item.ID); err != nil {
+		return 0, err
+	}
+
+	return secrets, nil
+}
+
+func (s *purger) deleteSecretEntries(ctx context.Context, vaultID string) error {
+
+	filters := []database.Conditional{
+		{
+			Field:  "vaultId",
+			Value:  vaultID,
+			Filter: database.FilterEquals,
+		},
+		{
+			Field:  "itemType",
+			Value:  string(entity.TRASH_SECRET),
+			Filter: database.FilterEquals,
+		},
+	}
+
+	opts := database.QueryOptions{PageSize: database.MaxPageSize}
+	for {
+		entries, next, err := s.trash.List(ctx, filters, opts)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := s.trash.Delete(ctx, entry.ID); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+		opts.PageToken = next
+	}
+}
+
+// revokeTuples enqueues the removal of every tuple on the vault, not only the ownership ones.
+func (s *purger) revokeTuples(ctx context.Context, item *entity.TrashItem) error {
+
+	object := item.Object()
+
+	tuples, err := s.authz.ReadTuples(ctx, authorization.TupleKey{Object: object})
+	if err != nil {
+		return fmt.Errorf("failed to read tuples of %s: %w", object, err)
+	}
+
+	if len(tuples) == 0 {
+		return nil
+	}
+
+	_, err = s.outbox.Enqueue(ctx, entity_outbox.OUTBOX_DELETE, tuples, object)
+	return err
+}
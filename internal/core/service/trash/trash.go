@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity_audit "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type trash struct {
+	repo      entity.TrashRepository
+	authz     authorization.Authorizer
+	audit     entity_audit.AuditSystemEventService
+	retention time.Duration
+}
+
+// InitializeTrashService creates the trash service. A zero retention uses entity.DefaultRetention.
+func InitializeTrashService(repo entity.TrashRepository, authz authorization.Authorizer, audit entity_audit.AuditSystemEventService, retention time.Duration) (entity.TrashService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("TrashRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if audit == nil {
+		return nil, core.ErrServiceNotFound("AuditSystemEventService")
+	}
+
+	if retention <= 0 {
+		retention = entity.DefaultRetention
+	}
+
+	return &trash{
+		repo:      repo,
+		authz:     authz,
+		audit:     audit,
+		retention: retention,
+	}, nil
+}
+
+// MoveToTrash flags the item as deleted and creates its trash entry. The caller is
+// responsible for checking that the user is allowed to delete the item.
+func (s *trash) MoveToTrash(ctx context.Context, item *entity.TrashItem) (*entity.TrashItem, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if err := item.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	payload, err := utils.StructToMap(item)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert trash item data to map")
+	}
+	delete(payload, "purgeAt")
+
+	result, err := s.repo.MoveToTrash(ctx, payload, item.DeletedPatch())
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, result, deletedEvent(result.ItemType), "trash", "moved to the trash")
+
+	return result.WithPurgeAt(s.retention), nil
+}
+
+// List returns the vaults the user can manage and the secrets of the vaults where the
+// user can delete secrets, the same permissions required to restore them.
+func (s *trash) List(ctx context.Context, opts database.QueryOptions) ([]entity.TrashItem, string, error) {
+
+	if ctx.Err() != nil {
+		return nil, "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	opts = opts.WithDefaultOrder(database.OrderBy{Field: "deletedAt", Direction: database.Desc})
+	if err := opts.Validate(entity.TrashSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, "", core.ErrUnauthorized(err.Error())
+	}
+
+	manage, err := s.allowedVaults(ctx, userID, authorization.CanManage)
+	if err != nil {
+		return nil, "", err
+	}
+
+	deletable, err := s.allowedVaults(ctx, userID, authorization.CanDelete)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(manage) == 0 && len(deletable) == 0 {
+		return []entity.TrashItem{}, "", nil
+	}
+
+	result, next, err := s.repo.List(ctx, nil, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := []entity.TrashItem{}
+	for _, item := range result {
+		if (item.ItemType == entity.TRASH_VAULT && manage[item.VaultID]) ||
+			(item.ItemType == entity.TRASH_SECRET && deletable[item.VaultID]) {
+			items = append(items, *item.WithPurgeAt(s.retention))
+		}
+	}
+
+	return items, next, nil
+}
+
+// Restore reactivates the item. A secret can only be restored while its vault is active.
+func (s *trash) Restore(ctx context.Context, id string) (*entity.TrashItem, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("trash item ID is required")
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, core.ErrUnauthorized(err.Error())
+	}
+
+	item, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	relation := authorization.CanManage
+	if item.ItemType == entity.TRASH_SECRET {
+		relation = authorization.CanDelete
+	}
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), relation, authorization.Object(authorization.TypeVault, item.VaultID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check trash permission: %w", err)
+	}
+
+	if !allowed {
+		return nil, core.ErrForbidden("user is not allowed to restore " + id)
+	}
+
+	if item.ItemType == entity.TRASH_SECRET {
+		_, err := s.repo.Get(ctx, entity.TrashID(entity.TRASH_VAULT, item.VaultID))
+		if err == nil {
+			return nil, core.ErrConflict("vault " + item.VaultID + " is in the trash, restore it first")
+		}
+		if !core.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Restore(ctx, item, entity.RestoredPatch(time.Now().UTC())); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, item, modifiedEvent(item.ItemType), "restore", "restored from the trash by "+userID)
+
+	return item, nil
+}
+
+func (s *trash) allowedVaults(ctx context.Context, userID, relation string) (map[string]bool, error) {
+
+	objects, err := s.authz.ListObjects(ctx, authorization.User(userID), relation, authorization.TypeVault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allowed vaults: %w", err)
+	}
+
+	allowed := map[string]bool{}
+	for _, id := range authorization.ObjectIDs(objects) {
+		allowed[id] = true
+	}
+
+	return allowed, nil
+}
+
+// record stores the audit event of the item. Failures are only logged, the item is already
+// in its new state.
+func (s *trash) record(ctx context.Context, item *entity.TrashItem, eventType entity_audit.EventType, action, reason string) {
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		userID = item.DeletedBy
+	}
+
+	event := entity_audit.NewResourceEvent(eventType, userID, item.TenantID, item.Object(), action, reason)
+	if _, err := s.audit.Record(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Failed to record %s event for %s: %v", eventType, item.Object(), err)
+	}
+}
+
+func deletedEvent(itemType entity.ItemType) entity_audit.EventType {
+	if itemType == entity.TRASH_VAULT {
+		return entity_audit.VAULT_DELETED
+	}
+	return entity_audit.SECRET_DELETED
+}
+
+func modifiedEvent(itemType entity.ItemType) entity_audit.EventType {
+	if itemType == entity.TRASH_VAULT {
+		return entity_audit.VAULT_MODIFIED
+	}
+	return entity_audit.SECRET_MODIFIED
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity_secret "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity_vault "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_secret "github.com/synera-br/lockari-backend-app/internal/core/repository/secret"
+	repo_trash "github.com/synera-br/lockari-backend-app/internal/core/repository/trash"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+func TestTrashLifecycle(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "UserID", "alice")
+	ctx = context.WithValue(ctx, "TenantID", "t1")
+
+	db := database.NewMemoryDB()
+	_, err := db.CreateWithID(ctx, "v1", map[string]interface{}{"name": "prod", "tenantId": "t1", "isActive": true}, "tenant/t1/vaults")
+	require.NoError(t, err)
+	_, err = db.CreateWithID(ctx, "s1", map[string]interface{}{"name": "db-password", "vaultId": "v1", "isActive": true}, "tenant/t1/vaults/v1/secrets")
+	require.NoError(t, err)
+	_, err = db.CreateWithID(ctx, "1", map[string]interface{}{"version": 1}, "tenant/t1/vaults/v1/secrets/s1/versions")
+	require.NoError(t, err)
+
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+	vault := entity_vault.Vault{ID: "v1", TenantID: "t1", CreatedBy: "alice"}
+	require.NoError(t, authz.WriteTuples(ctx, append(vault.OwnershipTuples(),
+		authorization.TupleKey{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: authorization.Object(authorization.TypeTenant, "t1")})))
+
+	trashRepo, err := repo_trash.InitializeTrashRepository(db)
+	require.NoError(t, err)
+	vaults, err := repo_vault.InitializeVaultRepository(db)
+	require.NoError(t, err)
+	secrets, err := repo_secret.InitializeSecretRepository(db)
+	require.NoError(t, err)
+
+	audit := &servicetest.Audit{}
+	outbox := &servicetest.Outbox{}
+	svc, err := InitializeTrashService(trashRepo, authz, audit, 0)
+	require.NoError(t, err)
+
+	secretItem := entity.NewTrashItem(entity.TRASH_SECRET, "t1", "v1", "s1", "db-password", entity_secret.SecretCollection("v1"), "alice")
+	moved, err := svc.MoveToTrash(ctx, secretItem)
+	require.NoError(t, err)
+	assert.Equal(t, moved.DeletedAt.Add(entity.DefaultRetention), *moved.PurgeAt)
+
+	s1, err := secrets.Get(ctx, "v1", "s1")
+	require.NoError(t, err)
+	assert.True(t, s1.IsDeleted())
+
+	_, err = svc.MoveToTrash(ctx, secretItem)
+	assert.True(t, core.IsConflict(err), "an item is in the trash only once")
+
+	_, err = svc.MoveToTrash(ctx, entity.NewTrashItem(entity.TRASH_VAULT, "t1", "v1", "v1", "prod", entity_vault.VaultCollection, "alice"))
+	require.NoError(t, err)
+
+	items, _, err := svc.List(ctx, database.QueryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	_, err = svc.Restore(ctx, secretItem.ID)
+	assert.True(t, core.IsConflict(err), "a secret cannot be restored while its vault is in the trash")
+
+	restored, err := svc.Restore(ctx, entity.TrashID(entity.TRASH_VAULT, "v1"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", restored.ItemID)
+
+	v1, err := vaults.Get(ctx, "v1")
+	require.NoError(t, err)
+	assert.False(t, v1.IsDeleted())
+
+	_, err = svc.MoveToTrash(ctx, entity.NewTrashItem(entity.TRASH_VAULT, "t1", "v1", "v1", "prod", entity_vault.VaultCollection, "alice"))
+	require.NoError(t, err)
+
+	p, err := InitializePurgerService(trashRepo, vaults, secrets, authz, outbox, audit, 0)
+	require.NoError(t, err)
+
+	report, err := p.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Checked, "nothing expired yet")
+
+	p.(*purger).now = func() time.Time { return time.Now().Add(entity.DefaultRetention + time.Hour) }
+	report, err = p.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Purged)
+	assert.Equal(t, 0, report.Failed)
+
+	_, err = vaults.Get(ctx, "v1")
+	assert.True(t, core.IsNotFound(err))
+	_, err = secrets.Get(ctx, "v1", "s1")
+	assert.True(t, core.IsNotFound(err))
+	_, err = secrets.GetVersion(ctx, "v1", "s1", 1)
+	assert.True(t, core.IsNotFound(err))
+
+	items, _, err = svc.List(ctx, database.QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	assert.ElementsMatch(t, vault.OwnershipTuples(), outbox.Deleted)
+
+	last := audit.Events[len(audit.Events)-1]
+	assert.Equal(t, entity.PurgeActor, last.User.Uid)
+	assert.Equal(t, "purge", last.Action)
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	entity_outbox "github.com/synera-br/lockari-backend-app/internal/core/entity/outbox"
+	entity_trash "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type vault struct {
+	repo   entity.VaultRepository
+	authz  authorization.Authorizer
+	outbox entity_outbox.OutboxService
+	trash  entity_trash.TrashService
+}
+
+func InitializeVaultService(repo entity.VaultRepository, authz authorization.Authorizer, outbox entity_outbox.OutboxService, trash entity_trash.TrashService) (entity.VaultService, error) {
+
+	if repo == nil {
+		return nil, core.ErrRepositoryNotFound("VaultRepository")
+	}
+
+	if authz == nil {
+		return nil, core.ErrServiceNotFound("Authorizer")
+	}
+
+	if outbox == nil {
+		return nil, core.ErrServiceNotFound("OutboxService")
+	}
+
+	if trash == nil {
+		return nil, core.ErrServiceNotFound("TrashService")
+	}
+
+	return &vault{
+		repo:   repo,
+		authz:  authz,
+		outbox: outbox,
+		trash:  trash,
+	}, nil
+}
+
+func (s *vault) Create(ctx context.Context, data *entity.Vault) (*entity.Vault, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("vault is required")
+	}
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newVault := entity.NewVault(*data, tenantID, userID)
+	if err := newVault.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	// The ID is generated here so the outbox entry can reference the vault
+	// and both documents are written in the same transaction.
+	newVault.ID = utils.GenerateID()
+	entry := entity_outbox.NewOutboxEntry(utils.GenerateID(), entity_outbox.OUTBOX_WRITE, newVault.OwnershipTuples(), newVault.GetOpenFGAID())
+
+	payload, err := utils.StructToMap(newVault)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert vault data to map")
+	}
+
+	entryPayload, err := utils.StructToMap(entry)
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert outbox entry to map")
+	}
+
+	result, err := s.repo.CreateWithOutbox(ctx, payload, entryPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the tuples right away so the creator can use the vault; on failure the relay retries.
+	if err := s.outbox.Dispatch(ctx, entry); err != nil {
+		log.Printf("Vault %s created but permissions are pending: %v", result.ID, err)
+	}
+
+	return result, nil
+}
+
+func (s *vault) Get(ctx context.Context, id string) (*entity.Vault, error) {
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if id == "" {
+		return nil, core.ErrInvalidRequest("vault ID is required")
+	}
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, userID, authorization.CanView, id); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.TenantID != tenantID || result.IsDeleted() {
+		return nil, core.ErrNotFound("vault " + id)
+	}
+
+	return result, nil
+}
+
+// List returns the active vaults the user can view. The page is read from the
+// database and then filtered by the authorization model, so it may hold fewer
+// vaults than the page size even when there is a next page.
+func (s *vault) List(ctx context.Context, opts database.QueryOptions) ([]entity.Vault, string, error) {
+
+	if ctx.Err() != nil {
+		return nil, "", fmt.Errorf(utils.ContextCancelled, ctx.Err().Error())
+	}
+
+	if err := opts.Validate(entity.VaultSortableFields...); err != nil {
+		return nil, "", core.ErrInvalidRequest(err.Error())
+	}
+
+	userID, _, err := s.identity(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	objects, err := s.authz.ListObjects(ctx, authorization.User(userID), authorization.CanView, authorization.TypeVault)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list allowed vaults: %w", err)
+	}
+
+	if len(objects) == 0 {
+		return []entity.Vault{}, "", nil
+	}
+
+	allowed := map[string]bool{}
+	for _, id := range authorization.ObjectIDs(objects) {
+		allowed[id] = true
+	}
+
+	filters := []database.Conditional{
+		{
+			Field:  "isActive",
+			Value:  true,
+			Filter: database.FilterEquals,
+		},
+	}
+
+	result, next, err := s.repo.List(ctx, filters, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	vaults := []entity.Vault{}
+	for _, v := range result {
+		if allowed[v.ID] {
+			vaults = append(vaults, v)
+		}
+	}
+
+	return vaults, next, nil
+}
+
+func (s *vault) Update(ctx context.Context, id string, data *entity.Vault) (*entity.Vault, error) {
+
+	if data == nil {
+		return nil, core.ErrInvalidRequest("vault is required")
+	}
+
+	userID, _, err := s.identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, userID, authorization.CanManage, id); err != nil {
+		return nil, err
+	}
+
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current.Name = strings.TrimSpace(data.Name)
+	current.Description = data.Description
+	current.Icon = data.Icon
+	current.Color = data.Color
+	current.Tags = data.Tags
+	current.UpdatedAt = time.Now().UTC()
+
+	if err := current.IsValid(); err != nil {
+		return nil, core.ErrInvalidRequest(err.Error())
+	}
+
+	if current.Tags == nil {
+		current.Tags = []string{}
+	}
+
+	// The editable fields are written even when empty, so an update can clear them.
+	payload, err := utils.StructToMap(map[string]interface{}{
+		"name":        current.Name,
+		"description": current.Description,
+		"icon":        current.Icon,
+		"color":       current.Color,
+		"tags":        current.Tags,
+		"updatedAt":   current.UpdatedAt.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, core.ErrGenericError("Failed to convert vault data to map")
+	}
+
+	// Without an If-Match the revision that was read is the precondition, so a concurrent
+	// update is reported as a conflict instead of being overwritten.
+	revision := data.Revision
+	if revision == 0 {
+		revision = current.Revision
+	}
+
+	if err := s.repo.UpdateIfMatch(ctx, id, revision, payload); err != nil {
+		return nil, err
+	}
+	current.Revision = revision + 1
+
+	return current, nil
+}
+
+// Delete moves the vault to the trash. It keeps its secrets and tuples until it is purged.
+func (s *vault) Delete(ctx context.Context, id string) error {
+
+	userID, tenantID, err := s.identity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authorize(ctx, userID, authorization.CanManage, id); err != nil {
+		return err
+	}
+
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	item := entity_trash.NewTrashItem(entity_trash.TRASH_VAULT, tenantID, current.ID, current.ID, current.Name, entity.VaultCollection, userID)
+	_, err = s.trash.MoveToTrash(ctx, item)
+	return err
+}
+
+func (s *vault) Search(ctx context.Context, name string) ([]entity.Vault, error) {
+
+	if strings.TrimSpace(name) == "" {
+		return nil, core.ErrInvalidRequest("search term is required")
+	}
+
+	vaults, _, err := s.List(ctx, database.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []entity.Vault{}
+	for _, v := range vaults {
+		if v.MatchName(name) {
+			result = append(result, v)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *vault) identity(ctx context.Context) (string, string, error) {
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	tenantID, err := utils.GetTenantIDFromContext(ctx)
+	if err != nil {
+		return "", "", core.ErrUnauthorized(err.Error())
+	}
+
+	return userID, tenantID, nil
+}
+
+// authorize checks the relation on the vault before any access to the database.
+func (s *vault) authorize(ctx context.Context, userID, relation, id string) error {
+
+	if id == "" {
+		return core.ErrInvalidRequest("vault ID is required")
+	}
+
+	allowed, err := s.authz.Check(ctx, authorization.User(userID), relation, authorization.Object(authorization.TypeVault, id))
+	if err != nil {
+		return fmt.Errorf("failed to check vault permission: %w", err)
+	}
+
+	if !allowed {
+		return core.ErrForbidden(fmt.Sprintf("user is not allowed to %s vault %s", strings.TrimPrefix(relation, "can_"), id))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	repo_outbox "github.com/synera-br/lockari-backend-app/internal/core/repository/outbox"
+	repo_trash "github.com/synera-br/lockari-backend-app/internal/core/repository/trash"
+	repo_vault "github.com/synera-br/lockari-backend-app/internal/core/repository/vault"
+	svc_outbox "github.com/synera-br/lockari-backend-app/internal/core/service/outbox"
+	"github.com/synera-br/lockari-backend-app/internal/core/service/servicetest"
+	svc_trash "github.com/synera-br/lockari-backend-app/internal/core/service/trash"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+// setup returns the vault service over a memory database and the context of alice in t1.
+func setup(t *testing.T) (entity.VaultService, context.Context) {
+	ctx := context.WithValue(context.WithValue(context.Background(), "UserID", "alice"), "TenantID", "t1")
+
+	db := database.NewMemoryDB()
+	authz, err := authorization.NewMemoryAuthorizerFromFile(servicetest.ModelFile())
+	require.NoError(t, err)
+	require.NoError(t, authz.WriteTuples(ctx, []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: authorization.RelationOwner, Object: authorization.Object(authorization.TypeTenant, "t1")},
+	}))
+
+	outboxRepo, err := repo_outbox.InitializeOutboxRepository(db)
+	require.NoError(t, err)
+	outbox, err := svc_outbox.InitializeOutboxService(outboxRepo, authz)
+	require.NoError(t, err)
+	trashRepo, err := repo_trash.InitializeTrashRepository(db)
+	require.NoError(t, err)
+	trash, err := svc_trash.InitializeTrashService(trashRepo, authz, &servicetest.Audit{}, time.Hour)
+	require.NoError(t, err)
+	repo, err := repo_vault.InitializeVaultRepository(db)
+	require.NoError(t, err)
+	svc, err := InitializeVaultService(repo, authz, outbox, trash)
+	require.NoError(t, err)
+
+	return svc, ctx
+}
+
+func TestVaultUpdateRevision(t *testing.T) {
+	svc, ctx := setup(t)
+
+	created, err := svc.Create(ctx, &entity.Vault{Name: "Production"})
+	require.NoError(t, err)
+
+	// Without If-Match the revision that was read is used, and the stored one is returned.
+	updated, err := svc.Update(ctx, created.ID, &entity.Vault{Name: "Staging"})
+	require.NoError(t, err)
+	stored, err := svc.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Revision, updated.Revision)
+	assert.Equal(t, "Staging", stored.Name)
+
+	_, err = svc.Update(ctx, created.ID, &entity.Vault{Name: "Lost", Revision: created.Revision})
+	assert.True(t, core.IsConflict(err), "a stale revision is rejected")
+
+	updated, err = svc.Update(ctx, created.ID, &entity.Vault{Name: "Development", Revision: updated.Revision})
+	require.NoError(t, err)
+	stored, err = svc.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Revision, updated.Revision)
+}
+
+func TestVaultUpdateClearsFields(t *testing.T) {
+	svc, ctx := setup(t)
+
+	created, err := svc.Create(ctx, &entity.Vault{Name: "Production", Description: "main", Icon: "lock", Color: "#ff0000", Tags: []string{"prod"}})
+	require.NoError(t, err)
+
+	_, err = svc.Update(ctx, created.ID, &entity.Vault{Name: "Production"})
+	require.NoError(t, err)
+
+	stored, err := svc.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Description)
+	assert.Empty(t, stored.Icon)
+	assert.Empty(t, stored.Color)
+	assert.Empty(t, stored.Tags)
+}
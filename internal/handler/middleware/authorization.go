@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+)
+
+// Authorization enforces OpenFGA relations on routes.
+// It must run after ValidateToken, which stores the user ID in the Gin context.
+type Authorization struct {
+	authz authorization.Authorizer
+	audit entity.AuditSystemEventService
+}
+
+func NewAuthorizationMiddleware(authz authorization.Authorizer, audit entity.AuditSystemEventService) (*Authorization, error) {
+
+	if authz == nil {
+		return nil, errors.New("authorization middleware: authorizer is required")
+	}
+
+	if audit == nil {
+		return nil, errors.New("authorization middleware: audit service is required")
+	}
+
+	return &Authorization{
+		authz: authz,
+		audit: audit,
+	}, nil
+}
+
+// RequireRelation aborts with 403 unless the user has relation with the object.
+// param is either a route parameter (":vaultId") or a literal object ID.
+//
+//	routes.PUT("/:vaultId", permissions.RequireRelation("vault", ":vaultId", "can_write"), h.Update)
+func (a *Authorization) RequireRelation(objectType, param, relation string) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		userID := c.GetString(string(UserIDContextKey))
+		if userID == "" {
+			log.Println("User ID not found in context")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		objectID := param
+		if strings.HasPrefix(param, ":") {
+			objectID = c.Param(strings.TrimPrefix(param, ":"))
+		}
+
+		if objectID == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Resource identifier is required"})
+			return
+		}
+
+		object := authorization.Object(objectType, objectID)
+		allowed, err := a.authz.Check(c.Request.Context(), authorization.User(userID), relation, object)
+		if err != nil {
+			log.Printf("Authorization check failed for %s on %s: %v", relation, object, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+			return
+		}
+
+		if !allowed {
+			a.recordDenied(c, userID, object, relation)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// recordDenied stores the ACCESS_DENIED audit event. Failures are only logged so the
+// response to the client does not depend on the audit storage.
+func (a *Authorization) recordDenied(c *gin.Context, userID, object, relation string) {
+
+	email := ""
+	if claims, ok := c.Get(string(ClaimsContextKey)); ok {
+		if m, ok := claims.(map[string]interface{}); ok {
+			email, _ = m["email"].(string)
+		}
+	}
+
+	event := entity.NewAccessDeniedEvent(
+		userID,
+		email,
+		c.GetString(string(TenantIDContextKey)),
+		object,
+		relation,
+		entity.Client{IpAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()},
+	)
+
+	if _, err := a.audit.Record(context.WithoutCancel(c.Request.Context()), event); err != nil {
+		log.Printf("Failed to record access denied event for user %s on %s: %v", userID, object, err)
+	}
+}
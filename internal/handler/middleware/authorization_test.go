@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+type auditRecorder struct {
+	events []entity.AuditSystemEvent
+}
+
+func (a *auditRecorder) Create(ctx context.Context, event *entity.AuditSystemEvent) (*entity.AuditSystemEvent, error) {
+	return event, nil
+}
+
+func (a *auditRecorder) Get(ctx context.Context, id string) (*entity.AuditSystemEvent, error) {
+	return nil, nil
+}
+
+func (a *auditRecorder) List(ctx context.Context, filter entity.AuditFilter, opts database.QueryOptions) ([]entity.AuditSystemEvent, string, error) {
+	return nil, "", nil
+}
+
+func (a *auditRecorder) Record(ctx context.Context, event *entity.AuditSystemEvent) (*entity.AuditSystemEvent, error) {
+	a.events = append(a.events, *event)
+	return event, nil
+}
+
+const vaultModel = `{
+  "schema_version": "1.1",
+  "type_definitions": [
+    {"type": "user"},
+    {"type": "vault", "relations": {
+      "writer": {"this": {}},
+      "can_write": {"computedUserset": {"relation": "writer"}}
+    }}
+  ]
+}`
+
+func TestRequireRelation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	model, err := authorization.ParseModelJSON([]byte(vaultModel))
+	require.NoError(t, err)
+	authz, err := authorization.NewMemoryAuthorizer(model)
+	require.NoError(t, err)
+	require.NoError(t, authz.WriteTuples(context.Background(), []authorization.TupleKey{
+		{User: authorization.User("alice"), Relation: "writer", Object: "vault:v1"},
+	}))
+
+	audit := &auditRecorder{}
+	permissions, err := NewAuthorizationMiddleware(authz, audit)
+	require.NoError(t, err)
+
+	newRouter := func(userID string) *gin.Engine {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if userID != "" {
+				c.Set(string(UserIDContextKey), userID)
+				c.Set(string(TenantIDContextKey), "t1")
+			}
+		})
+		router.PUT("/vaults/:vaultId", permissions.RequireRelation("vault", ":vaultId", "can_write"), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		return router
+	}
+
+	serve := func(userID, path string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, path, nil)
+		newRouter(userID).ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve("alice", "/vaults/v1"))
+		assert.Empty(t, audit.events)
+	})
+
+	t.Run("denied is audited", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve("bob", "/vaults/v1"))
+		require.Len(t, audit.events, 1)
+		assert.Equal(t, entity.ACCESS_DENIED, audit.events[0].EventType)
+		assert.Equal(t, "bob", audit.events[0].User.Uid)
+		assert.Equal(t, "vault:v1", audit.events[0].Resource)
+		assert.Equal(t, "t1", audit.events[0].TenantID)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve("", "/vaults/v1"))
+	})
+}
@@ -73,6 +73,41 @@ func ValidateToken(ctx context.Context, auth authenticator.Authenticator) gin.Ha
 	}
 }
 
+// ValidateIdentity validates the Firebase token like ValidateToken but does not require the
+// tenantId claim, for the endpoints used by users that may not belong to a tenant yet.
+func ValidateIdentity(ctx context.Context, auth authenticator.Authenticator) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("X-AUTHORIZATION")
+		idToken := strings.TrimPrefix(authHeader, "Bearer ")
+		if authHeader == "" || idToken == authHeader {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token not provided"})
+			return
+		}
+
+		claims, err := auth.ValidateToken(c.Request.Context(), idToken)
+		if err != nil {
+			log.Printf("Token validation failed: %v", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+
+		uid, ok := claims["sub"].(string)
+		if !ok || uid == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User identifier not found in token"})
+			return
+		}
+
+		c.Set(string(UserIDContextKey), uid)
+		if tenantId, ok := claims["tenantId"].(string); ok && tenantId != "" {
+			c.Set(string(TenantIDContextKey), tenantId)
+		}
+		c.Set(string(ClaimsContextKey), claims)
+
+		c.Next()
+	}
+}
+
 func ValidateTokenJWT(token tokengen.TokenGenerator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the JWT from the Token header
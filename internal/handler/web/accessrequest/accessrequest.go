@@ -0,0 +1,228 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/accessrequest"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type accessRequestHandler struct {
+	svc        entity.AccessRequestService
+	encryptor  cryptserver.CryptDataInterface
+	authClient authenticator.Authenticator
+}
+
+type AccessRequestHandlerInterface interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Approve(c *gin.Context)
+	Deny(c *gin.Context)
+	Decide(c *gin.Context)
+}
+
+type decision struct {
+	Comment string `json:"comment"`
+}
+
+// signedDecision is the decision taken from the link of the email sent to the approvers.
+type signedDecision struct {
+	Token    string          `json:"token" binding:"required"`
+	Decision entity.Decision `json:"decision" binding:"required"`
+	Comment  string          `json:"comment"`
+}
+
+func InitializeAccessRequestHandler(
+	svc entity.AccessRequestService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (AccessRequestHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "access request service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "access request encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "access request auth client")
+	}
+
+	handler := &accessRequestHandler{
+		svc:        svc,
+		encryptor:  encryptor,
+		authClient: authClient,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+// setupRoutes registers the temporary access requests of the vaults of the current tenant
+// (/access-requests). GET /access-requests?vaultId= lists the requests of a vault to its admins.
+func (h *accessRequestHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	requestRoutes := routerGroup.Group("/access-requests")
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+	for _, mw := range middleware {
+		requestRoutes.Use(mw)
+	}
+
+	requestRoutes.GET("", h.List)
+	requestRoutes.POST("", h.Create)
+	requestRoutes.POST("/decide", h.Decide)
+	requestRoutes.GET("/:requestId", h.Get)
+	requestRoutes.POST("/:requestId/approve", h.Approve)
+	requestRoutes.POST("/:requestId/deny", h.Deny)
+}
+
+func (h *accessRequestHandler) Create(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request entity.AccessRequest
+	if err := web.DecodePayload(c, h.encryptor, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Create(ctx, &request)
+	if err != nil {
+		log.Println("Error creating access request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to create access request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *accessRequestHandler) Get(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Get(ctx, c.Param("requestId"))
+	if err != nil {
+		log.Println("Error getting access request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to get access request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *accessRequestHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, c.Query("vaultId"), opts)
+	if err != nil {
+		log.Println("Error listing access requests:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list access requests: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": result, "nextPageToken": next})
+}
+
+func (h *accessRequestHandler) Approve(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body decision
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Approve(ctx, c.Param("requestId"), body.Comment)
+	if err != nil {
+		log.Println("Error approving access request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to approve access request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *accessRequestHandler) Deny(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body decision
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Deny(ctx, c.Param("requestId"), body.Comment)
+	if err != nil {
+		log.Println("Error denying access request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to deny access request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *accessRequestHandler) Decide(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body signedDecision
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Decide(ctx, body.Token, body.Decision, body.Comment)
+	if err != nil {
+		log.Println("Error deciding access request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to decide access request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
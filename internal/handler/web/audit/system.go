@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/audit"
 	"github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
 	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
 	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
 	"github.com/synera-br/lockari-backend-app/pkg/tokengen"
@@ -113,10 +116,72 @@ func (h *auditSystemEventHandler) Create(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Audit event created successfully"})
 }
 
-func (w *auditSystemEventHandler) Get(c *gin.Context) {
+func (h *auditSystemEventHandler) Get(c *gin.Context) {
 
+	ctx := context.WithValue(c.Request.Context(), "token", c.GetHeader("X-TOKEN"))
+	event, err := h.svc.Get(ctx, c.Param("id"))
+	if err != nil {
+		log.Println("Error getting audit event:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to get audit event: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
 }
 
-func (w *auditSystemEventHandler) List(c *gin.Context) {
+func (h *auditSystemEventHandler) List(c *gin.Context) {
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), "token", c.GetHeader("X-TOKEN"))
+	events, next, err := h.svc.List(ctx, filter, opts)
+	if err != nil {
+		log.Println("Error listing audit events:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list audit events: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events, "nextPageToken": next})
+}
+
+// parseAuditFilter reads ?eventType=&from=&to=&userId=&tenantId=.
+// eventType accepts a comma separated list and from/to must be RFC3339 dates.
+func parseAuditFilter(c *gin.Context) (entity.AuditFilter, error) {
+
+	filter := entity.AuditFilter{
+		UserID:   c.Query("userId"),
+		TenantID: c.Query("tenantId"),
+	}
+
+	for _, value := range c.QueryArray("eventType") {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				filter.EventTypes = append(filter.EventTypes, entity.EventType(strings.ToUpper(eventType)))
+			}
+		}
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be a RFC3339 date", param)
+		}
+		*target = &date
+	}
 
+	return filter, nil
 }
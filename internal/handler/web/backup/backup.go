@@ -0,0 +1,149 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/backup"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type backupHandler struct {
+	svc        entity.BackupService
+	authClient authenticator.Authenticator
+}
+
+type BackupHandlerInterface interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	Restore(c *gin.Context)
+}
+
+func InitializeBackupHandler(
+	svc entity.BackupService,
+	authClient authenticator.Authenticator,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (BackupHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "backup service")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "backup auth client")
+	}
+
+	handler := &backupHandler{
+		svc:        svc,
+		authClient: authClient,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+// setupRoutes registers the backup endpoints. Backups cover the whole tenant, so the service
+// checks that the user is an owner or admin of the tenant.
+func (h *backupHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	backupRoutes := routerGroup.Group("/backups")
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+	for _, mw := range middleware {
+		backupRoutes.Use(mw)
+	}
+
+	backupRoutes.POST("", h.Create)
+	backupRoutes.GET("", h.List)
+	backupRoutes.GET("/:backupId", h.Get)
+	backupRoutes.POST("/:backupId/restore", h.Restore)
+}
+
+func (h *backupHandler) Create(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Create(ctx)
+	if err != nil {
+		log.Println("Error creating backup:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to create backup: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *backupHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, opts)
+	if err != nil {
+		log.Println("Error listing backups:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list backups: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backups": result, "nextPageToken": next})
+}
+
+func (h *backupHandler) Get(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Get(ctx, c.Param("backupId"))
+	if err != nil {
+		log.Println("Error retrieving backup:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to retrieve backup: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Restore restores the backup into its tenant, or into the empty tenant of the
+// targetTenantId query parameter.
+func (h *backupHandler) Restore(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := entity.RestoreRequest{TargetTenantID: c.Query("targetTenantId")}
+
+	result, err := h.svc.Restore(ctx, c.Param("backupId"), request)
+	if err != nil {
+		log.Println("Error restoring backup:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to restore backup: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,215 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/externalshare"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type externalShareHandler struct {
+	svc        entity.ExternalShareService
+	encryptor  cryptserver.CryptDataInterface
+	authClient authenticator.Authenticator
+}
+
+type ExternalShareHandlerInterface interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Approve(c *gin.Context)
+	Reject(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type decision struct {
+	Comment string `json:"comment"`
+}
+
+func InitializeExternalShareHandler(
+	svc entity.ExternalShareService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (ExternalShareHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "external share service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "external share encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "external share auth client")
+	}
+
+	handler := &externalShareHandler{
+		svc:        svc,
+		encryptor:  encryptor,
+		authClient: authClient,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+// setupRoutes registers the requests to share the vaults of the current tenant with users of
+// other tenants (/external-shares).
+func (h *externalShareHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	shareRoutes := routerGroup.Group("/external-shares")
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+	for _, mw := range middleware {
+		shareRoutes.Use(mw)
+	}
+
+	shareRoutes.GET("", h.List)
+	shareRoutes.POST("", h.Create)
+	shareRoutes.GET("/:requestId", h.Get)
+	shareRoutes.POST("/:requestId/approve", h.Approve)
+	shareRoutes.POST("/:requestId/reject", h.Reject)
+	shareRoutes.POST("/:requestId/revoke", h.Revoke)
+}
+
+func (h *externalShareHandler) Create(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request entity.ExternalShareRequest
+	if err := web.DecodePayload(c, h.encryptor, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Create(ctx, &request)
+	if err != nil {
+		log.Println("Error creating external share request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to create external share request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *externalShareHandler) Get(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Get(ctx, c.Param("requestId"))
+	if err != nil {
+		log.Println("Error getting external share request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to get external share request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *externalShareHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, opts)
+	if err != nil {
+		log.Println("Error listing external share requests:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list external share requests: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": result, "nextPageToken": next})
+}
+
+func (h *externalShareHandler) Approve(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body decision
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Approve(ctx, c.Param("requestId"), body.Comment)
+	if err != nil {
+		log.Println("Error approving external share request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to approve external share request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *externalShareHandler) Reject(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body decision
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Reject(ctx, c.Param("requestId"), body.Comment)
+	if err != nil {
+		log.Println("Error rejecting external share request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to reject external share request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *externalShareHandler) Revoke(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Revoke(ctx, c.Param("requestId"))
+	if err != nil {
+		log.Println("Error revoking external share request:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to revoke external share request: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
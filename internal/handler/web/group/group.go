@@ -0,0 +1,273 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/group"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type groupHandler struct {
+	svc        entity.GroupService
+	encryptor  cryptserver.CryptDataInterface
+	authClient authenticator.Authenticator
+}
+
+type GroupHandlerInterface interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	ListMembers(c *gin.Context)
+	AddMember(c *gin.Context)
+	RemoveMember(c *gin.Context)
+}
+
+func InitializeGroupHandler(
+	svc entity.GroupService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (GroupHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "group service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "group encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "group auth client")
+	}
+
+	handler := &groupHandler{
+		svc:        svc,
+		encryptor:  encryptor,
+		authClient: authClient,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+func (h *groupHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+
+	groupRoutes := routerGroup.Group("/groups")
+	for _, mw := range middleware {
+		groupRoutes.Use(mw)
+	}
+
+	groupRoutes.POST("", h.Create)
+	groupRoutes.GET("", h.List)
+	groupRoutes.GET("/:groupId", h.Get)
+	groupRoutes.PUT("/:groupId", h.Update)
+	groupRoutes.DELETE("/:groupId", h.Delete)
+	groupRoutes.GET("/:groupId/members", h.ListMembers)
+	groupRoutes.POST("/:groupId/members", h.AddMember)
+	groupRoutes.DELETE("/:groupId/members/:memberType/:memberId", h.RemoveMember)
+}
+
+func (h *groupHandler) Create(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group entity.Group
+	if err := web.DecodePayload(c, h.encryptor, &group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Create(ctx, &group)
+	if err != nil {
+		log.Println("Error creating group:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to create group: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *groupHandler) Get(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Get(ctx, c.Param("groupId"))
+	if err != nil {
+		log.Println("Error getting group:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to get group: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// List returns the groups of the tenant, or with ?userId= (or ?userId=me) the groups of a user,
+// including those it belongs to through nested groups.
+func (h *groupHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if userID, ok := c.GetQuery("userId"); ok {
+		if userID == "me" {
+			userID = ""
+		}
+
+		result, err := h.svc.ListForUser(ctx, userID)
+		if err != nil {
+			log.Println("Error listing groups of user:", err)
+			c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list groups: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"groups": result, "nextPageToken": ""})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, opts)
+	if err != nil {
+		log.Println("Error listing groups:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list groups: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"groups": result, "nextPageToken": next})
+}
+
+func (h *groupHandler) Update(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group entity.Group
+	if err := web.DecodePayload(c, h.encryptor, &group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Update(ctx, c.Param("groupId"), &group)
+	if err != nil {
+		log.Println("Error updating group:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to update group: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *groupHandler) Delete(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Delete(ctx, c.Param("groupId")); err != nil {
+		log.Println("Error deleting group:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to delete group: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+}
+
+func (h *groupHandler) ListMembers(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.ListMembers(ctx, c.Param("groupId"), opts)
+	if err != nil {
+		log.Println("Error listing group members:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list group members: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": result, "nextPageToken": next})
+}
+
+func (h *groupHandler) AddMember(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var member entity.Member
+	if err := web.DecodePayload(c, h.encryptor, &member); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.AddMember(ctx, c.Param("groupId"), &member)
+	if err != nil {
+		log.Println("Error adding group member:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to add group member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *groupHandler) RemoveMember(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	memberType := entity.MemberType(c.Param("memberType"))
+	if err := h.svc.RemoveMember(ctx, c.Param("groupId"), memberType, c.Param("memberId")); err != nil {
+		log.Println("Error removing group member:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to remove group member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
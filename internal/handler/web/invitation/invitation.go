@@ -0,0 +1,200 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/invitation"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type invitationHandler struct {
+	svc        entity.InvitationService
+	encryptor  cryptserver.CryptDataInterface
+	authClient authenticator.Authenticator
+}
+
+type InvitationHandlerInterface interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Revoke(c *gin.Context)
+	Accept(c *gin.Context)
+	Decline(c *gin.Context)
+}
+
+type invitationToken struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func InitializeInvitationHandler(
+	svc entity.InvitationService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (InvitationHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "invitation service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "invitation encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "invitation auth client")
+	}
+
+	handler := &invitationHandler{
+		svc:        svc,
+		encryptor:  encryptor,
+		authClient: authClient,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+// setupRoutes registers the invitations of the current tenant (/tenant/invitations) and the
+// answer of the invitee (/invitations), who may not belong to any tenant yet.
+func (h *invitationHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	tenantRoutes := routerGroup.Group("/tenant/invitations")
+	inviteeRoutes := routerGroup.Group("/invitations")
+	for _, mw := range middleware {
+		tenantRoutes.Use(mw)
+		inviteeRoutes.Use(mw)
+	}
+	tenantRoutes.Use(mid.ValidateToken(&gin.Context{}, h.authClient))
+	inviteeRoutes.Use(mid.ValidateIdentity(&gin.Context{}, h.authClient))
+
+	tenantRoutes.GET("", h.List)
+	tenantRoutes.POST("", h.Create)
+	tenantRoutes.DELETE("/:invitationId", h.Revoke)
+
+	inviteeRoutes.POST("/accept", h.Accept)
+	inviteeRoutes.POST("/decline", h.Decline)
+}
+
+func (h *invitationHandler) Create(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var invitation entity.Invitation
+	if err := web.DecodePayload(c, h.encryptor, &invitation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Create(ctx, &invitation)
+	if err != nil {
+		log.Println("Error creating invitation:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to create invitation: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *invitationHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, opts)
+	if err != nil {
+		log.Println("Error listing invitations:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list invitations: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": result, "nextPageToken": next})
+}
+
+func (h *invitationHandler) Revoke(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Revoke(ctx, c.Param("invitationId"))
+	if err != nil {
+		log.Println("Error revoking invitation:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to revoke invitation: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Accept adds the user to the tenant of the invitation. The client must refresh its token to use it.
+func (h *invitationHandler) Accept(c *gin.Context) {
+
+	ctx, err := web.NewUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body invitationToken
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Accept(ctx, body.Token)
+	if err != nil {
+		log.Println("Error accepting invitation:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to accept invitation: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *invitationHandler) Decline(c *gin.Context) {
+
+	ctx, err := web.NewUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body invitationToken
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Decline(ctx, body.Token)
+	if err != nil {
+		log.Println("Error declining invitation:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to decline invitation: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
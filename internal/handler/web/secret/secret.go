@@ -0,0 +1,335 @@
+package webhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/secret"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type secretHandler struct {
+	svc         entity.SecretService
+	encryptor   cryptserver.CryptDataInterface
+	authClient  authenticator.Authenticator
+	permissions *mid.Authorization
+}
+
+type SecretHandlerInterface interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	Reveal(c *gin.Context)
+	ListVersions(c *gin.Context)
+	GetVersion(c *gin.Context)
+	RestoreVersion(c *gin.Context)
+}
+
+type restoreVersionRequest struct {
+	ChangeNote string `json:"changeNote"`
+}
+
+func InitializeSecretHandler(
+	svc entity.SecretService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	permissions *mid.Authorization,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (SecretHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "secret service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "secret encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "secret auth client")
+	}
+
+	if permissions == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "secret authorization middleware")
+	}
+
+	handler := &secretHandler{
+		svc:         svc,
+		encryptor:   encryptor,
+		authClient:  authClient,
+		permissions: permissions,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+func (h *secretHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	secretRoutes := routerGroup.Group("/vaults/:vaultId/secrets")
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+	for _, mw := range middleware {
+		secretRoutes.Use(mw)
+	}
+
+	canView := h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanView)
+	canRead := h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanRead)
+	canWrite := h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanWrite)
+	canDelete := h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanDelete)
+
+	secretRoutes.POST("", canWrite, h.Create)
+	secretRoutes.GET("", canView, h.List)
+	secretRoutes.GET("/:secretId", canView, h.Get)
+	secretRoutes.PUT("/:secretId", canWrite, h.Update)
+	secretRoutes.DELETE("/:secretId", canDelete, h.Delete)
+	secretRoutes.GET("/:secretId/reveal", canRead, h.Reveal)
+	secretRoutes.GET("/:secretId/versions", canView, h.ListVersions)
+	secretRoutes.GET("/:secretId/versions/:version", canRead, h.GetVersion)
+	secretRoutes.POST("/:secretId/versions/:version/restore", canWrite, h.RestoreVersion)
+}
+
+func (h *secretHandler) Create(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var secret entity.Secret
+	if err := web.DecodePayload(c, h.encryptor, &secret); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Create(ctx, c.Param("vaultId"), &secret)
+	if err != nil {
+		log.Println("Error creating secret:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to create secret: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *secretHandler) Get(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Get(ctx, c.Param("vaultId"), c.Param("secretId"))
+	if err != nil {
+		log.Println("Error retrieving secret:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to retrieve secret: " + err.Error()})
+		return
+	}
+
+	web.SetETag(c, result.Revision)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *secretHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, c.Param("vaultId"), opts)
+	if err != nil {
+		log.Println("Error listing secrets:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list secrets: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secrets": result, "nextPageToken": next})
+}
+
+func (h *secretHandler) Update(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	revision, err := web.ParseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var secret entity.Secret
+	if err := web.DecodePayload(c, h.encryptor, &secret); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if revision > 0 {
+		secret.Revision = revision
+	}
+
+	result, err := h.svc.Update(ctx, c.Param("vaultId"), c.Param("secretId"), &secret)
+	if err != nil {
+		log.Println("Error updating secret:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to update secret: " + err.Error()})
+		return
+	}
+
+	web.SetETag(c, result.Revision)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *secretHandler) Delete(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Delete(ctx, c.Param("vaultId"), c.Param("secretId")); err != nil {
+		log.Println("Error deleting secret:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to delete secret: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Secret deleted successfully"})
+}
+
+// Reveal returns the decrypted value wrapped in the transport envelope,
+// so the plaintext never travels as clear JSON.
+func (h *secretHandler) Reveal(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Reveal(ctx, c.Param("vaultId"), c.Param("secretId"))
+	if err != nil {
+		log.Println("Error revealing secret:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to reveal secret: " + err.Error()})
+		return
+	}
+
+	h.respondEncrypted(c, result)
+}
+
+func (h *secretHandler) ListVersions(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.ListVersions(ctx, c.Param("vaultId"), c.Param("secretId"))
+	if err != nil {
+		log.Println("Error listing secret versions:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list secret versions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"versions": result})
+}
+
+// GetVersion returns the version with its decrypted value, wrapped like Reveal.
+func (h *secretHandler) GetVersion(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	result, err := h.svc.GetVersion(ctx, c.Param("vaultId"), c.Param("secretId"), version)
+	if err != nil {
+		log.Println("Error retrieving secret version:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to retrieve secret version: " + err.Error()})
+		return
+	}
+
+	h.respondEncrypted(c, result)
+}
+
+// RestoreVersion accepts an optional encrypted body with a changeNote.
+func (h *secretHandler) RestoreVersion(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	var request restoreVersionRequest
+	if c.Request.ContentLength > 0 {
+		if err := web.DecodePayload(c, h.encryptor, &request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.svc.RestoreVersion(ctx, c.Param("vaultId"), c.Param("secretId"), version, request.ChangeNote)
+	if err != nil {
+		log.Println("Error restoring secret version:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to restore secret version: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *secretHandler) respondEncrypted(c *gin.Context, result interface{}) {
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode secret"})
+		return
+	}
+
+	payload, err := h.encryptor.EncryptPayload(data)
+	if err != nil {
+		log.Println("Error encrypting secret payload:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payload": payload})
+}
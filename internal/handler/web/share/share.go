@@ -0,0 +1,195 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/share"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type shareHandler struct {
+	svc         entity.ShareService
+	encryptor   cryptserver.CryptDataInterface
+	authClient  authenticator.Authenticator
+	permissions *mid.Authorization
+}
+
+type ShareHandlerInterface interface {
+	Grant(c *gin.Context)
+	Change(c *gin.Context)
+	Revoke(c *gin.Context)
+	ListAccess(c *gin.Context)
+	SharedWithMe(c *gin.Context)
+}
+
+type shareRole struct {
+	Role      entity.Role `json:"role" binding:"required"`
+	ExpiresAt *time.Time  `json:"expiresAt,omitempty"`
+}
+
+func InitializeShareHandler(
+	svc entity.ShareService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	permissions *mid.Authorization,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (ShareHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "share service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "share encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "share auth client")
+	}
+
+	if permissions == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "share authorization middleware")
+	}
+
+	handler := &shareHandler{
+		svc:         svc,
+		encryptor:   encryptor,
+		authClient:  authClient,
+		permissions: permissions,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+// setupRoutes registers the shares of a vault (/vaults/:vaultId/shares) and the vaults shared
+// with the user (/vaults/shared).
+func (h *shareHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	shareRoutes := routerGroup.Group("/vaults")
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+	for _, mw := range middleware {
+		shareRoutes.Use(mw)
+	}
+
+	canView := h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanView)
+	canShare := h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanShare)
+
+	shareRoutes.GET("/shared", h.SharedWithMe)
+	shareRoutes.GET("/:vaultId/shares", canView, h.ListAccess)
+	shareRoutes.POST("/:vaultId/shares", canShare, h.Grant)
+	shareRoutes.PUT("/:vaultId/shares/:subjectType/:subjectId", canShare, h.Change)
+	shareRoutes.DELETE("/:vaultId/shares/:subjectType/:subjectId", canShare, h.Revoke)
+}
+
+func (h *shareHandler) Grant(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var share entity.Share
+	if err := web.DecodePayload(c, h.encryptor, &share); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Grant(ctx, c.Param("vaultId"), &share)
+	if err != nil {
+		log.Println("Error sharing vault:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to share vault: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *shareHandler) Change(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body shareRole
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Change(ctx, c.Param("vaultId"), entity.SubjectType(c.Param("subjectType")), c.Param("subjectId"), body.Role, body.ExpiresAt)
+	if err != nil {
+		log.Println("Error changing vault share:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to change vault share: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *shareHandler) Revoke(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Revoke(ctx, c.Param("vaultId"), entity.SubjectType(c.Param("subjectType")), c.Param("subjectId")); err != nil {
+		log.Println("Error revoking vault share:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to revoke vault share: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share revoked successfully"})
+}
+
+func (h *shareHandler) ListAccess(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.ListAccess(ctx, c.Param("vaultId"))
+	if err != nil {
+		log.Println("Error listing vault access:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list vault access: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *shareHandler) SharedWithMe(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.SharedWithMe(ctx)
+	if err != nil {
+		log.Println("Error listing shared vaults:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list shared vaults: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vaults": result})
+}
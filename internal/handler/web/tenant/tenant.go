@@ -0,0 +1,259 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/tenant"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type tenantHandler struct {
+	svc        entity.TenantService
+	encryptor  cryptserver.CryptDataInterface
+	authClient authenticator.Authenticator
+}
+
+type TenantHandlerInterface interface {
+	Get(c *gin.Context)
+	Update(c *gin.Context)
+	Memberships(c *gin.Context)
+	Switch(c *gin.Context)
+	ListMembers(c *gin.Context)
+	AddMember(c *gin.Context)
+	UpdateMember(c *gin.Context)
+	RemoveMember(c *gin.Context)
+}
+
+type memberRole struct {
+	Role entity.Role `json:"role" binding:"required"`
+}
+
+func InitializeTenantHandler(
+	svc entity.TenantService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (TenantHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "tenant service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "tenant encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "tenant auth client")
+	}
+
+	handler := &tenantHandler{
+		svc:        svc,
+		encryptor:  encryptor,
+		authClient: authClient,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+// setupRoutes registers the endpoints of the current tenant (/tenant) and of the tenants of
+// the user (/tenants). The roles are read from the memberships by the service.
+func (h *tenantHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+
+	tenantRoutes := routerGroup.Group("/tenant")
+	tenantsRoutes := routerGroup.Group("/tenants")
+	for _, mw := range middleware {
+		tenantRoutes.Use(mw)
+		tenantsRoutes.Use(mw)
+	}
+
+	tenantRoutes.GET("", h.Get)
+	tenantRoutes.PUT("", h.Update)
+	tenantRoutes.GET("/members", h.ListMembers)
+	tenantRoutes.POST("/members", h.AddMember)
+	tenantRoutes.PUT("/members/:userId", h.UpdateMember)
+	tenantRoutes.DELETE("/members/:userId", h.RemoveMember)
+
+	tenantsRoutes.GET("", h.Memberships)
+	tenantsRoutes.POST("/:tenantId/switch", h.Switch)
+}
+
+func (h *tenantHandler) Get(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Get(ctx)
+	if err != nil {
+		log.Println("Error getting tenant:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to get tenant: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *tenantHandler) Update(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tenant entity.Tenant
+	if err := web.DecodePayload(c, h.encryptor, &tenant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Update(ctx, &tenant)
+	if err != nil {
+		log.Println("Error updating tenant:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to update tenant: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *tenantHandler) Memberships(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Memberships(ctx)
+	if err != nil {
+		log.Println("Error listing tenants:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list tenants: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tenants": result})
+}
+
+// Switch changes the tenant claim of the user. The client must refresh its token to use it.
+func (h *tenantHandler) Switch(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Switch(ctx, c.Param("tenantId"))
+	if err != nil {
+		log.Println("Error switching tenant:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to switch tenant: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *tenantHandler) ListMembers(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.ListMembers(ctx, opts)
+	if err != nil {
+		log.Println("Error listing tenant members:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list members: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": result, "nextPageToken": next})
+}
+
+func (h *tenantHandler) AddMember(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var member entity.Member
+	if err := web.DecodePayload(c, h.encryptor, &member); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.AddMember(ctx, &member)
+	if err != nil {
+		log.Println("Error adding tenant member:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to add member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *tenantHandler) UpdateMember(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body memberRole
+	if err := web.DecodePayload(c, h.encryptor, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.UpdateMemberRole(ctx, c.Param("userId"), body.Role)
+	if err != nil {
+		log.Println("Error updating tenant member:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to update member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *tenantHandler) RemoveMember(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.RemoveMember(ctx, c.Param("userId")); err != nil {
+		log.Println("Error removing tenant member:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to remove member: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
@@ -0,0 +1,105 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/trash"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type trashHandler struct {
+	svc        entity.TrashService
+	authClient authenticator.Authenticator
+}
+
+type TrashHandlerInterface interface {
+	List(c *gin.Context)
+	Restore(c *gin.Context)
+}
+
+func InitializeTrashHandler(
+	svc entity.TrashService,
+	authClient authenticator.Authenticator,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (TrashHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "trash service")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "trash auth client")
+	}
+
+	handler := &trashHandler{
+		svc:        svc,
+		authClient: authClient,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+// setupRoutes registers the trash endpoints. The permissions depend on the vault of each
+// item, so they are checked by the service instead of the authorization middleware.
+func (h *trashHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	trashRoutes := routerGroup.Group("/trash")
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+	for _, mw := range middleware {
+		trashRoutes.Use(mw)
+	}
+
+	trashRoutes.GET("", h.List)
+	trashRoutes.POST("/:itemId/restore", h.Restore)
+}
+
+func (h *trashHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, opts)
+	if err != nil {
+		log.Println("Error listing trash:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list trash: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": result, "nextPageToken": next})
+}
+
+func (h *trashHandler) Restore(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Restore(ctx, c.Param("itemId"))
+	if err != nil {
+		log.Println("Error restoring trash item:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to restore trash item: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
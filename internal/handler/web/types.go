@@ -2,10 +2,19 @@ package web
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/gin-gonic/gin"
+	core "github.com/synera-br/lockari-backend-app/internal/core/entity/types"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
 	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
 )
 
 const AuthTokenKey = "Authorization"
@@ -55,3 +64,134 @@ func validAuth(authClient authenticator.Authenticator, userID, authToken string)
 
 	return tokenUserID, nil
 }
+
+// NewRequestContext builds the context passed to services from the values
+// stored by middleware.ValidateToken: the raw token, the user ID and the tenant ID.
+func NewRequestContext(c *gin.Context) (context.Context, error) {
+	userID := c.GetString(string(mid.UserIDContextKey))
+	if userID == "" {
+		return nil, fmt.Errorf("user ID not found in request context")
+	}
+
+	tenantID := c.GetString(string(mid.TenantIDContextKey))
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID not found in request context")
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("X-AUTHORIZATION"), "Bearer ")
+
+	ctx := context.WithValue(c.Request.Context(), AuthTokenKey, token)
+	ctx = context.WithValue(ctx, "UserID", userID)
+	ctx = context.WithValue(ctx, "TenantID", tenantID)
+
+	return ctx, nil
+}
+
+// NewUserContext is NewRequestContext for the routes guarded by ValidateIdentity: the
+// tenant is only set when the user has one.
+func NewUserContext(c *gin.Context) (context.Context, error) {
+	userID := c.GetString(string(mid.UserIDContextKey))
+	if userID == "" {
+		return nil, fmt.Errorf("user ID not found in request context")
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("X-AUTHORIZATION"), "Bearer ")
+
+	ctx := context.WithValue(c.Request.Context(), AuthTokenKey, token)
+	ctx = context.WithValue(ctx, "UserID", userID)
+	if tenantID := c.GetString(string(mid.TenantIDContextKey)); tenantID != "" {
+		ctx = context.WithValue(ctx, "TenantID", tenantID)
+	}
+
+	return ctx, nil
+}
+
+// ErrorStatus maps service errors to the HTTP status returned to the client.
+func ErrorStatus(err error) int {
+	switch {
+	case core.IsInvalidRequest(err):
+		return http.StatusBadRequest
+	case core.IsUnauthorized(err):
+		return http.StatusUnauthorized
+	case core.IsForbidden(err):
+		return http.StatusForbidden
+	case core.IsNotFound(err):
+		return http.StatusNotFound
+	case core.IsConflict(err):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// DecodePayload reads the encrypted CryptData envelope and unmarshals the decrypted JSON into v.
+func DecodePayload(c *gin.Context, encryptor cryptserver.CryptDataInterface, v interface{}) error {
+
+	var body cryptserver.CryptData
+	if err := c.ShouldBindJSON(&body); err != nil {
+		return fmt.Errorf("Invalid request payload")
+	}
+
+	decryptedData, err := encryptor.PayloadData(body.Payload)
+	if err != nil {
+		log.Println("Error decrypting payload:", err)
+		return fmt.Errorf("Error processing request data")
+	}
+
+	if err := json.Unmarshal(decryptedData, v); err != nil {
+		log.Println("Error unmarshalling payload:", err)
+		return fmt.Errorf("Invalid request data")
+	}
+
+	return nil
+}
+
+// ParseIfMatch reads the revision of the If-Match header, as sent back from the ETag
+// of a previous response. It returns 0 when the header is missing or "*".
+func ParseIfMatch(c *gin.Context) (int64, error) {
+
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	revision, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+	if err != nil || revision <= 0 {
+		return 0, fmt.Errorf("If-Match must be an ETag returned by the API")
+	}
+
+	return revision, nil
+}
+
+// SetETag sets the ETag header to the revision of the returned document.
+func SetETag(c *gin.Context, revision int64) {
+	if revision > 0 {
+		c.Header("ETag", fmt.Sprintf("%q", strconv.FormatInt(revision, 10)))
+	}
+}
+
+// ParseQueryOptions reads the ?pageSize=&pageToken=&orderBy= query parameters of the list endpoints.
+// The page size defaults to database.DefaultPageSize.
+func ParseQueryOptions(c *gin.Context) (database.QueryOptions, error) {
+
+	opts := database.QueryOptions{
+		PageSize:  database.DefaultPageSize,
+		PageToken: c.Query("pageToken"),
+	}
+
+	if value := c.Query("pageSize"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 || size > database.MaxPageSize {
+			return opts, fmt.Errorf("pageSize must be a number between 1 and %d", database.MaxPageSize)
+		}
+		opts.PageSize = size
+	}
+
+	orderBy, err := database.ParseOrderBy(c.Query("orderBy"))
+	if err != nil {
+		return opts, err
+	}
+	opts.OrderBy = orderBy
+
+	return opts, nil
+}
@@ -0,0 +1,222 @@
+package webhandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	entity "github.com/synera-br/lockari-backend-app/internal/core/entity/vault"
+	mid "github.com/synera-br/lockari-backend-app/internal/handler/middleware"
+	"github.com/synera-br/lockari-backend-app/internal/handler/web"
+	"github.com/synera-br/lockari-backend-app/pkg/authenticator"
+	"github.com/synera-br/lockari-backend-app/pkg/authorization"
+	cryptserver "github.com/synera-br/lockari-backend-app/pkg/crypt/crypt_server"
+	"github.com/synera-br/lockari-backend-app/pkg/utils"
+)
+
+type vaultHandler struct {
+	svc         entity.VaultService
+	encryptor   cryptserver.CryptDataInterface
+	authClient  authenticator.Authenticator
+	permissions *mid.Authorization
+}
+
+type VaultHandlerInterface interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	Search(c *gin.Context)
+}
+
+func InitializeVaultHandler(
+	svc entity.VaultService,
+	encryptor cryptserver.CryptDataInterface,
+	authClient authenticator.Authenticator,
+	permissions *mid.Authorization,
+	routerGroup *gin.RouterGroup,
+	middleware ...gin.HandlerFunc,
+) (VaultHandlerInterface, error) {
+
+	if svc == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "vault service")
+	}
+
+	if encryptor == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "vault encryptor")
+	}
+
+	if authClient == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "vault auth client")
+	}
+
+	if permissions == nil {
+		return nil, fmt.Errorf(utils.ServiceNotFoundError, "vault authorization middleware")
+	}
+
+	handler := &vaultHandler{
+		svc:         svc,
+		encryptor:   encryptor,
+		authClient:  authClient,
+		permissions: permissions,
+	}
+
+	handler.setupRoutes(routerGroup, middleware...)
+
+	return handler, nil
+}
+
+func (h *vaultHandler) setupRoutes(routerGroup *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+
+	vaultRoutes := routerGroup.Group("/vaults")
+	middleware = append(middleware, mid.ValidateToken(&gin.Context{}, h.authClient))
+	for _, mw := range middleware {
+		vaultRoutes.Use(mw)
+	}
+
+	vaultRoutes.POST("", h.Create)
+	vaultRoutes.GET("", h.List)
+	vaultRoutes.GET("/search", h.Search)
+	vaultRoutes.GET("/:vaultId", h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanView), h.Get)
+	vaultRoutes.PUT("/:vaultId", h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanManage), h.Update)
+	vaultRoutes.DELETE("/:vaultId", h.permissions.RequireRelation(authorization.TypeVault, ":vaultId", authorization.CanManage), h.Delete)
+}
+
+func (h *vaultHandler) Create(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var vault entity.Vault
+	if err := web.DecodePayload(c, h.encryptor, &vault); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Create(ctx, &vault)
+	if err != nil {
+		log.Println("Error creating vault:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to create vault: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *vaultHandler) Get(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Get(ctx, c.Param("vaultId"))
+	if err != nil {
+		log.Println("Error retrieving vault:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to retrieve vault: " + err.Error()})
+		return
+	}
+
+	web.SetETag(c, result.Revision)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *vaultHandler) List(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts, err := web.ParseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, next, err := h.svc.List(ctx, opts)
+	if err != nil {
+		log.Println("Error listing vaults:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to list vaults: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vaults": result, "nextPageToken": next})
+}
+
+func (h *vaultHandler) Update(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	revision, err := web.ParseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var vault entity.Vault
+	if err := web.DecodePayload(c, h.encryptor, &vault); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if revision > 0 {
+		vault.Revision = revision
+	}
+
+	result, err := h.svc.Update(ctx, c.Param("vaultId"), &vault)
+	if err != nil {
+		log.Println("Error updating vault:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to update vault: " + err.Error()})
+		return
+	}
+
+	web.SetETag(c, result.Revision)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *vaultHandler) Delete(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Delete(ctx, c.Param("vaultId")); err != nil {
+		log.Println("Error deleting vault:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to delete vault: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vault deleted successfully"})
+}
+
+func (h *vaultHandler) Search(c *gin.Context) {
+
+	ctx, err := web.NewRequestContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.Search(ctx, c.Query("name"))
+	if err != nil {
+		log.Println("Error searching vaults:", err)
+		c.JSON(web.ErrorStatus(err), gin.H{"error": "Failed to search vaults: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vaults": result})
+}
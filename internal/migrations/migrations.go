@@ -0,0 +1,42 @@
+// Package migrations holds the document migrations of the backend, applied by cmd/migrate.
+// A migration is never edited once released: shape changes are added as a new version.
+package migrations
+
+import (
+	"github.com/synera-br/lockari-backend-app/pkg/migration"
+)
+
+// All returns every migration of the backend. The runner sorts them by version.
+func All() []migration.Migration {
+	return []migration.Migration{
+		signupUser,
+	}
+}
+
+// signupUser copies the user fields that Signup embeds at the top level into a nested
+// user object, the shape used by Login, so both events can be read the same way.
+// The top level fields are kept until Signup stops embedding User.
+var signupUser = migration.Migration{
+	Version:     "0001_signup_user",
+	Description: "nest the user of signup events under user",
+	Collection:  "subscription",
+	Up: func(doc map[string]interface{}) (map[string]interface{}, error) {
+
+		if _, ok := doc["user"].(map[string]interface{}); ok {
+			return nil, nil
+		}
+
+		user := map[string]interface{}{}
+		for _, field := range []string{"uid", "email", "name", "plan"} {
+			if value, ok := doc[field]; ok {
+				user[field] = value
+			}
+		}
+
+		if len(user) == 0 {
+			return nil, nil
+		}
+
+		return map[string]interface{}{"user": user}, nil
+	},
+}
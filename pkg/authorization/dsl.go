@@ -0,0 +1,385 @@
+package authorization
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// LoadModelFile reads an authorization model from a .fga (DSL) or .json file.
+func LoadModelFile(path string) (*AuthorizationModel, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authorization: failed to read model file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ParseModelJSON(data)
+	}
+
+	return ParseModelDSL(data)
+}
+
+// ParseModelDSL parses the OpenFGA DSL used by the files in docker/openfga.
+//
+// Supported: direct types ([user, group#member, user:*]), computed relations, "x from y",
+// "or", "and", "but not" and parentheses. Two forms rejected by the official parser are
+// accepted because the shipped models use them: mixing "and"/"or" without parentheses
+// ("and" binds tighter) and "and not x", which is read as "but not x".
+// Conditions ("with") are parsed and ignored.
+func ParseModelDSL(data []byte) (*AuthorizationModel, error) {
+
+	model := &AuthorizationModel{}
+	var current *TypeDefinition
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: line %d: %s", ErrInvalidModel, lineNumber, fmt.Sprintf(format, args...))
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "model", "relations":
+			if len(fields) != 1 {
+				return nil, fail("unexpected %q", line)
+			}
+			if fields[0] == "relations" && current == nil {
+				return nil, fail("relations outside of a type")
+			}
+
+		case "schema":
+			if len(fields) != 2 {
+				return nil, fail("invalid schema declaration")
+			}
+			model.SchemaVersion = fields[1]
+
+		case "type":
+			if len(fields) != 2 || !isIdentifier(fields[1]) {
+				return nil, fail("invalid type declaration")
+			}
+			model.TypeDefinitions = append(model.TypeDefinitions, TypeDefinition{
+				Type:      fields[1],
+				Relations: map[string]Userset{},
+				Metadata:  &Metadata{Relations: map[string]RelationMetadata{}},
+			})
+			current = &model.TypeDefinitions[len(model.TypeDefinitions)-1]
+
+		case "define":
+			if current == nil {
+				return nil, fail("define outside of a type")
+			}
+
+			name, expression, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "define")), ":")
+			name = strings.TrimSpace(name)
+			if !ok || !isIdentifier(name) {
+				return nil, fail("invalid relation definition")
+			}
+
+			if _, exists := current.Relations[name]; exists {
+				return nil, fail("relation %s is defined twice on type %s", name, current.Type)
+			}
+
+			p := &dslParser{tokens: tokenize(expression)}
+			rewrite, err := p.parse()
+			if err != nil {
+				return nil, fail("%s#%s: %s", current.Type, name, err.Error())
+			}
+
+			current.Relations[name] = rewrite
+			if len(p.directTypes) > 0 {
+				current.Metadata.Relations[name] = RelationMetadata{DirectlyRelatedUserTypes: p.directTypes}
+			}
+
+		default:
+			return nil, fail("unexpected %q", line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidModel, err.Error())
+	}
+
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// stripComment removes "# ..." comments. A '#' glued to an identifier (group#member) is not a comment.
+func stripComment(line string) string {
+	for i, r := range line {
+		if r == '#' && (i == 0 || unicode.IsSpace(rune(line[i-1]))) {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func tokenize(expression string) []string {
+
+	tokens := []string{}
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range expression {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case r == '[' || r == ']' || r == '(' || r == ')' || r == ',':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+type dslParser struct {
+	tokens      []string
+	pos         int
+	directTypes []RelationReference
+}
+
+func (p *dslParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *dslParser) next() string {
+	token := p.peek()
+	if token != "" {
+		p.pos++
+	}
+	return token
+}
+
+func (p *dslParser) expect(token string) error {
+	if got := p.next(); got != token {
+		return fmt.Errorf("expected %q, got %q", token, got)
+	}
+	return nil
+}
+
+func (p *dslParser) parse() (Userset, error) {
+
+	rewrite, err := p.parseExpression()
+	if err != nil {
+		return Userset{}, err
+	}
+
+	if p.peek() != "" {
+		return Userset{}, fmt.Errorf("unexpected %q", p.peek())
+	}
+
+	return rewrite, nil
+}
+
+// parseExpression: or-expression followed by any number of "but not <term>".
+func (p *dslParser) parseExpression() (Userset, error) {
+
+	base, err := p.parseOr()
+	if err != nil {
+		return Userset{}, err
+	}
+
+	for p.peek() == "but" {
+		p.next()
+		if err := p.expect("not"); err != nil {
+			return Userset{}, err
+		}
+		subtract, err := p.parseTerm()
+		if err != nil {
+			return Userset{}, err
+		}
+		base = Userset{Difference: &Difference{Base: base, Subtract: subtract}}
+	}
+
+	return base, nil
+}
+
+func (p *dslParser) parseOr() (Userset, error) {
+
+	first, err := p.parseAnd()
+	if err != nil {
+		return Userset{}, err
+	}
+
+	children := []Userset{first}
+	for p.peek() == "or" {
+		p.next()
+		child, err := p.parseAnd()
+		if err != nil {
+			return Userset{}, err
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+
+	return Userset{Union: &Usersets{Child: children}}, nil
+}
+
+// parseAnd handles "a and b and not c", producing (a and b) but not c.
+func (p *dslParser) parseAnd() (Userset, error) {
+
+	var included, excluded []Userset
+
+	for {
+		negated := false
+		if p.peek() == "not" {
+			p.next()
+			negated = true
+		}
+
+		term, err := p.parseTerm()
+		if err != nil {
+			return Userset{}, err
+		}
+
+		if negated {
+			excluded = append(excluded, term)
+		} else {
+			included = append(included, term)
+		}
+
+		if p.peek() != "and" {
+			break
+		}
+		p.next()
+	}
+
+	if len(included) == 0 {
+		return Userset{}, fmt.Errorf("\"not\" needs a base expression")
+	}
+
+	result := included[0]
+	if len(included) > 1 {
+		result = Userset{Intersection: &Usersets{Child: included}}
+	}
+
+	for _, subtract := range excluded {
+		result = Userset{Difference: &Difference{Base: result, Subtract: subtract}}
+	}
+
+	return result, nil
+}
+
+func (p *dslParser) parseTerm() (Userset, error) {
+
+	token := p.next()
+	switch token {
+	case "":
+		return Userset{}, fmt.Errorf("unexpected end of expression")
+
+	case "(":
+		inner, err := p.parseExpression()
+		if err != nil {
+			return Userset{}, err
+		}
+		if err := p.expect(")"); err != nil {
+			return Userset{}, err
+		}
+		return inner, nil
+
+	case "[":
+		return p.parseDirectTypes()
+	}
+
+	if !isIdentifier(token) {
+		return Userset{}, fmt.Errorf("unexpected %q", token)
+	}
+
+	if p.peek() == "from" {
+		p.next()
+		tupleset := p.next()
+		if !isIdentifier(tupleset) {
+			return Userset{}, fmt.Errorf("invalid tupleset %q", tupleset)
+		}
+		return Userset{TupleToUserset: &TupleToUserset{
+			Tupleset:        ObjectRelation{Relation: tupleset},
+			ComputedUserset: ObjectRelation{Relation: token},
+		}}, nil
+	}
+
+	return Userset{ComputedUserset: &ObjectRelation{Relation: token}}, nil
+}
+
+// parseDirectTypes reads "user, group#member, user:* with condition]" after the opening bracket.
+func (p *dslParser) parseDirectTypes() (Userset, error) {
+
+	for {
+		token := p.next()
+		if token == "" {
+			return Userset{}, fmt.Errorf("unterminated type restriction")
+		}
+
+		ref := RelationReference{}
+		switch {
+		case strings.HasSuffix(token, ":*"):
+			ref.Type = strings.TrimSuffix(token, ":*")
+			ref.Wildcard = &struct{}{}
+		case strings.Contains(token, "#"):
+			ref.Type, ref.Relation, _ = strings.Cut(token, "#")
+		default:
+			ref.Type = token
+		}
+
+		if !isIdentifier(ref.Type) || (strings.Contains(token, "#") && !isIdentifier(ref.Relation)) {
+			return Userset{}, fmt.Errorf("invalid type restriction %q", token)
+		}
+		p.directTypes = append(p.directTypes, ref)
+
+		if p.peek() == "with" {
+			p.next()
+			p.next()
+		}
+
+		switch p.next() {
+		case ",":
+			continue
+		case "]":
+			return Userset{This: &struct{}{}}, nil
+		default:
+			return Userset{}, fmt.Errorf("expected \",\" or \"]\" in type restriction")
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const refinedModelFile = "../../docker/openfga/lockari-refined-model.fga"
+
+type RefinedModelTestSuite struct {
+	suite.Suite
+	ctx   context.Context
+	authz Authorizer
+}
+
+func TestRefinedModelTestSuite(t *testing.T) {
+	suite.Run(t, new(RefinedModelTestSuite))
+}
+
+func (s *RefinedModelTestSuite) SetupTest() {
+	s.ctx = context.Background()
+
+	model, err := LoadModelFile(refinedModelFile)
+	s.Require().NoError(err)
+
+	s.authz, err = NewMemoryAuthorizer(model)
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.authz.WriteTuples(s.ctx, []TupleKey{
+		// tenant t1: alice owns the vault, bob and dave are members, dave reads the vault, mallory
+		// reads it but is banned, grace kept a reader role after leaving and gus is a guest
+		{User: User("alice"), Relation: RelationOwner, Object: "tenant:t1"},
+		{User: User("bob"), Relation: RelationMember, Object: "tenant:t1"},
+		{User: User("dave"), Relation: RelationMember, Object: "tenant:t1"},
+		{User: User("dave"), Relation: "reader", Object: "vault:v1"},
+		{User: User("mallory"), Relation: RelationMember, Object: "tenant:t1"},
+		{User: User("mallory"), Relation: "banned", Object: "tenant:t1"},
+		{User: User("mallory"), Relation: "reader", Object: "vault:v1"},
+		{User: User("grace"), Relation: "reader", Object: "vault:v1"},
+		{User: User("gus"), Relation: "guest", Object: "tenant:t1"},
+		{User: User("gus"), Relation: "reader", Object: "vault:v1"},
+		{User: User("alice"), Relation: RelationOwner, Object: "vault:v1"},
+		{User: "tenant:t1", Relation: RelationTenant, Object: "vault:v1"},
+		{User: "vault:v1", Relation: RelationVault, Object: "secret:s1"},
+
+		// group ops of tenant t1 writes to vault v2
+		{User: User("carol"), Relation: RelationMember, Object: "tenant:t1"},
+		{User: User("carol"), Relation: RelationMember, Object: "group:ops"},
+		{User: "tenant:t1", Relation: RelationTenant, Object: "group:ops"},
+		{User: "tenant:t1", Relation: RelationTenant, Object: "vault:v2"},
+		{User: UsersetOf(TypeGroup, "ops", "active_member"), Relation: "writer", Object: "vault:v2"},
+
+		// external guest from another tenant
+		{User: User("erin"), Relation: "guest", Object: "tenant:t1"},
+		{User: User("frank"), Relation: "guest", Object: "tenant:t1"},
+		{User: User("erin"), Relation: "external_guest", Object: "vault:v1"},
+		{User: User("erin"), Relation: "viewer", Object: "vault:v1"},
+		{User: User("frank"), Relation: "external_guest", Object: "vault:v1"},
+	}))
+}
+
+func (s *RefinedModelTestSuite) check(user, relation, object string) bool {
+	allowed, err := s.authz.Check(s.ctx, User(user), relation, object)
+	s.Require().NoError(err)
+	return allowed
+}
+
+func (s *RefinedModelTestSuite) TestVaultPermissions() {
+	s.True(s.check("alice", CanManage, "vault:v1"))
+	s.True(s.check("alice", CanDelete, "vault:v1"))
+	s.True(s.check("dave", CanRead, "vault:v1"))
+	s.False(s.check("dave", CanWrite, "vault:v1"))
+	s.False(s.check("bob", CanView, "vault:v1"), "being a member of the tenant gives no access to its vaults")
+	s.False(s.check("mallory", CanRead, "vault:v1"), "a banned user loses the roles it was given")
+	s.False(s.check("grace", CanView, "vault:v1"), "roles only count for members of the tenant")
+	s.True(s.check("gus", CanRead, "vault:v1"), "guests of the tenant use the roles they were given")
+}
+
+func (s *RefinedModelTestSuite) TestGroupUserset() {
+	s.True(s.check("carol", CanWrite, "vault:v2"))
+	s.True(s.check("carol", CanView, "vault:v2"))
+	s.False(s.check("carol", CanManage, "vault:v2"))
+	s.False(s.check("bob", CanWrite, "vault:v2"))
+}
+
+func (s *RefinedModelTestSuite) TestSecretInheritsFromVault() {
+	s.True(s.check("dave", CanRead, "secret:s1"))
+	s.True(s.check("alice", CanDelete, "secret:s1"))
+	s.False(s.check("dave", CanDelete, "secret:s1"))
+	s.False(s.check("bob", CanRead, "secret:s1"))
+	s.False(s.check("mallory", CanView, "secret:s1"))
+}
+
+func (s *RefinedModelTestSuite) TestExternalGuestIntersection() {
+	s.True(s.check("erin", "can_view_external", "vault:v1"))
+	s.True(s.check("erin", CanView, "secret:s1"))
+	s.False(s.check("erin", "can_copy_external", "vault:v1"))
+	s.False(s.check("frank", "can_view_external", "vault:v1"), "external_guest alone grants nothing")
+	s.False(s.check("frank", CanView, "secret:s1"))
+
+	s.Require().NoError(s.authz.WriteTuples(s.ctx, []TupleKey{{User: User("erin"), Relation: "banned", Object: "tenant:t1"}}))
+	s.False(s.check("erin", CanView, "secret:s1"), "a banned guest loses its external roles")
+}
+
+func (s *RefinedModelTestSuite) TestAndNot() {
+	s.Require().NoError(s.authz.WriteTuples(s.ctx, []TupleKey{
+		{User: User("alice"), Relation: "user", Object: "session:x"},
+		{User: User("alice"), Relation: "mfa_verified", Object: "session:x"},
+	}))
+	s.True(s.check("alice", "can_use", "session:x"))
+
+	s.Require().NoError(s.authz.WriteTuples(s.ctx, []TupleKey{
+		{User: User("alice"), Relation: "suspicious", Object: "session:x"},
+	}))
+	s.False(s.check("alice", "can_use", "session:x"))
+}
+
+func (s *RefinedModelTestSuite) TestListObjects() {
+	objects, err := s.authz.ListObjects(s.ctx, User("dave"), CanRead, TypeVault)
+	s.Require().NoError(err)
+	s.Equal([]string{"vault:v1"}, objects)
+
+	objects, err = s.authz.ListObjects(s.ctx, User("carol"), CanWrite, TypeVault)
+	s.Require().NoError(err)
+	s.Equal([]string{"vault:v2"}, objects)
+}
+
+func TestLoadModelFile_UndefinedReferences(t *testing.T) {
+	model, err := LoadModelFile(refinedModelFile)
+	require.NoError(t, err)
+
+	// Known gaps of the refined model; they resolve to no users.
+	assert.Equal(t, []string{
+		"vault#can_share_cross_tenant -> external_share_request",
+		"session#can_audit -> tenant",
+		"session#can_audit -> system",
+		"session#can_terminate -> tenant",
+	}, model.UndefinedReferences())
+}
+
+func TestParseModelDSL_Errors(t *testing.T) {
+	cases := map[string]string{
+		"define outside type": "model\n  schema 1.1\ndefine x: [user]",
+		"unterminated types":  "model\ntype doc\n  relations\n    define viewer: [user",
+		"dangling operator":   "model\ntype doc\n  relations\n    define viewer: [user] or",
+		"duplicated relation": "model\ntype doc\n  relations\n    define viewer: [user]\n    define viewer: [user]",
+		"not without base":    "model\ntype doc\n  relations\n    define a: [user]\n    define b: not a",
+		"unbalanced paren":    "model\ntype doc\n  relations\n    define a: [user]\n    define b: (a",
+	}
+
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseModelDSL([]byte(src))
+			assert.ErrorIs(t, err, ErrInvalidModel)
+		})
+	}
+}
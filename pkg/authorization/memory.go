@@ -0,0 +1,404 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxResolutionDepth matches the default limit of the OpenFGA server.
+const maxResolutionDepth = 25
+
+// memoryAuthorizer evaluates an authorization model against tuples kept in memory.
+// It is meant for tests and local development; results follow the OpenFGA semantics
+// for direct relations, usersets, computed relations, "from", union, intersection and "but not".
+type memoryAuthorizer struct {
+	model  *AuthorizationModel
+	mu     sync.RWMutex
+	tuples map[TupleKey]struct{}
+}
+
+// NewMemoryAuthorizer creates an in-memory Authorizer that evaluates model.
+func NewMemoryAuthorizer(model *AuthorizationModel) (Authorizer, error) {
+
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &memoryAuthorizer{
+		model:  model,
+		tuples: map[TupleKey]struct{}{},
+	}, nil
+}
+
+// NewMemoryAuthorizerFromFile creates an in-memory Authorizer from a .fga or .json model file,
+// e.g. docker/openfga/lockari-refined-model.fga.
+func NewMemoryAuthorizerFromFile(path string) (Authorizer, error) {
+
+	model, err := LoadModelFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMemoryAuthorizer(model)
+}
+
+func (m *memoryAuthorizer) Check(ctx context.Context, user, relation, object string) (bool, error) {
+
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if err := (TupleKey{User: user, Relation: relation, Object: object}).IsValid(); err != nil {
+		return false, err
+	}
+
+	objectType, _, _ := SplitObject(object)
+	if !m.model.HasRelation(objectType, relation) {
+		return false, fmt.Errorf("%w: relation %s is not defined on type %s", ErrInvalidTuple, relation, objectType)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.check(user, relation, object, 0)
+}
+
+func (m *memoryAuthorizer) BatchCheck(ctx context.Context, checks []TupleKey) ([]bool, error) {
+
+	results := make([]bool, len(checks))
+	for i, c := range checks {
+		allowed, err := m.Check(ctx, c.User, c.Relation, c.Object)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = allowed
+	}
+
+	return results, nil
+}
+
+func (m *memoryAuthorizer) ListObjects(ctx context.Context, user, relation, objectType string) ([]string, error) {
+
+	if !m.model.HasRelation(objectType, relation) {
+		return nil, fmt.Errorf("%w: relation %s is not defined on type %s", ErrInvalidTuple, relation, objectType)
+	}
+
+	m.mu.RLock()
+	candidates := map[string]struct{}{}
+	for t := range m.tuples {
+		if strings.HasPrefix(t.Object, objectType+":") {
+			candidates[t.Object] = struct{}{}
+		}
+	}
+	m.mu.RUnlock()
+
+	objects := []string{}
+	for object := range candidates {
+		allowed, err := m.Check(ctx, user, relation, object)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			objects = append(objects, object)
+		}
+	}
+
+	sort.Strings(objects)
+	return objects, nil
+}
+
+func (m *memoryAuthorizer) WriteTuples(ctx context.Context, tuples []TupleKey) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	for _, t := range tuples {
+		if err := m.validateWrite(t); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Like OpenFGA, a write is all or nothing.
+	for _, t := range tuples {
+		if _, ok := m.tuples[t]; ok {
+			return fmt.Errorf("%w: %s", ErrTupleExists, t.String())
+		}
+	}
+
+	for _, t := range tuples {
+		m.tuples[t] = struct{}{}
+	}
+
+	return nil
+}
+
+func (m *memoryAuthorizer) DeleteTuples(ctx context.Context, tuples []TupleKey) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range tuples {
+		if _, ok := m.tuples[t]; !ok {
+			return fmt.Errorf("%w: %s", ErrTupleNotFound, t.String())
+		}
+	}
+
+	for _, t := range tuples {
+		delete(m.tuples, t)
+	}
+
+	return nil
+}
+
+func (m *memoryAuthorizer) ReadTuples(ctx context.Context, filter TupleKey) ([]TupleKey, error) {
+
+	if err := ValidateReadFilter(filter); err != nil {
+		return nil, err
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := []TupleKey{}
+	for t := range m.tuples {
+		if matchFilter(t, filter) {
+			result = append(result, t)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+
+	return result, nil
+}
+
+func matchFilter(t, filter TupleKey) bool {
+
+	if filter.User != "" && t.User != filter.User {
+		return false
+	}
+
+	if filter.Relation != "" && t.Relation != filter.Relation {
+		return false
+	}
+
+	if filter.Object != "" {
+		if strings.HasSuffix(filter.Object, ":") {
+			return strings.HasPrefix(t.Object, filter.Object)
+		}
+		return t.Object == filter.Object
+	}
+
+	return true
+}
+
+// validateWrite checks that the relation can be assigned directly and that the user type is allowed.
+func (m *memoryAuthorizer) validateWrite(t TupleKey) error {
+
+	if err := t.IsValid(); err != nil {
+		return err
+	}
+
+	objectType, _, _ := SplitObject(t.Object)
+	td, ok := m.model.TypeDefinition(objectType)
+	if !ok {
+		return fmt.Errorf("%w: type %s is not defined", ErrInvalidTuple, objectType)
+	}
+
+	rewrite, ok := td.Relations[t.Relation]
+	if !ok {
+		return fmt.Errorf("%w: relation %s is not defined on type %s", ErrInvalidTuple, t.Relation, objectType)
+	}
+
+	if !isDirect(rewrite) {
+		return fmt.Errorf("%w: relation %s#%s cannot be written directly", ErrInvalidTuple, objectType, t.Relation)
+	}
+
+	if td.Metadata == nil || len(td.Metadata.Relations[t.Relation].DirectlyRelatedUserTypes) == 0 {
+		return nil
+	}
+
+	userType, userID, relation := splitUser(t.User)
+	for _, ref := range td.Metadata.Relations[t.Relation].DirectlyRelatedUserTypes {
+		if ref.Type != userType {
+			continue
+		}
+		if ref.Wildcard != nil && userID == "*" && relation == "" {
+			return nil
+		}
+		if ref.Wildcard == nil && userID != "*" && ref.Relation == relation {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: user %s is not allowed on %s#%s", ErrInvalidTuple, t.User, objectType, t.Relation)
+}
+
+func isDirect(u Userset) bool {
+
+	switch {
+	case u.This != nil:
+		return true
+	case u.Union != nil:
+		for _, child := range u.Union.Child {
+			if isDirect(child) {
+				return true
+			}
+		}
+	case u.Intersection != nil:
+		for _, child := range u.Intersection.Child {
+			if isDirect(child) {
+				return true
+			}
+		}
+	case u.Difference != nil:
+		return isDirect(u.Difference.Base)
+	}
+
+	return false
+}
+
+// splitUser splits "type:id#relation" into its parts.
+func splitUser(user string) (string, string, string) {
+
+	relation := ""
+	if i := strings.Index(user, "#"); i > 0 {
+		relation = user[i+1:]
+		user = user[:i]
+	}
+
+	userType, id, _ := SplitObject(user)
+	return userType, id, relation
+}
+
+func (m *memoryAuthorizer) check(user, relation, object string, depth int) (bool, error) {
+
+	if depth > maxResolutionDepth {
+		return false, ErrResolutionDepthExceeded
+	}
+
+	objectType, _, err := SplitObject(object)
+	if err != nil {
+		return false, err
+	}
+
+	td, ok := m.model.TypeDefinition(objectType)
+	if !ok {
+		return false, nil
+	}
+
+	rewrite, ok := td.Relations[relation]
+	if !ok {
+		return false, nil
+	}
+
+	return m.evaluate(user, relation, object, rewrite, depth)
+}
+
+func (m *memoryAuthorizer) evaluate(user, relation, object string, rewrite Userset, depth int) (bool, error) {
+
+	switch {
+	case rewrite.This != nil:
+		return m.checkDirect(user, relation, object, depth)
+
+	case rewrite.ComputedUserset != nil:
+		return m.check(user, rewrite.ComputedUserset.Relation, object, depth+1)
+
+	case rewrite.TupleToUserset != nil:
+		ttu := rewrite.TupleToUserset
+		for t := range m.tuples {
+			if t.Object != object || t.Relation != ttu.Tupleset.Relation {
+				continue
+			}
+			allowed, err := m.check(user, ttu.ComputedUserset.Relation, t.User, depth+1)
+			if err != nil {
+				return false, err
+			}
+			if allowed {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case rewrite.Union != nil:
+		for _, child := range rewrite.Union.Child {
+			allowed, err := m.evaluate(user, relation, object, child, depth)
+			if err != nil {
+				return false, err
+			}
+			if allowed {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case rewrite.Intersection != nil:
+		for _, child := range rewrite.Intersection.Child {
+			allowed, err := m.evaluate(user, relation, object, child, depth)
+			if err != nil {
+				return false, err
+			}
+			if !allowed {
+				return false, nil
+			}
+		}
+		return len(rewrite.Intersection.Child) > 0, nil
+
+	case rewrite.Difference != nil:
+		allowed, err := m.evaluate(user, relation, object, rewrite.Difference.Base, depth)
+		if err != nil || !allowed {
+			return false, err
+		}
+		excluded, err := m.evaluate(user, relation, object, rewrite.Difference.Subtract, depth)
+		if err != nil {
+			return false, err
+		}
+		return !excluded, nil
+	}
+
+	return false, nil
+}
+
+// checkDirect resolves the tuples written for object#relation, following usersets such as group:x#member.
+func (m *memoryAuthorizer) checkDirect(user, relation, object string, depth int) (bool, error) {
+
+	userType, _, _ := splitUser(user)
+
+	for t := range m.tuples {
+		if t.Object != object || t.Relation != relation {
+			continue
+		}
+
+		if t.User == user || t.User == Object(userType, "*") {
+			return true, nil
+		}
+
+		if i := strings.Index(t.User, "#"); i > 0 {
+			allowed, err := m.check(user, t.User[i+1:], t.User[:i], depth+1)
+			if err != nil {
+				return false, err
+			}
+			if allowed {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
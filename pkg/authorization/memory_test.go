@@ -0,0 +1,167 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testModel is a reduced version of docker/openfga/lockari-refined-model.fga in JSON format.
+const testModel = `{
+  "schema_version": "1.1",
+  "type_definitions": [
+    {"type": "user"},
+    {
+      "type": "tenant",
+      "relations": {
+        "member": {"this": {}},
+        "banned": {"this": {}},
+        "active_member": {"difference": {
+          "base": {"computedUserset": {"relation": "member"}},
+          "subtract": {"computedUserset": {"relation": "banned"}}
+        }}
+      },
+      "metadata": {"relations": {
+        "member": {"directly_related_user_types": [{"type": "user"}]},
+        "banned": {"directly_related_user_types": [{"type": "user"}]}
+      }}
+    },
+    {
+      "type": "group",
+      "relations": {"member": {"this": {}}},
+      "metadata": {"relations": {
+        "member": {"directly_related_user_types": [{"type": "user"}]}
+      }}
+    },
+    {
+      "type": "vault",
+      "relations": {
+        "owner": {"this": {}},
+        "reader": {"this": {}},
+        "tenant": {"this": {}},
+        "can_view": {"union": {"child": [
+          {"computedUserset": {"relation": "reader"}},
+          {"computedUserset": {"relation": "owner"}},
+          {"tupleToUserset": {"tupleset": {"relation": "tenant"}, "computedUserset": {"relation": "active_member"}}}
+        ]}},
+        "can_manage": {"computedUserset": {"relation": "owner"}},
+        "can_share_internal": {"intersection": {"child": [
+          {"computedUserset": {"relation": "owner"}},
+          {"tupleToUserset": {"tupleset": {"relation": "tenant"}, "computedUserset": {"relation": "active_member"}}}
+        ]}}
+      },
+      "metadata": {"relations": {
+        "owner": {"directly_related_user_types": [{"type": "user"}]},
+        "reader": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}]},
+        "tenant": {"directly_related_user_types": [{"type": "tenant"}]}
+      }}
+    }
+  ]
+}`
+
+func newTestAuthorizer(t *testing.T) Authorizer {
+	t.Helper()
+
+	model, err := ParseModelJSON([]byte(testModel))
+	require.NoError(t, err)
+
+	authz, err := NewMemoryAuthorizer(model)
+	require.NoError(t, err)
+
+	require.NoError(t, authz.WriteTuples(context.Background(), []TupleKey{
+		{User: User("alice"), Relation: RelationOwner, Object: "vault:v1"},
+		{User: "tenant:t1", Relation: RelationTenant, Object: "vault:v1"},
+		{User: User("bob"), Relation: RelationMember, Object: "tenant:t1"},
+		{User: User("carol"), Relation: RelationMember, Object: "tenant:t1"},
+		{User: User("carol"), Relation: "banned", Object: "tenant:t1"},
+		{User: User("dave"), Relation: RelationMember, Object: "group:ops"},
+		{User: UsersetOf(TypeGroup, "ops", RelationMember), Relation: "reader", Object: "vault:v2"},
+	}))
+
+	return authz
+}
+
+func TestMemoryAuthorizer_Check(t *testing.T) {
+	ctx := context.Background()
+	authz := newTestAuthorizer(t)
+
+	cases := []struct {
+		name     string
+		user     string
+		relation string
+		object   string
+		allowed  bool
+	}{
+		{"direct owner", User("alice"), CanManage, "vault:v1", true},
+		{"owner through union", User("alice"), CanView, "vault:v1", true},
+		{"tenant member through from", User("bob"), CanView, "vault:v1", true},
+		{"banned member is subtracted", User("carol"), CanView, "vault:v1", false},
+		{"member cannot manage", User("bob"), CanManage, "vault:v1", false},
+		{"group member through userset", User("dave"), CanView, "vault:v2", true},
+		{"intersection requires both sides", User("alice"), "can_share_internal", "vault:v1", false},
+		{"unknown user", User("eve"), CanView, "vault:v1", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, err := authz.Check(ctx, tc.user, tc.relation, tc.object)
+			require.NoError(t, err)
+			assert.Equal(t, tc.allowed, allowed)
+		})
+	}
+
+	t.Run("undefined relation", func(t *testing.T) {
+		_, err := authz.Check(ctx, User("alice"), "can_fly", "vault:v1")
+		assert.True(t, errors.Is(err, ErrInvalidTuple))
+	})
+}
+
+func TestMemoryAuthorizer_ListObjects(t *testing.T) {
+	authz := newTestAuthorizer(t)
+
+	objects, err := authz.ListObjects(context.Background(), User("bob"), CanView, TypeVault)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vault:v1"}, objects)
+
+	objects, err = authz.ListObjects(context.Background(), User("dave"), CanView, TypeVault)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vault:v2"}, objects)
+}
+
+func TestMemoryAuthorizer_WriteAndDelete(t *testing.T) {
+	ctx := context.Background()
+	authz := newTestAuthorizer(t)
+
+	t.Run("duplicate tuple", func(t *testing.T) {
+		err := authz.WriteTuples(ctx, []TupleKey{{User: User("alice"), Relation: RelationOwner, Object: "vault:v1"}})
+		assert.True(t, errors.Is(err, ErrTupleExists))
+	})
+
+	t.Run("computed relation cannot be written", func(t *testing.T) {
+		err := authz.WriteTuples(ctx, []TupleKey{{User: User("alice"), Relation: CanView, Object: "vault:v3"}})
+		assert.True(t, errors.Is(err, ErrInvalidTuple))
+	})
+
+	t.Run("user type not allowed", func(t *testing.T) {
+		err := authz.WriteTuples(ctx, []TupleKey{{User: "tenant:t1", Relation: RelationOwner, Object: "vault:v3"}})
+		assert.True(t, errors.Is(err, ErrInvalidTuple))
+	})
+
+	t.Run("read and delete", func(t *testing.T) {
+		tuples, err := authz.ReadTuples(ctx, TupleKey{Object: "vault:v1"})
+		require.NoError(t, err)
+		assert.Len(t, tuples, 2)
+
+		require.NoError(t, authz.DeleteTuples(ctx, []TupleKey{{User: "tenant:t1", Relation: RelationTenant, Object: "vault:v1"}}))
+
+		allowed, err := authz.Check(ctx, User("bob"), CanView, "vault:v1")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		err = authz.DeleteTuples(ctx, []TupleKey{{User: "tenant:t1", Relation: RelationTenant, Object: "vault:v1"}})
+		assert.True(t, errors.Is(err, ErrTupleNotFound))
+	})
+}
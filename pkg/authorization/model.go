@@ -0,0 +1,219 @@
+package authorization
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// AuthorizationModel mirrors the JSON representation of an OpenFGA authorization model
+// (the format returned by the API and by "fga model transform").
+type AuthorizationModel struct {
+	ID              string           `json:"id,omitempty"`
+	SchemaVersion   string           `json:"schema_version"`
+	TypeDefinitions []TypeDefinition `json:"type_definitions"`
+}
+
+type TypeDefinition struct {
+	Type      string             `json:"type"`
+	Relations map[string]Userset `json:"relations,omitempty"`
+	Metadata  *Metadata          `json:"metadata,omitempty"`
+}
+
+type Metadata struct {
+	Relations map[string]RelationMetadata `json:"relations,omitempty"`
+}
+
+type RelationMetadata struct {
+	DirectlyRelatedUserTypes []RelationReference `json:"directly_related_user_types,omitempty"`
+}
+
+// RelationReference is an allowed user type of a direct relation: "user", "group#member" or "user:*".
+type RelationReference struct {
+	Type     string    `json:"type"`
+	Relation string    `json:"relation,omitempty"`
+	Wildcard *struct{} `json:"wildcard,omitempty"`
+}
+
+// Userset is the rewrite of a relation. Exactly one of the fields is set.
+type Userset struct {
+	This            *struct{}       `json:"this,omitempty"`
+	ComputedUserset *ObjectRelation `json:"computedUserset,omitempty"`
+	TupleToUserset  *TupleToUserset `json:"tupleToUserset,omitempty"`
+	Union           *Usersets       `json:"union,omitempty"`
+	Intersection    *Usersets       `json:"intersection,omitempty"`
+	Difference      *Difference     `json:"difference,omitempty"`
+}
+
+type ObjectRelation struct {
+	Object   string `json:"object,omitempty"`
+	Relation string `json:"relation"`
+}
+
+// TupleToUserset resolves ComputedUserset on the objects related through Tupleset ("x from y").
+type TupleToUserset struct {
+	Tupleset        ObjectRelation `json:"tupleset"`
+	ComputedUserset ObjectRelation `json:"computedUserset"`
+}
+
+type Usersets struct {
+	Child []Userset `json:"child"`
+}
+
+type Difference struct {
+	Base     Userset `json:"base"`
+	Subtract Userset `json:"subtract"`
+}
+
+// ParseModelJSON reads an authorization model in the OpenFGA JSON format.
+// Both the bare model and the API envelope {"authorization_model": {...}} are accepted.
+func ParseModelJSON(data []byte) (*AuthorizationModel, error) {
+
+	var envelope struct {
+		AuthorizationModel *AuthorizationModel `json:"authorization_model"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope.AuthorizationModel != nil {
+		return envelope.AuthorizationModel, envelope.AuthorizationModel.Validate()
+	}
+
+	var model AuthorizationModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidModel, err.Error())
+	}
+
+	return &model, model.Validate()
+}
+
+// TypeDefinition returns the definition of objectType.
+func (m *AuthorizationModel) TypeDefinition(objectType string) (*TypeDefinition, bool) {
+	for i := range m.TypeDefinitions {
+		if m.TypeDefinitions[i].Type == objectType {
+			return &m.TypeDefinitions[i], true
+		}
+	}
+	return nil, false
+}
+
+// HasRelation reports whether objectType defines relation.
+func (m *AuthorizationModel) HasRelation(objectType, relation string) bool {
+	td, ok := m.TypeDefinition(objectType)
+	if !ok {
+		return false
+	}
+	_, ok = td.Relations[relation]
+	return ok
+}
+
+// Validate checks that type names are unique and that every rewrite is well formed.
+// References to undefined relations are accepted and resolve to no users, as the shipped
+// models contain a few of them; use UndefinedReferences to list them.
+func (m *AuthorizationModel) Validate() error {
+
+	if m == nil || len(m.TypeDefinitions) == 0 {
+		return fmt.Errorf("%w: no type definitions", ErrInvalidModel)
+	}
+
+	seen := map[string]bool{}
+	for _, td := range m.TypeDefinitions {
+		if td.Type == "" {
+			return fmt.Errorf("%w: type name is required", ErrInvalidModel)
+		}
+		if seen[td.Type] {
+			return fmt.Errorf("%w: type %s is defined twice", ErrInvalidModel, td.Type)
+		}
+		seen[td.Type] = true
+	}
+
+	for _, td := range m.TypeDefinitions {
+		for name, rewrite := range td.Relations {
+			if err := validateUserset(rewrite); err != nil {
+				return fmt.Errorf("%w: %s#%s: %s", ErrInvalidModel, td.Type, name, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// UndefinedReferences lists the relations used by rewrites that are not defined on the type,
+// in the format "type#relation -> missing".
+func (m *AuthorizationModel) UndefinedReferences() []string {
+
+	missing := []string{}
+	for _, td := range m.TypeDefinitions {
+		names := make([]string, 0, len(td.Relations))
+		for name := range td.Relations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, ref := range referencedRelations(td.Relations[name]) {
+				if _, ok := td.Relations[ref]; !ok {
+					missing = append(missing, fmt.Sprintf("%s#%s -> %s", td.Type, name, ref))
+				}
+			}
+		}
+	}
+
+	return missing
+}
+
+// referencedRelations returns the relations of the same object used by the rewrite:
+// computed relations and tuplesets.
+func referencedRelations(u Userset) []string {
+
+	switch {
+	case u.ComputedUserset != nil:
+		return []string{u.ComputedUserset.Relation}
+	case u.TupleToUserset != nil:
+		return []string{u.TupleToUserset.Tupleset.Relation}
+	case u.Union != nil:
+		return referencedChildren(u.Union.Child)
+	case u.Intersection != nil:
+		return referencedChildren(u.Intersection.Child)
+	case u.Difference != nil:
+		return append(referencedRelations(u.Difference.Base), referencedRelations(u.Difference.Subtract)...)
+	}
+
+	return nil
+}
+
+func referencedChildren(children []Userset) []string {
+	refs := []string{}
+	for _, child := range children {
+		refs = append(refs, referencedRelations(child)...)
+	}
+	return refs
+}
+
+func validateUserset(u Userset) error {
+
+	switch {
+	case u.This != nil, u.ComputedUserset != nil, u.TupleToUserset != nil:
+		return nil
+	case u.Union != nil:
+		return validateChildren(u.Union.Child)
+	case u.Intersection != nil:
+		return validateChildren(u.Intersection.Child)
+	case u.Difference != nil:
+		if err := validateUserset(u.Difference.Base); err != nil {
+			return err
+		}
+		return validateUserset(u.Difference.Subtract)
+	}
+
+	return fmt.Errorf("empty rewrite")
+}
+
+func validateChildren(children []Userset) error {
+	if len(children) == 0 {
+		return fmt.Errorf("empty set operation")
+	}
+	for _, child := range children {
+		if err := validateUserset(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTuplesPerWrite is the default limit of tuples accepted by a single OpenFGA write.
+	maxTuplesPerWrite = 100
+	// maxParallelChecks bounds the concurrent requests made by BatchCheck.
+	maxParallelChecks = 10
+	readPageSize      = 100
+)
+
+// APIError is returned when the OpenFGA API answers with a non 2xx status.
+type APIError struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("openfga: status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+type openFGAClient struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewOpenFGAClient creates an Authorizer that talks to the OpenFGA HTTP API.
+func NewOpenFGAClient(cfg Config) (Authorizer, error) {
+
+	if cfg.APIURL == "" {
+		return nil, errors.New("openfga: api_url is required")
+	}
+
+	if cfg.StoreID == "" {
+		return nil, errors.New("openfga: store_id is required")
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+
+	return &openFGAClient{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (c *openFGAClient) Check(ctx context.Context, user, relation, object string) (bool, error) {
+
+	request := map[string]interface{}{
+		"tuple_key": TupleKey{User: user, Relation: relation, Object: object},
+	}
+
+	var response struct {
+		Allowed bool `json:"allowed"`
+	}
+
+	if err := c.post(ctx, "check", request, &response); err != nil {
+		return false, err
+	}
+
+	return response.Allowed, nil
+}
+
+// BatchCheck runs the checks concurrently, which works with every OpenFGA server version.
+func (c *openFGAClient) BatchCheck(ctx context.Context, checks []TupleKey) ([]bool, error) {
+
+	results := make([]bool, len(checks))
+	errs := make([]error, len(checks))
+	limit := make(chan struct{}, maxParallelChecks)
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, check TupleKey) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			results[i], errs[i] = c.Check(ctx, check.User, check.Relation, check.Object)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+func (c *openFGAClient) ListObjects(ctx context.Context, user, relation, objectType string) ([]string, error) {
+
+	request := map[string]interface{}{
+		"type":     objectType,
+		"relation": relation,
+		"user":     user,
+	}
+
+	var response struct {
+		Objects []string `json:"objects"`
+	}
+
+	if err := c.post(ctx, "list-objects", request, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Objects == nil {
+		response.Objects = []string{}
+	}
+
+	return response.Objects, nil
+}
+
+// WriteTuples writes the tuples in chunks of maxTuplesPerWrite. Each chunk is atomic.
+func (c *openFGAClient) WriteTuples(ctx context.Context, tuples []TupleKey) error {
+	return c.write(ctx, "writes", tuples)
+}
+
+// DeleteTuples deletes the tuples in chunks of maxTuplesPerWrite. Each chunk is atomic.
+func (c *openFGAClient) DeleteTuples(ctx context.Context, tuples []TupleKey) error {
+	return c.write(ctx, "deletes", tuples)
+}
+
+func (c *openFGAClient) write(ctx context.Context, operation string, tuples []TupleKey) error {
+
+	for _, t := range tuples {
+		if err := t.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(tuples); start += maxTuplesPerWrite {
+		end := start + maxTuplesPerWrite
+		if end > len(tuples) {
+			end = len(tuples)
+		}
+
+		request := map[string]interface{}{
+			operation: map[string]interface{}{
+				"tuple_keys": tuples[start:end],
+			},
+		}
+
+		if err := c.post(ctx, "write", request, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *openFGAClient) ReadTuples(ctx context.Context, filter TupleKey) ([]TupleKey, error) {
+
+	if err := ValidateReadFilter(filter); err != nil {
+		return nil, err
+	}
+
+	result := []TupleKey{}
+	token := ""
+
+	for {
+		request := map[string]interface{}{
+			"page_size": readPageSize,
+		}
+		if filter != (TupleKey{}) {
+			request["tuple_key"] = filter
+		}
+		if token != "" {
+			request["continuation_token"] = token
+		}
+
+		var response struct {
+			Tuples []struct {
+				Key TupleKey `json:"key"`
+			} `json:"tuples"`
+			ContinuationToken string `json:"continuation_token"`
+		}
+
+		if err := c.post(ctx, "read", request, &response); err != nil {
+			return nil, err
+		}
+
+		for _, t := range response.Tuples {
+			result = append(result, t.Key)
+		}
+
+		if response.ContinuationToken == "" {
+			return result, nil
+		}
+		token = response.ContinuationToken
+	}
+}
+
+func (c *openFGAClient) post(ctx context.Context, endpoint string, request map[string]interface{}, response interface{}) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if c.cfg.ModelID != "" && endpoint != "read" {
+		request["authorization_model_id"] = c.cfg.ModelID
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("openfga: failed to encode request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/stores/%s/%s", c.cfg.APIURL, c.cfg.StoreID, endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("openfga: failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("openfga: %s request failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("openfga: failed to read %s response: %w", endpoint, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+
+	if response == nil || len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("openfga: failed to decode %s response: %w", endpoint, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Authorizer defines the relationship based authorization operations used by the services.
+// Users, relations and objects follow the OpenFGA notation: "user:123", "can_read", "vault:abc".
+type Authorizer interface {
+	// Check reports whether user has relation with object.
+	Check(ctx context.Context, user, relation, object string) (bool, error)
+	// BatchCheck runs several checks and returns the results in the same order.
+	BatchCheck(ctx context.Context, checks []TupleKey) ([]bool, error)
+	// ListObjects returns the objects of objectType ("vault:<id>") that user has relation with.
+	ListObjects(ctx context.Context, user, relation, objectType string) ([]string, error)
+	WriteTuples(ctx context.Context, tuples []TupleKey) error
+	DeleteTuples(ctx context.Context, tuples []TupleKey) error
+	// ReadTuples returns the stored tuples matching the filter. Empty fields match anything;
+	// Object may be a bare type ("vault:") to read every object of that type. The filter must
+	// be accepted by ValidateReadFilter.
+	ReadTuples(ctx context.Context, filter TupleKey) ([]TupleKey, error)
+}
+
+// Config holds the configuration for the OpenFGA server
+type Config struct {
+	APIURL  string `mapstructure:"api_url" json:"api_url"`
+	StoreID string `mapstructure:"store_id" json:"store_id"`
+	ModelID string `mapstructure:"model_id" json:"model_id"`
+	Token   string `mapstructure:"token" json:"token"`
+	// Timeout in seconds for each request. Defaults to 10.
+	Timeout int `mapstructure:"timeout" json:"timeout"`
+}
+
+// TupleKey is a relationship: User has Relation with Object.
+type TupleKey struct {
+	User     string `json:"user"`
+	Relation string `json:"relation"`
+	Object   string `json:"object"`
+}
+
+// String returns the tuple as object#relation@user
+func (t TupleKey) String() string {
+	return fmt.Sprintf("%s#%s@%s", t.Object, t.Relation, t.User)
+}
+
+// IsValid checks that the tuple has all fields and that user and object are typed.
+func (t TupleKey) IsValid() error {
+
+	if t.User == "" || t.Relation == "" || t.Object == "" {
+		return fmt.Errorf("%w: user, relation and object are required", ErrInvalidTuple)
+	}
+
+	if _, _, err := SplitObject(t.Object); err != nil {
+		return err
+	}
+
+	user := t.User
+	if i := strings.Index(user, "#"); i > 0 {
+		user = user[:i]
+	}
+
+	if _, _, err := SplitObject(user); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const (
+	TypeUser   = "user"
+	TypeTenant = "tenant"
+	TypeGroup  = "group"
+	TypeVault  = "vault"
+	TypeSecret = "secret"
+
+	RelationOwner  = "owner"
+	RelationAdmin  = "admin"
+	RelationMember = "member"
+	RelationTenant = "tenant"
+	RelationVault  = "vault"
+
+	CanView   = "can_view"
+	CanRead   = "can_read"
+	CanWrite  = "can_write"
+	CanDelete = "can_delete"
+	CanShare  = "can_share"
+	CanManage = "can_manage"
+)
+
+var (
+	ErrInvalidTuple            = errors.New("authorization: invalid tuple")
+	ErrInvalidModel            = errors.New("authorization: invalid model")
+	ErrTupleExists             = errors.New("authorization: tuple already exists")
+	ErrTupleNotFound           = errors.New("authorization: tuple not found")
+	ErrResolutionDepthExceeded = errors.New("authorization: resolution depth exceeded")
+)
+
+// ValidateReadFilter reports whether the OpenFGA Read API accepts the filter. An empty filter
+// reads every tuple; otherwise the object is required, and a bare type ("vault:") also needs
+// the user.
+func ValidateReadFilter(filter TupleKey) error {
+
+	if filter == (TupleKey{}) {
+		return nil
+	}
+
+	if filter.Object == "" {
+		return fmt.Errorf("%w: reading tuples requires an object or an object type", ErrInvalidTuple)
+	}
+
+	if strings.HasSuffix(filter.Object, ":") && filter.User == "" {
+		return fmt.Errorf("%w: reading the tuples of type %s requires a user", ErrInvalidTuple, strings.TrimSuffix(filter.Object, ":"))
+	}
+
+	return nil
+}
+
+// Object returns the OpenFGA object identifier: <type>:<id>
+func Object(objectType, id string) string {
+	return objectType + ":" + id
+}
+
+// User returns the OpenFGA identifier of a user
+func User(id string) string {
+	return Object(TypeUser, id)
+}
+
+// UsersetOf returns the OpenFGA identifier of a set of users: <type>:<id>#<relation>
+func UsersetOf(objectType, id, relation string) string {
+	return Object(objectType, id) + "#" + relation
+}
+
+// SplitObject splits "<type>:<id>" into type and id.
+func SplitObject(object string) (string, string, error) {
+
+	i := strings.Index(object, ":")
+	if i <= 0 {
+		return "", "", fmt.Errorf("%w: %q must be in the format type:id", ErrInvalidTuple, object)
+	}
+
+	return object[:i], object[i+1:], nil
+}
+
+// ObjectIDs strips the type prefix of the objects returned by ListObjects.
+func ObjectIDs(objects []string) []string {
+
+	ids := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if _, id, err := SplitObject(object); err == nil && id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
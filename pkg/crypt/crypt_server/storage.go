@@ -0,0 +1,120 @@
+package cryptserver
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// StorageCryptVersion identifica o formato do valor criptografado: v1:<keyId>:<base64(nonce + ciphertext + tag)>
+	StorageCryptVersion = "v1"
+)
+
+// StorageCrypt implementa StorageCryptInterface com AES-256-GCM.
+// A chave é independente da chave de transporte usada por CryptData.
+type StorageCrypt struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+// InicializationStorageCrypt inicializa a criptografia em repouso a partir de uma chave Base64 (16, 24 ou 32 bytes).
+// A chave é derivada com SHA-256 para sempre usar AES-256.
+func InicializationStorageCrypt(encryptKey *string) (StorageCryptInterface, error) {
+
+	if encryptKey == nil || *encryptKey == "" {
+		return nil, errors.New("storage key is nil")
+	}
+
+	key := strings.TrimSpace(strings.NewReplacer("\n", "", "\r", "", "\t", "").Replace(*encryptKey))
+	if key == "" {
+		return nil, errors.New("storage key is empty after trimming whitespace")
+	}
+
+	validator := &CryptData{}
+	if err := validator.validateTokenFromString(&key); err != nil {
+		return nil, fmt.Errorf("invalid storage key: %w", err)
+	}
+
+	derivedKey, err := validator.deriveKeyFromBase64(key)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, fmt.Errorf("storage crypt: failed to create AES cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("storage crypt: failed to create GCM mode: %w", err)
+	}
+
+	// O keyId permite identificar valores gravados com outra chave (rotação) sem expor a chave.
+	fingerprint := sha256.Sum256(derivedKey)
+
+	return &StorageCrypt{
+		keyID: hex.EncodeToString(fingerprint[:4]),
+		aead:  aead,
+	}, nil
+}
+
+// Encrypt criptografa o valor e retorna no formato v1:<keyId>:<base64>.
+func (s *StorageCrypt) Encrypt(plaintext []byte) (string, error) {
+
+	if len(plaintext) == 0 {
+		return "", errors.New("storage crypt: plaintext is empty")
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("storage crypt: failed to generate nonce: %w", err)
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, plaintext, nil)
+
+	return fmt.Sprintf("%s:%s:%s", StorageCryptVersion, s.keyID, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt descriptografa um valor gerado por Encrypt.
+func (s *StorageCrypt) Decrypt(ciphertext string) ([]byte, error) {
+
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != StorageCryptVersion {
+		return nil, errors.New("storage crypt: unsupported ciphertext format")
+	}
+
+	if parts[1] != s.keyID {
+		return nil, fmt.Errorf("storage crypt: value encrypted with key %s, current key is %s", parts[1], s.keyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("storage crypt: failed to decode ciphertext: %w", err)
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(sealed) < nonceSize+s.aead.Overhead() {
+		return nil, errors.New("storage crypt: ciphertext too short")
+	}
+
+	plaintext, err := s.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("storage crypt: failed to decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// KeyID retorna o identificador da chave atual.
+func (s *StorageCrypt) KeyID() string {
+	return s.keyID
+}
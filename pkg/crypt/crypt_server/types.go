@@ -23,3 +23,11 @@ type CryptDataInterface interface {
 
 	CryptDataInternalInterface
 }
+
+// StorageCryptInterface criptografa valores persistidos no banco (criptografia em repouso).
+// Diferente de CryptDataInterface, que trata apenas o envelope de transporte das requisições.
+type StorageCryptInterface interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+	KeyID() string
+}
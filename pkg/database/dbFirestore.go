@@ -6,40 +6,44 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"time"
 
 	"cloud.google.com/go/firestore"
 
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FirebaseDBInterface interface {
 	Get(ctx context.Context, collection string) ([]byte, error)
+	GetByID(ctx context.Context, id, collection string) ([]byte, error)
 	Create(ctx context.Context, data interface{}, collection string) ([]byte, error)
+	CreateWithID(ctx context.Context, id string, data interface{}, collection string) ([]byte, error)
+	RunTransaction(ctx context.Context, fn func(tx Tx) error) error
+	CommitBatch(ctx context.Context, batch *Batch) error
 	Update(ctx context.Context, id string, data interface{}, collection string) error
+	UpdateIfMatch(ctx context.Context, id string, revision int64, data map[string]interface{}, collection string) error
 	Delete(ctx context.Context, id, collection string) error
 	GetByQuery(ctx context.Context, collection string) firestore.Query
 	GetByConditional(ctx context.Context, conditional []Conditional, collection string) ([]byte, error)
 	GetByFilter(ctx context.Context, filters map[string]interface{}, collection string) ([]byte, error)
+	GetCollectionGroup(ctx context.Context, collectionID string, conditional []Conditional) ([]byte, error)
+	GetPage(ctx context.Context, collection string, conditional []Conditional, opts QueryOptions) (*Page, error)
+	// Watch streams the changes of the documents of the collection that match the conditionals
+	// until ctx is done. The first events are the matching documents, reported as added.
+	Watch(ctx context.Context, collection string, conditional []Conditional) (<-chan ChangeEvent, error)
+	// Walk calls fn for every document of every collection below the document path root,
+	// e.g. tenant/<id>, whether or not the root document exists. It stops at the first error of fn.
+	Walk(ctx context.Context, root string, fn WalkFunc) error
 	StructToData(data interface{}) (map[string]interface{}, error)
 	IsConnected() bool
 }
 
-type Filter string
-
-const (
-	FilterEquals        Filter = "=="
-	FilterNotEquals     Filter = "!="
-	FilterGreaterThan   Filter = ">"
-	FilterLessThan      Filter = "<"
-	FilterArrayContains Filter = "array-contains"
-)
-
-type Conditional struct {
-	Field  string
-	Value  interface{}
-	Filter Filter
-}
+// WalkFunc is called by Walk with the full path of the collection, the document ID and its fields.
+type WalkFunc func(collection, id string, data map[string]interface{}) error
 
 // FirebaseDB implements the DatabaseService interface for Firebase Firestore.
 type FirebaseDB struct {
@@ -137,6 +141,32 @@ func (db *FirebaseDB) Get(ctx context.Context, collection string) ([]byte, error
 	return b, nil
 }
 
+// GetByID retrieves a single document of the collection. The document ID is returned in the "id" field.
+// ErrNotFound is returned when the document does not exist.
+func (db *FirebaseDB) GetByID(ctx context.Context, id, collection string) ([]byte, error) {
+
+	if err := db.validateWithoutData(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	doc, err := db.client.Collection(collection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	data := doc.Data()
+	data["id"] = doc.Ref.ID
+
+	return json.Marshal(data)
+}
+
 // Create adds a new document to a default collection.
 // Placeholder: Collection name needed.
 func (db *FirebaseDB) Create(ctx context.Context, data interface{}, collection string) ([]byte, error) {
@@ -172,6 +202,34 @@ func (db *FirebaseDB) Create(ctx context.Context, data interface{}, collection s
 	return b, err
 }
 
+// CreateWithID adds a new document using the given ID.
+// Unlike Update, it never overwrites: if the document already exists ErrAlreadyExists is returned.
+func (db *FirebaseDB) CreateWithID(ctx context.Context, id string, data interface{}, collection string) ([]byte, error) {
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if err := db.validateWithData(ctx, data, collection); err != nil {
+		return nil, err
+	}
+
+	_, err := db.client.Collection(collection).Doc(id).Create(ctx, data)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return nil, ErrAlreadyExists
+		}
+		return nil, err
+	}
+
+	if mapData, ok := data.(map[string]interface{}); ok {
+		mapData["id"] = id
+		data = mapData
+	}
+
+	return json.Marshal(data)
+}
+
 // Update modifies an existing document in a default collection.
 // Placeholder: Collection name needed.
 func (db *FirebaseDB) Update(ctx context.Context, id string, data interface{}, collection string) error {
@@ -185,6 +243,7 @@ func (db *FirebaseDB) Update(ctx context.Context, id string, data interface{}, c
 		if _, exists := mapData["updatedAt"]; !exists {
 			mapData["updatedAt"] = firestore.ServerTimestamp
 		}
+		mapData[RevisionField] = firestore.Increment(1)
 	}
 
 	if id == "" {
@@ -203,6 +262,12 @@ func (db *FirebaseDB) Update(ctx context.Context, id string, data interface{}, c
 	return nil
 }
 
+// UpdateIfMatch merges data into the document only if its revision is still revision,
+// otherwise it returns ErrRevisionMismatch.
+func (db *FirebaseDB) UpdateIfMatch(ctx context.Context, id string, revision int64, data map[string]interface{}, collection string) error {
+	return updateIfMatch(ctx, db.RunTransaction, id, revision, data, collection)
+}
+
 // Delete removes a document from a default collection by its ID.
 // Placeholder: Collection name needed.
 func (db *FirebaseDB) Delete(ctx context.Context, id, collection string) error {
@@ -247,24 +312,9 @@ func (db *FirebaseDB) GetByConditional(ctx context.Context, conditional []Condit
 		return nil, errors.New(errorConditionalRequired)
 	}
 
-	query := db.client.Collection(collection).Query
-	for _, cond := range conditional {
-		if cond.Field == "" {
-			return nil, errors.New(errorConditionalFieldRequired)
-		}
-		if cond.Value == nil {
-			return nil, errors.New(errorConditionalValueRequired)
-		}
-		if cond.Filter == "" {
-			return nil, errors.New(errorConditionalFilterRequired)
-		}
-		if cond.Filter != FilterEquals && cond.Filter != FilterNotEquals &&
-			cond.Filter != FilterGreaterThan && cond.Filter != FilterLessThan &&
-			cond.Filter != FilterArrayContains {
-			return nil, fmt.Errorf(errorGenericError, "invalid filter operator")
-		}
-
-		query = query.Where(cond.Field, string(cond.Filter), cond.Value)
+	query, err := applyConditional(db.client.Collection(collection).Query, conditional)
+	if err != nil {
+		return nil, err
 	}
 
 	iter := query.Documents(ctx)
@@ -331,6 +381,222 @@ func (db *FirebaseDB) GetByFilter(ctx context.Context, filters map[string]interf
 	return b, nil
 }
 
+// GetCollectionGroup retrieves the documents of every collection named collectionID, whatever its parent.
+// It is used by background jobs that work across tenants (tenant/<id>/vaults).
+func (db *FirebaseDB) GetCollectionGroup(ctx context.Context, collectionID string, conditional []Conditional) ([]byte, error) {
+
+	if err := db.validateWithoutData(ctx, collectionID); err != nil {
+		return nil, err
+	}
+
+	query, err := applyConditional(db.client.CollectionGroup(collectionID).Query, conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []interface{}{}
+	for _, d := range docs {
+		data := d.Data()
+		data["id"] = d.Ref.ID
+		results = append(results, data)
+	}
+
+	return json.Marshal(results)
+}
+
+// GetPage retrieves the documents of the collection that match the conditionals, ordered and
+// paginated by opts. The page token holds the ID of the last document of the previous page,
+// whose snapshot is used as the query cursor.
+func (db *FirebaseDB) GetPage(ctx context.Context, collection string, conditional []Conditional, opts QueryOptions) (*Page, error) {
+
+	if err := db.validateWithoutData(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	query, err := applyConditional(db.client.Collection(collection).Query, conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range opts.OrderBy {
+		direction := firestore.Asc
+		if order.Direction == Desc {
+			direction = firestore.Desc
+		}
+		query = query.OrderBy(order.Field, direction)
+	}
+
+	if opts.PageToken != "" {
+		lastID, err := DecodePageToken(opts.PageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot, err := db.client.Collection(collection).Doc(lastID).Get(ctx)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil, fmt.Errorf("%w: page token points to a missing document", ErrInvalidQuery)
+			}
+			return nil, err
+		}
+		query = query.StartAfter(snapshot)
+	}
+
+	// One extra document tells whether there is a next page.
+	switch {
+	case opts.PageSize > 0:
+		query = query.Limit(opts.PageSize + 1)
+	case opts.Limit > 0:
+		query = query.Limit(opts.Limit)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{}
+	if opts.PageSize > 0 && len(docs) > opts.PageSize {
+		docs = docs[:opts.PageSize]
+		page.NextPageToken = EncodePageToken(docs[len(docs)-1].Ref.ID)
+	}
+
+	results := []interface{}{}
+	for _, d := range docs {
+		data := d.Data()
+		data["id"] = d.Ref.ID
+		results = append(results, data)
+	}
+
+	page.Data, err = json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Watch listens to the query snapshots of the collection. A document that stops matching the
+// conditionals is reported as removed, with its last data. Cancelling ctx stops the listener.
+func (db *FirebaseDB) Watch(ctx context.Context, collection string, conditional []Conditional) (<-chan ChangeEvent, error) {
+
+	if err := db.validateWithoutData(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	query, err := applyConditional(db.client.Collection(collection).Query, conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := query.Snapshots(ctx)
+	events := make(chan ChangeEvent, watchBuffer)
+
+	go func() {
+		defer close(events)
+		defer iter.Stop()
+
+		for {
+			snapshot, err := iter.Next()
+			if err != nil {
+				if ctx.Err() == nil && status.Code(err) != codes.Canceled {
+					select {
+					case events <- ChangeEvent{Collection: collection, Time: time.Now().UTC(), Err: err}:
+					case <-ctx.Done():
+					}
+				}
+				return
+			}
+
+			for _, change := range snapshot.Changes {
+				event := firestoreChangeEvent(collection, change, snapshot.ReadTime)
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// Walk lists the subcollections of root and of each of their documents recursively.
+// Collections are visited in ID order and their documents in document ID order.
+func (db *FirebaseDB) Walk(ctx context.Context, root string, fn WalkFunc) error {
+
+	if err := db.validateWithoutData(ctx, root); err != nil {
+		return err
+	}
+
+	return db.walk(ctx, db.client.Doc(root), root, fn)
+}
+
+// walk visits the subcollections of doc, whose path relative to the database is path.
+func (db *FirebaseDB) walk(ctx context.Context, doc *firestore.DocumentRef, path string, fn WalkFunc) error {
+
+	if doc == nil {
+		return fmt.Errorf(errorGenericError, "invalid document path")
+	}
+
+	collections, err := doc.Collections(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(collections, func(i, j int) bool { return collections[i].ID < collections[j].ID })
+
+	for _, collection := range collections {
+		collectionPath := path + "/" + collection.ID
+		docs, err := collection.OrderBy(firestore.DocumentID, firestore.Asc).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, d := range docs {
+			if err := fn(collectionPath, d.Ref.ID, d.Data()); err != nil {
+				return err
+			}
+			if err := db.walk(ctx, d.Ref, collectionPath+"/"+d.Ref.ID, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func firestoreChangeEvent(collection string, change firestore.DocumentChange, readTime time.Time) ChangeEvent {
+
+	event := ChangeEvent{Collection: collection, ID: change.Doc.Ref.ID, Time: readTime}
+
+	switch change.Kind {
+	case firestore.DocumentAdded:
+		event.Type = ChangeAdded
+	case firestore.DocumentModified:
+		event.Type = ChangeModified
+	case firestore.DocumentRemoved:
+		event.Type = ChangeRemoved
+	}
+
+	if data := change.Doc.Data(); data != nil {
+		data["id"] = change.Doc.Ref.ID
+		event.Data, event.Err = json.Marshal(data)
+	}
+
+	return event
+}
+
 // Close terminates the Firebase connection.
 func (db *FirebaseDB) Close() error {
 	if db.client != nil {
@@ -409,3 +675,45 @@ func (db *FirebaseDB) StructToData(data interface{}) (map[string]interface{}, er
 
 	return result, nil
 }
+
+// applyConditional validates the conditionals and adds them to the query.
+// Groups (Or/And) are translated to Firestore composite filters.
+func applyConditional(query firestore.Query, conditional []Conditional) (firestore.Query, error) {
+
+	for _, cond := range conditional {
+		if err := cond.Validate(); err != nil {
+			return query, err
+		}
+
+		if cond.IsGroup() {
+			query = query.WhereEntity(toEntityFilter(cond))
+			continue
+		}
+
+		query = query.Where(cond.Field, string(cond.Filter), cond.Value)
+	}
+
+	return query, nil
+}
+
+func toEntityFilter(cond Conditional) firestore.EntityFilter {
+
+	if !cond.IsGroup() {
+		return firestore.PropertyFilter{Path: cond.Field, Operator: string(cond.Filter), Value: cond.Value}
+	}
+
+	children := cond.Or
+	if len(children) == 0 {
+		children = cond.And
+	}
+
+	filters := make([]firestore.EntityFilter, 0, len(children))
+	for _, child := range children {
+		filters = append(filters, toEntityFilter(child))
+	}
+
+	if len(cond.Or) > 0 {
+		return firestore.OrFilter{Filters: filters}
+	}
+	return firestore.AndFilter{Filters: filters}
+}
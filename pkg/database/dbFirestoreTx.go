@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RunTransaction runs fn inside a Firestore transaction. Firestore retries fn when the
+// documents it read are changed concurrently, so fn must not have side effects outside tx.
+func (db *FirebaseDB) RunTransaction(ctx context.Context, fn func(tx Tx) error) error {
+
+	if db.client == nil {
+		return errors.New(errorClientNotInitialized)
+	}
+
+	if fn == nil {
+		return fmt.Errorf(errorGenericError, "transaction function is nil")
+	}
+
+	err := db.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return fn(&firestoreTx{ctx: ctx, client: db.client, tx: tx})
+	})
+
+	return translateError(err)
+}
+
+// CommitBatch applies every write of the batch atomically.
+func (db *FirebaseDB) CommitBatch(ctx context.Context, batch *Batch) error {
+
+	if db.client == nil {
+		return errors.New(errorClientNotInitialized)
+	}
+
+	if err := batch.Validate(); err != nil {
+		return err
+	}
+
+	return db.RunTransaction(ctx, batch.apply)
+}
+
+type firestoreTx struct {
+	ctx    context.Context
+	client *firestore.Client
+	tx     *firestore.Transaction
+	wrote  bool
+}
+
+func (t *firestoreTx) Get(collection, id string) ([]byte, error) {
+
+	if t.wrote {
+		return nil, ErrReadAfterWrite
+	}
+
+	if collection == "" || id == "" {
+		return nil, fmt.Errorf(errorGenericError, "collection and id are required")
+	}
+
+	doc, err := t.tx.Get(t.client.Collection(collection).Doc(id))
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	data := doc.Data()
+	data["id"] = doc.Ref.ID
+
+	return json.Marshal(data)
+}
+
+func (t *firestoreTx) GetByConditional(collection string, conditional []Conditional) ([]byte, error) {
+
+	if t.wrote {
+		return nil, ErrReadAfterWrite
+	}
+
+	if collection == "" {
+		return nil, errors.New(errorCollectionRequired)
+	}
+
+	query, err := applyConditional(t.client.Collection(collection).Query, conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := t.tx.Documents(query).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []interface{}{}
+	for _, d := range docs {
+		data := d.Data()
+		data["id"] = d.Ref.ID
+		results = append(results, data)
+	}
+
+	return json.Marshal(results)
+}
+
+func (t *firestoreTx) Create(collection, id string, data interface{}) error {
+	ref, err := t.ref(collection, id, data)
+	if err != nil {
+		return err
+	}
+	return t.tx.Create(ref, data)
+}
+
+func (t *firestoreTx) Set(collection, id string, data interface{}) error {
+	ref, err := t.ref(collection, id, data)
+	if err != nil {
+		return err
+	}
+	return t.tx.Set(ref, data)
+}
+
+func (t *firestoreTx) Update(collection, id string, data map[string]interface{}) error {
+	ref, err := t.ref(collection, id, data)
+	if err != nil {
+		return err
+	}
+	if _, exists := data["updatedAt"]; !exists {
+		data["updatedAt"] = firestore.ServerTimestamp
+	}
+	data[RevisionField] = firestore.Increment(1)
+	return t.tx.Set(ref, data, firestore.MergeAll)
+}
+
+func (t *firestoreTx) Delete(collection, id string) error {
+	ref, err := t.ref(collection, id, struct{}{})
+	if err != nil {
+		return err
+	}
+	return t.tx.Delete(ref)
+}
+
+// ref validates a write and marks the transaction as written.
+func (t *firestoreTx) ref(collection, id string, data interface{}) (*firestore.DocumentRef, error) {
+
+	if collection == "" {
+		return nil, errors.New(errorCollectionRequired)
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if data == nil {
+		return nil, fmt.Errorf(errorGenericError, "data is nil")
+	}
+
+	t.wrote = true
+
+	return t.client.Collection(collection).Doc(id), nil
+}
+
+// translateError maps the Firestore status codes to the errors of this package.
+func translateError(err error) error {
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return ErrAlreadyExists
+	case codes.NotFound:
+		return ErrNotFound
+	default:
+		return err
+	}
+}
@@ -0,0 +1,998 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+const autoIDChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// MemoryDB is an in-memory FirebaseDBInterface for tests and local development.
+// It follows the Firestore behaviour the repositories rely on: collections addressed
+// by slash separated paths (tenant/<id>/vaults/<id>/secrets), conditionals, ordering,
+// cursor pagination, transactions, server timestamps on Create and Update and change streams.
+// Documents are stored as JSON values, so numbers are read back as float64.
+type MemoryDB struct {
+	mu          sync.Mutex
+	collections map[string]map[string]map[string]interface{}
+	watchers    map[*memoryWatcher]struct{}
+	now         func() time.Time
+}
+
+// NewMemoryDB creates an empty MemoryDB.
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		collections: map[string]map[string]map[string]interface{}{},
+		watchers:    map[*memoryWatcher]struct{}{},
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SetClock replaces the clock used for server timestamps.
+func (db *MemoryDB) SetClock(now func() time.Time) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.now = now
+}
+
+func (db *MemoryDB) IsConnected() bool {
+	return true
+}
+
+func (db *MemoryDB) Get(ctx context.Context, collection string) ([]byte, error) {
+	return db.GetByConditional(ctx, nil, collection)
+}
+
+func (db *MemoryDB) GetByID(ctx context.Context, id, collection string) ([]byte, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	doc, ok := db.collections[collection][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return marshalDocument(id, doc)
+}
+
+func (db *MemoryDB) Create(ctx context.Context, data interface{}, collection string) ([]byte, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	doc, err := db.normalize(data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	id := autoID()
+	db.put(collection, id, doc)
+
+	return marshalDocument(id, doc)
+}
+
+func (db *MemoryDB) CreateWithID(ctx context.Context, id string, data interface{}, collection string) ([]byte, error) {
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.collections[collection][id]; ok {
+		return nil, ErrAlreadyExists
+	}
+
+	doc, err := db.normalize(data, false)
+	if err != nil {
+		return nil, err
+	}
+
+	db.put(collection, id, doc)
+
+	return marshalDocument(id, doc)
+}
+
+// Update merges data into the document, creating it when missing, like Set with MergeAll.
+func (db *MemoryDB) Update(ctx context.Context, id string, data interface{}, collection string) error {
+
+	if id == "" {
+		return fmt.Errorf("id is empty")
+	}
+
+	if err := db.validate(ctx, collection); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	doc, err := db.normalize(withUpdatedAt(data), false)
+	if err != nil {
+		return err
+	}
+
+	db.merge(collection, id, doc)
+
+	return nil
+}
+
+// UpdateIfMatch merges data into the document only if its revision is still revision,
+// otherwise it returns ErrRevisionMismatch.
+func (db *MemoryDB) UpdateIfMatch(ctx context.Context, id string, revision int64, data map[string]interface{}, collection string) error {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return err
+	}
+
+	return updateIfMatch(ctx, db.RunTransaction, id, revision, data, collection)
+}
+
+func (db *MemoryDB) Delete(ctx context.Context, id, collection string) error {
+
+	if id == "" {
+		return fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if err := db.validate(ctx, collection); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.remove(collection, id)
+
+	return nil
+}
+
+// GetByQuery has no in-memory equivalent: the returned query is not bound to a client.
+func (db *MemoryDB) GetByQuery(ctx context.Context, collection string) firestore.Query {
+	return firestore.Query{}
+}
+
+func (db *MemoryDB) GetByConditional(ctx context.Context, conditional []Conditional, collection string) ([]byte, error) {
+
+	page, err := db.GetPage(ctx, collection, conditional, QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Data, nil
+}
+
+func (db *MemoryDB) GetByFilter(ctx context.Context, filters map[string]interface{}, collection string) ([]byte, error) {
+
+	conditional := make([]Conditional, 0, len(filters))
+	for field, value := range filters {
+		conditional = append(conditional, Conditional{Field: field, Value: value, Filter: FilterEquals})
+	}
+
+	return db.GetByConditional(ctx, conditional, collection)
+}
+
+func (db *MemoryDB) GetCollectionGroup(ctx context.Context, collectionID string, conditional []Conditional) ([]byte, error) {
+
+	if err := db.validate(ctx, collectionID); err != nil {
+		return nil, err
+	}
+
+	conditions, err := normalizeConditionals(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var docs []memoryDoc
+	for path := range db.collections {
+		if path == collectionID || strings.HasSuffix(path, "/"+collectionID) {
+			docs = append(docs, db.query(path, conditions)...)
+		}
+	}
+	sortDocuments(docs, nil)
+
+	return marshalDocuments(docs)
+}
+
+func (db *MemoryDB) GetPage(ctx context.Context, collection string, conditional []Conditional, opts QueryOptions) (*Page, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	conditions, err := normalizeConditionals(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	docs := db.query(collection, conditions)
+
+	// Like Firestore, documents without an ordered field are left out.
+	if len(opts.OrderBy) > 0 {
+		ordered := docs[:0]
+		for _, d := range docs {
+			if d.hasFields(opts.OrderBy) {
+				ordered = append(ordered, d)
+			}
+		}
+		docs = ordered
+	}
+	sortDocuments(docs, opts.OrderBy)
+
+	if opts.PageToken != "" {
+		lastID, err := DecodePageToken(opts.PageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		last, ok := db.collections[collection][lastID]
+		if !ok {
+			return nil, fmt.Errorf("%w: page token points to a missing document", ErrInvalidQuery)
+		}
+
+		cursor := memoryDoc{id: lastID, data: last}
+		start := sort.Search(len(docs), func(i int) bool {
+			return compareDocuments(docs[i], cursor, opts.OrderBy) > 0
+		})
+		docs = docs[start:]
+	}
+
+	page := &Page{}
+	switch {
+	case opts.PageSize > 0 && len(docs) > opts.PageSize:
+		docs = docs[:opts.PageSize]
+		page.NextPageToken = EncodePageToken(docs[len(docs)-1].id)
+	case opts.Limit > 0 && len(docs) > opts.Limit:
+		docs = docs[:opts.Limit]
+	}
+
+	page.Data, err = marshalDocuments(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// RunTransaction runs fn while holding the database lock, so fn must only use tx.
+// The writes are applied when fn returns nil and discarded otherwise.
+func (db *MemoryDB) RunTransaction(ctx context.Context, fn func(tx Tx) error) error {
+
+	if fn == nil {
+		return fmt.Errorf(errorGenericError, "transaction function is nil")
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx := &memoryTx{db: db, exists: map[string]bool{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	for _, w := range tx.writes {
+		switch w.Operation {
+		case WriteCreate, WriteSet:
+			db.put(w.Collection, w.ID, w.Data.(map[string]interface{}))
+		case WriteUpdate:
+			db.merge(w.Collection, w.ID, w.Data.(map[string]interface{}))
+		case WriteDelete:
+			db.remove(w.Collection, w.ID)
+		}
+	}
+
+	return nil
+}
+
+func (db *MemoryDB) CommitBatch(ctx context.Context, batch *Batch) error {
+
+	if err := batch.Validate(); err != nil {
+		return err
+	}
+
+	return db.RunTransaction(ctx, batch.apply)
+}
+
+// Watch streams the changes of the documents of the collection that match the conditionals,
+// starting with the current documents as added. Events are delivered in write order and
+// the channel is closed when ctx is done.
+func (db *MemoryDB) Watch(ctx context.Context, collection string, conditional []Conditional) (<-chan ChangeEvent, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	conditions, err := normalizeConditionals(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &memoryWatcher{
+		tracker: newChangeTracker(collection, conditions),
+		queue:   newEventQueue(),
+	}
+
+	db.mu.Lock()
+	for _, doc := range db.query(collection, conditions) {
+		if event, ok := watcher.tracker.apply(doc.id, doc.data, db.now()); ok {
+			watcher.queue.push(event)
+		}
+	}
+	db.watchers[watcher] = struct{}{}
+	db.mu.Unlock()
+
+	events := make(chan ChangeEvent, watchBuffer)
+	go watcher.queue.drain(ctx.Done(), events)
+	go func() {
+		<-ctx.Done()
+		db.mu.Lock()
+		delete(db.watchers, watcher)
+		db.mu.Unlock()
+	}()
+
+	return events, nil
+}
+
+// Walk visits the collections below root in path order, so a document is visited before its
+// subcollections. The documents are copied first, so fn may use the database.
+func (db *MemoryDB) Walk(ctx context.Context, root string, fn WalkFunc) error {
+
+	if err := db.validate(ctx, root); err != nil {
+		return err
+	}
+
+	type walkDoc struct {
+		collection string
+		memoryDoc
+	}
+
+	db.mu.Lock()
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	paths := []string{}
+	for path := range db.collections {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	docs := []walkDoc{}
+	for _, path := range paths {
+		collection := db.query(path, nil)
+		sortDocuments(collection, nil)
+		for _, d := range collection {
+			docs = append(docs, walkDoc{collection: path, memoryDoc: memoryDoc{id: d.id, data: cloneDocument(d.data)}})
+		}
+	}
+	db.mu.Unlock()
+
+	for _, d := range docs {
+		if err := fn(d.collection, d.id, d.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *MemoryDB) StructToData(data interface{}) (map[string]interface{}, error) {
+	return (&FirebaseDB{}).StructToData(data)
+}
+
+func (db *MemoryDB) validate(ctx context.Context, collection string) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if collection == "" {
+		return errors.New(errorCollectionRequired)
+	}
+
+	return nil
+}
+
+func (db *MemoryDB) normalize(data interface{}, create bool) (map[string]interface{}, error) {
+	return normalizeDocument(data, create, db.now)
+}
+
+func (db *MemoryDB) put(collection, id string, doc map[string]interface{}) {
+	if db.collections[collection] == nil {
+		db.collections[collection] = map[string]map[string]interface{}{}
+	}
+	db.collections[collection][id] = doc
+	db.notify(collection, id)
+}
+
+func (db *MemoryDB) remove(collection, id string) {
+	if _, ok := db.collections[collection][id]; !ok {
+		return
+	}
+	delete(db.collections[collection], id)
+	db.notify(collection, id)
+}
+
+// notify pushes the new state of the document to the watchers of its collection. It expects the lock.
+func (db *MemoryDB) notify(collection, id string) {
+	doc := db.collections[collection][id]
+	for w := range db.watchers {
+		if w.tracker.collection != collection {
+			continue
+		}
+		if event, ok := w.tracker.apply(id, doc, db.now()); ok {
+			w.queue.push(event)
+		}
+	}
+}
+
+type memoryWatcher struct {
+	tracker *changeTracker
+	queue   *eventQueue
+}
+
+func (db *MemoryDB) merge(collection, id string, doc map[string]interface{}) {
+	current, ok := db.collections[collection][id]
+	if !ok {
+		db.put(collection, id, withNextRevision(nil, doc))
+		return
+	}
+	db.put(collection, id, mergeMaps(current, withNextRevision(current, doc)))
+}
+
+// query returns the documents of the collection that match every condition. It expects the lock.
+func (db *MemoryDB) query(collection string, conditions []Conditional) []memoryDoc {
+
+	docs := []memoryDoc{}
+	for id, data := range db.collections[collection] {
+		if matchAll(data, conditions) {
+			docs = append(docs, memoryDoc{id: id, data: data})
+		}
+	}
+
+	return docs
+}
+
+type memoryDoc struct {
+	id   string
+	data map[string]interface{}
+}
+
+func (d memoryDoc) hasFields(orders []OrderBy) bool {
+	for _, o := range orders {
+		if _, ok := lookupField(d.data, o.Field); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// memoryTx stages the writes of a transaction. exists tracks the documents created
+// or deleted by earlier writes of the same transaction.
+type memoryTx struct {
+	db     *MemoryDB
+	writes []Write
+	exists map[string]bool
+}
+
+func (t *memoryTx) Get(collection, id string) ([]byte, error) {
+
+	if len(t.writes) > 0 {
+		return nil, ErrReadAfterWrite
+	}
+
+	doc, ok := t.db.collections[collection][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return marshalDocument(id, doc)
+}
+
+func (t *memoryTx) GetByConditional(collection string, conditional []Conditional) ([]byte, error) {
+
+	if len(t.writes) > 0 {
+		return nil, ErrReadAfterWrite
+	}
+
+	conditions, err := normalizeConditionals(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := t.db.query(collection, conditions)
+	sortDocuments(docs, nil)
+
+	return marshalDocuments(docs)
+}
+
+func (t *memoryTx) Create(collection, id string, data interface{}) error {
+
+	if err := t.check(collection, id, data); err != nil {
+		return err
+	}
+
+	if t.documentExists(collection, id) {
+		return ErrAlreadyExists
+	}
+
+	doc, err := t.db.normalize(data, false)
+	if err != nil {
+		return err
+	}
+
+	return t.stage(Write{Operation: WriteCreate, Collection: collection, ID: id, Data: doc}, true)
+}
+
+func (t *memoryTx) Set(collection, id string, data interface{}) error {
+
+	if err := t.check(collection, id, data); err != nil {
+		return err
+	}
+
+	doc, err := t.db.normalize(data, false)
+	if err != nil {
+		return err
+	}
+
+	return t.stage(Write{Operation: WriteSet, Collection: collection, ID: id, Data: doc}, true)
+}
+
+func (t *memoryTx) Update(collection, id string, data map[string]interface{}) error {
+
+	if err := t.check(collection, id, data); err != nil {
+		return err
+	}
+
+	doc, err := t.db.normalize(withUpdatedAt(data), false)
+	if err != nil {
+		return err
+	}
+
+	return t.stage(Write{Operation: WriteUpdate, Collection: collection, ID: id, Data: doc}, true)
+}
+
+func (t *memoryTx) Delete(collection, id string) error {
+
+	if err := t.check(collection, id, struct{}{}); err != nil {
+		return err
+	}
+
+	return t.stage(Write{Operation: WriteDelete, Collection: collection, ID: id}, false)
+}
+
+func (t *memoryTx) check(collection, id string, data interface{}) error {
+
+	if collection == "" {
+		return errors.New(errorCollectionRequired)
+	}
+
+	if id == "" {
+		return fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if data == nil {
+		return fmt.Errorf(errorGenericError, "data is nil")
+	}
+
+	if len(t.writes) >= MaxBatchWrites {
+		return ErrBatchTooLarge
+	}
+
+	return nil
+}
+
+func (t *memoryTx) stage(w Write, exists bool) error {
+	t.writes = append(t.writes, w)
+	t.exists[w.Collection+"/"+w.ID] = exists
+	return nil
+}
+
+func (t *memoryTx) documentExists(collection, id string) bool {
+	if exists, ok := t.exists[collection+"/"+id]; ok {
+		return exists
+	}
+	_, ok := t.db.collections[collection][id]
+	return ok
+}
+
+// normalizeDocument converts data into its JSON representation, replacing firestore.ServerTimestamp
+// with now. On create, updatedAt is set when createdAt is missing, as FirebaseDB.Create does.
+func normalizeDocument(data interface{}, create bool, now func() time.Time) (map[string]interface{}, error) {
+
+	if data == nil {
+		return nil, errors.New("data is nil")
+	}
+
+	if m, ok := data.(map[string]interface{}); ok {
+		if _, exists := m["createdAt"]; create && !exists {
+			m["updatedAt"] = firestore.ServerTimestamp
+		}
+		data = resolveTimestamps(m, now)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		return nil, errors.New("data is not a document")
+	}
+	delete(doc, "id")
+
+	return doc, nil
+}
+
+func resolveTimestamps(data map[string]interface{}, now func() time.Time) map[string]interface{} {
+
+	resolved := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			resolved[k] = resolveTimestamps(value, now)
+		default:
+			if v == firestore.ServerTimestamp {
+				v = now()
+			}
+			resolved[k] = v
+		}
+	}
+
+	return resolved
+}
+
+// withUpdatedAt sets updatedAt to the server timestamp when the caller did not, as FirebaseDB.Update does.
+func withUpdatedAt(data interface{}) interface{} {
+	if m, ok := data.(map[string]interface{}); ok {
+		if _, exists := m["updatedAt"]; !exists {
+			m["updatedAt"] = firestore.ServerTimestamp
+		}
+	}
+	return data
+}
+
+func mergeMaps(current, update map[string]interface{}) map[string]interface{} {
+
+	merged := make(map[string]interface{}, len(current)+len(update))
+	for k, v := range current {
+		merged[k] = v
+	}
+
+	for k, v := range update {
+		if next, ok := v.(map[string]interface{}); ok {
+			if prev, ok := merged[k].(map[string]interface{}); ok {
+				merged[k] = mergeMaps(prev, next)
+				continue
+			}
+		}
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// cloneDocument returns a deep copy of a stored document, whose values are JSON values.
+func cloneDocument(doc map[string]interface{}) map[string]interface{} {
+
+	clone := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		clone[k] = cloneValue(v)
+	}
+
+	return clone
+}
+
+func cloneValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return cloneDocument(value)
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = cloneValue(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+func marshalDocument(id string, doc map[string]interface{}) ([]byte, error) {
+	data := make(map[string]interface{}, len(doc)+1)
+	for k, v := range doc {
+		data[k] = v
+	}
+	data["id"] = id
+	return json.Marshal(data)
+}
+
+func marshalDocuments(docs []memoryDoc) ([]byte, error) {
+	results := make([]json.RawMessage, 0, len(docs))
+	for _, d := range docs {
+		b, err := marshalDocument(d.id, d.data)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, b)
+	}
+	return json.Marshal(results)
+}
+
+func autoID() string {
+	id := make([]byte, 20)
+	max := big.NewInt(int64(len(autoIDChars)))
+	for i := range id {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		id[i] = autoIDChars[n.Int64()]
+	}
+	return string(id)
+}
+
+// normalizeConditionals validates the conditionals and converts their values to JSON values,
+// so they can be compared with the stored documents.
+func normalizeConditionals(conditional []Conditional) ([]Conditional, error) {
+
+	normalized := make([]Conditional, 0, len(conditional))
+	for _, c := range conditional {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+
+		if c.IsGroup() {
+			or, err := normalizeConditionals(c.Or)
+			if err != nil {
+				return nil, err
+			}
+			and, err := normalizeConditionals(c.And)
+			if err != nil {
+				return nil, err
+			}
+			normalized = append(normalized, Conditional{Or: or, And: and})
+			continue
+		}
+
+		b, err := json.Marshal(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := json.Unmarshal(b, &value); err != nil {
+			return nil, err
+		}
+		c.Value = value
+		normalized = append(normalized, c)
+	}
+
+	return normalized, nil
+}
+
+func matchAll(doc map[string]interface{}, conditions []Conditional) bool {
+	for _, c := range conditions {
+		if !match(doc, c) {
+			return false
+		}
+	}
+	return true
+}
+
+func match(doc map[string]interface{}, c Conditional) bool {
+
+	if len(c.Or) > 0 {
+		for _, child := range c.Or {
+			if match(doc, child) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(c.And) > 0 {
+		return matchAll(doc, c.And)
+	}
+
+	value, ok := lookupField(doc, c.Field)
+	if !ok {
+		// Firestore never matches documents without the field, not even with != or not-in.
+		return false
+	}
+
+	switch c.Filter {
+	case FilterEquals:
+		return reflect.DeepEqual(value, c.Value)
+	case FilterNotEquals:
+		return value != nil && !reflect.DeepEqual(value, c.Value)
+	case FilterGreaterThan, FilterGreaterThanOrEqual, FilterLessThan, FilterLessThanOrEqual:
+		cmp, comparable := compareValues(value, c.Value)
+		if !comparable {
+			return false
+		}
+		switch c.Filter {
+		case FilterGreaterThan:
+			return cmp > 0
+		case FilterGreaterThanOrEqual:
+			return cmp >= 0
+		case FilterLessThan:
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	case FilterIn:
+		return containsValue(c.Value, value)
+	case FilterNotIn:
+		return value != nil && !containsValue(c.Value, value)
+	case FilterArrayContains:
+		return containsValue(value, c.Value)
+	case FilterArrayContainsAny:
+		list, _ := c.Value.([]interface{})
+		for _, item := range list {
+			if containsValue(value, item) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// lookupField resolves dotted paths such as user.uid.
+func lookupField(doc map[string]interface{}, field string) (interface{}, bool) {
+
+	var current interface{} = doc
+	for _, part := range strings.Split(field, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func containsValue(list, value interface{}) bool {
+	items, ok := list.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareValues compares two JSON values of the same kind.
+func compareValues(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case !x:
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+// typeRank orders values of different kinds the way Firestore does.
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// compareDocuments orders by the fields and then by document ID, which is also the cursor order.
+func compareDocuments(a, b memoryDoc, orders []OrderBy) int {
+
+	for _, o := range orders {
+		x, _ := lookupField(a.data, o.Field)
+		y, _ := lookupField(b.data, o.Field)
+
+		cmp, ok := compareValues(x, y)
+		if !ok {
+			cmp = typeRank(x) - typeRank(y)
+		}
+		if o.Direction == Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	return strings.Compare(a.id, b.id)
+}
+
+func sortDocuments(docs []memoryDoc, orders []OrderBy) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		return compareDocuments(docs[i], docs[j], orders) < 0
+	})
+}
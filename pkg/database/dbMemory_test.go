@@ -0,0 +1,284 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type MemoryDBTestSuite struct {
+	suite.Suite
+	ctx context.Context
+	db  *MemoryDB
+	now time.Time
+}
+
+func TestMemoryDBTestSuite(t *testing.T) {
+	suite.Run(t, new(MemoryDBTestSuite))
+}
+
+func (s *MemoryDBTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.db = NewMemoryDB()
+	s.now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.db.SetClock(func() time.Time { return s.now })
+
+	events := []map[string]interface{}{
+		{"eventType": "LOGIN_FAILURE", "timestamp": "2024-05-01T10:00:00Z", "user": map[string]interface{}{"uid": "alice"}, "tags": []string{"web"}},
+		{"eventType": "ACCESS_DENIED", "timestamp": "2024-05-01T11:00:00Z", "user": map[string]interface{}{"uid": "bob"}, "tags": []string{"api"}},
+		{"eventType": "LOGIN_SUCCESS", "timestamp": "2024-05-01T12:00:00Z", "user": map[string]interface{}{"uid": "alice"}},
+		{"eventType": "ACCESS_DENIED", "timestamp": "2024-05-02T09:00:00Z", "user": map[string]interface{}{"uid": "carol"}, "tags": []string{"web", "api"}},
+	}
+	for i, e := range events {
+		_, err := s.db.CreateWithID(s.ctx, string(rune('a'+i)), e, "tenant/t1/audit")
+		s.Require().NoError(err)
+	}
+}
+
+func (s *MemoryDBTestSuite) ids(data []byte) []string {
+	var docs []map[string]interface{}
+	s.Require().NoError(json.Unmarshal(data, &docs))
+	ids := []string{}
+	for _, d := range docs {
+		ids = append(ids, d["id"].(string))
+	}
+	return ids
+}
+
+func (s *MemoryDBTestSuite) find(conditional ...Conditional) []string {
+	data, err := s.db.GetByConditional(s.ctx, conditional, "tenant/t1/audit")
+	s.Require().NoError(err)
+	return s.ids(data)
+}
+
+func (s *MemoryDBTestSuite) TestCreateAndServerTimestamps() {
+	created, err := s.db.Create(s.ctx, map[string]interface{}{"name": "prod"}, "tenant/t1/vaults")
+	s.Require().NoError(err)
+
+	var doc map[string]interface{}
+	s.Require().NoError(json.Unmarshal(created, &doc))
+	s.Len(doc["id"], 20)
+	s.Equal("2024-05-01T12:00:00Z", doc["updatedAt"])
+
+	id := doc["id"].(string)
+	s.now = s.now.Add(time.Hour)
+	s.Require().NoError(s.db.Update(s.ctx, id, map[string]interface{}{"settings": map[string]interface{}{"color": "#fff"}}, "tenant/t1/vaults"))
+
+	got, err := s.db.GetByID(s.ctx, id, "tenant/t1/vaults")
+	s.Require().NoError(err)
+	s.JSONEq(`{"id":"`+id+`","name":"prod","revision":1,"settings":{"color":"#fff"},"updatedAt":"2024-05-01T13:00:00Z"}`, string(got))
+
+	_, err = s.db.CreateWithID(s.ctx, id, map[string]interface{}{"name": "dup"}, "tenant/t1/vaults")
+	s.ErrorIs(err, ErrAlreadyExists)
+
+	_, err = s.db.GetByID(s.ctx, id, "tenant/t2/vaults")
+	s.ErrorIs(err, ErrNotFound, "collections are isolated by path")
+}
+
+func (s *MemoryDBTestSuite) TestUpdateIfMatch() {
+	_, err := s.db.CreateWithID(s.ctx, "v1", map[string]interface{}{"name": "prod", "revision": 1}, "tenant/t1/vaults")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.db.UpdateIfMatch(s.ctx, "v1", 1, map[string]interface{}{"name": "staging", "revision": 7}, "tenant/t1/vaults"))
+
+	err = s.db.UpdateIfMatch(s.ctx, "v1", 1, map[string]interface{}{"name": "stale"}, "tenant/t1/vaults")
+	s.ErrorIs(err, ErrRevisionMismatch)
+
+	s.Require().NoError(s.db.Update(s.ctx, "v1", map[string]interface{}{"name": "blind"}, "tenant/t1/vaults"))
+	err = s.db.UpdateIfMatch(s.ctx, "v1", 2, map[string]interface{}{"name": "stale"}, "tenant/t1/vaults")
+	s.ErrorIs(err, ErrRevisionMismatch, "a blind update also changes the revision")
+
+	got, err := s.db.GetByID(s.ctx, "v1", "tenant/t1/vaults")
+	s.Require().NoError(err)
+	var doc map[string]interface{}
+	s.Require().NoError(json.Unmarshal(got, &doc))
+	s.Equal("blind", doc["name"])
+	s.Equal(int64(3), DocumentRevision(doc))
+
+	err = s.db.UpdateIfMatch(s.ctx, "missing", 1, map[string]interface{}{"name": "x"}, "tenant/t1/vaults")
+	s.ErrorIs(err, ErrNotFound)
+}
+
+func (s *MemoryDBTestSuite) TestConditionals() {
+	s.Equal([]string{"b", "d"}, s.find(Conditional{Field: "eventType", Value: "ACCESS_DENIED", Filter: FilterEquals}))
+	s.Equal([]string{"a", "c"}, s.find(Conditional{Field: "user.uid", Value: "alice", Filter: FilterEquals}))
+	s.Equal([]string{"a", "b", "d"}, s.find(Conditional{Field: "eventType", Value: []string{"ACCESS_DENIED", "LOGIN_FAILURE"}, Filter: FilterIn}))
+	s.Equal([]string{"c"}, s.find(Conditional{Field: "eventType", Value: []string{"ACCESS_DENIED", "LOGIN_FAILURE"}, Filter: FilterNotIn}))
+	s.Equal([]string{"a", "d"}, s.find(Conditional{Field: "tags", Value: "web", Filter: FilterArrayContains}))
+	s.Equal([]string{"a", "b", "d"}, s.find(Conditional{Field: "tags", Value: []string{"web", "api"}, Filter: FilterArrayContainsAny}))
+	s.Equal([]string{"b", "c"}, s.find(
+		Conditional{Field: "timestamp", Value: "2024-05-01T11:00:00Z", Filter: FilterGreaterThanOrEqual},
+		Conditional{Field: "timestamp", Value: "2024-05-01T12:00:00Z", Filter: FilterLessThanOrEqual},
+	))
+	s.Equal([]string{"b", "c"}, s.find(Or(
+		Conditional{Field: "user.uid", Value: "bob", Filter: FilterEquals},
+		And(
+			Conditional{Field: "user.uid", Value: "alice", Filter: FilterEquals},
+			Conditional{Field: "eventType", Value: "LOGIN_SUCCESS", Filter: FilterEquals},
+		),
+	)))
+
+	_, err := s.db.GetByConditional(s.ctx, []Conditional{{Field: "eventType", Filter: "like", Value: "x"}}, "tenant/t1/audit")
+	s.ErrorIs(err, ErrInvalidQuery)
+}
+
+func (s *MemoryDBTestSuite) TestPagination() {
+	opts := QueryOptions{PageSize: 3, OrderBy: []OrderBy{{Field: "timestamp", Direction: Desc}}}
+
+	page, err := s.db.GetPage(s.ctx, "tenant/t1/audit", nil, opts)
+	s.Require().NoError(err)
+	s.Equal([]string{"d", "c", "b"}, s.ids(page.Data))
+	s.NotEmpty(page.NextPageToken)
+
+	opts.PageToken = page.NextPageToken
+	page, err = s.db.GetPage(s.ctx, "tenant/t1/audit", nil, opts)
+	s.Require().NoError(err)
+	s.Equal([]string{"a"}, s.ids(page.Data))
+	s.Empty(page.NextPageToken)
+
+	page, err = s.db.GetPage(s.ctx, "tenant/t1/audit", nil, QueryOptions{Limit: 2, OrderBy: []OrderBy{{Field: "tags", Direction: Asc}}})
+	s.Require().NoError(err)
+	s.Len(s.ids(page.Data), 2, "documents without the ordered field are left out")
+
+	_, err = s.db.GetPage(s.ctx, "tenant/t1/audit", nil, QueryOptions{PageSize: 2, PageToken: EncodePageToken("missing")})
+	s.ErrorIs(err, ErrInvalidQuery)
+}
+
+func (s *MemoryDBTestSuite) TestCollectionGroup() {
+	_, err := s.db.CreateWithID(s.ctx, "z", map[string]interface{}{"eventType": "LOGOUT"}, "tenant/t2/audit")
+	s.Require().NoError(err)
+
+	data, err := s.db.GetCollectionGroup(s.ctx, "audit", []Conditional{{Field: "eventType", Value: "LOGOUT", Filter: FilterEquals}})
+	s.Require().NoError(err)
+	s.Equal([]string{"z"}, s.ids(data))
+}
+
+func (s *MemoryDBTestSuite) TestWalk() {
+	_, err := s.db.CreateWithID(s.ctx, "v1", map[string]interface{}{"name": "prod"}, "tenant/t1/vaults")
+	s.Require().NoError(err)
+	_, err = s.db.CreateWithID(s.ctx, "s1", map[string]interface{}{"name": "key"}, "tenant/t1/vaults/v1/secrets")
+	s.Require().NoError(err)
+	_, err = s.db.CreateWithID(s.ctx, "z", map[string]interface{}{"eventType": "LOGOUT"}, "tenant/t10/audit")
+	s.Require().NoError(err)
+
+	visited := []string{}
+	err = s.db.Walk(s.ctx, "tenant/t1", func(collection, id string, data map[string]interface{}) error {
+		data["name"] = "changed"
+		visited = append(visited, collection+"/"+id)
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal([]string{
+		"tenant/t1/audit/a", "tenant/t1/audit/b", "tenant/t1/audit/c", "tenant/t1/audit/d",
+		"tenant/t1/vaults/v1", "tenant/t1/vaults/v1/secrets/s1",
+	}, visited, "another tenant sharing the prefix is not visited")
+
+	data, err := s.db.GetByID(s.ctx, "v1", "tenant/t1/vaults")
+	s.Require().NoError(err)
+	s.Contains(string(data), `"name":"prod"`, "fn gets a copy of the document")
+
+	stop := errors.New("stop")
+	err = s.db.Walk(s.ctx, "tenant/t1", func(collection, id string, data map[string]interface{}) error { return stop })
+	s.ErrorIs(err, stop)
+}
+
+func (s *MemoryDBTestSuite) TestTransaction() {
+	err := s.db.RunTransaction(s.ctx, func(tx Tx) error {
+		if _, err := tx.Get("tenant/t1/audit", "a"); err != nil {
+			return err
+		}
+		if err := tx.Delete("tenant/t1/audit", "a"); err != nil {
+			return err
+		}
+		_, err := tx.Get("tenant/t1/audit", "b")
+		return err
+	})
+	s.ErrorIs(err, ErrReadAfterWrite)
+
+	_, err = s.db.GetByID(s.ctx, "a", "tenant/t1/audit")
+	s.NoError(err, "a failed transaction does not apply its writes")
+
+	boom := errors.New("boom")
+	err = s.db.CommitBatch(s.ctx, NewBatch().
+		Create("tenant/t1/vaults", "v1", map[string]interface{}{"name": "prod"}).
+		Create("tenant/t1/audit", "a", map[string]interface{}{"eventType": "dup"}))
+	s.ErrorIs(err, ErrAlreadyExists)
+	_, err = s.db.GetByID(s.ctx, "v1", "tenant/t1/vaults")
+	s.ErrorIs(err, ErrNotFound, "batches are atomic")
+
+	err = s.db.RunTransaction(s.ctx, func(tx Tx) error {
+		s.Require().NoError(tx.Create("tenant/t1/vaults", "v1", map[string]interface{}{"name": "prod"}))
+		return boom
+	})
+	s.ErrorIs(err, boom)
+
+	s.Require().NoError(s.db.CommitBatch(s.ctx, NewBatch().
+		Create("tenant/t1/vaults", "v1", map[string]interface{}{"name": "prod"}).
+		Update("tenant/t1/counters", "t1", map[string]interface{}{"vaults": 1})))
+	_, err = s.db.GetByID(s.ctx, "v1", "tenant/t1/vaults")
+	s.NoError(err)
+}
+
+func (s *MemoryDBTestSuite) TestWatch() {
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	events, err := s.db.Watch(ctx, "tenant/t1/audit", []Conditional{{Field: "eventType", Value: "ACCESS_DENIED", Filter: FilterEquals}})
+	s.Require().NoError(err)
+
+	next := func() ChangeEvent {
+		select {
+		case event := <-events:
+			s.Require().NoError(event.Err)
+			return event
+		case <-time.After(time.Second):
+			s.FailNow("no change event")
+			return ChangeEvent{}
+		}
+	}
+
+	initial := []string{next().ID, next().ID}
+	s.ElementsMatch([]string{"b", "d"}, initial, "the matching documents are reported as added first")
+
+	s.Require().NoError(s.db.Update(s.ctx, "b", map[string]interface{}{"reason": "missing relation"}, "tenant/t1/audit"))
+	s.Require().NoError(s.db.Update(s.ctx, "a", map[string]interface{}{"eventType": "ACCESS_DENIED"}, "tenant/t1/audit"))
+	s.Require().NoError(s.db.Update(s.ctx, "d", map[string]interface{}{"eventType": "LOGOUT"}, "tenant/t1/audit"))
+	s.Require().NoError(s.db.CommitBatch(s.ctx, NewBatch().Delete("tenant/t1/audit", "b").Delete("tenant/t1/audit", "c")))
+	_, err = s.db.CreateWithID(s.ctx, "v1", map[string]interface{}{"eventType": "ACCESS_DENIED"}, "tenant/t1/vaults")
+	s.Require().NoError(err)
+
+	modified := next()
+	s.Equal(ChangeModified, modified.Type)
+	s.Equal("b", modified.ID)
+	s.Contains(string(modified.Data), `"reason":"missing relation"`)
+
+	s.Equal(ChangeEvent{Type: ChangeAdded, Collection: "tenant/t1/audit", ID: "a"}, withoutData(next()))
+	s.Equal(ChangeEvent{Type: ChangeRemoved, Collection: "tenant/t1/audit", ID: "d"}, withoutData(next()), "a document that stops matching is removed")
+	s.Equal(ChangeEvent{Type: ChangeRemoved, Collection: "tenant/t1/audit", ID: "b"}, withoutData(next()))
+
+	cancel()
+	for range events {
+	}
+	s.Eventually(func() bool {
+		s.db.mu.Lock()
+		defer s.db.mu.Unlock()
+		return len(s.db.watchers) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func withoutData(event ChangeEvent) ChangeEvent {
+	event.Data = nil
+	event.Time = time.Time{}
+	return event
+}
+
+func TestMemoryDB_ImplementsInterface(t *testing.T) {
+	var db FirebaseDBInterface = NewMemoryDB()
+	require.True(t, db.IsConnected())
+	assert.NotNil(t, db)
+}
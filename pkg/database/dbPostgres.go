@@ -0,0 +1,988 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+const (
+	postgresMigrationsDir = "migrations/postgres"
+	// postgresMigrationLock is the advisory lock that serializes migrations between instances.
+	postgresMigrationLock = 7263541
+	// postgresMaxRetries is how many times a transaction is retried on a serialization failure.
+	postgresMaxRetries           = 5
+	postgresSerializationFailure = "40001"
+	// postgresChangesChannel is notified by the documents trigger of migration 0003.
+	postgresChangesChannel = "documents_changes"
+)
+
+// PostgresDB is a FirebaseDBInterface backed by PostgreSQL. Documents are kept as JSONB in
+// the documents table, keyed by the full collection path and document ID, so the repositories
+// keep addressing collections like Firestore (tenant/<id>/vaults/<id>/secrets). The tenant of
+// the path is stored in tenant_id. Conditionals, ordering and pagination behave like MemoryDB.
+type PostgresDB struct {
+	pool *pgxpool.Pool
+	now  func() time.Time
+}
+
+// InitializePostgresDB connects to PostgreSQL and applies the pending migrations.
+func InitializePostgresDB(config PostgresConfig) (FirebaseDBInterface, error) {
+
+	if config.DSN == "" {
+		return nil, errors.New(errorDSNRequired)
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf(errorNotConnected, err.Error())
+	}
+
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf(errorNotConnected, err.Error())
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf(errorNotConnected, err.Error())
+	}
+
+	db := &PostgresDB{
+		pool: pool,
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+
+	if err := db.Migrate(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	log.Printf("Successfully connected to PostgreSQL database: %s", poolConfig.ConnConfig.Database)
+
+	return db, nil
+}
+
+// Migrate applies the embedded migrations that were not applied yet, in file name order.
+// It is safe to call from several instances at once.
+func (db *PostgresDB) Migrate(ctx context.Context) error {
+
+	entries, err := fs.ReadDir(postgresMigrations, postgresMigrationsDir)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	return pgx.BeginFunc(ctx, db.pool, func(tx pgx.Tx) error {
+
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, postgresMigrationLock); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    TEXT        PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			var applied bool
+			err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, name).Scan(&applied)
+			if err != nil {
+				return err
+			}
+			if applied {
+				continue
+			}
+
+			script, err := postgresMigrations.ReadFile(postgresMigrationsDir + "/" + name)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(ctx, string(script)); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", name, err)
+			}
+
+			if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, name); err != nil {
+				return err
+			}
+			log.Printf("Applied PostgreSQL migration %s", name)
+		}
+
+		return nil
+	})
+}
+
+func (db *PostgresDB) IsConnected() bool {
+	return db.pool != nil
+}
+
+// Close releases every connection of the pool.
+func (db *PostgresDB) Close() error {
+	if db.pool == nil {
+		return errors.New(errorNotInitialized)
+	}
+	db.pool.Close()
+	return nil
+}
+
+func (db *PostgresDB) Get(ctx context.Context, collection string) ([]byte, error) {
+	return db.GetByConditional(ctx, nil, collection)
+}
+
+func (db *PostgresDB) GetByID(ctx context.Context, id, collection string) ([]byte, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	doc, err := pgGet(ctx, db.pool, collection, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalDocument(id, doc)
+}
+
+func (db *PostgresDB) Create(ctx context.Context, data interface{}, collection string) ([]byte, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	doc, err := normalizeDocument(data, true, db.now)
+	if err != nil {
+		return nil, err
+	}
+
+	id := autoID()
+	if err := pgInsert(ctx, db.pool, collection, id, doc); err != nil {
+		return nil, err
+	}
+
+	return marshalDocument(id, doc)
+}
+
+func (db *PostgresDB) CreateWithID(ctx context.Context, id string, data interface{}, collection string) ([]byte, error) {
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	doc, err := normalizeDocument(data, false, db.now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pgInsert(ctx, db.pool, collection, id, doc); err != nil {
+		return nil, err
+	}
+
+	return marshalDocument(id, doc)
+}
+
+// Update merges data into the document, creating it when missing, like Set with MergeAll.
+func (db *PostgresDB) Update(ctx context.Context, id string, data interface{}, collection string) error {
+
+	if id == "" {
+		return fmt.Errorf("id is empty")
+	}
+
+	if err := db.validate(ctx, collection); err != nil {
+		return err
+	}
+
+	doc, err := normalizeDocument(withUpdatedAt(data), false, db.now)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, db.pool, func(tx pgx.Tx) error {
+		return pgMerge(ctx, tx, collection, id, doc)
+	})
+}
+
+// UpdateIfMatch merges data into the document only if its revision is still revision,
+// otherwise it returns ErrRevisionMismatch.
+func (db *PostgresDB) UpdateIfMatch(ctx context.Context, id string, revision int64, data map[string]interface{}, collection string) error {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return err
+	}
+
+	return updateIfMatch(ctx, db.RunTransaction, id, revision, data, collection)
+}
+
+func (db *PostgresDB) Delete(ctx context.Context, id, collection string) error {
+
+	if id == "" {
+		return fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if err := db.validate(ctx, collection); err != nil {
+		return err
+	}
+
+	_, err := db.pool.Exec(ctx, `DELETE FROM documents WHERE collection = $1 AND id = $2`, collection, id)
+
+	return err
+}
+
+// GetByQuery has no PostgreSQL equivalent: the returned query is not bound to a client.
+func (db *PostgresDB) GetByQuery(ctx context.Context, collection string) firestore.Query {
+	return firestore.Query{}
+}
+
+func (db *PostgresDB) GetByConditional(ctx context.Context, conditional []Conditional, collection string) ([]byte, error) {
+
+	page, err := db.GetPage(ctx, collection, conditional, QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Data, nil
+}
+
+func (db *PostgresDB) GetByFilter(ctx context.Context, filters map[string]interface{}, collection string) ([]byte, error) {
+
+	conditional := make([]Conditional, 0, len(filters))
+	for field, value := range filters {
+		conditional = append(conditional, Conditional{Field: field, Value: value, Filter: FilterEquals})
+	}
+
+	return db.GetByConditional(ctx, conditional, collection)
+}
+
+func (db *PostgresDB) GetCollectionGroup(ctx context.Context, collectionID string, conditional []Conditional) ([]byte, error) {
+
+	if err := db.validate(ctx, collectionID); err != nil {
+		return nil, err
+	}
+
+	q := &pgQuery{}
+	where, err := q.where(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := `SELECT id, data FROM documents WHERE collection_id = ` + q.arg(collectionID)
+	if where != "" {
+		sql += ` AND ` + where
+	}
+	sql += ` ORDER BY id COLLATE "C"`
+
+	docs, err := pgSelect(ctx, db.pool, sql, q.args)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalDocuments(docs)
+}
+
+// GetPage retrieves the documents of the collection that match the conditionals, ordered and
+// paginated by opts. The page token holds the ID of the last document of the previous page,
+// whose ordered fields are used as the keyset of the next query.
+func (db *PostgresDB) GetPage(ctx context.Context, collection string, conditional []Conditional, opts QueryOptions) (*Page, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var cursor *memoryDoc
+	if opts.PageToken != "" {
+		lastID, err := DecodePageToken(opts.PageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		last, err := pgGet(ctx, db.pool, collection, lastID, false)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, fmt.Errorf("%w: page token points to a missing document", ErrInvalidQuery)
+			}
+			return nil, err
+		}
+		cursor = &memoryDoc{id: lastID, data: last}
+	}
+
+	q := &pgQuery{}
+	sql, err := q.selectPage(collection, conditional, opts, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := pgSelect(ctx, db.pool, sql, q.args)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{}
+	if opts.PageSize > 0 && len(docs) > opts.PageSize {
+		docs = docs[:opts.PageSize]
+		page.NextPageToken = EncodePageToken(docs[len(docs)-1].id)
+	}
+
+	page.Data, err = marshalDocuments(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// RunTransaction runs fn in a serializable transaction. Like Firestore, the transaction is
+// retried when it conflicts with a concurrent one, so fn must not have side effects outside tx.
+func (db *PostgresDB) RunTransaction(ctx context.Context, fn func(tx Tx) error) error {
+
+	if db.pool == nil {
+		return errors.New(errorNotInitialized)
+	}
+
+	if fn == nil {
+		return fmt.Errorf(errorGenericError, "transaction function is nil")
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := pgx.BeginTxFunc(ctx, db.pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+			return fn(&postgresTx{ctx: ctx, db: db, tx: tx})
+		})
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgresSerializationFailure && attempt < postgresMaxRetries {
+			continue
+		}
+
+		return err
+	}
+}
+
+// CommitBatch applies every write of the batch atomically.
+func (db *PostgresDB) CommitBatch(ctx context.Context, batch *Batch) error {
+
+	if err := batch.Validate(); err != nil {
+		return err
+	}
+
+	return db.RunTransaction(ctx, batch.apply)
+}
+
+// Watch streams the changes of the collection from the notifications of the documents
+// trigger. It holds a dedicated connection, taken out of the pool, until ctx is done.
+// Notifications are only sent on commit, so uncommitted writes are never reported.
+func (db *PostgresDB) Watch(ctx context.Context, collection string, conditional []Conditional) (<-chan ChangeEvent, error) {
+
+	if err := db.validate(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	conditions, err := normalizeConditionals(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	pooled, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errorNotConnected, err.Error())
+	}
+	conn := pooled.Hijack()
+
+	// Listen before reading the current documents, so no change is lost in between.
+	if _, err := conn.Exec(ctx, `LISTEN `+postgresChangesChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+
+	q := &pgQuery{}
+	where, err := q.where(conditions)
+	if err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+
+	sql := `SELECT id, data FROM documents WHERE collection = ` + q.arg(collection)
+	if where != "" {
+		sql += ` AND ` + where
+	}
+	sql += ` ORDER BY id COLLATE "C"`
+
+	docs, err := pgSelect(ctx, db.pool, sql, q.args)
+	if err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+
+	tracker := newChangeTracker(collection, conditions)
+	events := make(chan ChangeEvent, watchBuffer)
+
+	go func() {
+		defer close(events)
+		defer conn.Close(context.Background())
+
+		send := func(event ChangeEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, doc := range docs {
+			if event, ok := tracker.apply(doc.id, doc.data, db.now()); ok && !send(event) {
+				return
+			}
+		}
+
+		for {
+			notification, err := conn.WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					send(ChangeEvent{Collection: collection, Time: db.now(), Err: err})
+				}
+				return
+			}
+
+			var change struct {
+				Op         string `json:"op"`
+				Collection string `json:"collection"`
+				ID         string `json:"id"`
+			}
+			if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil || change.Collection != collection {
+				continue
+			}
+
+			var doc map[string]interface{}
+			if change.Op != "DELETE" {
+				doc, err = pgGet(ctx, db.pool, collection, change.ID, false)
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					send(ChangeEvent{Collection: collection, ID: change.ID, Time: db.now(), Err: err})
+					return
+				}
+			}
+
+			if event, ok := tracker.apply(change.ID, doc, db.now()); ok && !send(event) {
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// Walk streams the documents whose collection path starts with root, ordered by collection
+// and ID, so a document is visited before its subcollections.
+func (db *PostgresDB) Walk(ctx context.Context, root string, fn WalkFunc) error {
+
+	if err := db.validate(ctx, root); err != nil {
+		return err
+	}
+
+	rows, err := db.pool.Query(ctx, `SELECT collection, id, data FROM documents
+		WHERE starts_with(collection, $1)
+		ORDER BY collection COLLATE "C", id COLLATE "C"`, strings.TrimSuffix(root, "/")+"/")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var collection, id string
+		var raw []byte
+		if err := rows.Scan(&collection, &id, &raw); err != nil {
+			return err
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return err
+		}
+
+		if err := fn(collection, id, data); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (db *PostgresDB) StructToData(data interface{}) (map[string]interface{}, error) {
+	return (&FirebaseDB{}).StructToData(data)
+}
+
+func (db *PostgresDB) validate(ctx context.Context, collection string) error {
+
+	if db.pool == nil {
+		return errors.New(errorNotInitialized)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if collection == "" {
+		return errors.New(errorCollectionRequired)
+	}
+
+	return nil
+}
+
+type postgresTx struct {
+	ctx   context.Context
+	db    *PostgresDB
+	tx    pgx.Tx
+	wrote bool
+}
+
+func (t *postgresTx) Get(collection, id string) ([]byte, error) {
+
+	if t.wrote {
+		return nil, ErrReadAfterWrite
+	}
+
+	if collection == "" || id == "" {
+		return nil, fmt.Errorf(errorGenericError, "collection and id are required")
+	}
+
+	doc, err := pgGet(t.ctx, t.tx, collection, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalDocument(id, doc)
+}
+
+func (t *postgresTx) GetByConditional(collection string, conditional []Conditional) ([]byte, error) {
+
+	if t.wrote {
+		return nil, ErrReadAfterWrite
+	}
+
+	if collection == "" {
+		return nil, errors.New(errorCollectionRequired)
+	}
+
+	q := &pgQuery{}
+	sql, err := q.selectPage(collection, conditional, QueryOptions{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := pgSelect(t.ctx, t.tx, sql, q.args)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalDocuments(docs)
+}
+
+func (t *postgresTx) Create(collection, id string, data interface{}) error {
+
+	doc, err := t.document(collection, id, data)
+	if err != nil {
+		return err
+	}
+
+	return pgInsert(t.ctx, t.tx, collection, id, doc)
+}
+
+func (t *postgresTx) Set(collection, id string, data interface{}) error {
+
+	doc, err := t.document(collection, id, data)
+	if err != nil {
+		return err
+	}
+
+	return pgUpsert(t.ctx, t.tx, collection, id, doc)
+}
+
+func (t *postgresTx) Update(collection, id string, data map[string]interface{}) error {
+
+	doc, err := t.document(collection, id, withUpdatedAt(data))
+	if err != nil {
+		return err
+	}
+
+	return pgMerge(t.ctx, t.tx, collection, id, doc)
+}
+
+func (t *postgresTx) Delete(collection, id string) error {
+
+	if _, err := t.document(collection, id, nil); err != nil {
+		return err
+	}
+
+	_, err := t.tx.Exec(t.ctx, `DELETE FROM documents WHERE collection = $1 AND id = $2`, collection, id)
+
+	return err
+}
+
+// document validates a write, marks the transaction as written and normalizes data.
+// data is nil for deletes.
+func (t *postgresTx) document(collection, id string, data interface{}) (map[string]interface{}, error) {
+
+	if collection == "" {
+		return nil, errors.New(errorCollectionRequired)
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	t.wrote = true
+
+	if data == nil {
+		return nil, nil
+	}
+
+	return normalizeDocument(data, false, t.db.now)
+}
+
+// pgExecutor is satisfied by both the pool and a transaction.
+type pgExecutor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// pgGet returns the data of the document or ErrNotFound. lock takes a row lock until the
+// end of the transaction.
+func pgGet(ctx context.Context, q pgExecutor, collection, id string, lock bool) (map[string]interface{}, error) {
+
+	sql := `SELECT data FROM documents WHERE collection = $1 AND id = $2`
+	if lock {
+		sql += ` FOR UPDATE`
+	}
+
+	var raw []byte
+	if err := q.QueryRow(ctx, sql, collection, id).Scan(&raw); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// pgInsert adds the document and fails with ErrAlreadyExists if it already exists.
+func pgInsert(ctx context.Context, q pgExecutor, collection, id string, doc map[string]interface{}) error {
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	tag, err := q.Exec(ctx, `INSERT INTO documents (tenant_id, collection, collection_id, id, data)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (collection, id) DO NOTHING`,
+		tenantFromPath(collection), collection, collectionID(collection), id, data)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+// pgUpsert replaces the document, creating it when missing.
+func pgUpsert(ctx context.Context, q pgExecutor, collection, id string, doc map[string]interface{}) error {
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(ctx, `INSERT INTO documents (tenant_id, collection, collection_id, id, data)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (collection, id) DO UPDATE SET data = EXCLUDED.data, updated_at = now()`,
+		tenantFromPath(collection), collection, collectionID(collection), id, data)
+
+	return err
+}
+
+// pgMerge merges doc into the stored document the way MemoryDB does. q must be a transaction.
+func pgMerge(ctx context.Context, q pgExecutor, collection, id string, doc map[string]interface{}) error {
+
+	current, err := pgGet(ctx, q, collection, id, true)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return pgUpsert(ctx, q, collection, id, withNextRevision(nil, doc))
+	case err != nil:
+		return err
+	}
+
+	return pgUpsert(ctx, q, collection, id, mergeMaps(current, withNextRevision(current, doc)))
+}
+
+func pgSelect(ctx context.Context, q pgExecutor, sql string, args []interface{}) ([]memoryDoc, error) {
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []memoryDoc{}
+	for rows.Next() {
+		var id string
+		var raw []byte
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+		docs = append(docs, memoryDoc{id: id, data: data})
+	}
+
+	return docs, rows.Err()
+}
+
+// pgQuery builds a SQL statement and its positional arguments.
+type pgQuery struct {
+	args []interface{}
+}
+
+// arg adds a positional argument and returns its placeholder.
+func (q *pgQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// field returns the JSONB expression of a dotted field such as user.uid.
+// It is SQL NULL when the document does not have the field.
+func (q *pgQuery) field(name string) string {
+	return "(data #> " + q.arg(strings.Split(name, ".")) + "::text[])"
+}
+
+// jsonb adds the JSON encoding of value as a JSONB argument.
+func (q *pgQuery) jsonb(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidQuery, err.Error())
+	}
+	return q.arg(string(b)) + "::jsonb", nil
+}
+
+// selectPage builds the query of GetPage. cursor is the last document of the previous page.
+func (q *pgQuery) selectPage(collection string, conditional []Conditional, opts QueryOptions, cursor *memoryDoc) (string, error) {
+
+	clauses := []string{"collection = " + q.arg(collection)}
+
+	where, err := q.where(conditional)
+	if err != nil {
+		return "", err
+	}
+	if where != "" {
+		clauses = append(clauses, where)
+	}
+
+	// Like Firestore, documents without an ordered field are left out.
+	for _, o := range opts.OrderBy {
+		clauses = append(clauses, q.field(o.Field)+" IS NOT NULL")
+	}
+
+	if cursor != nil {
+		after, err := q.after(*cursor, opts.OrderBy)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, after)
+	}
+
+	orders := make([]string, 0, len(opts.OrderBy)+1)
+	for _, o := range opts.OrderBy {
+		direction := "ASC"
+		if o.Direction == Desc {
+			direction = "DESC"
+		}
+		orders = append(orders, q.field(o.Field)+" "+direction)
+	}
+	orders = append(orders, `id COLLATE "C" ASC`)
+
+	sql := "SELECT id, data FROM documents WHERE " + strings.Join(clauses, " AND ") +
+		" ORDER BY " + strings.Join(orders, ", ")
+
+	// One extra document tells whether there is a next page.
+	switch {
+	case opts.PageSize > 0:
+		sql += " LIMIT " + q.arg(opts.PageSize+1)
+	case opts.Limit > 0:
+		sql += " LIMIT " + q.arg(opts.Limit)
+	}
+
+	return sql, nil
+}
+
+// after matches the documents that sort after the cursor, in the order of selectPage.
+func (q *pgQuery) after(cursor memoryDoc, orders []OrderBy) (string, error) {
+
+	values := make([]string, len(orders))
+	for i, o := range orders {
+		value, _ := lookupField(cursor.data, o.Field)
+		v, err := q.jsonb(value)
+		if err != nil {
+			return "", err
+		}
+		values[i] = v
+	}
+
+	alternatives := make([]string, 0, len(orders)+1)
+	for i := 0; i <= len(orders); i++ {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, q.field(orders[j].Field)+" = "+values[j])
+		}
+
+		if i < len(orders) {
+			op := " > "
+			if orders[i].Direction == Desc {
+				op = " < "
+			}
+			parts = append(parts, q.field(orders[i].Field)+op+values[i])
+		} else {
+			parts = append(parts, `id COLLATE "C" > `+q.arg(cursor.id))
+		}
+
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+// where validates the conditionals and joins them with AND. It returns an empty string without conditionals.
+func (q *pgQuery) where(conditional []Conditional) (string, error) {
+
+	clauses := make([]string, 0, len(conditional))
+	for _, c := range conditional {
+		if err := c.Validate(); err != nil {
+			return "", err
+		}
+
+		clause, err := q.condition(c)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+
+	return strings.Join(clauses, " AND "), nil
+}
+
+// condition translates a validated conditional. Like Firestore, a document without the
+// field never matches, not even with != or not-in, because the field expression is NULL.
+func (q *pgQuery) condition(c Conditional) (string, error) {
+
+	if c.IsGroup() {
+		children, operator := c.And, " AND "
+		if len(c.Or) > 0 {
+			children, operator = c.Or, " OR "
+		}
+
+		clauses := make([]string, 0, len(children))
+		for _, child := range children {
+			clause, err := q.condition(child)
+			if err != nil {
+				return "", err
+			}
+			clauses = append(clauses, clause)
+		}
+		return "(" + strings.Join(clauses, operator) + ")", nil
+	}
+
+	field := q.field(c.Field)
+	value, err := q.jsonb(c.Value)
+	if err != nil {
+		return "", err
+	}
+
+	// elements expands a JSONB array, or nothing when the value is not an array.
+	elements := func(expr string) string {
+		return "jsonb_array_elements(CASE WHEN jsonb_typeof(" + expr + ") = 'array' THEN " + expr + " ELSE '[]'::jsonb END)"
+	}
+
+	switch c.Filter {
+	case FilterEquals:
+		return "(" + field + " = " + value + ")", nil
+	case FilterNotEquals:
+		return "(" + field + " <> 'null'::jsonb AND " + field + " <> " + value + ")", nil
+	case FilterGreaterThan, FilterGreaterThanOrEqual, FilterLessThan, FilterLessThanOrEqual:
+		// Only values of the same kind are compared, as Firestore does.
+		return "(jsonb_typeof(" + field + ") IN ('string', 'number', 'boolean') AND jsonb_typeof(" + field + ") = jsonb_typeof(" + value + ") AND " +
+			field + " " + string(c.Filter) + " " + value + ")", nil
+	case FilterIn:
+		return "(EXISTS (SELECT 1 FROM " + elements(value) + " AS v(value) WHERE v.value = " + field + "))", nil
+	case FilterNotIn:
+		return "(" + field + " <> 'null'::jsonb AND NOT EXISTS (SELECT 1 FROM " + elements(value) + " AS v(value) WHERE v.value = " + field + "))", nil
+	case FilterArrayContains:
+		return "(EXISTS (SELECT 1 FROM " + elements(field) + " AS d(value) WHERE d.value = " + value + "))", nil
+	case FilterArrayContainsAny:
+		return "(EXISTS (SELECT 1 FROM " + elements(field) + " AS d(value) JOIN " + elements(value) + " AS v(value) ON d.value = v.value))", nil
+	}
+
+	return "", fmt.Errorf("%w: "+errorConditionalFilterInvalid, ErrInvalidQuery, c.Field, c.Filter)
+}
+
+// tenantFromPath returns the tenant of a tenant/<tenantId>/... path, or "" for global collections.
+func tenantFromPath(collection string) string {
+	parts := strings.Split(collection, "/")
+	if len(parts) >= 3 && parts[0] == "tenant" {
+		return parts[1]
+	}
+	return ""
+}
+
+// collectionID returns the last segment of the collection path.
+func collectionID(collection string) string {
+	return collection[strings.LastIndex(collection, "/")+1:]
+}
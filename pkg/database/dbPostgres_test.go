@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPgQueryCondition(t *testing.T) {
+	q := &pgQuery{}
+
+	where, err := q.where([]Conditional{
+		{Field: "user.uid", Value: "alice", Filter: FilterEquals},
+		Or(
+			Conditional{Field: "eventType", Value: []string{"LOGIN_FAILURE", "ACCESS_DENIED"}, Filter: FilterIn},
+			Conditional{Field: "timestamp", Value: "2024-05-01T00:00:00Z", Filter: FilterGreaterThanOrEqual},
+		),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "((data #> $1::text[]) = $2::jsonb) AND "+
+		"((EXISTS (SELECT 1 FROM jsonb_array_elements(CASE WHEN jsonb_typeof($4::jsonb) = 'array' THEN $4::jsonb ELSE '[]'::jsonb END) AS v(value) WHERE v.value = (data #> $3::text[]))) OR "+
+		"(jsonb_typeof((data #> $5::text[])) IN ('string', 'number', 'boolean') AND jsonb_typeof((data #> $5::text[])) = jsonb_typeof($6::jsonb) AND (data #> $5::text[]) >= $6::jsonb))", where)
+	assert.Equal(t, []interface{}{
+		[]string{"user", "uid"}, `"alice"`,
+		[]string{"eventType"}, `["LOGIN_FAILURE","ACCESS_DENIED"]`,
+		[]string{"timestamp"}, `"2024-05-01T00:00:00Z"`,
+	}, q.args)
+}
+
+func TestPgQueryConditionInvalid(t *testing.T) {
+	q := &pgQuery{}
+
+	_, err := q.where([]Conditional{{Field: "eventType", Value: []string{}, Filter: FilterIn}})
+	assert.True(t, errors.Is(err, ErrInvalidQuery))
+}
+
+func TestPgQuerySelectPage(t *testing.T) {
+	q := &pgQuery{}
+
+	cursor := &memoryDoc{id: "b", data: map[string]interface{}{"name": "beta"}}
+	sql, err := q.selectPage("tenant/t1/vaults", nil, QueryOptions{
+		PageSize: 2,
+		OrderBy:  []OrderBy{{Field: "name", Direction: Desc}},
+	}, cursor)
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT id, data FROM documents WHERE collection = $1 AND (data #> $2::text[]) IS NOT NULL AND "+
+		`(((data #> $4::text[]) < $3::jsonb) OR ((data #> $5::text[]) = $3::jsonb AND id COLLATE "C" > $6)) `+
+		`ORDER BY (data #> $7::text[]) DESC, id COLLATE "C" ASC LIMIT $8`, sql)
+	assert.Equal(t, "b", q.args[5])
+	assert.Equal(t, 3, q.args[7])
+}
+
+func TestTenantFromPath(t *testing.T) {
+	assert.Equal(t, "t1", tenantFromPath("tenant/t1/vaults"))
+	assert.Equal(t, "t1", tenantFromPath("tenant/t1/vaults/v1/secrets"))
+	assert.Equal(t, "", tenantFromPath("system_audit"))
+	assert.Equal(t, "secrets", collectionID("tenant/t1/vaults/v1/secrets"))
+	assert.Equal(t, "outbox", collectionID("outbox"))
+}
+
+// TestPostgresDB runs against a real server when LOCKARI_TEST_POSTGRES_DSN is set.
+func TestPostgresDB(t *testing.T) {
+	dsn := os.Getenv("LOCKARI_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("LOCKARI_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := InitializePostgresDB(PostgresConfig{DSN: dsn})
+	require.NoError(t, err)
+	defer db.(*PostgresDB).Close()
+
+	ctx := context.Background()
+	collection := "tenant/test-" + autoID() + "/vaults"
+
+	for i, name := range []string{"alpha", "beta", "gamma"} {
+		_, err := db.CreateWithID(ctx, string(rune('a'+i)), map[string]interface{}{"name": name, "tags": []string{name}}, collection)
+		require.NoError(t, err)
+	}
+
+	_, err = db.CreateWithID(ctx, "a", map[string]interface{}{"name": "again"}, collection)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	require.NoError(t, db.Update(ctx, "b", map[string]interface{}{"owner": map[string]interface{}{"uid": "alice"}}, collection))
+
+	data, err := db.GetByConditional(ctx, []Conditional{{Field: "owner.uid", Value: "alice", Filter: FilterEquals}}, collection)
+	require.NoError(t, err)
+	var docs []map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &docs))
+	require.Len(t, docs, 1)
+	assert.Equal(t, "beta", docs[0]["name"])
+
+	page, err := db.GetPage(ctx, collection, nil, QueryOptions{PageSize: 2, OrderBy: []OrderBy{{Field: "name", Direction: Desc}}})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextPageToken)
+
+	page, err = db.GetPage(ctx, collection, nil, QueryOptions{PageSize: 2, PageToken: page.NextPageToken, OrderBy: []OrderBy{{Field: "name", Direction: Desc}}})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(page.Data, &docs))
+	require.Len(t, docs, 1)
+	assert.Equal(t, "alpha", docs[0]["name"])
+	assert.Empty(t, page.NextPageToken)
+
+	err = db.CommitBatch(ctx, NewBatch().Delete(collection, "c").Create(collection, "a", map[string]interface{}{"name": "dup"}))
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	_, err = db.GetByID(ctx, "c", collection)
+	assert.NoError(t, err, "a failed batch must not apply its writes")
+
+	_, err = db.CreateWithID(ctx, "s1", map[string]interface{}{"name": "key"}, collection+"/b/secrets")
+	require.NoError(t, err)
+	visited := []string{}
+	err = db.Walk(ctx, strings.TrimSuffix(collection, "/vaults"), func(c, id string, data map[string]interface{}) error {
+		visited = append(visited, c+"/"+id)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{collection + "/a", collection + "/b", collection + "/c", collection + "/b/secrets/s1"}, visited)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := db.Watch(watchCtx, collection, []Conditional{{Field: "name", Value: "alpha", Filter: FilterEquals}})
+	require.NoError(t, err)
+	assert.Equal(t, ChangeAdded, (<-events).Type)
+
+	require.NoError(t, db.Delete(ctx, "a", collection))
+	event := <-events
+	require.NoError(t, event.Err)
+	assert.Equal(t, ChangeRemoved, event.Type)
+	assert.Equal(t, "a", event.ID)
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type Filter string
+
+const (
+	FilterEquals             Filter = "=="
+	FilterNotEquals          Filter = "!="
+	FilterGreaterThan        Filter = ">"
+	FilterGreaterThanOrEqual Filter = ">="
+	FilterLessThan           Filter = "<"
+	FilterLessThanOrEqual    Filter = "<="
+	FilterIn                 Filter = "in"
+	FilterNotIn              Filter = "not-in"
+	FilterArrayContains      Filter = "array-contains"
+	FilterArrayContainsAny   Filter = "array-contains-any"
+)
+
+const (
+	// MaxInValues is the largest list accepted by in and array-contains-any.
+	MaxInValues = 30
+	// MaxNotInValues is the largest list accepted by not-in.
+	MaxNotInValues = 10
+)
+
+// IsValid reports whether the filter is a supported operator.
+func (f Filter) IsValid() bool {
+	switch f {
+	case FilterEquals, FilterNotEquals, FilterGreaterThan, FilterGreaterThanOrEqual,
+		FilterLessThan, FilterLessThanOrEqual, FilterIn, FilterNotIn,
+		FilterArrayContains, FilterArrayContainsAny:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxValues returns the size limit of the list operators, or 0 for the operators that take a single value.
+func (f Filter) maxValues() int {
+	switch f {
+	case FilterIn, FilterArrayContainsAny:
+		return MaxInValues
+	case FilterNotIn:
+		return MaxNotInValues
+	default:
+		return 0
+	}
+}
+
+// Conditional is a condition on a field, or a group of conditions when Or or And is set.
+// A slice of conditionals is combined with AND.
+type Conditional struct {
+	Field  string
+	Value  interface{}
+	Filter Filter
+
+	Or  []Conditional
+	And []Conditional
+}
+
+// Or matches the documents that satisfy any of the conditionals.
+func Or(conditionals ...Conditional) Conditional {
+	return Conditional{Or: conditionals}
+}
+
+// And matches the documents that satisfy all the conditionals. It is useful inside Or.
+func And(conditionals ...Conditional) Conditional {
+	return Conditional{And: conditionals}
+}
+
+// IsGroup reports whether the conditional is an Or/And group.
+func (c Conditional) IsGroup() bool {
+	return len(c.Or) > 0 || len(c.And) > 0
+}
+
+// Validate checks the conditional and, for groups, all their members.
+// The errors wrap ErrInvalidQuery and name the offending field.
+func (c Conditional) Validate() error {
+
+	if c.IsGroup() {
+		if (len(c.Or) > 0 && len(c.And) > 0) || c.Field != "" || c.Filter != "" || c.Value != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidQuery, errorConditionalGroupInvalid)
+		}
+		for _, child := range append(c.Or, c.And...) {
+			if err := child.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if c.Field == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidQuery, errorConditionalFieldRequired)
+	}
+
+	if c.Filter == "" {
+		return fmt.Errorf("%w: "+errorConditionalFilterRequired, ErrInvalidQuery, c.Field)
+	}
+
+	if !c.Filter.IsValid() {
+		return fmt.Errorf("%w: "+errorConditionalFilterInvalid, ErrInvalidQuery, c.Field, c.Filter)
+	}
+
+	if c.Value == nil {
+		return fmt.Errorf("%w: "+errorConditionalValueRequired, ErrInvalidQuery, c.Field)
+	}
+
+	if max := c.Filter.maxValues(); max > 0 {
+		value := reflect.ValueOf(c.Value)
+		if (value.Kind() != reflect.Slice && value.Kind() != reflect.Array) || value.Len() == 0 || value.Len() > max {
+			return fmt.Errorf("%w: "+errorConditionalListRequired, ErrInvalidQuery, c.Field, c.Filter, max)
+		}
+	}
+
+	return nil
+}
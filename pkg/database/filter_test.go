@@ -0,0 +1,53 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalValidate(t *testing.T) {
+	valid := []Conditional{
+		{Field: "createdAt", Value: "2024-01-01T00:00:00Z", Filter: FilterGreaterThanOrEqual},
+		{Field: "createdAt", Value: "2024-02-01T00:00:00Z", Filter: FilterLessThanOrEqual},
+		{Field: "eventType", Value: []string{"LOGIN_FAILURE", "ACCESS_DENIED"}, Filter: FilterIn},
+		{Field: "status", Value: []string{"done"}, Filter: FilterNotIn},
+		{Field: "tags", Value: []interface{}{"prod", "db"}, Filter: FilterArrayContainsAny},
+		Or(
+			Conditional{Field: "user.uid", Value: "alice", Filter: FilterEquals},
+			And(
+				Conditional{Field: "tenantId", Value: "t1", Filter: FilterEquals},
+				Conditional{Field: "eventType", Value: "ACCESS_DENIED", Filter: FilterEquals},
+			),
+		),
+	}
+	for _, c := range valid {
+		assert.NoError(t, c.Validate(), c.Field)
+	}
+
+	tooMany := make([]string, MaxNotInValues+1)
+
+	cases := map[string]struct {
+		conditional Conditional
+		message     string
+	}{
+		"empty field":        {Conditional{Value: 1, Filter: FilterEquals}, "field cannot be empty"},
+		"empty operator":     {Conditional{Field: "name", Value: 1}, `field "name": operator cannot be empty`},
+		"unknown operator":   {Conditional{Field: "name", Value: 1, Filter: "like"}, `field "name": unsupported operator "like"`},
+		"nil value":          {Conditional{Field: "name", Filter: FilterEquals}, `field "name": value cannot be nil`},
+		"in without list":    {Conditional{Field: "eventType", Value: "LOGIN", Filter: FilterIn}, `field "eventType": operator in requires a list`},
+		"empty list":         {Conditional{Field: "tags", Value: []string{}, Filter: FilterArrayContainsAny}, `field "tags"`},
+		"not-in too large":   {Conditional{Field: "status", Value: tooMany, Filter: FilterNotIn}, `field "status"`},
+		"group with field":   {Conditional{Field: "name", Or: []Conditional{{Field: "a", Value: 1, Filter: FilterEquals}}}, "a group must have"},
+		"invalid in group":   {Or(Conditional{Field: "a", Value: 1, Filter: FilterEquals}, Conditional{Field: "b", Filter: FilterEquals}), `field "b"`},
+		"or and and at once": {Conditional{Or: []Conditional{{Field: "a", Value: 1, Filter: FilterEquals}}, And: []Conditional{{Field: "b", Value: 1, Filter: FilterEquals}}}, "a group must have"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.conditional.Validate()
+			assert.ErrorIs(t, err, ErrInvalidQuery)
+			assert.ErrorContains(t, err, tc.message)
+		})
+	}
+}
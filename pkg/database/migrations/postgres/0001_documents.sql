@@ -0,0 +1,17 @@
+-- Every collection is stored in one JSONB table. collection holds the full path
+-- (tenant/<tenantId>/vaults/<vaultId>/secrets) and collection_id its last segment,
+-- which is what collection group queries match on.
+CREATE TABLE IF NOT EXISTS documents (
+    tenant_id     TEXT        NOT NULL DEFAULT '',
+    collection    TEXT        NOT NULL,
+    collection_id TEXT        NOT NULL,
+    id            TEXT        NOT NULL,
+    data          JSONB       NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (collection, id)
+);
+
+CREATE INDEX IF NOT EXISTS documents_tenant_idx ON documents (tenant_id, collection);
+CREATE INDEX IF NOT EXISTS documents_collection_id_idx ON documents (collection_id);
+CREATE INDEX IF NOT EXISTS documents_data_idx ON documents USING GIN (data jsonb_path_ops);
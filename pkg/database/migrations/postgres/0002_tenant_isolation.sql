@@ -0,0 +1,12 @@
+-- Row level security keeps a session scoped to one tenant when app.tenant_id is set,
+-- e.g. for reporting connections. The backend connects as the table owner and scopes
+-- every statement by collection path, so it is not affected by the policy.
+ALTER TABLE documents ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS documents_tenant_isolation ON documents;
+CREATE POLICY documents_tenant_isolation ON documents
+    USING (
+        current_setting('app.tenant_id', true) IS NULL
+        OR current_setting('app.tenant_id', true) = ''
+        OR tenant_id = current_setting('app.tenant_id', true)
+    );
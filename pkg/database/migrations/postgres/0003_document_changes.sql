@@ -0,0 +1,23 @@
+-- Every change of a document is notified on the documents_changes channel, which
+-- PostgresDB.Watch listens to. The payload only holds the key of the document, since
+-- notifications are limited to 8000 bytes; the watcher reads the document itself.
+CREATE OR REPLACE FUNCTION notify_document_change() RETURNS trigger AS $$
+DECLARE
+    doc documents;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        doc := OLD;
+    ELSE
+        doc := NEW;
+    END IF;
+
+    PERFORM pg_notify('documents_changes',
+        json_build_object('op', TG_OP, 'collection', doc.collection, 'id', doc.id)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS documents_changes ON documents;
+CREATE TRIGGER documents_changes
+    AFTER INSERT OR UPDATE OR DELETE ON documents
+    FOR EACH ROW EXECUTE FUNCTION notify_document_change();
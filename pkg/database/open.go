@@ -0,0 +1,36 @@
+package database
+
+import (
+	"fmt"
+	"log"
+)
+
+// Config selects the storage backend. Provider is firestore (the default), postgres or memory.
+type Config struct {
+	Provider string         `json:"provider" yaml:"provider"`
+	Postgres PostgresConfig `json:"postgres" yaml:"postgres"`
+}
+
+// Open initializes the backend selected by config. firebase is only used by the firestore provider.
+func Open(config Config, firebase FirebaseConfig) (FirebaseDBInterface, error) {
+
+	switch config.Provider {
+	case "", "firestore":
+		db, err := InitializeFirebaseDB(firebase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize firebase DB: %w", err)
+		}
+		return db, nil
+	case "postgres":
+		db, err := InitializePostgresDB(config.Postgres)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize postgres DB: %w", err)
+		}
+		return db, nil
+	case "memory":
+		log.Println("Using the in-memory database: data is lost on restart")
+		return NewMemoryDB(), nil
+	default:
+		return nil, fmt.Errorf("%s: %q", errorProviderNotFound, config.Provider)
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is used by the list endpoints when the client does not send a page size.
+	DefaultPageSize = 50
+	// MaxPageSize is the largest page a client may request.
+	MaxPageSize = 500
+)
+
+// ErrInvalidQuery is returned when the query options or the page token are invalid.
+var ErrInvalidQuery = errors.New("invalid query")
+
+type Direction string
+
+const (
+	Asc  Direction = "asc"
+	Desc Direction = "desc"
+)
+
+// OrderBy sorts the results by Field.
+type OrderBy struct {
+	Field     string
+	Direction Direction
+}
+
+// QueryOptions controls the size and the order of a query.
+// PageSize enables cursor pagination: the Page returns a token to fetch the next page.
+// Limit caps the number of documents of a query without pagination.
+type QueryOptions struct {
+	PageSize  int
+	PageToken string
+	OrderBy   []OrderBy
+	Limit     int
+}
+
+// Page is the result of GetPage. Data is the JSON array of documents and
+// NextPageToken is empty when there are no more documents.
+type Page struct {
+	Data          []byte
+	NextPageToken string
+}
+
+// Validate checks the options. When sortable is informed, only those fields can be used in OrderBy.
+func (o QueryOptions) Validate(sortable ...string) error {
+
+	if o.PageSize < 0 || o.Limit < 0 {
+		return fmt.Errorf("%w: page size and limit cannot be negative", ErrInvalidQuery)
+	}
+
+	if o.PageSize > MaxPageSize {
+		return fmt.Errorf("%w: page size must be at most %d", ErrInvalidQuery, MaxPageSize)
+	}
+
+	if o.PageSize > 0 && o.Limit > 0 {
+		return fmt.Errorf("%w: page size and limit cannot be used together", ErrInvalidQuery)
+	}
+
+	if o.PageToken != "" && o.PageSize == 0 {
+		return fmt.Errorf("%w: page token requires a page size", ErrInvalidQuery)
+	}
+
+	for _, order := range o.OrderBy {
+		if order.Field == "" {
+			return fmt.Errorf("%w: order by field cannot be empty", ErrInvalidQuery)
+		}
+		if order.Direction != Asc && order.Direction != Desc {
+			return fmt.Errorf("%w: invalid direction %q for field %s", ErrInvalidQuery, order.Direction, order.Field)
+		}
+		if len(sortable) > 0 && !contains(sortable, order.Field) {
+			return fmt.Errorf("%w: cannot order by %s", ErrInvalidQuery, order.Field)
+		}
+	}
+
+	return nil
+}
+
+// WithDefaultOrder returns a copy of the options ordered by the given fields when the client sent no order.
+func (o QueryOptions) WithDefaultOrder(order ...OrderBy) QueryOptions {
+	if len(o.OrderBy) == 0 {
+		o.OrderBy = order
+	}
+	return o
+}
+
+// ParseOrderBy parses a comma separated list of fields. A field may be prefixed
+// with "-" or suffixed with ":desc" / ":asc", e.g. "name,-createdAt" or "createdAt:desc".
+func ParseOrderBy(value string) ([]OrderBy, error) {
+
+	var orders []OrderBy
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		order := OrderBy{Field: item, Direction: Asc}
+		if strings.HasPrefix(item, "-") {
+			order = OrderBy{Field: strings.TrimPrefix(item, "-"), Direction: Desc}
+		} else if field, direction, ok := strings.Cut(item, ":"); ok {
+			order = OrderBy{Field: field, Direction: Direction(strings.ToLower(direction))}
+		}
+
+		if order.Field == "" || (order.Direction != Asc && order.Direction != Desc) {
+			return nil, fmt.Errorf("%w: invalid order by %q", ErrInvalidQuery, item)
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// EncodePageToken returns the opaque token that points to the last document of a page.
+func EncodePageToken(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID))
+}
+
+// DecodePageToken returns the document ID stored in the token.
+func DecodePageToken(token string) (string, error) {
+	id, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(id) == 0 {
+		return "", fmt.Errorf("%w: malformed page token", ErrInvalidQuery)
+	}
+	return string(id), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	orders, err := ParseOrderBy("name, -createdAt,updatedAt:DESC,type:asc")
+	require.NoError(t, err)
+	assert.Equal(t, []OrderBy{
+		{Field: "name", Direction: Asc},
+		{Field: "createdAt", Direction: Desc},
+		{Field: "updatedAt", Direction: Desc},
+		{Field: "type", Direction: Asc},
+	}, orders)
+
+	orders, err = ParseOrderBy("")
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+
+	for _, value := range []string{"-", "name:up", ":desc"} {
+		_, err := ParseOrderBy(value)
+		assert.ErrorIs(t, err, ErrInvalidQuery, value)
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	id, err := DecodePageToken(EncodePageToken("0190a1b2-vault"))
+	require.NoError(t, err)
+	assert.Equal(t, "0190a1b2-vault", id)
+
+	_, err = DecodePageToken("not base64!")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
+func TestQueryOptionsValidate(t *testing.T) {
+	assert.NoError(t, QueryOptions{PageSize: 10, PageToken: "abc", OrderBy: []OrderBy{{Field: "name", Direction: Asc}}}.Validate("name"))
+
+	cases := map[string]QueryOptions{
+		"negative page size":  {PageSize: -1},
+		"page size too large": {PageSize: MaxPageSize + 1},
+		"page size and limit": {PageSize: 10, Limit: 5},
+		"token without size":  {PageToken: "abc"},
+		"unsortable field":    {OrderBy: []OrderBy{{Field: "secret", Direction: Asc}}},
+		"invalid direction":   {OrderBy: []OrderBy{{Field: "name", Direction: "up"}}},
+	}
+
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, opts.Validate("name"), ErrInvalidQuery)
+		})
+	}
+}
+
+func TestWithDefaultOrder(t *testing.T) {
+	def := OrderBy{Field: "timestamp", Direction: Desc}
+	assert.Equal(t, []OrderBy{def}, QueryOptions{}.WithDefaultOrder(def).OrderBy)
+
+	custom := []OrderBy{{Field: "eventType", Direction: Asc}}
+	assert.Equal(t, custom, QueryOptions{OrderBy: custom}.WithDefaultOrder(def).OrderBy)
+}
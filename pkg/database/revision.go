@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// RevisionField holds the revision of a document. Update and UpdateIfMatch always increment
+// it, whatever value the caller sends; documents written before it existed are at revision 0.
+const RevisionField = "revision"
+
+// ErrRevisionMismatch is returned by UpdateIfMatch when the document changed since it was read.
+var ErrRevisionMismatch = errors.New("document was modified since it was read")
+
+// DocumentRevision returns the revision of a decoded document, 0 when it has none.
+func DocumentRevision(doc map[string]interface{}) int64 {
+	switch v := doc[RevisionField].(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	}
+	return 0
+}
+
+// withNextRevision sets the revision of the merged document to the one after current.
+func withNextRevision(current, doc map[string]interface{}) map[string]interface{} {
+	doc[RevisionField] = float64(DocumentRevision(current) + 1)
+	return doc
+}
+
+// updateIfMatch merges data into the document in a transaction, after checking that the
+// stored revision is still the expected one. Every backend implements UpdateIfMatch with it.
+func updateIfMatch(ctx context.Context, run func(ctx context.Context, fn func(tx Tx) error) error, id string, revision int64, data map[string]interface{}, collection string) error {
+
+	if id == "" {
+		return fmt.Errorf(errorGenericError, "id is empty")
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf(errorGenericError, "data is nil")
+	}
+
+	return run(ctx, func(tx Tx) error {
+
+		response, err := tx.Get(collection, id)
+		if err != nil {
+			return err
+		}
+
+		var current map[string]interface{}
+		if err := json.Unmarshal(response, &current); err != nil {
+			return err
+		}
+
+		if DocumentRevision(current) != revision {
+			return ErrRevisionMismatch
+		}
+
+		return tx.Update(collection, id, data)
+	})
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxBatchWrites is the largest number of writes committed by a Batch or a transaction.
+const MaxBatchWrites = 500
+
+var (
+	// ErrBatchTooLarge is returned when a Batch has more than MaxBatchWrites writes.
+	ErrBatchTooLarge = fmt.Errorf("batch cannot have more than %d writes", MaxBatchWrites)
+	// ErrReadAfterWrite is returned when a transaction reads a document after writing.
+	ErrReadAfterWrite = errors.New("transaction reads must happen before writes")
+)
+
+// Tx is the transaction passed to RunTransaction. Every read must happen before
+// the first write; the writes are applied only if the function returns nil.
+// The function may run more than once when the transaction is retried.
+type Tx interface {
+	// Get returns the document with the id field set, or ErrNotFound.
+	Get(collection, id string) ([]byte, error)
+	// GetByConditional returns the documents of the collection that match the conditionals.
+	GetByConditional(collection string, conditional []Conditional) ([]byte, error)
+	// Create adds a document and fails with ErrAlreadyExists if it already exists.
+	Create(collection, id string, data interface{}) error
+	// Set replaces the document.
+	Set(collection, id string, data interface{}) error
+	// Update merges the fields into the document.
+	Update(collection, id string, data map[string]interface{}) error
+	// Delete removes the document.
+	Delete(collection, id string) error
+}
+
+type WriteOperation string
+
+const (
+	WriteCreate WriteOperation = "create"
+	WriteSet    WriteOperation = "set"
+	WriteUpdate WriteOperation = "update"
+	WriteDelete WriteOperation = "delete"
+)
+
+// Write is a single operation of a Batch.
+type Write struct {
+	Operation  WriteOperation
+	Collection string
+	ID         string
+	Data       interface{}
+}
+
+// Batch groups writes that are committed atomically by CommitBatch: either every
+// write is applied or none. A batch holds at most MaxBatchWrites writes.
+type Batch struct {
+	writes []Write
+}
+
+// NewBatch creates an empty Batch.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Create adds a document; the commit fails with ErrAlreadyExists if it already exists.
+func (b *Batch) Create(collection, id string, data interface{}) *Batch {
+	return b.add(Write{Operation: WriteCreate, Collection: collection, ID: id, Data: data})
+}
+
+// Set replaces the document.
+func (b *Batch) Set(collection, id string, data interface{}) *Batch {
+	return b.add(Write{Operation: WriteSet, Collection: collection, ID: id, Data: data})
+}
+
+// Update merges the fields into the document.
+func (b *Batch) Update(collection, id string, data map[string]interface{}) *Batch {
+	return b.add(Write{Operation: WriteUpdate, Collection: collection, ID: id, Data: data})
+}
+
+// Delete removes the document.
+func (b *Batch) Delete(collection, id string) *Batch {
+	return b.add(Write{Operation: WriteDelete, Collection: collection, ID: id})
+}
+
+// Writes returns the writes in the order they were added.
+func (b *Batch) Writes() []Write {
+	return b.writes
+}
+
+// Len returns the number of writes.
+func (b *Batch) Len() int {
+	return len(b.writes)
+}
+
+// Validate checks the size of the batch and every write.
+func (b *Batch) Validate() error {
+
+	if b == nil || len(b.writes) == 0 {
+		return fmt.Errorf(errorGenericError, "no writes to commit")
+	}
+
+	if len(b.writes) > MaxBatchWrites {
+		return ErrBatchTooLarge
+	}
+
+	for _, w := range b.writes {
+		if w.Collection == "" {
+			return errors.New(errorCollectionRequired)
+		}
+		if w.ID == "" {
+			return fmt.Errorf(errorGenericError, "id is empty")
+		}
+		if w.Operation != WriteDelete && w.Data == nil {
+			return fmt.Errorf(errorGenericError, "data is nil")
+		}
+	}
+
+	return nil
+}
+
+// apply runs every write of the batch in the transaction.
+func (b *Batch) apply(tx Tx) error {
+	for _, w := range b.writes {
+		var err error
+		switch w.Operation {
+		case WriteCreate:
+			err = tx.Create(w.Collection, w.ID, w.Data)
+		case WriteSet:
+			err = tx.Set(w.Collection, w.ID, w.Data)
+		case WriteUpdate:
+			data, ok := w.Data.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf(errorGenericError, "update data must be a map")
+			}
+			err = tx.Update(w.Collection, w.ID, data)
+		case WriteDelete:
+			err = tx.Delete(w.Collection, w.ID)
+		default:
+			err = fmt.Errorf(errorGenericError, "invalid write operation "+string(w.Operation))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Batch) add(w Write) *Batch {
+	b.writes = append(b.writes, w)
+	return b
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	batch := NewBatch().
+		Create("vaults", "v1", map[string]interface{}{"name": "prod"}).
+		Update("counters", "t1", map[string]interface{}{"vaults": 1}).
+		Delete("trash", "v0")
+
+	assert.NoError(t, batch.Validate())
+	assert.Equal(t, 3, batch.Len())
+	assert.Equal(t, []WriteOperation{WriteCreate, WriteUpdate, WriteDelete}, []WriteOperation{
+		batch.Writes()[0].Operation, batch.Writes()[1].Operation, batch.Writes()[2].Operation,
+	})
+
+	assert.Error(t, NewBatch().Validate(), "empty batch")
+	assert.Error(t, NewBatch().Set("", "id", map[string]interface{}{}).Validate(), "missing collection")
+	assert.Error(t, NewBatch().Set("vaults", "", map[string]interface{}{}).Validate(), "missing id")
+	assert.Error(t, NewBatch().Set("vaults", "v1", nil).Validate(), "missing data")
+
+	large := NewBatch()
+	for i := 0; i <= MaxBatchWrites; i++ {
+		large.Delete("vaults", "v")
+	}
+	assert.ErrorIs(t, large.Validate(), ErrBatchTooLarge)
+}
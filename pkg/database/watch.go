@@ -0,0 +1,128 @@
+package database
+
+import (
+	"sync"
+	"time"
+)
+
+// ChangeType is the kind of change reported by Watch.
+type ChangeType string
+
+const (
+	// ChangeAdded is reported for the documents that match when the watch starts and for
+	// documents created, or changed to match the conditionals, afterwards.
+	ChangeAdded ChangeType = "added"
+	// ChangeModified is reported when a matching document changes and still matches.
+	ChangeModified ChangeType = "modified"
+	// ChangeRemoved is reported when a matching document is deleted or stops matching.
+	ChangeRemoved ChangeType = "removed"
+)
+
+// watchBuffer is the number of events a watcher holds before the producer waits for the consumer.
+const watchBuffer = 64
+
+// ChangeEvent is a document change streamed by Watch. Data holds the document as JSON with
+// its "id", as returned by GetByID; for removed documents it is the last known state, or nil
+// when the backend does not know it. A watch that fails sends a last event with Err set
+// before its channel is closed.
+type ChangeEvent struct {
+	Type       ChangeType `json:"type"`
+	Collection string     `json:"collection"`
+	ID         string     `json:"id"`
+	Data       []byte     `json:"data,omitempty"`
+	Time       time.Time  `json:"time"`
+	Err        error      `json:"-"`
+}
+
+// changeTracker turns the current state of documents into change events for one watch,
+// remembering which documents matched the conditionals. It is used by the backends that
+// are not notified of documents leaving the query (MemoryDB and PostgresDB).
+type changeTracker struct {
+	collection string
+	conditions []Conditional
+	matched    map[string]bool
+}
+
+func newChangeTracker(collection string, conditions []Conditional) *changeTracker {
+	return &changeTracker{
+		collection: collection,
+		conditions: conditions,
+		matched:    map[string]bool{},
+	}
+}
+
+// apply returns the event for the new state of the document, nil when it was deleted, and
+// false when the change is not visible to the watch.
+func (t *changeTracker) apply(id string, doc map[string]interface{}, now time.Time) (ChangeEvent, bool) {
+
+	event := ChangeEvent{Collection: t.collection, ID: id, Time: now}
+	if doc != nil {
+		event.Data, event.Err = marshalDocument(id, doc)
+	}
+
+	matches := doc != nil && matchAll(doc, t.conditions)
+	switch {
+	case matches && t.matched[id]:
+		event.Type = ChangeModified
+	case matches:
+		event.Type = ChangeAdded
+		t.matched[id] = true
+	case t.matched[id]:
+		event.Type = ChangeRemoved
+		delete(t.matched, id)
+	default:
+		return ChangeEvent{}, false
+	}
+
+	return event, true
+}
+
+// eventQueue delivers events to a watch channel without blocking the writer that produced
+// them, so a slow consumer never holds the database lock.
+type eventQueue struct {
+	mu      sync.Mutex
+	pending []ChangeEvent
+	ready   chan struct{}
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue) push(event ChangeEvent) {
+	q.mu.Lock()
+	q.pending = append(q.pending, event)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// drain sends the queued events to out until done is closed, then closes out.
+func (q *eventQueue) drain(done <-chan struct{}, out chan<- ChangeEvent) {
+
+	defer close(out)
+
+	for {
+		q.mu.Lock()
+		events := q.pending
+		q.pending = nil
+		q.mu.Unlock()
+
+		for _, event := range events {
+			select {
+			case out <- event:
+			case <-done:
+				return
+			}
+		}
+
+		select {
+		case <-q.ready:
+		case <-done:
+			return
+		}
+	}
+}
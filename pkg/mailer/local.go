@@ -0,0 +1,117 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ProviderLog  = "log"
+	ProviderFile = "file"
+
+	// DefaultFilePath is used when the file sender has no directory configured.
+	DefaultFilePath = "data/mail"
+)
+
+// logSender writes the messages to the application log. It is meant for development,
+// where nobody must receive the emails.
+type logSender struct {
+	from string
+}
+
+// NewLogSender returns a Sender that logs the messages instead of delivering them.
+func NewLogSender(from string) Sender {
+	return &logSender{from: from}
+}
+
+func (s *logSender) Provider() string {
+	return ProviderLog
+}
+
+func (s *logSender) Send(ctx context.Context, msg Message) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	msg = withSender(msg, s.from)
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// fileSender writes each message as an .eml file of a directory, so tests and local
+// environments can read the emails, e.g. to follow an invitation link.
+type fileSender struct {
+	dir  string
+	from string
+	mu   sync.Mutex
+	seq  int
+}
+
+// NewFileSender creates the directory if needed and returns a Sender backed by it.
+func NewFileSender(dir, from string) (Sender, error) {
+
+	if dir == "" {
+		dir = DefaultFilePath
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory %s: %w", dir, err)
+	}
+
+	return &fileSender{dir: dir, from: from}, nil
+}
+
+func (s *fileSender) Provider() string {
+	return ProviderFile
+}
+
+func (s *fileSender) Send(ctx context.Context, msg Message) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	msg = withSender(msg, s.from)
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	s.seq++
+	name := fmt.Sprintf("%s-%06d.eml", now.Format("20060102T150405.000000000"), s.seq)
+	s.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail to %s: %w", msg.To, err)
+	}
+
+	return nil
+}
+
+func withSender(msg Message, from string) Message {
+	if msg.From == "" {
+		msg.From = from
+	}
+	return msg
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	sender, err := New(Config{Provider: ProviderFile, Path: dir, From: "Lockari <no-reply@lockari.io>"})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderFile, sender.Provider())
+
+	require.NoError(t, sender.Send(ctx, Message{To: "bob@acme.io", Subject: "Invitation", Body: "https://app/invite?token=x"}))
+
+	err = sender.Send(ctx, Message{To: "not an address", Subject: "Invitation"})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "From: Lockari <no-reply@lockari.io>\r\n")
+	assert.Contains(t, string(data), "To: bob@acme.io\r\n")
+	assert.Contains(t, string(data), "https://app/invite?token=x")
+
+	_, err = New(Config{Provider: "smtp"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+)
+
+// ErrInvalidMessage is returned for messages without a valid recipient or subject.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Sender delivers email messages, such as tenant invitations.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+	// Provider names the sender in the logs, e.g. log or file.
+	Provider() string
+}
+
+// Message is a plain text email.
+type Message struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Validate checks the addresses and the subject of the message.
+func (m Message) Validate() error {
+
+	if _, err := mail.ParseAddress(m.To); err != nil {
+		return fmt.Errorf("%w: recipient %q: %v", ErrInvalidMessage, m.To, err)
+	}
+
+	if m.From != "" {
+		if _, err := mail.ParseAddress(m.From); err != nil {
+			return fmt.Errorf("%w: sender %q: %v", ErrInvalidMessage, m.From, err)
+		}
+	}
+
+	if m.Subject == "" {
+		return fmt.Errorf("%w: subject is required", ErrInvalidMessage)
+	}
+
+	return nil
+}
+
+// Config holds the configuration of the sender.
+type Config struct {
+	// Provider of the sender: log (default) or file.
+	Provider string `json:"provider" yaml:"provider"`
+	// Directory of the file sender.
+	Path string `json:"path" yaml:"path"`
+	// From is the sender address used when a message has none.
+	From string `json:"from" yaml:"from"`
+}
+
+// New returns the sender of the configured provider.
+func New(cfg Config) (Sender, error) {
+
+	switch cfg.Provider {
+	case "", ProviderLog:
+		return NewLogSender(cfg.From), nil
+	case ProviderFile:
+		return NewFileSender(cfg.Path, cfg.From)
+	default:
+		return nil, fmt.Errorf("unknown mailer provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,331 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+const (
+	// Collection holds one record per migration, keyed by version.
+	Collection = "system_migrations"
+
+	// DefaultBatchSize is the number of documents read and written per batch.
+	DefaultBatchSize = 200
+)
+
+// ErrInvalidMigration is returned when a migration is not well defined.
+var ErrInvalidMigration = errors.New("invalid migration")
+
+// UpFunc migrates one document. It returns the fields to merge into the document, or nil when
+// the document is already migrated, so running a migration twice changes nothing.
+type UpFunc func(doc map[string]interface{}) (map[string]interface{}, error)
+
+// Migration changes the shape of the documents of one collection.
+type Migration struct {
+	// Version orders the migrations and identifies their record, e.g. 0001_login_user.
+	Version     string
+	Description string
+	// Collection is the path of the migrated collection, e.g. logins.
+	Collection string
+	Up         UpFunc
+}
+
+func (m Migration) IsValid() error {
+
+	if m.Version == "" {
+		return fmt.Errorf("%w: version is required", ErrInvalidMigration)
+	}
+
+	if m.Collection == "" {
+		return fmt.Errorf("%w: collection is required for %s", ErrInvalidMigration, m.Version)
+	}
+
+	if m.Up == nil {
+		return fmt.Errorf("%w: up function is required for %s", ErrInvalidMigration, m.Version)
+	}
+
+	return nil
+}
+
+type Status string
+
+const (
+	STATUS_RUNNING Status = "running"
+	STATUS_DONE    Status = "done"
+	STATUS_FAILED  Status = "failed"
+)
+
+// Record is the state of a migration stored in Collection. Cursor is the page token after
+// the last committed batch, so a failed or interrupted migration resumes where it stopped.
+type Record struct {
+	Version     string     `json:"version"`
+	Description string     `json:"description"`
+	Collection  string     `json:"collection"`
+	Status      Status     `json:"status"`
+	Cursor      string     `json:"cursor"`
+	Scanned     int        `json:"scanned"`
+	Migrated    int        `json:"migrated"`
+	Error       string     `json:"error"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// Report summarises a migration run. In a dry run Migrated counts the documents that would change.
+type Report struct {
+	Version  string `json:"version"`
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+	Skipped  bool   `json:"skipped"`
+	DryRun   bool   `json:"dryRun"`
+	Resumed  bool   `json:"resumed"`
+}
+
+// Options control a run of the migrations.
+type Options struct {
+	// DryRun reads every document and reports the changes without writing anything.
+	DryRun bool
+	// Target stops after the migration with this version. Empty runs them all.
+	Target string
+}
+
+// Runner applies the registered migrations in version order.
+type Runner struct {
+	db         database.FirebaseDBInterface
+	migrations []Migration
+	batchSize  int
+	now        func() time.Time
+}
+
+// NewRunner validates the migrations and sorts them by version. A batch size of zero uses DefaultBatchSize.
+func NewRunner(db database.FirebaseDBInterface, batchSize int, migrations ...Migration) (*Runner, error) {
+
+	if db == nil {
+		return nil, errors.New("database is required")
+	}
+
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	if batchSize > database.MaxBatchWrites || batchSize > database.MaxPageSize {
+		return nil, fmt.Errorf("%w: batch size must be at most %d", ErrInvalidMigration, min(database.MaxBatchWrites, database.MaxPageSize))
+	}
+
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if err := m.IsValid(); err != nil {
+			return nil, err
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("%w: duplicated version %s", ErrInvalidMigration, m.Version)
+		}
+	}
+
+	return &Runner{
+		db:         db,
+		migrations: sorted,
+		batchSize:  batchSize,
+		now:        func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+// Status returns the record of every registered migration. Pending migrations have no status.
+func (r *Runner) Status(ctx context.Context) ([]Record, error) {
+
+	records := make([]Record, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		record, err := r.record(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+
+	return records, nil
+}
+
+// Run applies the pending migrations in order and stops at the first failure, which is
+// recorded with the cursor of the last committed batch.
+func (r *Runner) Run(ctx context.Context, opts Options) ([]Report, error) {
+
+	if opts.Target != "" && !r.has(opts.Target) {
+		return nil, fmt.Errorf("%w: unknown target version %s", ErrInvalidMigration, opts.Target)
+	}
+
+	reports := []Report{}
+	for _, m := range r.migrations {
+		report, err := r.apply(ctx, m, opts.DryRun)
+		if report != nil {
+			reports = append(reports, *report)
+		}
+		if err != nil {
+			return reports, fmt.Errorf("migration %s failed: %w", m.Version, err)
+		}
+
+		if m.Version == opts.Target {
+			break
+		}
+	}
+
+	return reports, nil
+}
+
+func (r *Runner) apply(ctx context.Context, m Migration, dryRun bool) (*Report, error) {
+
+	record, err := r.record(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Version: m.Version, DryRun: dryRun}
+	if record.Status == STATUS_DONE {
+		report.Skipped = true
+		return report, nil
+	}
+
+	if dryRun {
+		// A dry run always reads the whole collection and never touches the record.
+		record = &Record{Version: m.Version}
+	} else {
+		report.Resumed = record.Cursor != ""
+		if record.Status == "" {
+			record.StartedAt = r.now()
+		}
+		record.Status = STATUS_RUNNING
+		record.Error = ""
+		if err := r.save(ctx, record); err != nil {
+			return nil, err
+		}
+		log.Printf("Migration %s: running on %s", m.Version, m.Collection)
+	}
+
+	for {
+		page, err := r.db.GetPage(ctx, m.Collection, nil, database.QueryOptions{PageSize: r.batchSize, PageToken: record.Cursor})
+		if err != nil {
+			return report, r.fail(ctx, record, dryRun, err)
+		}
+
+		var docs []map[string]interface{}
+		if len(page.Data) > 0 {
+			if err := json.Unmarshal(page.Data, &docs); err != nil {
+				return report, r.fail(ctx, record, dryRun, err)
+			}
+		}
+
+		batch := database.NewBatch()
+		for _, doc := range docs {
+			id, _ := doc["id"].(string)
+			patch, err := m.Up(doc)
+			if err != nil {
+				return report, r.fail(ctx, record, dryRun, fmt.Errorf("document %s: %w", id, err))
+			}
+			if len(patch) > 0 {
+				batch.Update(m.Collection, id, patch)
+			}
+		}
+
+		if !dryRun && batch.Len() > 0 {
+			if err := r.db.CommitBatch(ctx, batch); err != nil {
+				return report, r.fail(ctx, record, dryRun, err)
+			}
+		}
+
+		report.Scanned += len(docs)
+		report.Migrated += batch.Len()
+		record.Scanned += len(docs)
+		record.Migrated += batch.Len()
+		record.Cursor = page.NextPageToken
+
+		if record.Cursor == "" {
+			break
+		}
+
+		if !dryRun {
+			if err := r.save(ctx, record); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	completedAt := r.now()
+	record.Status = STATUS_DONE
+	record.CompletedAt = &completedAt
+	if err := r.save(ctx, record); err != nil {
+		return report, err
+	}
+
+	log.Printf("Migration %s: done, %d documents scanned, %d migrated", m.Version, record.Scanned, record.Migrated)
+	return report, nil
+}
+
+// record returns the stored record of the migration, or a new one when it never ran.
+func (r *Runner) record(ctx context.Context, m Migration) (*Record, error) {
+
+	record := &Record{Version: m.Version, Description: m.Description, Collection: m.Collection}
+
+	response, err := r.db.GetByID(ctx, m.Version, Collection)
+	if errors.Is(err, database.ErrNotFound) {
+		return record, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration record %s: %w", m.Version, err)
+	}
+
+	if err := json.Unmarshal(response, record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal migration record %s: %w", m.Version, err)
+	}
+
+	return record, nil
+}
+
+func (r *Runner) save(ctx context.Context, record *Record) error {
+
+	data, err := r.db.StructToData(record)
+	if err != nil {
+		return err
+	}
+
+	if err := r.db.Update(ctx, record.Version, data, Collection); err != nil {
+		return fmt.Errorf("failed to save migration record %s: %w", record.Version, err)
+	}
+
+	return nil
+}
+
+// fail records the error with the cursor of the last committed batch and returns it.
+func (r *Runner) fail(ctx context.Context, record *Record, dryRun bool, cause error) error {
+
+	if dryRun {
+		return cause
+	}
+
+	record.Status = STATUS_FAILED
+	record.Error = cause.Error()
+	if err := r.save(context.WithoutCancel(ctx), record); err != nil {
+		log.Printf("Migration %s: failed to record the failure: %v", record.Version, err)
+	}
+
+	return cause
+}
+
+func (r *Runner) has(version string) bool {
+	for _, m := range r.migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synera-br/lockari-backend-app/pkg/database"
+)
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryDB()
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		_, err := db.CreateWithID(ctx, id, map[string]interface{}{"uid": id}, "logins")
+		require.NoError(t, err)
+	}
+
+	failOn := "d"
+	nest := Migration{
+		Version:    "0001_nest_user",
+		Collection: "logins",
+		Up: func(doc map[string]interface{}) (map[string]interface{}, error) {
+			if doc["id"] == failOn {
+				return nil, errors.New("boom")
+			}
+			if _, ok := doc["user"]; ok {
+				return nil, nil
+			}
+			return map[string]interface{}{"user": map[string]interface{}{"uid": doc["uid"]}}, nil
+		},
+	}
+
+	runner, err := NewRunner(db, 2, nest)
+	require.NoError(t, err)
+
+	reports, err := runner.Run(ctx, Options{DryRun: true})
+	require.Error(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, 2, reports[0].Migrated, "the first batch would change before the failure")
+	records, err := runner.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Status(""), records[0].Status, "a dry run does not record anything")
+	assert.Nil(t, user(t, db, "a"), "a dry run does not write")
+
+	_, err = runner.Run(ctx, Options{})
+	require.Error(t, err)
+	records, err = runner.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, STATUS_FAILED, records[0].Status)
+	assert.Contains(t, records[0].Error, "document d")
+	assert.NotEmpty(t, records[0].Cursor)
+	assert.Equal(t, 2, records[0].Migrated)
+
+	failOn = ""
+	reports, err = runner.Run(ctx, Options{})
+	require.NoError(t, err)
+	assert.True(t, reports[0].Resumed)
+	assert.Equal(t, 3, reports[0].Scanned, "the run resumes after the last committed batch")
+
+	records, err = runner.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, STATUS_DONE, records[0].Status)
+	assert.Equal(t, 5, records[0].Migrated)
+	assert.Empty(t, records[0].Error)
+	assert.Equal(t, map[string]interface{}{"uid": "e"}, user(t, db, "e"))
+
+	reports, err = runner.Run(ctx, Options{})
+	require.NoError(t, err)
+	assert.True(t, reports[0].Skipped)
+}
+
+func TestNewRunner(t *testing.T) {
+	db := database.NewMemoryDB()
+	up := func(doc map[string]interface{}) (map[string]interface{}, error) { return nil, nil }
+
+	_, err := NewRunner(db, 0, Migration{Version: "0001", Collection: "a", Up: up}, Migration{Version: "0001", Collection: "b", Up: up})
+	assert.ErrorIs(t, err, ErrInvalidMigration)
+
+	_, err = NewRunner(db, 0, Migration{Version: "0001", Up: up})
+	assert.ErrorIs(t, err, ErrInvalidMigration)
+
+	runner, err := NewRunner(db, 0, Migration{Version: "0002", Collection: "a", Up: up}, Migration{Version: "0001", Collection: "b", Up: up})
+	require.NoError(t, err)
+	assert.Equal(t, "0001", runner.migrations[0].Version)
+
+	_, err = runner.Run(context.Background(), Options{Target: "0003"})
+	assert.ErrorIs(t, err, ErrInvalidMigration)
+}
+
+func user(t *testing.T, db database.FirebaseDBInterface, id string) interface{} {
+	response, err := db.GetByID(context.Background(), id, "logins")
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(response, &doc))
+	return doc["user"]
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ProviderLocal = "local"
+
+	// DefaultLocalPath is used when the local store has no directory configured.
+	DefaultLocalPath = "data/backups"
+)
+
+// localStore keeps the objects as files of a directory. Writes go to a temporary file
+// renamed into place, so a reader never sees a partial object.
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore creates the directory if needed and returns a Store backed by it.
+func NewLocalStore(dir string) (Store, error) {
+
+	if dir == "" {
+		dir = DefaultLocalPath
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %s: %w", dir, err)
+	}
+
+	return &localStore{dir: dir}, nil
+}
+
+// New returns the store of the configured provider.
+func New(cfg Config) (Store, error) {
+
+	switch cfg.Provider {
+	case "", ProviderLocal:
+		return NewLocalStore(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unknown storage provider %q", cfg.Provider)
+	}
+}
+
+func (s *localStore) Provider() string {
+	return ProviderLocal
+}
+
+func (s *localStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	file, err := s.file(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", err
+	}
+
+	return ProviderLocal + "://" + key, nil
+}
+
+func (s *localStore) Get(ctx context.Context, key string) ([]byte, error) {
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	file, err := s.file(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+// Delete removes the object. A missing object is not an error.
+func (s *localStore) Delete(ctx context.Context, key string) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	file, err := s.file(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// file returns the path of the object, rejecting keys that would leave the directory.
+func (s *localStore) file(key string) (string, error) {
+
+	key = strings.TrimPrefix(key, ProviderLocal+"://")
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	location, err := store.Put(ctx, "t1/b1.jsonl.gz", []byte("archive"))
+	require.NoError(t, err)
+	assert.Equal(t, "local://t1/b1.jsonl.gz", location)
+
+	data, err := store.Get(ctx, location)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("archive"), data)
+
+	require.NoError(t, store.Delete(ctx, "t1/b1.jsonl.gz"))
+	require.NoError(t, store.Delete(ctx, "t1/b1.jsonl.gz"), "deleting a missing object is not an error")
+
+	_, err = store.Get(ctx, "t1/b1.jsonl.gz")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", "../escape", "t1/../../escape", "/abs"} {
+		_, err := store.Put(ctx, key, []byte("x"))
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
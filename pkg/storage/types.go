@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotFound is returned when no object is stored under the key.
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for empty keys and keys that escape the store, e.g. ../x.
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Store keeps opaque objects, such as backup archives, under slash separated keys.
+type Store interface {
+	// Put writes the object and returns its location, e.g. local://<key>.
+	Put(ctx context.Context, key string, data []byte) (string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// Provider names the store in the backup records, e.g. local.
+	Provider() string
+}
+
+// Config holds the configuration of the store.
+type Config struct {
+	// Provider of the store, only local for now.
+	Provider string `json:"provider" yaml:"provider"`
+	// Directory of the local store.
+	Path string `json:"path" yaml:"path"`
+}
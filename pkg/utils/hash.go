@@ -22,6 +22,13 @@ func GenerateTenant() string {
 	return uid.String()
 }
 
+// GenerateID returns a time ordered identifier for documents whose ID must be known before they are written.
+func GenerateID() string {
+	uid, _ := uuid.NewV7()
+
+	return uid.String()
+}
+
 func ValidateUUID(id string) (bool, error) {
 	_, err := uuid.Parse(id)
 	if err != nil {
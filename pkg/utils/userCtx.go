@@ -113,3 +113,25 @@ func GetUserIDFromContext(ctx context.Context) (string, error) {
 
 	return userID, nil
 }
+
+func GetTenantIDFromContext(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf(ContextError, err.Error())
+	}
+
+	tenantIDFromCtx := ctx.Value("TenantID")
+	if tenantIDFromCtx == nil {
+		return "", fmt.Errorf("tenantID not found in context")
+	}
+
+	tenantID, ok := tenantIDFromCtx.(string)
+	if !ok {
+		return "", fmt.Errorf("tenantID in context is not a string")
+	}
+
+	if tenantID == "" {
+		return "", fmt.Errorf("tenantID in context is empty")
+	}
+
+	return tenantID, nil
+}